@@ -2,7 +2,12 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
@@ -17,6 +22,15 @@ type Message struct {
 	Error  string `json:"error,omitempty"`
 }
 
+// Paginated is the shared response envelope of the list and search endpoints.
+// The "next_token" is only set when there are more records to be fetched, and
+// it is sent back as the "next_token" query parameter to fetch the next page.
+type Paginated struct {
+	Items     interface{} `json:"items"`
+	Count     int         `json:"count"`
+	NextToken string      `json:"next_token,omitempty"`
+}
+
 // Response returns a response to be returned by the API Gateway Request.
 func Response(status int, body interface{}) *events.APIGatewayProxyResponse {
 	return &events.APIGatewayProxyResponse{
@@ -39,6 +53,24 @@ func StatusOK(body interface{}) (*events.APIGatewayProxyResponse, error) {
 	}, nil
 }
 
+// StatusOKWithETag returns a response of an HTTP StatusOK with body and the
+// "ETag" header of the record version.
+func StatusOKWithETag(body interface{}, version int) (*events.APIGatewayProxyResponse, error) {
+	response, err := StatusOK(body)
+	response.Headers["ETag"] = ETag(version)
+
+	return response, err
+}
+
+// StatusOKWithPage returns a response of an HTTP StatusOK with the paginated body.
+func StatusOKWithPage(items interface{}, count int, nextToken string) (*events.APIGatewayProxyResponse, error) {
+	if count == 0 {
+		items = []interface{}{}
+	}
+
+	return StatusOK(Paginated{Items: items, Count: count, NextToken: nextToken})
+}
+
 // StatusOKWithoutBody returns a response of an HTTP StatusOK without body.
 func StatusOKWithoutBody() (*events.APIGatewayProxyResponse, error) {
 	return &events.APIGatewayProxyResponse{
@@ -73,6 +105,65 @@ func StatusBadRequestWithBody(body map[string]interface{}) (*events.APIGatewayPr
 	}, nil
 }
 
+// StatusUnauthorized returns a response of an HTTP StatusUnauthorized and an error message.
+func StatusUnauthorized(err error) (*events.APIGatewayProxyResponse, error) {
+	return &events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Content-Type": CONTENT_TYPE,
+		},
+		StatusCode: http.StatusUnauthorized,
+		Body:       utility.EncodeJSON(Message{Error: err.Error()}),
+	}, nil
+}
+
+// StatusForbidden returns a response of an HTTP StatusForbidden and an error message.
+func StatusForbidden(err error) (*events.APIGatewayProxyResponse, error) {
+	return &events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Content-Type": CONTENT_TYPE,
+		},
+		StatusCode: http.StatusForbidden,
+		Body:       utility.EncodeJSON(Message{Error: err.Error()}),
+	}, nil
+}
+
+// StatusConflict returns a response of an HTTP StatusConflict and an error message.
+func StatusConflict(err error) (*events.APIGatewayProxyResponse, error) {
+	return &events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Content-Type": CONTENT_TYPE,
+		},
+		StatusCode: http.StatusConflict,
+		Body:       utility.EncodeJSON(Message{Error: err.Error()}),
+	}, nil
+}
+
+// StatusPreconditionFailed returns a response of an HTTP StatusPreconditionFailed and an error message.
+func StatusPreconditionFailed(err error) (*events.APIGatewayProxyResponse, error) {
+	return &events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Content-Type": CONTENT_TYPE,
+		},
+		StatusCode: http.StatusPreconditionFailed,
+		Body:       utility.EncodeJSON(Message{Error: err.Error()}),
+	}, nil
+}
+
+// StatusTooManyRequests returns a response of an HTTP StatusTooManyRequests, an error
+// message, and the "Retry-After" header of the seconds to wait before the next request.
+func StatusTooManyRequests(err error, retryAfter time.Duration) (*events.APIGatewayProxyResponse, error) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+
+	return &events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Content-Type": CONTENT_TYPE,
+			"Retry-After":  strconv.FormatInt(seconds, 10),
+		},
+		StatusCode: http.StatusTooManyRequests,
+		Body:       utility.EncodeJSON(Message{Error: err.Error()}),
+	}, nil
+}
+
 // StatusUnhandledMethod returns a response of an HTTP StatusMethodNotAllowed and an error message of unhandled method.
 func StatusUnhandledMethod() (*events.APIGatewayProxyResponse, error) {
 	return &events.APIGatewayProxyResponse{
@@ -104,3 +195,42 @@ func StatusUnhandledRequest(err error) (*events.APIGatewayProxyResponse, error)
 		Body:       utility.EncodeJSON(Message{Error: err.Error()}),
 	}, err
 }
+
+// ETag returns the entity tag of the record version.
+//
+// Example:
+//  "3"
+func ETag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
+}
+
+// IfMatch returns the record version of the "If-Match" request header. It returns
+// nil if the header is not set or if it is "*", which matches any version.
+//
+// Example:
+//  If-Match: "3"
+func IfMatch(request events.APIGatewayProxyRequest) (*int, error) {
+	var value = strings.TrimSpace(Header(request, "If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return nil, errors.New("invalid 'If-Match' header value")
+	}
+
+	return &version, nil
+}
+
+// Header returns the value of the request header, the header names are case-insensitive.
+func Header(request events.APIGatewayProxyRequest, name string) string {
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
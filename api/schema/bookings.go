@@ -45,19 +45,27 @@ type Bookings struct {
 	SeatNumber    string           `json:"seat_number" dynamodbav:"seat_number"`                               // The specific seat number(s) for the particular booking
 	TravelDate    string           `json:"travel_date" dynamodbav:"travel_date"`                               // The date when to travel
 	DateCreated   string           `json:"date_created" dynamodbav:"date_created"`                             // The date it was created as unix epoch time
+	Version       int              `json:"version" dynamodbav:"version"`                                       // The version of the record that is incremented on every update
 	DateConfirmed string           `json:"date_confirmed,omitempty" dynamodbav:"date_confirmed,omitemptyelem"` // The date the booking was confirmed
 	IsCancelled   *bool            `json:"is_cancelled,omitempty" dynamodbav:"is_cancelled,omitemptyelem"`     // Indicates if the booking is cancelled or not
 	Cancelled     BookingCancelled `json:"cancelled,omitempty" dynamodbav:"-"`                                 // Contains the cancelled booking record
 	Timestamp     string           `json:"timestamp" dynamodbav:"timestamp"`                                   // The timestamp when the request was made
+	MaintenanceID string           `json:"maintenance_id,omitempty" dynamodbav:"maintenance_id,omitempty"`     // The maintenance of the bus unit that the booking has to be reassigned from
+	Currency      string           `json:"currency_code,omitempty" dynamodbav:"currency_code,omitempty"`       // The currency of the total amount
+	TotalAmount   float64          `json:"total_amount,omitempty" dynamodbav:"total_amount,omitempty"`         // The sum of the fares of the seats based on their class
+	RouteChangeID string           `json:"route_change_id,omitempty" dynamodbav:"route_change_id,omitempty"`   // The change of the bus route that the booking has to be rebooked from, or that cancelled it
 }
 
 // Cancelled contains the cancelled booking information.
 type BookingCancelled struct {
-	ID            string `json:"id" dynamodbav:"id"`                         // Unique booking cancellation ID
-	BookingID     string `json:"booking_id" dynamodbav:"booking_id"`         // Unique booking ID as the primary key
-	Reason        string `json:"reason" dynamodbav:"reason"`                 // Reason for booking cancellation
-	CancelledBy   string `json:"cancelled_by" dynamodbav:"cancelled_by"`     // Indicates who cancelled the booking
-	DateCancelled string `json:"date_cancelled" dynamodbav:"date_cancelled"` // The date when the booking was cancelled
+	ID            string  `json:"id" dynamodbav:"id"`                                             // Unique booking cancellation ID
+	BookingID     string  `json:"booking_id" dynamodbav:"booking_id"`                             // Unique booking ID as the primary key
+	Reason        string  `json:"reason" dynamodbav:"reason"`                                     // Reason for booking cancellation
+	CancelledBy   string  `json:"cancelled_by" dynamodbav:"cancelled_by"`                         // Indicates who cancelled the booking
+	DateCancelled string  `json:"date_cancelled" dynamodbav:"date_cancelled"`                     // The date when the booking was cancelled
+	ReleasedSeats string  `json:"released_seats,omitempty" dynamodbav:"released_seats,omitempty"` // The seat number(s) that were released by the cancellation
+	RefundPolicy  string  `json:"refund_policy,omitempty" dynamodbav:"refund_policy,omitempty"`   // The refund of the cancelled booking (e.g. FULL)
+	RefundAmount  float64 `json:"refund_amount,omitempty" dynamodbav:"refund_amount,omitempty"`   // The amount that is refunded in the currency of the booking
 }
 
 // Error sets the default key-value pair.
@@ -101,6 +109,20 @@ func (booking Bookings) IsValidStatus() error {
 	}
 }
 
+// IsValidTravelDate validates if the travel date is either a date or a date and time,
+// e.g. "2023-07-06 19:30". If it is an invalid travel date, it will return an error
+// message.
+func (booking Bookings) IsValidTravelDate() error {
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		_, err := time.Parse(layout, booking.TravelDate)
+		if err == nil {
+			return nil
+		}
+	}
+
+	return errors.New("invalid 'travel_date' value [format: YYYY-MM-DD HH:MM]")
+}
+
 // IsBookingCancelled validates if the details for the canceled booking
 // are set or not. If the required fields are not set, it will return an
 // error message.
@@ -153,6 +175,51 @@ func (booking Bookings) EventSource() (string, error) {
 	}
 }
 
+// IsUpcoming checks if the booking is not cancelled and its travel date is not before the
+// day, only the "2006-01-02" prefix of the travel date is compared.
+func (booking Bookings) IsUpcoming(day string) bool {
+	if booking.Status == booking.Status.Cancelled() || len(booking.TravelDate) < len(day) {
+		return false
+	}
+
+	return booking.TravelDate[:len(day)] >= day
+}
+
+// IsCancelledBy checks if the booking has been cancelled by the bus route change and its
+// travel date is not before the day.
+func (booking Bookings) IsCancelledBy(routeChangeId, day string) bool {
+	if booking.Status != booking.Status.Cancelled() || routeChangeId == "" || booking.RouteChangeID != routeChangeId {
+		return false
+	}
+
+	return len(booking.TravelDate) >= len(day) && booking.TravelDate[:len(day)] >= day
+}
+
+// SetTotal sets the currency of the bus route and the total amount of the booking, it
+// is the sum of the fares of its seats based on their class on the seat layout of the
+// bus unit. Every seat is a STANDARD seat if the bus unit has no seat layout.
+//
+// Example:
+//  seat_number: 1A,1B,5C
+//  fares: {"PREMIUM": 180} and rate: 120
+//  classes: 1A and 1B are PREMIUM, 5C is STANDARD
+//  total_amount: 480
+func (booking *Bookings) SetTotal(route BusRoute, layout SeatLayout) {
+	var total float64
+
+	for _, seat := range strings.Split(booking.SeatNumber, ",") {
+		seat = strings.TrimSpace(seat)
+		if seat == "" {
+			continue
+		}
+
+		total += route.Fare(layout.SeatClass(seat))
+	}
+
+	booking.Currency = route.Currency
+	booking.TotalAmount = total
+}
+
 // SetValues automatically generates the Bookings ID as your primary
 // key, and set the date it was created as unix epoch time.
 //
@@ -162,4 +229,5 @@ func (booking Bookings) EventSource() (string, error) {
 func (booking *Bookings) SetValues() {
 	booking.ID = uuid.NewString()
 	booking.DateCreated = time.Now().Format("2006-01-02 15:04:05")
+	booking.Version = 1
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/rmarasigan/bus-ticketing/internal/app"
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
@@ -23,6 +24,7 @@ type Bus struct {
 	Company      string `json:"company" dynamodbav:"company"`                                   // Name of the company and serves as your sort key and is a required field
 	MobileNumber string `json:"mobile_number" dynamodbav:"mobile_number"`                       // Bus company mobile number and is a required field
 	DateCreated  string `json:"date_created,omitempty" dynamodbav:"date_created,omitemptyelem"` // The date it was created as unix epoch time
+	Version      int    `json:"version" dynamodbav:"version"`                                   // The version of the record that is incremented on every update
 }
 
 // Error sets the default key-value pair.
@@ -53,7 +55,7 @@ func (bus Bus) IsEmptyPayload(payload string) error {
 // be used for the bus ID.
 //
 // Example:
-//		id: RLBSW-856996
+//		RLBSW
 func (bus Bus) partialPrimaryKey() string {
 	key, err := app.RemoveVowel(bus.Company)
 	if err != nil {
@@ -70,33 +72,44 @@ func (bus Bus) partialPrimaryKey() string {
 	return strings.ToUpper(key)
 }
 
-// SetValues automatically generates the Bus ID as your primary key,
-// and set the date it was created as unix epoch time.
+// SetValues automatically generates the Bus ID, it is the company
+// abbreviation and a ULID, and set the date it was created as unix
+// epoch time.
 //
 // Example:
-//		id: RLBSW-856996
+//		id: RLBSW-01H4Z3KX8Q9V7W5T2N6M4R3P1A
 //		date_created: 1685699666
 func (bus *Bus) SetValues() {
+	var err error
+
 	bus.DateCreated = fmt.Sprint(time.Now().Unix())
-	bus.ID = fmt.Sprintf("%s-%s", bus.partialPrimaryKey(), bus.DateCreated[2:8])
+	bus.Version = 1
+
+	bus.ID, err = idgen.New(bus.partialPrimaryKey())
+	if err != nil {
+		bus.Error(err, "SetValues", "failed to generate the bus ID")
+	}
 }
 
 // FailedBus represents the failed bus that needs to be re-processed.
 type FailedBus struct {
 	Failed []struct {
+		Index  int    `json:"index"`
 		Bus    Bus    `json:"bus"`
 		Reason string `json:"reason,omitempty"`
 	} `json:"failed"`
 }
 
 // SetFailedBus sets the failed bus information transaction by passing the
-// Bus and the reason why it failed.
-func (failed *FailedBus) SetFailedBus(bus Bus, reason string) {
+// index of the Bus in the request, the Bus and the reason why it failed.
+func (failed *FailedBus) SetFailedBus(index int, bus Bus, reason string) {
 	data := struct {
+		Index  int    `json:"index"`
 		Bus    Bus    `json:"bus"`
 		Reason string `json:"reason,omitempty"`
 	}{}
 
+	data.Index = index
 	data.Bus = bus
 	data.Reason = reason
 	failed.Failed = append(failed.Failed, data)
@@ -7,10 +7,13 @@ import (
 	"time"
 
 	"github.com/rmarasigan/bus-ticketing/internal/app"
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
-// BusRoute is used to store the specific bus unit route, rate, and schedule.
+// BusRoute is used to store the specific bus unit route, rate, and schedule. The
+// "fares" are the fares of the seat classes of the bus unit, the "rate" is charged
+// for the seats of the other classes.
 //
 // The "dynamodbav" struct tag can be used to control the value
 // that will be marshaled into a AttributeValue.
@@ -20,17 +23,25 @@ type BusRoute struct {
 	BusUnitID     string   `json:"bus_unit_id" dynamodbav:"bus_unit_id"`                           // The Bus Unit ID for the identification of specific bus unit route
 	Currency      string   `json:"currency_code" dynamodbav:"currency_code"`                       // Medium of exchange for goods and services
 	Rate          *float64 `json:"rate" dynamodbav:"rate"`                                         // Fare charged to the passenger
+	Fares         Fares    `json:"fares,omitempty" dynamodbav:"fares,omitempty"`                   // Fare charged to the passenger for a seat of the class
 	Active        *bool    `json:"active" dynamodbav:"active"`                                     // Defines if the bus is available for that route
 	DepartureTime string   `json:"departure_time" dynamodbav:"departure_time"`                     // Expected departure time on the starting point and in 24-hour format
 	ArrivalTime   string   `json:"arrival_time" dynamodbav:"arrival_time"`                         // Expected arrival time on the destination and in 24-hour format
 	FromRoute     string   `json:"from_route" dynamodbav:"from_route"`                             // Indicating the starting point of a bus
 	ToRoute       string   `json:"to_route" dynamodbav:"to_route"`                                 // Indicating the destination of bus
+	Amenities     []string `json:"amenities,omitempty" dynamodbav:"amenities,omitempty"`           // The amenities that are copied from the bus unit of the bus route
 	DateCreated   string   `json:"date_created,omitempty" dynamodbav:"date_created,omitemptyelem"` // The date it was created as unix epoch time
+	Version       int      `json:"version" dynamodbav:"version"`                                   // The version of the record that is incremented on every update
+
+	PendingChange *BusRouteChange `json:"-" dynamodbav:"pending_change,omitempty"` // The route change that has not been sent to the event bus yet
 }
 
+// Fares is the fare of every seat class of a bus route.
+type Fares map[string]float64
+
 // Error sets the default key-value pair.
 func (route BusRoute) Error(err error, code, message string, kv ...utility.KVP) {
-	if route != (BusRoute{}) {
+	if route.ID != "" || route.BusID != "" {
 		kv = append(kv, utility.KVP{Key: "bus_route", Value: route})
 	}
 
@@ -51,12 +62,51 @@ func (route BusRoute) IsEmptyPayload(payload string) error {
 	return nil
 }
 
-// primaryKey uses from_route, to_route, departure_time and arrival_time
-// to form the Bus Route key.
+// SetFares uppercases the seat classes of the fares and checks if every fare
+// is not negative.
+func (route *BusRoute) SetFares() error {
+	if route.Fares == nil {
+		return nil
+	}
+
+	var fares = make(Fares)
+	for class, fare := range route.Fares {
+		class = strings.ToUpper(strings.TrimSpace(class))
+		if class == "" {
+			return errors.New("the seat class of a fare is empty")
+		}
+
+		if fare < 0 {
+			return fmt.Errorf("the fare of the %s seats cannot be negative", class)
+		}
+
+		fares[class] = fare
+	}
+
+	route.Fares = fares
+	return nil
+}
+
+// Fare returns the fare of a seat of the class, it is the "rate" if the bus route
+// has no fare for the class.
+func (route BusRoute) Fare(class string) float64 {
+	if fare, ok := route.Fares[class]; ok {
+		return fare
+	}
+
+	if route.Rate == nil {
+		return 0
+	}
+
+	return *route.Rate
+}
+
+// partialPrimaryKey uses from_route, to_route, departure_time and
+// arrival_time to form the prefix of the Bus Route ID.
 //
 // Example:
-//		id: RTRTB15001900877732
-func (route BusRoute) primaryKey() string {
+//		RTRTB15001900
+func (route BusRoute) partialPrimaryKey() string {
 	var key string
 
 	from, err := app.RemoveVowel(route.FromRoute)
@@ -87,28 +137,40 @@ func (route BusRoute) primaryKey() string {
 	from = strings.ToUpper(from)
 	departure := strings.ReplaceAll(route.DepartureTime, ":", "")
 	arrival := strings.ReplaceAll(route.ArrivalTime, ":", "")
-	key = fmt.Sprintf("%s%s%s%s%s", from, to, departure, arrival, route.DateCreated[2:8])
+	key = fmt.Sprintf("%s%s%s%s", from, to, departure, arrival)
 
 	return key
 }
 
 // SetValues automatically generates the Bus Route ID as your primary
-// key, and set the date it was created as unix epoch time.
+// key, it is the route and schedule prefix and a ULID, and set the date
+// it was created as unix epoch time.
+//
+// Example:
+//		id: RTRTB15001900-01H4Z3KX8Q9V7W5T2N6M4R3P1A
 func (route *BusRoute) SetValues() {
+	var err error
+
 	route.DateCreated = fmt.Sprint(time.Now().Unix())
-	route.ID = route.primaryKey()
+	route.Version = 1
+
+	route.ID, err = idgen.New(route.partialPrimaryKey())
+	if err != nil {
+		route.Error(err, "SetValues", "failed to generate the bus route ID")
+	}
 }
 
 // BusRouteFilter contains the fields of a bus route
 // that can be used for filtering.
 type BusRouteFilter struct {
-	BusID     string // The unique Bus ID
-	BusUnitID string // The Bus Unit ID for the identification of specific bus unit route
-	Active    *bool  // Defines if the bus is available for that route
-	Departure string // Expected departure time on the starting point and in 24-hour format
-	Arrival   string // Expected arrival time on the destination and in 24-hour format
-	FromRoute string // Indicating the starting point of a bus
-	ToRoute   string // Indicating the destination of bus
+	BusID     string   // The unique Bus ID
+	BusUnitID string   // The Bus Unit ID for the identification of specific bus unit route
+	Active    *bool    // Defines if the bus is available for that route
+	Departure string   // Expected departure time on the starting point and in 24-hour format
+	Arrival   string   // Expected arrival time on the destination and in 24-hour format
+	FromRoute string   // Indicating the starting point of a bus
+	ToRoute   string   // Indicating the destination of bus
+	Amenities []string // The amenities that the bus unit of the bus route must have
 }
 
 // SetFilter sets and returns the fields of the bus route
@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+const (
+	ROUTE_CHANGE_CANCEL = "CANCEL" // The bookings of the deactivated bus route are cancelled
+	ROUTE_CHANGE_REBOOK = "REBOOK" // The bookings of the deactivated bus route are flagged to be rebooked
+
+	REFUND_FULL = "FULL" // The total amount of the booking is refunded
+)
+
+// BusRouteChange is the deactivation of a bus route or the change of its schedule. It is
+// sent to the event bus, so that the bookings of the upcoming trips of the bus route are
+// cancelled or flagged to be rebooked if it is deactivated, and their passengers are
+// informed of the new schedule if it is changed.
+//
+// It is kept on the bus route as its pending change by the same update, and removed once
+// it has been sent, so that it is not lost if it fails to be sent.
+type BusRouteChange struct {
+	ID                    string   `json:"id" dynamodbav:"id"`                                             // The unique route change ID, it is set on the bookings to be rebooked
+	Route                 BusRoute `json:"bus_route" dynamodbav:"bus_route"`                               // The bus route after the change
+	PreviousDepartureTime string   `json:"previous_departure_time" dynamodbav:"previous_departure_time"`   // The departure time before the change
+	PreviousArrivalTime   string   `json:"previous_arrival_time" dynamodbav:"previous_arrival_time"`       // The arrival time before the change
+	Deactivated           bool     `json:"deactivated" dynamodbav:"deactivated"`                           // Indicates if the bus route has been deactivated
+	Rescheduled           bool     `json:"rescheduled" dynamodbav:"rescheduled"`                           // Indicates if the departure or arrival time has been changed
+	BookingAction         string   `json:"booking_action,omitempty" dynamodbav:"booking_action,omitempty"` // What is done with the bookings of the deactivated bus route (CANCEL or REBOOK)
+	Reason                string   `json:"reason,omitempty" dynamodbav:"reason,omitempty"`                 // The reason of the cancellation of the bookings
+	RefundPolicy          string   `json:"refund_policy,omitempty" dynamodbav:"refund_policy,omitempty"`   // The refund of the cancelled bookings (e.g. FULL)
+	ChangedBy             string   `json:"changed_by" dynamodbav:"changed_by"`                             // The ID of the user account that updated the bus route
+	DateChanged           string   `json:"date_changed" dynamodbav:"date_changed"`                         // The date it was changed as unix epoch time
+}
+
+// NewBusRouteChange compares the bus route before and after the update, a bus route is
+// deactivated if it was active and is not anymore, and rescheduled if its departure or
+// arrival time is changed.
+func NewBusRouteChange(old, route BusRoute) BusRouteChange {
+	var change = BusRouteChange{
+		Route:                 route,
+		PreviousDepartureTime: old.DepartureTime,
+		PreviousArrivalTime:   old.ArrivalTime,
+	}
+
+	wasActive := old.Active != nil && *old.Active
+	isActive := route.Active != nil && *route.Active
+
+	change.Deactivated = wasActive && !isActive
+	change.Rescheduled = old.DepartureTime != route.DepartureTime || old.ArrivalTime != route.ArrivalTime
+
+	return change
+}
+
+// IsChanged checks if the bus route has been deactivated or rescheduled.
+func (change BusRouteChange) IsChanged() bool {
+	return change.Deactivated || change.Rescheduled
+}
+
+// EventSource returns the EventBridge event source of the route change.
+func (BusRouteChange) EventSource() string {
+	return "route:changed"
+}
+
+// ParseBookingAction returns the upper-cased booking action of a deactivated bus route,
+// it defaults to REBOOK.
+func ParseBookingAction(value string) (string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+
+	switch value {
+	case "":
+		return ROUTE_CHANGE_REBOOK, nil
+
+	case ROUTE_CHANGE_CANCEL, ROUTE_CHANGE_REBOOK:
+		return value, nil
+
+	default:
+		return "", fmt.Errorf("invalid 'booking_action' value [valid: %s, %s]", ROUTE_CHANGE_CANCEL, ROUTE_CHANGE_REBOOK)
+	}
+}
+
+// SetValues generates the route change ID and sets the user account that changed the
+// bus route and the date it was changed as unix epoch time. The bookings of a deactivated
+// bus route that are cancelled are fully refunded, and the reason defaults to the
+// deactivation of the bus route.
+//
+// Example:
+//  id: RTC-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  date_changed: 1688091891
+func (change *BusRouteChange) SetValues(changedBy, bookingAction, reason string) error {
+	id, err := idgen.New("RTC")
+	if err != nil {
+		return err
+	}
+
+	change.ID = id
+	change.ChangedBy = changedBy
+	change.DateChanged = fmt.Sprint(time.Now().Unix())
+
+	if !change.Deactivated {
+		return nil
+	}
+
+	change.BookingAction = bookingAction
+	change.Reason = strings.TrimSpace(reason)
+	if change.Reason == "" {
+		change.Reason = "the bus route has been deactivated"
+	}
+
+	if change.BookingAction == ROUTE_CHANGE_CANCEL {
+		change.RefundPolicy = REFUND_FULL
+	}
+
+	return nil
+}
+
+// Error sets the default key-value pair.
+func (change BusRouteChange) Error(err error, code, message string, kv ...utility.KVP) {
+	if change.ID != "" {
+		kv = append(kv, utility.KVP{Key: "bus_route_change", Value: change})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Bus Route Change"})
+	utility.Error(err, code, message, kv...)
+}
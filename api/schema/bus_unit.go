@@ -3,6 +3,8 @@ package schema
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
@@ -10,6 +12,29 @@ import (
 
 const BUS_UNIT_MIN_CAPACITY = 25
 
+const (
+	AMENITY_AC                = "AC"                // The bus unit is air-conditioned
+	AMENITY_WIFI              = "WIFI"              // The bus unit has Wi-Fi
+	AMENITY_TOILET            = "TOILET"            // The bus unit has a toilet
+	AMENITY_WHEELCHAIR_ACCESS = "WHEELCHAIR_ACCESS" // The bus unit has a lift or a ramp and a space for a wheelchair
+	AMENITY_POWER_OUTLET      = "POWER_OUTLET"      // The seats have power outlets or USB chargers
+	AMENITY_RECLINING_SEATS   = "RECLINING_SEATS"   // The seats can be reclined
+	AMENITY_ENTERTAINMENT     = "ENTERTAINMENT"     // The bus unit has a TV or personal screens
+	AMENITY_DOUBLE_DECKER     = "DOUBLE_DECKER"     // The bus unit has two decks
+)
+
+// amenities are the amenities and the vehicle attributes that a bus unit can have.
+var amenities = map[string]bool{
+	AMENITY_AC:                true,
+	AMENITY_WIFI:              true,
+	AMENITY_TOILET:            true,
+	AMENITY_WHEELCHAIR_ACCESS: true,
+	AMENITY_POWER_OUTLET:      true,
+	AMENITY_RECLINING_SEATS:   true,
+	AMENITY_ENTERTAINMENT:     true,
+	AMENITY_DOUBLE_DECKER:     true,
+}
+
 // BusUnit represents a bus company's active bus unit and the specific
 // unit's capacity. The "Active" is set to a boolean pointer for us to
 // validate if it is set by checking if the value is "nil" since it will
@@ -20,17 +45,20 @@ const BUS_UNIT_MIN_CAPACITY = 25
 // The "dynamodbav" struct tag can be used to control the value
 // that will be marshaled into a AttributeValue.
 type BusUnit struct {
-	BusID       string `json:"bus_id" dynamodbav:"bus_id"`                                     // The Bus ID as the sort key
-	Code        string `json:"code" dynamodbav:"code"`                                         // Code is a uniqe identification of a bus unit
-	Active      *bool  `json:"active" dynamodbav:"active"`                                     // Whether the bus unit is on trip and accepts a true or false value
-	MinCapacity *int   `json:"min_capacity" dynamodbav:"min_capacity"`                         // The minimum number of passenger of a bus unit
-	MaxCapacity *int   `json:"max_capacity" dynamodbav:"max_capacity"`                         // The maximum number of passenger of a bus unit
-	DateCreated string `json:"date_created,omitempty" dynamodbav:"date_created,omitemptyelem"` // The date it was created as unix epoch time
+	BusID       string   `json:"bus_id" dynamodbav:"bus_id"`                                     // The Bus ID as the sort key
+	Code        string   `json:"code" dynamodbav:"code"`                                         // Code is a uniqe identification of a bus unit
+	Active      *bool    `json:"active" dynamodbav:"active"`                                     // Whether the bus unit is on trip and accepts a true or false value
+	MinCapacity *int     `json:"min_capacity" dynamodbav:"min_capacity"`                         // The minimum number of passenger of a bus unit
+	MaxCapacity *int     `json:"max_capacity" dynamodbav:"max_capacity"`                         // The maximum number of passenger of a bus unit
+	LayoutID    string   `json:"layout_id,omitempty" dynamodbav:"layout_id,omitempty"`           // The seat layout of the bus unit that its maximum capacity is derived from
+	Amenities   []string `json:"amenities,omitempty" dynamodbav:"amenities,omitempty"`           // The amenities and the vehicle attributes of the bus unit (e.g. AC, WIFI, TOILET)
+	DateCreated string   `json:"date_created,omitempty" dynamodbav:"date_created,omitemptyelem"` // The date it was created as unix epoch time
+	Version     int      `json:"version" dynamodbav:"version"`                                   // The version of the record that is incremented on every update
 }
 
 // Error sets the default key-value pair.
 func (unit BusUnit) Error(err error, code, message string, kv ...utility.KVP) {
-	if unit != (BusUnit{}) {
+	if unit.Code != "" || unit.BusID != "" {
 		kv = append(kv, utility.KVP{Key: "bus_unit", Value: unit})
 	}
 
@@ -58,14 +86,26 @@ func (unit BusUnit) IsEmptyPayload(payload string) error {
 //		date_created: 1658837116
 func (unit *BusUnit) SetValues() {
 	unit.DateCreated = fmt.Sprint(time.Now().Unix())
+	unit.Version = 1
+}
+
+// MinimumCapacity returns the minimum capacity requirement of the Bus Unit. It is
+// the BUS_UNIT_MIN_CAPACITY, or the capacity of the seat layout of a bus unit that
+// has fewer seats than that.
+func (unit BusUnit) MinimumCapacity() int {
+	if unit.LayoutID != "" && unit.MaxCapacity != nil && *unit.MaxCapacity < BUS_UNIT_MIN_CAPACITY {
+		return *unit.MaxCapacity
+	}
+
+	return BUS_UNIT_MIN_CAPACITY
 }
 
 // ValidateMinimumCapacity validates if the amount set for the minimum
 // capacity is not a negative amount or less than the minimum capacity
 // requirement of the Bus Unit.
 func (unit BusUnit) ValidateMinimumCapacity() error {
-	if unit.MinCapacity != nil && *unit.MinCapacity < BUS_UNIT_MIN_CAPACITY {
-		return fmt.Errorf("cannot set %v as the minimum capacity", *unit.MinCapacity)
+	if unit.MinCapacity != nil && *unit.MinCapacity < unit.MinimumCapacity() {
+		return fmt.Errorf("cannot set %v as the minimum capacity that is lower than %v", *unit.MinCapacity, unit.MinimumCapacity())
 	}
 
 	return nil
@@ -82,22 +122,77 @@ func (unit BusUnit) ValidateMaximumCapacity(min_capacity int) error {
 	return nil
 }
 
+// ParseAmenities splits the comma-separated amenities, uppercases them and checks if
+// every amenity is a valid one. The amenities are sorted and listed only once.
+//
+// Valid Amenities:
+//  AC, WIFI, TOILET, WHEELCHAIR_ACCESS, POWER_OUTLET, RECLINING_SEATS, ENTERTAINMENT,
+//  DOUBLE_DECKER
+func ParseAmenities(value string) ([]string, error) {
+	return normalizeAmenities(strings.Split(value, ","))
+}
+
+// normalizeAmenities uppercases the amenities, checks if every amenity is a valid one,
+// and returns them sorted and listed only once.
+func normalizeAmenities(list []string) ([]string, error) {
+	var (
+		set    = make(map[string]bool)
+		result = []string{}
+	)
+
+	for _, amenity := range list {
+		amenity = strings.ToUpper(strings.TrimSpace(amenity))
+		if amenity == "" || set[amenity] {
+			continue
+		}
+
+		if !amenities[amenity] {
+			return nil, fmt.Errorf("invalid amenity '%s'", amenity)
+		}
+
+		set[amenity] = true
+		result = append(result, amenity)
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// SetAmenities uppercases the amenities of the bus unit, checks if every amenity is a
+// valid one, and lists them only once.
+func (unit *BusUnit) SetAmenities() error {
+	if unit.Amenities == nil {
+		return nil
+	}
+
+	list, err := normalizeAmenities(unit.Amenities)
+	if err != nil {
+		return err
+	}
+
+	unit.Amenities = list
+	return nil
+}
+
 // FailedBusUnits represents the failed bus unit that needs to be re-processed.
 type FailedBusUnits struct {
 	Failed []struct {
+		Index  int     `json:"index"`
 		Unit   BusUnit `json:"unit"`
 		Reason string  `json:"reason,omitempty"`
 	} `json:"failed"`
 }
 
 // SetFailedUnits sets the failed bus units information transaction by passing the
-// Bus Unit and the reason why it failed.
-func (failed *FailedBusUnits) SetFailedUnits(unit BusUnit, reason string) {
+// index of the Bus Unit in the request, the Bus Unit and the reason why it failed.
+func (failed *FailedBusUnits) SetFailedUnits(index int, unit BusUnit, reason string) {
 	data := struct {
+		Index  int     `json:"index"`
 		Unit   BusUnit `json:"unit"`
 		Reason string  `json:"reason,omitempty"`
 	}{}
 
+	data.Index = index
 	data.Unit = unit
 	data.Reason = reason
 	failed.Failed = append(failed.Failed, data)
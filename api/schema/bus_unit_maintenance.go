@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// BusUnitMaintenance is a window of days that a bus unit is under maintenance. The bus
+// routes of the bus unit cannot be booked on these days, and the existing bookings are
+// flagged to be reassigned to another bus unit.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type BusUnitMaintenance struct {
+	BusID       string `json:"bus_id" dynamodbav:"bus_id"`                     // The Bus ID of the bus unit as the partition/primary key
+	ID          string `json:"id" dynamodbav:"id"`                             // The unique maintenance ID as the sort key
+	BusUnitID   string `json:"bus_unit_id" dynamodbav:"bus_unit_id"`           // The code of the bus unit that is under maintenance
+	StartDate   string `json:"start_date" dynamodbav:"start_date"`             // The first day of the maintenance in the "2006-01-02" format
+	EndDate     string `json:"end_date" dynamodbav:"end_date"`                 // The last day of the maintenance in the "2006-01-02" format
+	Reason      string `json:"reason,omitempty" dynamodbav:"reason,omitempty"` // The reason of the maintenance (e.g. engine repair)
+	CreatedBy   string `json:"created_by" dynamodbav:"created_by"`             // The ID of the user account that scheduled the maintenance
+	DateCreated string `json:"date_created" dynamodbav:"date_created"`         // The date it was created as unix epoch time
+}
+
+// MaintenanceConflict is a booking whose travel date is within the maintenance of the
+// bus unit of its bus route, and that has to be reassigned.
+type MaintenanceConflict struct {
+	Maintenance BusUnitMaintenance `json:"maintenance"` // The maintenance of the bus unit
+	Booking     Bookings           `json:"booking"`     // The booking that has to be reassigned
+}
+
+// Error sets the default key-value pair.
+func (maintenance BusUnitMaintenance) Error(err error, code, message string, kv ...utility.KVP) {
+	if maintenance != (BusUnitMaintenance{}) {
+		kv = append(kv, utility.KVP{Key: "bus_unit_maintenance", Value: maintenance})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Bus Unit Maintenance"})
+	utility.Error(err, code, message, kv...)
+}
+
+// Validate checks if the bus unit and the dates of the maintenance are set, and if the
+// start date is not after the end date.
+func (maintenance BusUnitMaintenance) Validate() error {
+	if maintenance.BusID == "" || maintenance.BusUnitID == "" {
+		return errors.New("bus_id and bus_unit_id are required")
+	}
+
+	start, err := time.Parse("2006-01-02", maintenance.StartDate)
+	if err != nil {
+		return errors.New("invalid 'start_date' value [format: YYYY-MM-DD]")
+	}
+
+	end, err := time.Parse("2006-01-02", maintenance.EndDate)
+	if err != nil {
+		return errors.New("invalid 'end_date' value [format: YYYY-MM-DD]")
+	}
+
+	if end.Before(start) {
+		return errors.New("'start_date' must not be after 'end_date'")
+	}
+
+	return nil
+}
+
+// SetValues generates the maintenance ID and sets the user account that scheduled
+// it and the date it was created as unix epoch time.
+//
+// Example:
+//  id: MNT-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  date_created: 1688091891
+func (maintenance *BusUnitMaintenance) SetValues(createdBy string) error {
+	id, err := idgen.New("MNT")
+	if err != nil {
+		return err
+	}
+
+	maintenance.ID = id
+	maintenance.CreatedBy = createdBy
+	maintenance.DateCreated = fmt.Sprint(time.Now().Unix())
+
+	return nil
+}
+
+// Covers checks if the day of the travel date is within the maintenance, both the start
+// and the end date are included. Only the "2006-01-02" prefix of the travel date is
+// compared, so that a travel date with a time (e.g. "2023-07-06 19:30") is covered too.
+func (maintenance BusUnitMaintenance) Covers(travelDate string) bool {
+	if len(travelDate) < len("2006-01-02") {
+		return false
+	}
+
+	day := travelDate[:len("2006-01-02")]
+	return maintenance.StartDate <= day && day <= maintenance.EndDate
+}
+
+// Overlaps checks if the maintenance shares at least one day with the other maintenance
+// of the same bus unit.
+func (maintenance BusUnitMaintenance) Overlaps(other BusUnitMaintenance) bool {
+	if maintenance.BusID != other.BusID || maintenance.BusUnitID != other.BusUnitID {
+		return false
+	}
+
+	return maintenance.StartDate <= other.EndDate && other.StartDate <= maintenance.EndDate
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+const (
+	CREW_DRIVER    = "DRIVER"    // Drives the bus unit, a valid licence is required
+	CREW_CONDUCTOR = "CONDUCTOR" // Assists the passengers and collects the fares
+)
+
+// Crew is a driver or a conductor of a bus line that can be assigned to the trips
+// of its bus routes.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type Crew struct {
+	BusID         string `json:"bus_id" dynamodbav:"bus_id"`                                     // The Bus ID of the bus line as the partition/primary key
+	ID            string `json:"id" dynamodbav:"id"`                                             // The unique crew ID as the sort key
+	Role          string `json:"role" dynamodbav:"role"`                                         // The role of the crew (e.g. DRIVER, CONDUCTOR)
+	FirstName     string `json:"first_name" dynamodbav:"first_name"`                             // The first name of the crew
+	LastName      string `json:"last_name" dynamodbav:"last_name"`                               // The last name of the crew
+	MobileNumber  string `json:"mobile_number,omitempty" dynamodbav:"mobile_number,omitempty"`   // The mobile number of the crew
+	LicenceNumber string `json:"licence_number,omitempty" dynamodbav:"licence_number,omitempty"` // The number of the driver's licence
+	LicenceType   string `json:"licence_type,omitempty" dynamodbav:"licence_type,omitempty"`     // The type or restriction code of the driver's licence
+	LicenceExpiry string `json:"licence_expiry,omitempty" dynamodbav:"licence_expiry,omitempty"` // The expiry date of the driver's licence in the "2006-01-02" format
+	Active        *bool  `json:"active" dynamodbav:"active"`                                     // Defines if the crew can be assigned to the trips
+	DateCreated   string `json:"date_created,omitempty" dynamodbav:"date_created,omitempty"`     // The date it was created as unix epoch time
+	Version       int    `json:"version" dynamodbav:"version"`                                   // The version of the record that is incremented on every update
+}
+
+// Error sets the default key-value pair.
+func (crew Crew) Error(err error, code, message string, kv ...utility.KVP) {
+	if crew != (Crew{}) {
+		kv = append(kv, utility.KVP{Key: "crew", Value: crew})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Crew"})
+	utility.Error(err, code, message, kv...)
+}
+
+// IsEmptyPayload checks if the request payload is empty and if it is,
+// it will return an error message.
+func (crew Crew) IsEmptyPayload(payload string) error {
+	if payload == "" {
+		err := errors.New("request payload is empty")
+		crew.Error(err, "APIError", "request payload is empty")
+
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks if the role and the name of the crew are set, and if a driver has
+// the number and the expiry date of its licence.
+func (crew Crew) Validate() error {
+	crew.Role = strings.ToUpper(crew.Role)
+	if crew.Role != CREW_DRIVER && crew.Role != CREW_CONDUCTOR {
+		return fmt.Errorf("invalid 'role' value, it must be %s or %s", CREW_DRIVER, CREW_CONDUCTOR)
+	}
+
+	if crew.FirstName == "" || crew.LastName == "" {
+		return errors.New("first_name and last_name are required")
+	}
+
+	if crew.Role == CREW_DRIVER && (crew.LicenceNumber == "" || crew.LicenceExpiry == "") {
+		return errors.New("licence_number and licence_expiry are required for a driver")
+	}
+
+	if crew.LicenceExpiry != "" {
+		_, err := time.Parse("2006-01-02", crew.LicenceExpiry)
+		if err != nil {
+			return errors.New("invalid 'licence_expiry' value [format: YYYY-MM-DD]")
+		}
+	}
+
+	return nil
+}
+
+// SetValues generates the crew ID and sets the default values of the crew.
+//
+// Example:
+//  id: CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  active: true
+//  date_created: 1688091891
+func (crew *Crew) SetValues() error {
+	id, err := idgen.New("CRW")
+	if err != nil {
+		return err
+	}
+
+	crew.ID = id
+	crew.Role = strings.ToUpper(crew.Role)
+	crew.DateCreated = fmt.Sprint(time.Now().Unix())
+	crew.Version = 1
+
+	if crew.Active == nil {
+		active := true
+		crew.Active = &active
+	}
+
+	return nil
+}
+
+// IsActive checks if the crew can be assigned to the trips.
+func (crew Crew) IsActive() bool {
+	return crew.Active == nil || *crew.Active
+}
+
+// HasValidLicence checks if the licence of a driver has not expired on the day, the
+// other roles do not need a licence.
+func (crew Crew) HasValidLicence(day string) bool {
+	if crew.Role != CREW_DRIVER {
+		return true
+	}
+
+	return crew.LicenceExpiry != "" && day <= crew.LicenceExpiry
+}
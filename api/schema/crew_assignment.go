@@ -0,0 +1,244 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// CrewAssignment assigns a crew to the trips of a bus route. It is assigned to a single
+// trip if the travel date is set, otherwise to every trip of the bus route. The schedule
+// of the bus route is copied so that the duty of the crew can be checked without it, and
+// it is updated when the bus route is rescheduled. An assignment whose trips conflict
+// with the other trips of the crew after it is rescheduled is flagged with the conflict.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type CrewAssignment struct {
+	CrewID        string `json:"crew_id" dynamodbav:"crew_id"`                             // The crew ID as the partition/primary key
+	ID            string `json:"id" dynamodbav:"id"`                                       // The unique assignment ID as the sort key
+	BusID         string `json:"bus_id" dynamodbav:"bus_id"`                               // The Bus ID of the bus line of the crew
+	BusRouteID    string `json:"bus_route_id" dynamodbav:"bus_route_id"`                   // The bus route that the crew is assigned to
+	Role          string `json:"role" dynamodbav:"role"`                                   // The role of the crew on the trip (e.g. DRIVER, CONDUCTOR)
+	TravelDate    string `json:"travel_date,omitempty" dynamodbav:"travel_date,omitempty"` // The day of the trip in the "2006-01-02" format, every trip if it is empty
+	DepartureTime string `json:"departure_time" dynamodbav:"departure_time"`               // The departure time of the bus route in 24-hour format
+	ArrivalTime   string `json:"arrival_time" dynamodbav:"arrival_time"`                   // The arrival time of the bus route in 24-hour format
+	AssignedBy    string `json:"assigned_by" dynamodbav:"assigned_by"`                     // The ID of the user account that assigned the crew
+	Conflict      string `json:"conflict,omitempty" dynamodbav:"conflict,omitempty"`       // Why the trips conflict with the other trips of the crew since the bus route was rescheduled
+	DateCreated   string `json:"date_created" dynamodbav:"date_created"`                   // The date it was created as unix epoch time
+}
+
+// CrewConflictError is returned when the trips of an assignment overlap the other trips
+// of the crew, or the total duty of the crew exceeds its maximum duty.
+type CrewConflictError struct {
+	Reason string // Why the assignment conflicts with the other assignments of the crew
+}
+
+// Error returns the reason of the conflict.
+func (err CrewConflictError) Error() string {
+	return err.Reason
+}
+
+// Error sets the default key-value pair.
+func (assignment CrewAssignment) Error(err error, code, message string, kv ...utility.KVP) {
+	if assignment != (CrewAssignment{}) {
+		kv = append(kv, utility.KVP{Key: "crew_assignment", Value: assignment})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Crew Assignment"})
+	utility.Error(err, code, message, kv...)
+}
+
+// Validate checks if the crew and the bus route of the assignment are set, and if the
+// travel date is a valid date.
+func (assignment CrewAssignment) Validate() error {
+	if assignment.CrewID == "" || assignment.BusID == "" || assignment.BusRouteID == "" {
+		return errors.New("crew_id, bus_id and bus_route_id are required")
+	}
+
+	if assignment.TravelDate != "" {
+		_, err := time.Parse("2006-01-02", assignment.TravelDate)
+		if err != nil {
+			return errors.New("invalid 'travel_date' value [format: YYYY-MM-DD]")
+		}
+	}
+
+	return nil
+}
+
+// SetValues generates the assignment ID, and sets the role of the crew, the schedule
+// of the bus route, the user account that assigned it and the date it was created as
+// unix epoch time.
+//
+// Example:
+//  id: ASN-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  role: DRIVER
+//  departure_time: 15:00
+//  arrival_time: 17:00
+//  date_created: 1688091891
+func (assignment *CrewAssignment) SetValues(crew Crew, route BusRoute, assignedBy string) error {
+	id, err := idgen.New("ASN")
+	if err != nil {
+		return err
+	}
+
+	assignment.ID = id
+	assignment.Role = crew.Role
+	assignment.DepartureTime = route.DepartureTime
+	assignment.ArrivalTime = route.ArrivalTime
+	assignment.AssignedBy = assignedBy
+	assignment.DateCreated = fmt.Sprint(time.Now().Unix())
+
+	return nil
+}
+
+// Covers checks if the crew is assigned to the trip on the day of the travel date. Only
+// the "2006-01-02" prefix of the travel date is compared.
+func (assignment CrewAssignment) Covers(travelDate string) bool {
+	if assignment.TravelDate == "" {
+		return true
+	}
+
+	return len(travelDate) >= len("2006-01-02") && travelDate[:len("2006-01-02")] == assignment.TravelDate
+}
+
+// schedule returns the minutes of the departure and the arrival since the midnight of
+// the day of the trip. The arrival is on the next day if it is not after the departure.
+func (assignment CrewAssignment) schedule() (int, int, error) {
+	departure, err := time.Parse("15:04", assignment.DepartureTime)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid departure time of the assignment %s", assignment.ID)
+	}
+
+	arrival, err := time.Parse("15:04", assignment.ArrivalTime)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid arrival time of the assignment %s", assignment.ID)
+	}
+
+	start := departure.Hour()*60 + departure.Minute()
+	end := arrival.Hour()*60 + arrival.Minute()
+	if end <= start {
+		end += 24 * 60
+	}
+
+	return start, end, nil
+}
+
+// Duration returns how long the trip of the assignment takes.
+func (assignment CrewAssignment) Duration() (time.Duration, error) {
+	start, end, err := assignment.schedule()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(end-start) * time.Minute, nil
+}
+
+// Overlaps checks if the trips of the assignment and the other assignment of the crew
+// are on the road at the same time. A trip that arrives on the next day can overlap
+// the trips of the next day.
+func (assignment CrewAssignment) Overlaps(other CrewAssignment) (bool, error) {
+	start, end, err := assignment.schedule()
+	if err != nil {
+		return false, err
+	}
+
+	otherStart, otherEnd, err := other.schedule()
+	if err != nil {
+		return false, err
+	}
+
+	// The days of the other trips relative to the day of the trip, every trip of the
+	// bus route can be on the day before, on the same day or on the day after.
+	var days = []int{-1, 0, 1}
+	if assignment.TravelDate != "" && other.TravelDate != "" {
+		day, _ := time.Parse("2006-01-02", assignment.TravelDate)
+		otherDay, _ := time.Parse("2006-01-02", other.TravelDate)
+
+		days = []int{int(otherDay.Sub(day).Hours() / 24)}
+	}
+
+	for _, day := range days {
+		offset := day * 24 * 60
+		if start < otherEnd+offset && otherStart+offset < end {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Duty returns the total duty of the crew with the assignment and its other assignments.
+// If the assignment is on a single trip, it is the duty on the day of the trip, otherwise
+// it is the duty on the busiest day.
+func (assignment CrewAssignment) Duty(others []CrewAssignment) (time.Duration, error) {
+	var (
+		daily time.Duration
+		dated = make(map[string]time.Duration)
+	)
+
+	for _, item := range append(others, assignment) {
+		duration, err := item.Duration()
+		if err != nil {
+			return 0, err
+		}
+
+		if item.TravelDate == "" {
+			daily += duration
+			continue
+		}
+
+		dated[item.TravelDate] += duration
+	}
+
+	if assignment.TravelDate != "" {
+		return daily + dated[assignment.TravelDate], nil
+	}
+
+	var busiest time.Duration
+	for _, duration := range dated {
+		if duration > busiest {
+			busiest = duration
+		}
+	}
+
+	return daily + busiest, nil
+}
+
+// CheckSchedule checks if the trips of the assignment overlap the other assignments of
+// the crew, or the total duty of the crew exceeds the maximum duty, otherwise it returns
+// a CrewConflictError. The trips that departed before today are not counted.
+func (assignment CrewAssignment) CheckSchedule(others []CrewAssignment, today string, maxDuty time.Duration) error {
+	var upcoming []CrewAssignment
+
+	for _, other := range others {
+		if other.ID == assignment.ID || (other.TravelDate != "" && other.TravelDate < today) {
+			continue
+		}
+
+		overlaps, err := assignment.Overlaps(other)
+		if err != nil {
+			return err
+		}
+
+		if overlaps {
+			return CrewConflictError{Reason: fmt.Sprintf("the trip overlaps the assignment %s of the crew", other.ID)}
+		}
+
+		upcoming = append(upcoming, other)
+	}
+
+	duty, err := assignment.Duty(upcoming)
+	if err != nil {
+		return err
+	}
+
+	if duty > maxDuty {
+		return CrewConflictError{Reason: fmt.Sprintf("the duty of the crew would be %s, it exceeds the maximum duty of %s", duty, maxDuty)}
+	}
+
+	return nil
+}
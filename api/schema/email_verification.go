@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// EmailVerification is a pending verification of the e-mail address of a user account.
+// Only the hash of the verification token is stored, the token itself is only sent to
+// the e-mail address that is being verified.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type EmailVerification struct {
+	TokenHash   string `json:"-" dynamodbav:"token_hash"`                        // The SHA-256 hash of the verification token and the partition/primary key
+	UserID      string `json:"user_id" dynamodbav:"user_id"`                     // The ID of the user account
+	Username    string `json:"username" dynamodbav:"username"`                   // The username of the user account
+	Email       string `json:"email" dynamodbav:"email"`                         // The e-mail address that is being verified
+	ExpiresAt   int64  `json:"expires_at" dynamodbav:"expires_at"`               // The time it expires in seconds since the Unix epoch, it is the TTL attribute
+	DateCreated string `json:"date_created" dynamodbav:"date_created,omitempty"` // The date it was created
+}
+
+// NewEmailVerification returns the verification of the current e-mail address of the user
+// account that expires after the ttl.
+func NewEmailVerification(user User, tokenHash string, ttl time.Duration) EmailVerification {
+	var now = time.Now()
+
+	return EmailVerification{
+		TokenHash:   tokenHash,
+		UserID:      user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		ExpiresAt:   now.Add(ttl).Unix(),
+		DateCreated: fmt.Sprint(now.Unix()),
+	}
+}
+
+// Error sets the default key-value pair. The token hash is never logged.
+func (verification EmailVerification) Error(err error, code, message string, kv ...utility.KVP) {
+	verification.TokenHash = ""
+	if verification != (EmailVerification{}) {
+		kv = append(kv, utility.KVP{Key: "email_verification", Value: verification})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Email Verification"})
+	utility.Error(err, code, message, kv...)
+}
@@ -0,0 +1,51 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// LoginAttempt contains the failed login attempts of a username or of a source IP
+// address, and until when it is locked.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type LoginAttempt struct {
+	Key         string `json:"key" dynamodbav:"key"`                                       // Either the username or the source IP key and the partition/primary key
+	Failures    int    `json:"failures" dynamodbav:"failures"`                             // The number of consecutive failed login attempts
+	LastFailure int64  `json:"last_failure" dynamodbav:"last_failure"`                     // The time of the last failed login attempt in seconds since the Unix epoch
+	LockedUntil int64  `json:"locked_until,omitempty" dynamodbav:"locked_until,omitempty"` // The time the lockout ends in seconds since the Unix epoch
+	ExpiresAt   int64  `json:"expires_at" dynamodbav:"expires_at"`                         // The time it is forgotten in seconds since the Unix epoch, it is the TTL attribute
+}
+
+// UsernameAttemptKey returns the key of the login attempts of the username.
+//
+// Example:
+//  username#emilydavis
+func UsernameAttemptKey(username string) string {
+	return "username#" + username
+}
+
+// SourceIPAttemptKey returns the key of the login attempts of the source IP address.
+//
+// Example:
+//  ip#203.0.113.7
+func SourceIPAttemptKey(sourceIP string) string {
+	return "ip#" + sourceIP
+}
+
+// IsLocked checks if the login attempts are locked at the time.
+func (attempt LoginAttempt) IsLocked(now time.Time) bool {
+	return attempt.LockedUntil > now.Unix()
+}
+
+// Error sets the default key-value pair.
+func (attempt LoginAttempt) Error(err error, code, message string, kv ...utility.KVP) {
+	if attempt != (LoginAttempt{}) {
+		kv = append(kv, utility.KVP{Key: "login_attempt", Value: attempt})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Login Attempt"})
+	utility.Error(err, code, message, kv...)
+}
@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+const (
+	NOTIFY_BOOKING_CONFIRMED   = "BOOKING_CONFIRMED"
+	NOTIFY_BOOKING_CANCELLED   = "BOOKING_CANCELLED"
+	NOTIFY_BOOKING_RESCHEDULED = "BOOKING_RESCHEDULED"
+	NOTIFY_BOOKING_REBOOK      = "BOOKING_REBOOK"
+	NOTIFY_EMAIL_VERIFICATION  = "EMAIL_VERIFICATION"
+	NOTIFY_PASSWORD_RESET      = "PASSWORD_RESET"
+	NOTIFY_ACCOUNT_LOCKED      = "ACCOUNT_LOCKED"
+)
+
+// Notification is an e-mail that has been sent to a user account. Only the subject of
+// the e-mail is kept, not its message, since the message might contain a token.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type Notification struct {
+	UserID    string `json:"user_id" dynamodbav:"user_id"`                           // The ID of the user account and the partition/primary key
+	ID        string `json:"id" dynamodbav:"id"`                                     // The unique notification ID and the sort key, it is sorted by the time it was sent
+	Type      string `json:"type" dynamodbav:"type"`                                 // The type of the notification (e.g. BOOKING_CONFIRMED)
+	BookingID string `json:"booking_id,omitempty" dynamodbav:"booking_id,omitempty"` // The booking that the notification is about
+	ChangeID  string `json:"change_id,omitempty" dynamodbav:"change_id,omitempty"`   // The bus route change that the notification is about
+	Recipient string `json:"recipient" dynamodbav:"recipient"`                       // The e-mail address(es) it was sent to
+	Subject   string `json:"subject" dynamodbav:"subject"`                           // The subject of the e-mail
+	DateSent  string `json:"date_sent" dynamodbav:"date_sent"`                       // The date it was sent
+}
+
+// NewNotification returns the notification of the type that is sent to the user account,
+// the booking ID is only set if it is about a booking.
+//
+// Example:
+//  id: NTF-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  type: BOOKING_CONFIRMED
+func NewNotification(user User, notificationType, bookingId string) Notification {
+	return Notification{
+		UserID:    user.ID,
+		Type:      notificationType,
+		BookingID: bookingId,
+	}
+}
+
+// SetSent sets the ID, the recipients and the subject of the e-mail, and the date it
+// was sent.
+func (notification *Notification) SetSent(recipients []string, subject string) error {
+	id, err := idgen.New("NTF")
+	if err != nil {
+		return err
+	}
+
+	notification.ID = id
+	notification.Recipient = strings.Join(recipients, ",")
+	notification.Subject = subject
+	notification.DateSent = fmt.Sprint(time.Now().Unix())
+
+	return nil
+}
+
+// Error sets the default key-value pair. The recipient is never logged.
+func (notification Notification) Error(err error, code, message string, kv ...utility.KVP) {
+	notification.Recipient = ""
+	if notification != (Notification{}) {
+		kv = append(kv, utility.KVP{Key: "notification", Value: notification})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Notification"})
+	utility.Error(err, code, message, kv...)
+}
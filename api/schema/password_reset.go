@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// PasswordReset is a pending request to reset the password of a user account. Only the
+// hash of the reset token is stored, the token itself is only sent to the user e-mail.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type PasswordReset struct {
+	TokenHash      string `json:"-" dynamodbav:"token_hash"`                        // The SHA-256 hash of the reset token and the partition/primary key
+	UserID         string `json:"user_id" dynamodbav:"user_id"`                     // The ID of the user account
+	Username       string `json:"username" dynamodbav:"username"`                   // The username of the user account
+	SessionVersion int    `json:"session_version" dynamodbav:"session_version"`     // The session version of the user account when it was requested
+	ExpiresAt      int64  `json:"expires_at" dynamodbav:"expires_at"`               // The time it expires in seconds since the Unix epoch, it is the TTL attribute
+	DateCreated    string `json:"date_created" dynamodbav:"date_created,omitempty"` // The date it was created
+}
+
+// NewPasswordReset returns the password reset of the user account that expires after the ttl.
+func NewPasswordReset(user User, tokenHash string, ttl time.Duration) PasswordReset {
+	var now = time.Now()
+
+	return PasswordReset{
+		TokenHash:      tokenHash,
+		UserID:         user.ID,
+		Username:       user.Username,
+		SessionVersion: user.SessionVersion,
+		ExpiresAt:      now.Add(ttl).Unix(),
+		DateCreated:    fmt.Sprint(now.Unix()),
+	}
+}
+
+// IsExpired checks if the password reset can no longer be used.
+func (reset PasswordReset) IsExpired() bool {
+	return time.Now().Unix() >= reset.ExpiresAt
+}
+
+// Error sets the default key-value pair. The token hash is never logged.
+func (reset PasswordReset) Error(err error, code, message string, kv ...utility.KVP) {
+	reset.TokenHash = ""
+	if reset != (PasswordReset{}) {
+		kv = append(kv, utility.KVP{Key: "password_reset", Value: reset})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Password Reset"})
+	utility.Error(err, code, message, kv...)
+}
@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"sort"
+	"strings"
+)
+
+// SeatAvailability is the number of seats of every seat class of a trip that can still
+// be booked, a trip is a bus route on a travel date.
+type SeatAvailability struct {
+	BusRouteID string              `json:"bus_route_id"`  // The bus route of the trip
+	TravelDate string              `json:"travel_date"`   // The day of the trip in the "2006-01-02" format
+	Currency   string              `json:"currency_code"` // The currency of the fares
+	Classes    []ClassAvailability `json:"classes"`       // The availability of every seat class of the bus unit
+}
+
+// ClassAvailability is the fare and the number of seats of a seat class of a trip.
+type ClassAvailability struct {
+	Class     string   `json:"class"`           // The seat class (e.g. STANDARD, PREMIUM, SLEEPER)
+	Fare      float64  `json:"fare"`            // The fare of a seat of the class
+	Capacity  int      `json:"capacity"`        // The number of seats of the class that can be booked
+	Booked    int      `json:"booked"`          // The number of seats of the class that are booked
+	Available int      `json:"available"`       // The number of seats of the class that are not booked yet
+	Seats     []string `json:"seats,omitempty"` // The seat numbers of the class that are not booked yet
+}
+
+// NewSeatAvailability counts the seats of every class of the seat layout of the bus unit
+// that are booked by the bookings of the trip that are not cancelled. A bus unit without
+// a seat layout has STANDARD seats up to its maximum capacity.
+func NewSeatAvailability(route BusRoute, unit BusUnit, layout SeatLayout, travelDate string, bookings []Bookings) SeatAvailability {
+	var (
+		booked  = make(map[string]bool)
+		classes = make(map[string]*ClassAvailability)
+	)
+
+	for _, booking := range bookings {
+		for _, seat := range strings.Split(booking.SeatNumber, ",") {
+			seat = strings.TrimSpace(seat)
+			if seat != "" {
+				booked[seat] = true
+			}
+		}
+	}
+
+	class := func(name string) *ClassAvailability {
+		if _, ok := classes[name]; !ok {
+			classes[name] = &ClassAvailability{Class: name, Fare: route.Fare(name)}
+		}
+
+		return classes[name]
+	}
+
+	if layout.ID != "" {
+		for _, row := range layout.Rows {
+			for _, cell := range row {
+				if cell.Type != SEAT {
+					continue
+				}
+
+				seats := class(layout.SeatClass(cell.Label))
+				seats.Capacity++
+
+				if booked[cell.Label] {
+					seats.Booked++
+					continue
+				}
+
+				seats.Available++
+				seats.Seats = append(seats.Seats, cell.Label)
+			}
+		}
+
+	} else {
+		seats := class(SEAT_CLASS_STANDARD)
+		seats.Booked = len(booked)
+
+		if unit.MaxCapacity != nil {
+			seats.Capacity = *unit.MaxCapacity
+		}
+
+		if seats.Capacity > seats.Booked {
+			seats.Available = seats.Capacity - seats.Booked
+		}
+	}
+
+	var availability = SeatAvailability{
+		BusRouteID: route.ID,
+		TravelDate: travelDate,
+		Currency:   route.Currency,
+		Classes:    []ClassAvailability{},
+	}
+
+	for _, seats := range classes {
+		availability.Classes = append(availability.Classes, *seats)
+	}
+
+	sort.Slice(availability.Classes, func(i, j int) bool {
+		return availability.Classes[i].Class < availability.Classes[j].Class
+	})
+
+	return availability
+}
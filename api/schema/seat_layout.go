@@ -0,0 +1,263 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+const (
+	SEAT_LAYOUT_TEMPLATE    = "TEMPLATE" // The "bus_id" of the seat layouts that are shared by every bus line
+	SEAT_LAYOUT_MAX_ROWS    = 30         // The maximum number of rows of a seat layout
+	SEAT_LAYOUT_MAX_COLUMNS = 10         // The maximum number of cells of a row
+)
+
+const (
+	SEAT         = "SEAT"    // A seat that can be booked
+	SEAT_BLOCKED = "BLOCKED" // A seat that cannot be booked (e.g. broken, reserved for the crew)
+	SEAT_AISLE   = "AISLE"   // The walkway between the seats
+	SEAT_DOOR    = "DOOR"    // The position of a door
+	SEAT_DRIVER  = "DRIVER"  // The position of the driver
+	SEAT_EMPTY   = "EMPTY"   // An empty space (e.g. the stairs, the toilet)
+)
+
+// SEAT_CLASS_STANDARD is the class of the seats that are not given a class, and of every
+// seat of a bus unit without a seat layout.
+const SEAT_CLASS_STANDARD = "STANDARD"
+
+// Seat is a cell of the seat layout grid. Only the seats and the blocked
+// seats have a label and a class.
+type Seat struct {
+	Label string `json:"label,omitempty" dynamodbav:"label,omitempty"` // The seat number that is used in the bookings
+	Type  string `json:"type" dynamodbav:"type"`                       // The type of the cell (e.g. SEAT, BLOCKED, AISLE, DOOR)
+	Class string `json:"class,omitempty" dynamodbav:"class,omitempty"` // The class of the seat that its fare is based on (e.g. STANDARD, PREMIUM, SLEEPER)
+}
+
+// SeatLayout is the grid of the seats of a bus unit from the front to the back of the
+// bus. It belongs to a bus line and can be attached to its bus units, or it is shared
+// by every bus line as a template if the "bus_id" is TEMPLATE.
+//
+// A seat layout cannot be changed once it is created, so that the seat numbers of the
+// bookings stay valid. A new seat layout is attached to the bus unit instead.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type SeatLayout struct {
+	BusID       string         `json:"bus_id" dynamodbav:"bus_id"`             // The Bus ID of the bus line or TEMPLATE as the partition/primary key
+	ID          string         `json:"id" dynamodbav:"id"`                     // The unique seat layout ID as the sort key
+	Name        string         `json:"name" dynamodbav:"name"`                 // The name of the seat layout (e.g. "2x2 Deluxe 45 seater")
+	Rows        [][]Seat       `json:"rows" dynamodbav:"rows"`                 // The rows of the seat layout from the front to the back
+	Capacity    int            `json:"capacity" dynamodbav:"capacity"`         // The number of seats that can be booked
+	Classes     map[string]int `json:"classes" dynamodbav:"classes"`           // The number of seats of every class that can be booked
+	CreatedBy   string         `json:"created_by" dynamodbav:"created_by"`     // The ID of the user account that created it
+	DateCreated string         `json:"date_created" dynamodbav:"date_created"` // The date it was created as unix epoch time
+}
+
+// Error sets the default key-value pair.
+func (layout SeatLayout) Error(err error, code, message string, kv ...utility.KVP) {
+	if layout.ID != "" || layout.BusID != "" {
+		kv = append(kv, utility.KVP{Key: "seat_layout", Value: layout})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – Seat Layout"})
+	utility.Error(err, code, message, kv...)
+}
+
+// IsEmptyPayload checks if the request payload is empty and if it is,
+// it will return an error message.
+func (layout SeatLayout) IsEmptyPayload(payload string) error {
+	if payload == "" {
+		err := errors.New("request payload is empty")
+		layout.Error(err, "APIError", "request payload is empty")
+
+		return err
+	}
+
+	return nil
+}
+
+// IsTemplate checks if the seat layout is shared by every bus line.
+func (layout SeatLayout) IsTemplate() bool {
+	return layout.BusID == SEAT_LAYOUT_TEMPLATE
+}
+
+// Validate checks if the name is set, if the size of the grid is within the limits, if
+// every cell has a valid type, if the labels of the seats are set and unique, and if
+// the classes of the seats are single words.
+func (layout SeatLayout) Validate() error {
+	if layout.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if len(layout.Rows) == 0 || len(layout.Rows) > SEAT_LAYOUT_MAX_ROWS {
+		return fmt.Errorf("a seat layout must have 1 to %d rows", SEAT_LAYOUT_MAX_ROWS)
+	}
+
+	var labels = make(map[string]bool)
+	for index, row := range layout.Rows {
+		if len(row) == 0 || len(row) > SEAT_LAYOUT_MAX_COLUMNS {
+			return fmt.Errorf("row %d must have 1 to %d cells", index+1, SEAT_LAYOUT_MAX_COLUMNS)
+		}
+
+		for _, cell := range row {
+			switch strings.ToUpper(cell.Type) {
+			case SEAT, SEAT_BLOCKED:
+				label := strings.TrimSpace(cell.Label)
+				if label == "" {
+					return fmt.Errorf("a seat on row %d has no label", index+1)
+				}
+
+				if strings.Contains(label, ",") {
+					return fmt.Errorf("the seat label %s cannot contain a comma", label)
+				}
+
+				if labels[label] {
+					return fmt.Errorf("the seat label %s is used more than once", label)
+				}
+
+				if strings.ContainsAny(strings.TrimSpace(cell.Class), " ,") {
+					return fmt.Errorf("the class of the seat %s cannot contain a space or a comma", label)
+				}
+
+				labels[label] = true
+
+			case SEAT_AISLE, SEAT_DOOR, SEAT_DRIVER, SEAT_EMPTY:
+
+			default:
+				return fmt.Errorf("invalid seat type '%s' on row %d", cell.Type, index+1)
+			}
+		}
+	}
+
+	if layout.capacity() == 0 {
+		return errors.New("a seat layout must have at least one seat that can be booked")
+	}
+
+	return nil
+}
+
+// capacity returns the number of seats that can be booked.
+func (layout SeatLayout) capacity() int {
+	var count int
+	for _, row := range layout.Rows {
+		for _, cell := range row {
+			if strings.ToUpper(cell.Type) == SEAT {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// classes returns the number of seats of every class that can be booked.
+func (layout SeatLayout) classes() map[string]int {
+	var classes = make(map[string]int)
+	for _, row := range layout.Rows {
+		for _, cell := range row {
+			if cell.Type == SEAT {
+				classes[cell.Class]++
+			}
+		}
+	}
+
+	return classes
+}
+
+// SetValues generates the seat layout ID, normalizes the cells of the grid and sets
+// the capacity and the classes that are derived from the seats, the user account that
+// created it and the date it was created as unix epoch time. A seat without a class
+// is a STANDARD seat.
+//
+// Example:
+//  id: LYT-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  capacity: 45
+//  classes: {"STANDARD": 37, "PREMIUM": 8}
+//  date_created: 1688091891
+func (layout *SeatLayout) SetValues(createdBy string) error {
+	id, err := idgen.New("LYT")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range layout.Rows {
+		for index, cell := range row {
+			row[index].Type = strings.ToUpper(cell.Type)
+			row[index].Label = strings.TrimSpace(cell.Label)
+			row[index].Class = strings.ToUpper(strings.TrimSpace(cell.Class))
+
+			// Only the seats are labelled and have a class
+			if row[index].Type != SEAT && row[index].Type != SEAT_BLOCKED {
+				row[index].Label = ""
+				row[index].Class = ""
+
+			} else if row[index].Class == "" {
+				row[index].Class = SEAT_CLASS_STANDARD
+			}
+		}
+	}
+
+	layout.ID = id
+	layout.Capacity = layout.capacity()
+	layout.Classes = layout.classes()
+	layout.CreatedBy = createdBy
+	layout.DateCreated = fmt.Sprint(time.Now().Unix())
+
+	return nil
+}
+
+// ValidateSeats checks if every seat of the comma-separated seat numbers of a booking
+// is a seat of the layout that can be booked, and that it is only booked once.
+func (layout SeatLayout) ValidateSeats(seatNumber string) error {
+	var seats = make(map[string]string)
+	for _, row := range layout.Rows {
+		for _, cell := range row {
+			if cell.Label != "" {
+				seats[cell.Label] = cell.Type
+			}
+		}
+	}
+
+	var booked = make(map[string]bool)
+	for _, seat := range strings.Split(seatNumber, ",") {
+		seat = strings.TrimSpace(seat)
+		if seat == "" {
+			continue
+		}
+
+		seatType, ok := seats[seat]
+		if !ok {
+			return fmt.Errorf("seat %s does not exist on the bus unit", seat)
+		}
+
+		if seatType != SEAT {
+			return fmt.Errorf("seat %s is blocked and cannot be booked", seat)
+		}
+
+		if booked[seat] {
+			return fmt.Errorf("seat %s is booked more than once", seat)
+		}
+
+		booked[seat] = true
+	}
+
+	return nil
+}
+
+// SeatClass returns the class of the seat, or STANDARD if the seat layout has no seat
+// with the label or if the seat was created before the seat classes.
+func (layout SeatLayout) SeatClass(label string) string {
+	for _, row := range layout.Rows {
+		for _, cell := range row {
+			if cell.Label == label && cell.Class != "" {
+				return cell.Class
+			}
+		}
+	}
+
+	return SEAT_CLASS_STANDARD
+}
@@ -0,0 +1,30 @@
+package schema
+
+import "strings"
+
+// TripManifest is the list of the crew and the passengers of a trip, a trip is a bus
+// route on a travel date.
+type TripManifest struct {
+	BusRoute   BusRoute       `json:"bus_route"`   // The bus route of the trip
+	TravelDate string         `json:"travel_date"` // The day of the trip in the "2006-01-02" format
+	Crew       []ManifestCrew `json:"crew"`        // The crew that is assigned to the trip
+	Bookings   []Bookings     `json:"bookings"`    // The bookings of the trip that are not cancelled
+	Seats      int            `json:"seats"`       // The number of seats that are booked
+}
+
+// ManifestCrew is a crew of the trip together with its assignment.
+type ManifestCrew struct {
+	Crew       Crew           `json:"crew"`       // The driver or the conductor
+	Assignment CrewAssignment `json:"assignment"` // The assignment of the crew to the trip
+}
+
+// AddBooking adds the booking to the manifest and counts its seats.
+func (manifest *TripManifest) AddBooking(booking Bookings) {
+	manifest.Bookings = append(manifest.Bookings, booking)
+
+	for _, seat := range strings.Split(booking.SeatNumber, ",") {
+		if strings.TrimSpace(seat) != "" {
+			manifest.Seats++
+		}
+	}
+}
@@ -4,24 +4,32 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
 const (
 	ADMN  = "ADMIN"
 	CSTMR = "CUSTOMER"
+	OPRTR = "OPERATOR"
 )
 
+// ERASED_USERNAME is the prefix of the username of an erased user account.
+const ERASED_USERNAME = "erased-"
+
 var UserType = map[int]string{
 	1: ADMN,
 	2: CSTMR,
+	3: OPRTR,
 }
 
 var UserIDCode = map[int]string{
 	1: "ADMN",
 	2: "CSTMR",
+	3: "OPRTR",
 }
 
 // User contains the user account information.
@@ -30,20 +38,104 @@ var UserIDCode = map[int]string{
 // that will be marshaled into a AttributeValue.
 type User struct {
 	ID           string `json:"id" dynamodbav:"id"`                                             // The unique user ID and the sort key
-	UserType     string `json:"user_type" dynamodbav:"user_type"`                               // The type of the user account (either ADMIN or CUSTOMER)
+	UserType     string `json:"user_type" dynamodbav:"user_type"`                               // The type of the user account (either ADMIN, CUSTOMER or OPERATOR)
+	BusID        string `json:"bus_id,omitempty" dynamodbav:"bus_id,omitempty"`                 // The bus line that an OPERATOR user account manages
 	FirstName    string `json:"first_name" dynamodbav:"first_name"`                             // The first name of the user
 	LastName     string `json:"last_name" dynamodbav:"last_name"`                               // The last name of the user
 	Username     string `json:"username" dynamodbav:"username"`                                 // The username of the user account and the primary key
-	Password     string `json:"password,omitempty" dynamodbav:"password"`                       // THe user security password for the account
+	Password     string `json:"password,omitempty" dynamodbav:"password"`                       // The user security password for the account, stored as a bcrypt hash
 	Address      string `json:"address" dynamodbav:"address"`                                   // The user address
 	Email        string `json:"email" dynamodbav:"email"`                                       // The user e-mail address
 	MobileNumber string `json:"mobile_number" dynamodbav:"mobile_number"`                       // The user phone
 	DateCreated  string `json:"date_created,omitempty" dynamodbav:"date_created,omitemptyelem"` // The date it was created
+	Version      int    `json:"version" dynamodbav:"version"`                                   // The version of the record that is incremented on every update
 	LastLogin    string `json:"last_login,omitempty" dynamodbav:"last_login,omitemptyelem"`     // The last login session of the user
+
+	// Whether the e-mail address has been verified. It is not set on the user accounts
+	// that were created before the e-mail addresses were verified, they are treated
+	// as verified.
+	EmailVerified *bool `json:"email_verified,omitempty" dynamodbav:"email_verified,omitempty"`
+
+	// The version of the sessions of the user account that is incremented when the
+	// password is reset, the tokens that were issued before are no longer valid.
+	SessionVersion int `json:"-" dynamodbav:"session_version"`
+
+	// Whether the login requires a TOTP code or a recovery code after the password
+	MFAEnabled bool `json:"mfa_enabled" dynamodbav:"mfa_enabled,omitempty"`
+
+	// The TOTP secret and the recovery codes, they are never returned
+	MFA *UserMFA `json:"-" dynamodbav:"mfa,omitempty"`
+
+	// The date the personal data of the user account was erased, it is only set on the
+	// anonymized user account that is kept for the bookings.
+	DateErased string `json:"date_erased,omitempty" dynamodbav:"date_erased,omitempty"`
+
+	// Whether the user account has been deactivated by an ADMIN and can no longer log in,
+	// and the date it was deactivated. They are removed when it is reactivated.
+	Deactivated     bool   `json:"deactivated,omitempty" dynamodbav:"deactivated,omitempty"`
+	DateDeactivated string `json:"date_deactivated,omitempty" dynamodbav:"date_deactivated,omitempty"`
+}
+
+// UserFilter contains the fields of a user account
+// that can be used for filtering.
+type UserFilter struct {
+	Name        string // A part of the first name or the last name
+	Email       string // A part of the e-mail address
+	UserType    string // The type of the user account (either ADMIN, CUSTOMER or OPERATOR)
+	BusID       string // The bus line that the OPERATOR user account manages
+	CreatedFrom string // The earliest date it was created in seconds since the Unix epoch
+	CreatedTo   string // The latest date it was created in seconds since the Unix epoch
+}
+
+// SetCreated sets the dates of the filter that the user account was created between.
+// The dates are in the "2006-01-02" format and in UTC, and both days are included.
+//
+// Example:
+//  SetCreated("2023-06-01", "2023-06-30")
+//  CreatedFrom: 1685577600
+//  CreatedTo: 1688169599
+func (filter *UserFilter) SetCreated(from, to string) error {
+	var start, end time.Time
+
+	if from != "" {
+		date, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return errors.New("invalid 'created_from' parameter value [format: YYYY-MM-DD]")
+		}
+
+		start = date
+		filter.CreatedFrom = fmt.Sprint(date.Unix())
+	}
+
+	if to != "" {
+		date, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return errors.New("invalid 'created_to' parameter value [format: YYYY-MM-DD]")
+		}
+
+		end = date.AddDate(0, 0, 1).Add(-time.Second)
+		filter.CreatedTo = fmt.Sprint(end.Unix())
+	}
+
+	if from != "" && to != "" && end.Before(start) {
+		return errors.New("'created_from' must not be after 'created_to'")
+	}
+
+	return nil
+}
+
+// UserMFA contains the multi-factor authentication of a user account, it is stored as
+// the "mfa" map attribute of the user account.
+type UserMFA struct {
+	Secret        string   `dynamodbav:"secret,omitempty"`                   // The TOTP secret of the authenticator app
+	PendingSecret string   `dynamodbav:"pending_secret,omitempty"`           // The TOTP secret that is not confirmed yet
+	LastStep      int64    `dynamodbav:"last_step,omitempty"`                // The time step of the last TOTP code that was used
+	RecoveryCodes []string `dynamodbav:"recovery_codes,omitempty,stringset"` // The hashes of the unused recovery codes
 }
 
-// Error sets the default key-value pair.
+// Error sets the default key-value pair. The password is never logged.
 func (user User) Error(err error, code, message string, kv ...utility.KVP) {
+	user.Password = ""
 	if user != (User{}) {
 		kv = append(kv, utility.KVP{Key: "user", Value: user})
 	}
@@ -65,16 +157,101 @@ func (user User) IsEmptyPayload(payload string) error {
 	return nil
 }
 
+// IsValidUserType checks if the user type of the request payload is one of the UserType,
+// and if an OPERATOR user account has the bus line it manages. It must be called before
+// SetValues.
+func (user User) IsValidUserType() error {
+	Type, err := strconv.Atoi(user.UserType)
+	if err != nil || UserType[Type] == "" {
+		return fmt.Errorf("invalid user type [valid: 1 (%s), 2 (%s), 3 (%s)]", ADMN, CSTMR, OPRTR)
+	}
+
+	if UserType[Type] == OPRTR && user.BusID == "" {
+		return errors.New("bus_id is required for an OPERATOR user account")
+	}
+
+	return nil
+}
+
+// ParseUserType returns the user type of either its name or its number of the UserType.
+//
+// Example:
+//  ParseUserType("3")
+//  OPERATOR
+func ParseUserType(value string) (string, error) {
+	if Type, err := strconv.Atoi(value); err == nil && UserType[Type] != "" {
+		return UserType[Type], nil
+	}
+
+	for _, userType := range UserType {
+		if strings.EqualFold(value, userType) {
+			return userType, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid user type [valid: 1 (%s), 2 (%s), 3 (%s)]", ADMN, CSTMR, OPRTR)
+}
+
+// IsEmailVerified checks if the e-mail address of the user account has been verified.
+func (user User) IsEmailVerified() bool {
+	return user.EmailVerified == nil || *user.EmailVerified
+}
+
+// CanBook checks if the user account is allowed to book. A CUSTOMER must have verified
+// its e-mail address, unless the account is still within the grace period since it was
+// created.
+func (user User) CanBook(grace time.Duration) bool {
+	if user.UserType != CSTMR || user.IsEmailVerified() {
+		return true
+	}
+
+	created, err := strconv.ParseInt(user.DateCreated, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Since(time.Unix(created, 0)) < grace
+}
+
+// IsErased checks if the personal data of the user account has been erased.
+func (user User) IsErased() bool {
+	return user.DateErased != ""
+}
+
+// Anonymize returns the user account without its personal data. The ID, the user type,
+// the bus line and the date it was created are kept so that its bookings can still be
+// accounted for. The username is replaced with one of the ID, the password and the MFA
+// are removed so that it can no longer log in, and the session version is incremented
+// so that its tokens are no longer valid.
+//
+// Example:
+//  username: erased-cstmr-01h4z3kx8q9v7w5t2n6m4r3p1a
+//  date_erased: 1685498070
+func (user User) Anonymize(now time.Time) User {
+	return User{
+		ID:             user.ID,
+		UserType:       user.UserType,
+		BusID:          user.BusID,
+		Username:       ERASED_USERNAME + strings.ToLower(user.ID),
+		DateCreated:    user.DateCreated,
+		Version:        user.Version + 1,
+		SessionVersion: user.SessionVersion + 1,
+		DateErased:     fmt.Sprint(now.Unix()),
+	}
+}
+
 // LastLogIn returns the current time when the user logged in.
 func (user User) LastLogIn() string {
 	return time.Now().Format("02 Jan 2006 15:04:05")
 }
 
-// SetValues automatically generates the User ID as your primary key,
-// set the user type and the date it was created.
+// SetValues automatically generates the User ID as your sort key, it is
+// the prefix of the user type and a ULID, and set the user type and the
+// date it was created. Only an OPERATOR user account keeps its bus_id,
+// the e-mail address is not verified yet, and the MFA is not enabled yet.
 //
 // Example:
-//		id: CSTMR-854980
+//		id: CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A
 //		user_type: CUSTOMER
 //		date_created: 1685498070
 func (user *User) SetValues() {
@@ -85,6 +262,20 @@ func (user *User) SetValues() {
 	}
 
 	user.UserType = UserType[Type]
+	if user.UserType != OPRTR {
+		user.BusID = ""
+	}
+
+	verified := false
+	user.EmailVerified = &verified
+	user.MFAEnabled = false
+	user.MFA = nil
+
 	user.DateCreated = fmt.Sprint(time.Now().Unix())
-	user.ID = fmt.Sprintf("%s-%s", UserIDCode[Type], user.DateCreated[2:8])
+	user.Version = 1
+
+	user.ID, err = idgen.New(UserIDCode[Type])
+	if err != nil {
+		user.Error(err, "SetValues", "failed to generate the user ID")
+	}
 }
@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/idgen"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+const (
+	AUDIT_USER_TYPE_CHANGED = "USER_TYPE_CHANGED"
+	AUDIT_USER_DEACTIVATED  = "USER_DEACTIVATED"
+	AUDIT_USER_REACTIVATED  = "USER_REACTIVATED"
+)
+
+// UserAudit is a change of a user account that was made by an ADMIN, such as the change
+// of its user type or its deactivation.
+//
+// The "dynamodbav" struct tag can be used to control the value that
+// will be marshaled into a AttributeValue.
+type UserAudit struct {
+	UserID      string `json:"user_id" dynamodbav:"user_id"`                   // The ID of the user account that was changed and the partition/primary key
+	ID          string `json:"id" dynamodbav:"id"`                             // The unique audit ID and the sort key, it is sorted by the time it was made
+	Action      string `json:"action" dynamodbav:"action"`                     // The change that was made (e.g. USER_TYPE_CHANGED)
+	ChangedBy   string `json:"changed_by" dynamodbav:"changed_by"`             // The ID of the user account that made the change
+	From        string `json:"from,omitempty" dynamodbav:"from,omitempty"`     // The previous value, e.g. the previous user type and bus line
+	To          string `json:"to,omitempty" dynamodbav:"to,omitempty"`         // The new value, e.g. the new user type
+	Reason      string `json:"reason,omitempty" dynamodbav:"reason,omitempty"` // The reason of the change
+	DateCreated string `json:"date_created" dynamodbav:"date_created"`         // The date the change was made
+}
+
+// NewUserAudit returns the audit of the change of the user account that was made by
+// the user ID of changedBy.
+//
+// Example:
+//  id: AUD-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  action: USER_TYPE_CHANGED
+//  from: CUSTOMER
+//  to: OPERATOR
+func NewUserAudit(user User, action, changedBy, from, to, reason string) (UserAudit, error) {
+	id, err := idgen.New("AUD")
+	if err != nil {
+		return UserAudit{}, err
+	}
+
+	return UserAudit{
+		UserID:      user.ID,
+		ID:          id,
+		Action:      action,
+		ChangedBy:   changedBy,
+		From:        from,
+		To:          to,
+		Reason:      reason,
+		DateCreated: fmt.Sprint(time.Now().Unix()),
+	}, nil
+}
+
+// Error sets the default key-value pair.
+func (audit UserAudit) Error(err error, code, message string, kv ...utility.KVP) {
+	if audit != (UserAudit{}) {
+		kv = append(kv, utility.KVP{Key: "user_audit", Value: audit})
+	}
+
+	kv = append(kv, utility.KVP{Key: "Integration", Value: "Bus Ticketing – User Audit"})
+	utility.Error(err, code, message, kv...)
+}
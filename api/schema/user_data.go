@@ -0,0 +1,11 @@
+package schema
+
+// UserDataExport is the archive of the personal data of a user account, it is every
+// record that is tied to the user ID.
+type UserDataExport struct {
+	ExportedAt    string             `json:"exported_at"`   // The date it was exported
+	User          User               `json:"user"`          // The user account without its password and MFA
+	Bookings      []Bookings         `json:"bookings"`      // The bookings of the user account, including the cancelled ones
+	Cancellations []BookingCancelled `json:"cancellations"` // The cancellation records of the cancelled bookings
+	Notifications []Notification     `json:"notifications"` // The e-mails that have been sent to the user account
+}
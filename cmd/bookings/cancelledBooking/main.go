@@ -2,20 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/google/uuid"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
 	"github.com/rmarasigan/bus-ticketing/internal/app/config"
 	"github.com/rmarasigan/bus-ticketing/internal/app/email/template"
+	"github.com/rmarasigan/bus-ticketing/internal/app/notification"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
-	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
@@ -37,57 +35,37 @@ func handler(ctx context.Context, event events.CloudWatchEvent) error {
 	}
 
 	// ********************************************************************* //
-	// ********************* Update the booking record ********************* //
-	// ********************************************************************* //
-	// Create a composite key that has both the partition/primary key
-	// and the sort key of the item.
-	var bookingCompositeKey = map[string]types.AttributeValue{
-		"id":           &types.AttributeValueMemberS{Value: booking.ID},
-		"bus_route_id": &types.AttributeValueMemberS{Value: booking.BusRouteID},
-	}
-
-	// Construct the update builder for booking
-	var updateBooking = expression.Set(expression.Name("status"), expression.Value(booking.Status)).
-		Set(expression.Name("is_cancelled"), expression.Value(booking.IsCancelled)).
-		Set(expression.Name("date_confirmed"), expression.Value(""))
-
-	bookingResult, err := query.UpdateBooking(ctx, bookingCompositeKey, updateBooking)
-	if err != nil {
-		booking.Error(err, "DynamoDBError", "failed to update the booking record")
-		return err
-	}
-
-	// ********************************************************************* //
-	// ************** Update/add the cancelled booking record ************** //
+	// ****************** Cancel the booking transaction ******************* //
 	// ********************************************************************* //
 	booking.Cancelled.ID = uuid.NewString()
 	booking.Cancelled.BookingID = booking.ID
 	booking.Cancelled.DateCancelled = time.Now().Format("2006-01-02 15:04:05")
 
-	// Check if the record exist
-	cancelledBookingExists, err := validate.IsCancelledBookingExists(ctx, booking.ID)
-	if err != nil {
-		booking.Error(err, "IsCancelledBookingExists", "failed to validate cancelled booking if it exist")
-		return err
-	}
-
-	if !cancelledBookingExists {
-		// Create a partition/primary key of the item.
-		var cancelledBookingKey = map[string]types.AttributeValue{
-			"booking_id": &types.AttributeValueMemberS{Value: booking.ID},
-		}
-
-		// Construct the update builder for cancelled booking.
-		var updateCancelledBooking = expression.Set(expression.Name("id"), expression.Value(booking.Cancelled.ID)).
-			Set(expression.Name("reason"), expression.Value(booking.Cancelled.Reason)).
-			Set(expression.Name("cancelled_by"), expression.Value(booking.Cancelled.CancelledBy)).
-			Set(expression.Name("date_cancelled"), expression.Value(booking.Cancelled.DateCancelled))
-
-		_, err := query.RecordBookingCancelled(ctx, cancelledBookingKey, updateCancelledBooking)
+	// The booking status change, the cancellation record and the release of
+	// the seats are committed together. The booking is only cancelled if it
+	// is still on the version it was validated against, otherwise a newer
+	// change has already been applied and retrying the event would not succeed.
+	err = query.CancelBooking(ctx, booking)
+	switch {
+	case errors.Is(err, query.ErrVersionMismatch):
+		// The booking is already cancelled if the event is retried because the
+		// e-mail failed to be sent, then only the e-mail is sent again. It is not
+		// sent if the booking has been cancelled by another request, e.g. by the
+		// change of its bus route which sends its own e-mail.
+		cancelled, err := isCancelledByEvent(ctx, booking)
 		if err != nil {
-			booking.Error(err, "DynamoDBError", "failed to record the cancelled booking")
+			booking.Error(err, "DynamoDBError", "failed to fetch the booking record")
 			return err
 		}
+
+		if !cancelled {
+			booking.Error(query.ErrVersionMismatch, "DynamoDBError", "the booking has been modified since the cancelled event was sent")
+			return nil
+		}
+
+	case err != nil:
+		booking.Error(err, "DynamoDBError", "failed to cancel the booking record")
+		return err
 	}
 
 	// ********************************************************************* //
@@ -107,31 +85,78 @@ func handler(ctx context.Context, event events.CloudWatchEvent) error {
 		return err
 	}
 
+	// The e-mail is only sent once, even if the event is delivered more than once
+	record := schema.NewNotification(user, schema.NOTIFY_BOOKING_CANCELLED, booking.ID)
+	sent, err := query.IsNotificationSent(ctx, record)
+	if err != nil {
+		booking.Error(err, "DynamoDBError", "failed to check if the cancellation e-mail has been sent")
+		return err
+	}
+
+	if sent {
+		utility.Info("CancelledBooking", "The cancellation e-mail has already been sent", utility.KVP{Key: "booking", Value: booking})
+		return nil
+	}
+
 	// Fetch the bus route record
-	routes, err := query.GetBusRouteRecords(ctx, booking.BusRouteID, booking.BusID)
+	routes, _, err := query.GetBusRouteRecords(ctx, booking.BusRouteID, booking.BusID, query.Page{})
 	if err != nil {
 		booking.Error(err, "DynamoDBError", "failed to fetch the bus route record")
 		return err
 	}
+
+	if len(routes) == 0 {
+		err := errors.New("the bus route of the booking does not exist")
+		booking.Error(err, "APIError", "failed to send the cancellation e-mail")
+
+		return nil
+	}
 	route := routes[0]
 
 	// Set the email content
 	email.Content.To = append(email.Content.To, user.Email)
 	email.Content.Subject = fmt.Sprintf("CANCELLED BOOKING: %s to %s [%s]", route.FromRoute, route.ToRoute, booking.TravelDate)
 
-	if strings.HasPrefix(booking.Cancelled.CancelledBy, "ADMN") {
-		email.Content.Message = template.CancelledBooking(user, route, booking, email.CustomerSupport)
-	} else {
+	// The booking is cancelled by the customer, or by an ADMIN or the OPERATOR of the bus line
+	if booking.Cancelled.CancelledBy == booking.UserID {
 		email.Content.Message = template.CustomerCancelledBooking(user, route, booking, email.CustomerSupport)
+	} else {
+		email.Content.Message = template.CancelledBooking(user, route, booking, email.CustomerSupport)
 	}
 
 	// Send email to the client
-	err = email.Send()
+	err = notification.Send(ctx, email, record)
 	if err != nil {
 		booking.Error(err, "EmailError", "failed to send email to client")
 		return err
 	}
-	utility.Info("CancelledBooking", "Successfully cancelled the booking record", utility.KVP{Key: "booking", Value: bookingResult})
+	utility.Info("CancelledBooking", "Successfully cancelled the booking record", utility.KVP{Key: "booking", Value: booking})
 
 	return nil
 }
+
+// isCancelledByEvent checks if the current record of the booking has been cancelled
+// by the cancelled event, that is its cancellation has the same reason and it has been
+// cancelled by the same user account.
+func isCancelledByEvent(ctx context.Context, booking schema.Bookings) (bool, error) {
+	bookings, _, err := query.GetBookingRecords(ctx, booking.ID, booking.BusRouteID, query.Page{})
+	if err != nil {
+		return false, err
+	}
+
+	if len(bookings) == 0 || bookings[0].Status != booking.Status.Cancelled() || bookings[0].RouteChangeID != booking.RouteChangeID {
+		return false, nil
+	}
+
+	cancellations, _, err := query.GetCancelledBookingRecords(ctx, booking.ID, query.Page{})
+	if err != nil {
+		return false, err
+	}
+
+	if len(cancellations) == 0 {
+		return false, nil
+	}
+
+	cancelled := cancellations[0]
+	return cancelled.Reason == booking.Cancelled.Reason && cancelled.CancelledBy == booking.Cancelled.CancelledBy, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/rmarasigan/bus-ticketing/api/schema"
 	"github.com/rmarasigan/bus-ticketing/internal/app/config"
 	"github.com/rmarasigan/bus-ticketing/internal/app/email/template"
+	"github.com/rmarasigan/bus-ticketing/internal/app/notification"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -49,7 +51,21 @@ func handler(ctx context.Context, event events.CloudWatchEvent) error {
 		Set(expression.Name("date_confirmed"), expression.Value(booking.DateConfirmed)).
 		Set(expression.Name("seat_number"), expression.Value(booking.SeatNumber))
 
-	result, err := query.UpdateBooking(ctx, compositeKey, update)
+	// The total amount is computed again if the seat number has been changed
+	if booking.Currency != "" {
+		update = update.Set(expression.Name("currency_code"), expression.Value(booking.Currency)).
+			Set(expression.Name("total_amount"), expression.Value(booking.TotalAmount))
+	}
+
+	// The booking is only updated if it is still on the version it was
+	// validated against, otherwise a newer change has already been applied
+	// and retrying the event would not succeed.
+	result, err := query.UpdateBooking(ctx, compositeKey, update, booking.Version)
+	if errors.Is(err, query.ErrVersionMismatch) {
+		booking.Error(err, "DynamoDBError", "the booking has been modified since the confirmed event was sent")
+		return nil
+	}
+
 	if err != nil {
 		booking.Error(err, "DynamoDBError", "failed to update thte booking record")
 		return err
@@ -73,7 +89,7 @@ func handler(ctx context.Context, event events.CloudWatchEvent) error {
 	}
 
 	// Fetch the bus route record
-	routes, err := query.GetBusRouteRecords(ctx, booking.BusRouteID, booking.BusID)
+	routes, _, err := query.GetBusRouteRecords(ctx, booking.BusRouteID, booking.BusID, query.Page{})
 	if err != nil {
 		booking.Error(err, "DynamoDBError", "failed to fetch the bus route record")
 		return err
@@ -86,7 +102,7 @@ func handler(ctx context.Context, event events.CloudWatchEvent) error {
 	email.Content.Subject = fmt.Sprintf("BOOKING SCHEDULE: %s to %s [%s]", route.FromRoute, route.ToRoute, booking.TravelDate)
 
 	// Send email to the client
-	err = email.Send()
+	err = notification.Send(ctx, email, schema.NewNotification(user, schema.NOTIFY_BOOKING_CONFIRMED, booking.ID))
 	if err != nil {
 		booking.Error(err, "EmailError", "failed to send email to client")
 		return err
@@ -10,9 +10,16 @@ import (
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
 	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
+// ErrEmailNotVerified is returned when a CUSTOMER books without verifying its e-mail
+// address after the grace period.
+var ErrEmailNotVerified = errors.New("the e-mail address of the account must be verified before booking")
+
 func main() {
 	lambda.Start(handler)
 }
@@ -21,6 +28,25 @@ func main() {
 // request body, sends the validated request body to the SQS, and responds with
 // a 200 OK HTTP Status.
 //
+// A CUSTOMER can only book for its own user account and an OPERATOR only on its
+// own bus line, otherwise it responds with a 403 Forbidden HTTP Status. The bus
+// route must belong to the bus line of the booking.
+//
+// A CUSTOMER that has not verified its e-mail address can only book within the
+// EMAIL_VERIFICATION_GRACE since the account was created (no grace by default),
+// otherwise it responds with a 403 Forbidden HTTP Status.
+//
+// The "travel_date" must be a date, with or without the time of the trip, otherwise it
+// responds with a 400 Bad Request HTTP Status. If the bus route has been deactivated,
+// or the bus unit of the bus route is under maintenance on the travel date, it responds
+// with a 409 Conflict HTTP Status. If the bus unit has a seat layout, every seat of the
+// "seat_number" must be a seat of the layout that is not blocked, otherwise it responds
+// with a 400 Bad Request HTTP Status.
+//
+// The "total_amount" of the booking is the sum of the fares of its seats based on their
+// class on the seat layout, every seat is charged the "rate" of the bus route if it has
+// no fare for the class.
+//
 // Method: POST
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bookings/create
@@ -57,6 +83,102 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	// Check if the caller can book for the user account on the bus line
+	identity := auth.GetIdentity(request)
+	err = identity.Authorize(auth.BOOKING_CREATE, auth.Resource{UserID: booking.UserID, BusID: booking.BusID})
+	if err != nil {
+		return auth.Deny(identity, auth.BOOKING_CREATE, err)
+	}
+
+	// A CUSTOMER must have verified its e-mail address before it can book
+	grace, err := config.GetVerificationGrace()
+	if err != nil {
+		booking.Error(err, "ConfigError", "failed to get the e-mail verification grace period")
+		return api.StatusInternalServerError(err)
+	}
+
+	user, err := query.GetUserAccountById(ctx, booking.UserID)
+	if err != nil {
+		booking.Error(err, "DynamoDBError", "failed to fetch the user account record")
+		return api.StatusInternalServerError(err)
+	}
+
+	if user.ID == "" {
+		err := errors.New("the user account of the booking does not exist")
+		booking.Error(err, "APIError", "the user account does not exist")
+
+		return api.StatusBadRequest(err)
+	}
+
+	if !user.CanBook(grace) {
+		booking.Error(ErrEmailNotVerified, "APIError", "the e-mail address of the customer is not verified")
+		return api.StatusForbidden(ErrEmailNotVerified)
+	}
+
+	// The travel date is checked against the maintenance of the bus unit
+	err = booking.IsValidTravelDate()
+	if err != nil {
+		booking.Error(err, "APIError", "the travel date is invalid")
+		return api.StatusBadRequest(err)
+	}
+
+	// The bus route must belong to the bus line that the booking is authorized on
+	routes, _, err := query.GetBusRouteRecords(ctx, booking.BusRouteID, booking.BusID, query.Page{})
+	if err != nil {
+		booking.Error(err, "DynamoDBError", "failed to fetch the bus route record")
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(routes) == 0 || routes[0].ID != booking.BusRouteID {
+		err := errors.New("the bus route of the booking does not exist")
+		booking.Error(err, "APIError", "the bus route does not belong to the bus line")
+
+		return api.StatusBadRequest(err)
+	}
+
+	// A deactivated bus route cannot be booked, its bookings have been cancelled or
+	// flagged to be rebooked to another bus route.
+	if routes[0].Active != nil && !*routes[0].Active {
+		err := errors.New("the bus route of the booking is not active")
+		booking.Error(err, "APIError", "the bus route has been deactivated")
+
+		return api.StatusConflict(err)
+	}
+
+	// The bus routes of a bus unit under maintenance cannot be booked
+	maintenances, err := query.GetBusUnitMaintenance(ctx, routes[0].BusID, routes[0].BusUnitID)
+	if err != nil {
+		booking.Error(err, "DynamoDBError", "failed to fetch the maintenance of the bus unit")
+		return api.StatusInternalServerError(err)
+	}
+
+	for _, maintenance := range maintenances {
+		if maintenance.Covers(booking.TravelDate) {
+			err := errors.New("the bus unit of the bus route is under maintenance on the travel date")
+			booking.Error(err, "APIError", "the bus unit is under maintenance", utility.KVP{Key: "maintenance", Value: maintenance})
+
+			return api.StatusConflict(err)
+		}
+	}
+
+	// The seats must be on the seat layout of the bus unit if it has one
+	layout, err := query.GetBusUnitLayout(ctx, routes[0].BusID, routes[0].BusUnitID)
+	if err != nil {
+		booking.Error(err, "DynamoDBError", "failed to fetch the seat layout of the bus unit")
+		return api.StatusInternalServerError(err)
+	}
+
+	if layout.ID != "" {
+		err = layout.ValidateSeats(booking.SeatNumber)
+		if err != nil {
+			booking.Error(err, "APIError", "the seat number is invalid", utility.KVP{Key: "layout_id", Value: layout.ID})
+			return api.StatusBadRequest(err)
+		}
+	}
+
+	// Every seat is charged the fare of its class
+	booking.SetTotal(routes[0], layout)
+
 	// Validate if the booking status is a valid one.
 	err = booking.IsValidStatus()
 	if err != nil {
@@ -65,7 +187,7 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 	}
 
 	// Send the message to the queue
-	err = awswrapper.SQSSendMessage(ctx, queue, request.Body, awswrapper.BOOKING_MSG_GROUP_ID)
+	err = awswrapper.SQSSendMessage(ctx, queue, utility.EncodeJSON(booking), awswrapper.BOOKING_MSG_GROUP_ID)
 	if err != nil {
 		booking.Error(err, "SQSError", "failed to send message", utility.KVP{Key: "queue", Value: queue})
 		return api.StatusInternalServerError(err)
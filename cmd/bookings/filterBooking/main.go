@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -17,46 +19,73 @@ func main() {
 // It receives the Amazon API Gateway event record data as input, retrieves a
 // list of booking records, and responds with a 200 OK HTTP Status.
 //
+// Only an ADMIN can search every booking. A CUSTOMER only searches its own bookings
+// and an OPERATOR only the bookings of its bus line, a filter on the other bookings
+// responds with a 403 Forbidden HTTP Status.
+//
 // Method: GET
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bookings/search?status=xxxxxx
 //
 // Sample API Params:
 //  status=PENDING
+//  user_id=CSTMR-854980
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "id": "bd866a7e-34cd-4ea1-8411-5351a6b76ffd",
-// 	    "user_id": "ADMN-878495",
-// 	    "bus_id": "BCBSCMPN-884690",
-// 	    "bus_route_id": "RTBRTC15001900884691",
-// 	    "status": "PENDING",
-// 	    "seat_number": "23,24,25,26",
-// 	    "travel_date": "2023-07-06 19:30",
-// 	    "date_created": "2023-07-05 07:48:26",
-// 	    "cancelled": {
-// 	      "id": "",
-// 	      "booking_id": "",
-// 	      "reason": "",
-// 	      "cancelled_by": "",
-// 	      "date_cancelled": ""
-// 	    },
-// 	    "timestamp": "2023-07-01 10:30"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "id": "bd866a7e-34cd-4ea1-8411-5351a6b76ffd",
+// 	      "user_id": "ADMN-878495",
+// 	      "bus_id": "BCBSCMPN-884690",
+// 	      "bus_route_id": "RTBRTC15001900884691",
+// 	      "status": "PENDING",
+// 	      "seat_number": "23,24,25,26",
+// 	      "travel_date": "2023-07-06 19:30",
+// 	      "date_created": "2023-07-05 07:48:26",
+// 	      "cancelled": {
+// 	        "id": "",
+// 	        "booking_id": "",
+// 	        "reason": "",
+// 	        "cancelled_by": "",
+// 	        "date_cancelled": ""
+// 	      },
+// 	      "timestamp": "2023-07-01 10:30"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		status_query  = request.QueryStringParameters["status"]
+		userId_query  = request.QueryStringParameters["user_id"]
 		busId_query   = request.QueryStringParameters["bus_id"]
 		routeId_query = request.QueryStringParameters["route_id"]
 	)
 
-	bookings, err := query.FilterBookings(ctx, busId_query, routeId_query, status_query)
+	// Check if the caller can search the bookings of the filter
+	identity := auth.GetIdentity(request)
+	filter, err := identity.Restrict(auth.BOOKING_READ, auth.Resource{UserID: userId_query, BusID: busId_query})
+	if err != nil {
+		return auth.Deny(identity, auth.BOOKING_READ, err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	bookings, nextToken, err := query.FilterBookings(ctx, filter.UserID, filter.BusID, routeId_query, status_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to filter the bookings")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(bookings)
+	return api.StatusOKWithPage(bookings, len(bookings), nextToken)
 }
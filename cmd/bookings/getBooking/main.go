@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -18,6 +21,10 @@ func main() {
 // request query, fetches the booking record(s), and responds with a 200
 // OK HTTP Status.
 //
+// Only an ADMIN can fetch every booking. A CUSTOMER only fetches its own bookings
+// and an OPERATOR only the bookings of its bus line, the other bookings respond
+// with a 403 Forbidden HTTP Status.
+//
 // Method: GET
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bookings/get
@@ -25,43 +32,93 @@ func main() {
 // Sample API Params:
 //  id=bd866a7e-34cd-4ea1-8411-5351a6b76ffd
 //  bus_route_id=RTBRTC15001900884691
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "id": "bd866a7e-34cd-4ea1-8411-5351a6b76ffd",
-// 	    "user_id": "ADMN-878495",
-// 	    "bus_id": "BCBSCMPN-884690",
-// 	    "bus_route_id": "RTBRTC15001900884691",
-// 	    "status": "PENDING",
-// 	    "seat_number": "23,24,25,26",
-// 	    "travel_date": "2023-07-06 19:30",
-// 	    "date_created": "2023-07-05 07:48:26",
-// 	    "cancelled": {
-// 	      "id": "",
-// 	      "booking_id": "",
-// 	      "reason": "",
-// 	      "cancelled_by": "",
-// 	      "date_cancelled": ""
-// 	    },
-// 	    "timestamp": "2023-07-01 10:30"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "id": "bd866a7e-34cd-4ea1-8411-5351a6b76ffd",
+// 	      "user_id": "ADMN-878495",
+// 	      "bus_id": "BCBSCMPN-884690",
+// 	      "bus_route_id": "RTBRTC15001900884691",
+// 	      "status": "PENDING",
+// 	      "seat_number": "23,24,25,26",
+// 	      "travel_date": "2023-07-06 19:30",
+// 	      "date_created": "2023-07-05 07:48:26",
+// 	      "cancelled": {
+// 	        "id": "",
+// 	        "booking_id": "",
+// 	        "reason": "",
+// 	        "cancelled_by": "",
+// 	        "date_cancelled": ""
+// 	      },
+// 	      "timestamp": "2023-07-01 10:30"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		id_query         = request.QueryStringParameters["id"]
 		busRouteId_query = request.QueryStringParameters["bus_route_id"]
 	)
 
-	bookings, err := query.GetBookingRecords(ctx, id_query, busRouteId_query)
+	// Check if the caller can fetch the bookings
+	identity := auth.GetIdentity(request)
+	scope, err := identity.Scope(auth.BOOKING_READ)
+	if err != nil {
+		return auth.Deny(identity, auth.BOOKING_READ, err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	var (
+		bookings  []schema.Bookings
+		nextToken string
+		specific  = (id_query != "" && busRouteId_query != "")
+	)
+
+	// The list of bookings is restricted to the bookings that the caller can fetch
+	if scope == auth.SCOPE_ALL || specific {
+		bookings, nextToken, err = query.GetBookingRecords(ctx, id_query, busRouteId_query, page)
+	} else {
+		filter, _ := identity.Restrict(auth.BOOKING_READ, auth.Resource{})
+		bookings, nextToken, err = query.FilterBookings(ctx, filter.UserID, filter.BusID, "", "ALL", page)
+	}
+
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to fetch the booking record")
 		return api.StatusInternalServerError(err)
 	}
 
+	// Check if the caller can fetch the specific booking
+	if specific && len(bookings) == 1 {
+		err = identity.Authorize(auth.BOOKING_READ, auth.Resource{UserID: bookings[0].UserID, BusID: bookings[0].BusID})
+		if err != nil {
+			return auth.Deny(identity, auth.BOOKING_READ, err)
+		}
+	}
+
 	if len(bookings) == 0 {
 		return api.StatusOK(api.Message{Custom: "no record(s) found"})
 	}
 
-	return api.StatusOK(bookings)
+	response, err := api.StatusOKWithPage(bookings, len(bookings), nextToken)
+
+	// Set the "ETag" header to the record version if a specific record is fetched,
+	// it is sent back as the "If-Match" header when updating the record.
+	if id_query != "" && busRouteId_query != "" && len(bookings) == 1 {
+		response.Headers["ETag"] = api.ETag(bookings[0].Version)
+	}
+
+	return response, err
 }
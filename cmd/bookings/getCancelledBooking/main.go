@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -18,27 +20,71 @@ func main() {
 // request query, fetches the cancelled booking record, and responds with a 200
 // OK HTTP Status.
 //
+// Only an ADMIN can list the cancelled bookings. The other user types must set the
+// booking_id of a booking that they can fetch, otherwise it responds with a 403
+// Forbidden HTTP Status.
+//
 // Method: GET
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bookings/cancelled/get
 //
 // Sample API Params:
 //  booking_id=ce4e0245-b772-47f8-92fc-0d70cbd511c0
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "id": "053607ed-3dc6-40a3-aea4-d7e87fd015f6",
-// 	    "booking_id": "ce4e0245-b772-47f8-92fc-0d70cbd511c0",
-// 	    "reason": "sample reason",
-// 	    "cancelled_by": "ADMN-878495",
-// 	    "date_cancelled": "2023-07-05 04:16:41"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "id": "053607ed-3dc6-40a3-aea4-d7e87fd015f6",
+// 	      "booking_id": "ce4e0245-b772-47f8-92fc-0d70cbd511c0",
+// 	      "reason": "sample reason",
+// 	      "cancelled_by": "ADMN-878495",
+// 	      "date_cancelled": "2023-07-05 04:16:41",
+// 	      "released_seats": "23,24,25,26"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var bookingId_query = request.QueryStringParameters["booking_id"]
 
-	cancelledBookings, err := query.GetCancelledBookingRecords(ctx, bookingId_query)
+	// Check if the caller can fetch the cancelled bookings
+	identity := auth.GetIdentity(request)
+	scope, err := identity.Scope(auth.BOOKING_READ)
+	if err != nil {
+		return auth.Deny(identity, auth.BOOKING_READ, err)
+	}
+
+	// The cancellation has no owner, so the caller must be able to fetch its booking
+	if scope != auth.SCOPE_ALL {
+		if bookingId_query == "" {
+			return auth.Deny(identity, auth.BOOKING_READ, auth.ErrForbidden)
+		}
+
+		booking, err := query.GetBookingById(ctx, bookingId_query)
+		if err != nil {
+			utility.Error(err, "DynamoDBError", "failed to fetch the booking record", utility.KVP{Key: "booking_id", Value: bookingId_query})
+			return api.StatusInternalServerError(err)
+		}
+
+		err = identity.Authorize(auth.BOOKING_READ, auth.Resource{UserID: booking.UserID, BusID: booking.BusID})
+		if err != nil {
+			return auth.Deny(identity, auth.BOOKING_READ, err)
+		}
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	cancelledBookings, nextToken, err := query.GetCancelledBookingRecords(ctx, bookingId_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to fetch the cancelled booking record")
 		return api.StatusInternalServerError(err)
@@ -48,5 +94,5 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusOK(api.Message{Custom: "no record(s) found"})
 	}
 
-	return api.StatusOK(cancelledBookings)
+	return api.StatusOKWithPage(cancelledBookings, len(cancelledBookings), nextToken)
 }
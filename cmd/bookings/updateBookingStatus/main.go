@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
@@ -24,6 +25,19 @@ func main() {
 // request query and body, updates the booking record and responds with a 200 OK
 // HTTP Status without body.
 //
+// The "If-Match" header is optional. If it is set and the booking has been modified
+// since that version, it responds with a 412 Precondition Failed HTTP Status. The
+// booking is only updated by the event consumer if it is still on the same version.
+//
+// Only an ADMIN or the OPERATOR of the bus line can confirm a booking, and the
+// CUSTOMER can also cancel its own booking, otherwise it responds with a 403
+// Forbidden HTTP Status. The "cancelled_by" of a cancelled booking is the caller.
+//
+// If the "seat_number" is set and the bus unit of the bus route has a seat layout,
+// every seat must be a seat of the layout that is not blocked, otherwise it responds
+// with a 400 Bad Request HTTP Status. The "total_amount" is computed again from the fares
+// of the classes of the seats.
+//
 // Method: POST
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-route/update?id=xxxxx&bus_route_id=xxxxx
@@ -32,6 +46,9 @@ func main() {
 //  id=bd866a7e-34cd-4ea1-8411-5351a6b76ffd
 //  bus_route_id=RTBRTC15001900884691
 //
+// Sample API Headers:
+//  If-Match: "1"
+//
 // Sample API Payload:
 // 	{
 // 	  "status": "CONFIRMED",
@@ -65,26 +82,54 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	// Get the version the update is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		booking.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
 	// ********************************************************************* //
 	// ***************** Fetch and validate booking record ***************** //
 	// ********************************************************************* //
 	// 1. Fetch the existing booking record
-	records, err := query.GetBookingRecords(ctx, id_query, routeId_query)
+	records, _, err := query.GetBookingRecords(ctx, id_query, routeId_query, query.Page{})
 	if err != nil {
 		booking.Error(err, "DynamoDBError", "failed to fetch the booking record")
 		return api.StatusInternalServerError(err)
 	}
 
 	// 2. Check if there is an existing record
-	record := records[0]
-	if record == (schema.Bookings{}) {
+	if len(records) == 0 || records[0] == (schema.Bookings{}) {
 		err := errors.New("the booking record you're trying to update is non-existent")
 		booking.Error(err, "APIError", "the booking record does not exist")
 
 		return api.StatusBadRequest(err)
 	}
 
-	// 3. Return an error if thay want to re-confirm the booking
+	// 3. Check if the caller can confirm or cancel the booking
+	var (
+		record   = records[0]
+		action   = auth.BOOKING_CANCEL
+		identity = auth.GetIdentity(request)
+	)
+
+	if booking.Status == booking.Status.Confirmed() {
+		action = auth.BOOKING_CONFIRM
+	}
+
+	err = identity.Authorize(action, auth.Resource{UserID: record.UserID, BusID: record.BusID})
+	if err != nil {
+		return auth.Deny(identity, action, err)
+	}
+
+	// 4. Check if the booking is still on the version the update is based on
+	if ifMatch != nil && *ifMatch != record.Version {
+		record.Error(query.ErrVersionMismatch, "APIError", "the booking version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
+	// 5. Return an error if thay want to re-confirm the booking
 	// that was already cancelled.
 	if booking.Status == booking.Status.Confirmed() && *record.IsCancelled {
 		err := errors.New("this booking has been cancelled and cannot be re-confirmed")
@@ -93,30 +138,59 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusBadRequest(err)
 	}
 
-	// 4. If the booking status is cancelled, set the "is_cancelled"
-	// field automatically to "true".
+	// 6. If the booking status is cancelled, set the "is_cancelled"
+	// field automatically to "true" and who cancelled it.
 	if booking.Status == booking.Status.Cancelled() {
 		flag := true
 		record.IsCancelled = &flag
 		booking.IsCancelled = &flag
+		booking.Cancelled.CancelledBy = identity.UserID
 	}
 	record = validate.UpdateBookingFields(booking, record)
 
-	// 5. Check if the booking status is valid or not
+	// 7. Check if the seats are on the seat layout of the bus unit if it has one, and
+	// charge every seat the fare of its class
+	if booking.SeatNumber != "" {
+		routes, _, err := query.GetBusRouteRecords(ctx, record.BusRouteID, record.BusID, query.Page{})
+		if err != nil {
+			record.Error(err, "DynamoDBError", "failed to fetch the bus route record")
+			return api.StatusInternalServerError(err)
+		}
+
+		if len(routes) > 0 {
+			layout, err := query.GetBusUnitLayout(ctx, routes[0].BusID, routes[0].BusUnitID)
+			if err != nil {
+				record.Error(err, "DynamoDBError", "failed to fetch the seat layout of the bus unit")
+				return api.StatusInternalServerError(err)
+			}
+
+			if layout.ID != "" {
+				err = layout.ValidateSeats(record.SeatNumber)
+				if err != nil {
+					record.Error(err, "APIError", "the seat number is invalid", utility.KVP{Key: "layout_id", Value: layout.ID})
+					return api.StatusBadRequest(err)
+				}
+			}
+
+			record.SetTotal(routes[0], layout)
+		}
+	}
+
+	// 8. Check if the booking status is valid or not
 	err = record.IsValidStatus()
 	if err != nil {
 		record.Error(err, "APIError", "the booking status is invalid")
 		return api.StatusBadRequest(err)
 	}
 
-	// 6. Validate if the booking status is a valid event source
+	// 9. Validate if the booking status is a valid event source
 	eventSource, err := record.EventSource()
 	if err != nil {
 		record.Error(err, "EventBridgeError", "incorrect event source of booking")
 		return api.StatusBadRequest(err)
 	}
 
-	// 7. Check if it is a cancelled booking and validate if the
+	// 10. Check if it is a cancelled booking and validate if the
 	// required fields are present.
 	err = record.IsBookingCancelled()
 	if err != nil {
@@ -2,14 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
-	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
@@ -19,7 +20,12 @@ func main() {
 
 // It receives the Amazon API Gateway event record data as input, validates the
 // request body, saves the validated request body to the DynamoDB Table, and
-// responds with a 200 OK HTTP Status.
+// responds with a 200 OK HTTP Status. If every bus line that failed already exists,
+// it responds with a 409 Conflict HTTP Status, otherwise a 400 Bad Request HTTP
+// Status with the failed bus lines.
+//
+// Only an ADMIN can create the bus lines, otherwise it responds with a 403 Forbidden
+// HTTP Status.
 //
 // Method: POST
 //
@@ -50,8 +56,15 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		failedBus schema.FailedBus
 	)
 
+	// Only an ADMIN can create the bus lines
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_CREATE, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_CREATE, err)
+	}
+
 	// Unmarshal the received JSON-encoded data
-	err := utility.ParseJSON([]byte(request.Body), &busList)
+	err = utility.ParseJSON([]byte(request.Body), &busList)
 	if err != nil {
 		utility.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data",
 			utility.KVP{Key: "Integration", Value: "Bus Ticketing – Bus"}, utility.KVP{Key: "payload", Value: request.Body})
@@ -59,33 +72,63 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
-	for _, bus := range busList {
-		// Checks whether the bus line exist or not
-		busLineExist, err := validate.IsBusLineExisting(ctx, bus.Name, bus.Company)
-		if err != nil {
-			failedBus.SetFailedBus(bus, "failed to validate bus line if it exist")
-			bus.Error(err, "IsBusLineExisting", "failed to validate bus line if it exist")
+	var (
+		newBus    []schema.Bus
+		indexes   []int                   // The index of the new bus line in the request
+		keys      = make(map[string]bool) // The bus lines that are already in the batch
+		conflicts int                     // The number of bus lines that already exist
+	)
 
-			continue
-		}
+	for index, bus := range busList {
+		// If the bus line is already in the batch, continue to the next item
+		key := bus.Name + "/" + bus.Company
+		if keys[key] {
+			failedBus.SetFailedBus(index, bus, query.ErrAlreadyExists.Error())
+			conflicts++
 
-		// If the bus line exists, continue to the next item
-		if busLineExist {
-			utility.Info("BusLineExisting", "already existing bus line", utility.KVP{Key: "bus", Value: bus})
 			continue
 		}
 
 		// Set default values of the bus line information
 		bus.SetValues()
 
-		// Inserts a new bus line record to the DynamoDB
-		err = query.CreateBusLine(ctx, bus)
-		if err != nil {
-			failedBus.SetFailedBus(bus, "failed to create a new bus line record")
-			bus.Error(err, "DynamoDBError", "failed to create a new bus line record")
+		keys[key] = true
+		newBus = append(newBus, bus)
+		indexes = append(indexes, index)
+	}
+
+	// Inserts the new bus line records to the DynamoDB in batches
+	failed, err := query.CreateBusLines(ctx, newBus)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to create the new bus line records",
+			utility.KVP{Key: "Integration", Value: "Bus Ticketing – Bus"})
 
+		return api.StatusInternalServerError(err)
+	}
+
+	for i, bus := range newBus {
+		err, ok := failed[i]
+		if !ok {
 			continue
 		}
+
+		reason := "failed to create a new bus line record"
+		switch {
+		case errors.Is(err, query.ErrAlreadyExists):
+			reason = err.Error()
+			conflicts++
+
+		case errors.Is(err, query.ErrUnprocessedItem):
+			reason = err.Error()
+		}
+
+		failedBus.SetFailedBus(indexes[i], bus, reason)
+		bus.Error(err, "DynamoDBError", reason)
+	}
+
+	// If every failed bus line already exists, return a 409 Conflict HTTP Status
+	if len(failedBus.Failed) > 0 && len(failedBus.Failed) == conflicts {
+		return api.Response(http.StatusConflict, failedBus), nil
 	}
 
 	if len(failedBus.Failed) > 0 {
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -24,32 +26,53 @@ func main() {
 // Sample API Params:
 //  name=Yellow Sunshine
 //  company=Transit Bus Co
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "id": "TRNSTBSC-875011",
-// 	    "name": "Yellow Sunshine",
-// 	    "owner": "Melissa Anderson",
-// 	    "email": "melissa.anderson@example.com",
-// 	    "address": "741 Oak Avenue, Suburb",
-// 	    "company": "Transit Bus Co",
-// 	    "mobile_number": "999-333-7777",
-// 	    "date_created": "1687501112"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "id": "TRNSTBSC-875011",
+// 	      "name": "Yellow Sunshine",
+// 	      "owner": "Melissa Anderson",
+// 	      "email": "melissa.anderson@example.com",
+// 	      "address": "741 Oak Avenue, Suburb",
+// 	      "company": "Transit Bus Co",
+// 	      "mobile_number": "999-333-7777",
+// 	      "date_created": "1687501112"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		name_query    = request.QueryStringParameters["name"]
 		company_query = request.QueryStringParameters["company"]
 	)
 
+	// Check if the caller can fetch the bus lines
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_READ, err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch a list of bus line information
-	listOfBus, err := query.FilterBusLine(ctx, name_query, company_query)
+	listOfBus, nextToken, err := query.FilterBusLine(ctx, name_query, company_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to filter the bus line")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(listOfBus)
+	return api.StatusOKWithPage(listOfBus, len(listOfBus), nextToken)
 }
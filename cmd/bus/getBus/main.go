@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -25,27 +27,48 @@ func main() {
 // Sample API Params:
 //  id=BCBSCMPN-875011
 //  name=Blue Horizon
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "id": "BCBSCMPN-875011",
-// 	    "name": "Blue Horizon",
-// 	    "owner": "John Doe",
-// 	    "email": "john.doe@example.com",
-// 	    "address": "123 Main Street, City",
-// 	    "company": "ABC Bus Company",
-// 	    "mobile_number": "123-456-7890"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "id": "BCBSCMPN-875011",
+// 	      "name": "Blue Horizon",
+// 	      "owner": "John Doe",
+// 	      "email": "john.doe@example.com",
+// 	      "address": "123 Main Street, City",
+// 	      "company": "ABC Bus Company",
+// 	      "mobile_number": "123-456-7890"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		id_query   = request.QueryStringParameters["id"]
 		name_query = request.QueryStringParameters["name"]
 	)
 
+	// Check if the caller can fetch the bus lines
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_READ, err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch the existing bus line record
-	busList, err := query.GetBusLineRecords(ctx, id_query, name_query)
+	busList, nextToken, err := query.GetBusLineRecords(ctx, id_query, name_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to fetch the bus line record", utility.KVP{Key: "id", Value: id_query},
 			utility.KVP{Key: "name", Value: name_query})
@@ -57,5 +80,13 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusOK(api.Message{Custom: "no record(s) found"})
 	}
 
-	return api.StatusOK(busList)
+	response, err := api.StatusOKWithPage(busList, len(busList), nextToken)
+
+	// Set the "ETag" header to the record version if a specific record is fetched,
+	// it is sent back as the "If-Match" header when updating the record.
+	if id_query != "" && name_query != "" && len(busList) == 1 {
+		response.Headers["ETag"] = api.ETag(busList[0].Version)
+	}
+
+	return response, err
 }
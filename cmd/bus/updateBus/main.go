@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
@@ -21,7 +22,13 @@ func main() {
 
 // It receives the Amazon API Gateway event record data as input, validates the
 // request query and body, updates the bus line's information/record and responds
-// with a 200 OK HTTP Status.
+// with a 200 OK HTTP Status and the "ETag" header of the new version.
+//
+// The "If-Match" header is optional. If it is set and the bus line has been modified
+// since that version, it responds with a 412 Precondition Failed HTTP Status.
+//
+// An OPERATOR can only update its own bus line, otherwise it responds with a 403
+// Forbidden HTTP Status.
 //
 // Method: POST
 //
@@ -31,6 +38,9 @@ func main() {
 //  id=BCBSCMPN-875011
 //  name=Blue Horizon
 //
+// Sample API Headers:
+//  If-Match: "1"
+//
 // Sample API Payload:
 // 	{
 // 		"address": "Långbro, Stockholm",
@@ -46,7 +56,8 @@ func main() {
 // 	  "address": "Långbro, Stockholm",
 // 	  "company": "ABC Bus Company",
 // 	  "mobile_number": "0567-8809105",
-// 	  "date_created": "1687501112"
+// 	  "date_created": "1687501112",
+// 	  "version": 2
 // 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
@@ -55,7 +66,14 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		name_query = request.QueryStringParameters["name"]
 	)
 
-	err := bus.IsEmptyPayload(request.Body)
+	// Check if the caller can update the bus line
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_UPDATE, auth.Resource{BusID: id_query})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UPDATE, err)
+	}
+
+	err = bus.IsEmptyPayload(request.Body)
 	if err != nil {
 		return api.StatusBadRequest(err)
 	}
@@ -69,21 +87,34 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	// Get the version the update is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		bus.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch the existing bus line record
-	busLines, err := query.GetBusLineRecords(ctx, id_query, name_query)
+	busLines, _, err := query.GetBusLineRecords(ctx, id_query, name_query, query.Page{})
 	if err != nil {
 		bus.Error(err, "DynamoDBError", "failed to fetch the bus line record")
 		return api.StatusInternalServerError(err)
 	}
 
-	busLine := busLines[0]
-	if busLine == (schema.Bus{}) {
+	if len(busLines) == 0 || busLines[0] == (schema.Bus{}) {
 		err := errors.New("the bus line record you're trying to update is non-existent")
 		bus.Error(err, "APIError", "the bus line does not exist")
 
 		return api.StatusBadRequest(err)
 	}
 
+	busLine := busLines[0]
+	version := busLine.Version
+	if ifMatch != nil && *ifMatch != version {
+		busLine.Error(query.ErrVersionMismatch, "APIError", "the bus line version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
 	// Create a composite key that has both the partition/primary key
 	// and the sort key of the item.
 	var compositeKey = map[string]types.AttributeValue{
@@ -98,11 +129,16 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		Set(expression.Name("address"), expression.Value(busLine.Address)).
 		Set(expression.Name("mobile_number"), expression.Value(busLine.MobileNumber))
 
-	result, err := query.UpdateBusLine(ctx, compositeKey, update)
+	result, err := query.UpdateBusLine(ctx, compositeKey, update, version)
+	if errors.Is(err, query.ErrVersionMismatch) {
+		busLine.Error(err, "DynamoDBError", "the bus line has been modified by another request")
+		return api.StatusPreconditionFailed(err)
+	}
+
 	if err != nil {
 		busLine.Error(err, "DynamoDBError", "failed to update the bus line record")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(result)
+	return api.StatusOKWithETag(result, result.Version)
 }
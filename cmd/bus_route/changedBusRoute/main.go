@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/email"
+	"github.com/rmarasigan/bus-ticketing/internal/app/email/template"
+	"github.com/rmarasigan/bus-ticketing/internal/app/notification"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the "route:changed" event of a bus route that has been deactivated or
+// rescheduled, and handles every booking of the bus route that is not cancelled and
+// whose travel date is not in the past.
+//
+// The bookings of a deactivated bus route are cancelled with a full refund if the
+// booking action is CANCEL, or flagged with the route change to be rebooked to another
+// bus route if it is REBOOK. The passengers of a rescheduled bus route are informed of
+// the new departure and arrival time, and the assignments of the crew to the upcoming
+// trips of the bus route are moved to the new schedule. An assignment whose trips now
+// overlap the other trips of the crew or exceed its maximum duty is flagged with the
+// conflict.
+//
+// A booking that fails to be handled is retried with the event. The bookings that have
+// already been cancelled or flagged by the route change are not changed again, and an
+// e-mail is only sent if it has not been recorded as a notification of the booking and
+// the route change yet, so that a retry only sends the e-mails that failed.
+func handler(ctx context.Context, event events.CloudWatchEvent) error {
+	var (
+		detail = event.Detail
+		change schema.BusRouteChange
+	)
+
+	// Unmarshal the received JSON-encoded event data
+	err := utility.ParseJSON([]byte(detail), &change)
+	if err != nil {
+		change.Error(err, "JSONError", "failed to unmarshal the JSON-encoded event data", utility.KVP{Key: "event", Value: event})
+		return err
+	}
+
+	// Fetch email configuration
+	mail, err := config.GetEmailConfig(ctx)
+	if err != nil {
+		change.Error(err, "EmailError", "failed to fetch and set the email configuration")
+		return err
+	}
+
+	// The assignments of the crew are on the current schedule of the bus route, it might
+	// have been rescheduled again since the event was sent.
+	if change.Rescheduled {
+		routes, _, err := query.GetBusRouteRecords(ctx, change.Route.ID, change.Route.BusID, query.Page{})
+		if err != nil {
+			change.Error(err, "DynamoDBError", "failed to fetch the bus route record")
+			return err
+		}
+
+		var route = change.Route
+		if len(routes) > 0 && routes[0].ID != "" {
+			route = routes[0]
+		}
+
+		maxDuty, err := config.GetMaxDuty()
+		if err != nil {
+			change.Error(err, "ConfigError", "failed to get the maximum duty of the crew")
+			return err
+		}
+
+		err = query.RescheduleCrewAssignments(ctx, route, time.Now().UTC().Format("2006-01-02"), maxDuty)
+		if err != nil {
+			change.Error(err, "DynamoDBError", "failed to reschedule the crew assignments of the bus route")
+			return err
+		}
+	}
+
+	// The bookings of the upcoming trips of the bus route
+	bookings, err := query.GetUpcomingBookings(ctx, change.Route.BusID, change.Route.ID, change.ID, time.Now().Format("2006-01-02"))
+	if err != nil {
+		change.Error(err, "DynamoDBError", "failed to fetch the upcoming bookings of the bus route")
+		return err
+	}
+
+	var failed int
+	for _, booking := range bookings {
+		err := handleBooking(ctx, mail, change, booking)
+		if err != nil {
+			booking.Error(err, "RouteChangeError", "failed to handle the booking of the bus route change",
+				utility.KVP{Key: "bus_route_change_id", Value: change.ID})
+
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		err := fmt.Errorf("failed to handle %d of %d bookings of the bus route change", failed, len(bookings))
+		change.Error(err, "RouteChangeError", "failed to handle the bookings of the bus route change")
+
+		return err
+	}
+
+	utility.Info("ChangedBusRoute", "Successfully handled the bookings of the bus route change", utility.KVP{Key: "id", Value: change.ID},
+		utility.KVP{Key: "bookings", Value: len(bookings)})
+
+	return nil
+}
+
+// handleBooking cancels or flags the booking of a deactivated bus route, and sends the
+// e-mail of the route change to the passenger if it has not been sent yet.
+func handleBooking(ctx context.Context, mail email.Configuration, change schema.BusRouteChange, booking schema.Bookings) error {
+	var (
+		subject          string
+		message          func(schema.User) string
+		notificationType string
+	)
+
+	switch {
+	case change.Deactivated && change.BookingAction == schema.ROUTE_CHANGE_CANCEL:
+		err := cancelBooking(ctx, change, &booking)
+		if errors.Is(err, errNotChanged) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		subject = "CANCELLED BOOKING"
+		notificationType = schema.NOTIFY_BOOKING_CANCELLED
+		message = func(user schema.User) string {
+			return template.RouteCancelledBooking(user, change, booking, mail.CustomerSupport)
+		}
+
+	case change.Deactivated:
+		if booking.Status == booking.Status.Cancelled() {
+			return nil
+		}
+
+		// The booking is only flagged if it has not been cancelled since it was fetched
+		if booking.RouteChangeID != change.ID {
+			err := query.FlagRebookBooking(ctx, booking, change)
+			if errors.Is(err, query.ErrVersionMismatch) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		booking.RouteChangeID = change.ID
+		subject = "REBOOKING REQUIRED"
+		notificationType = schema.NOTIFY_BOOKING_REBOOK
+		message = func(user schema.User) string {
+			return template.RebookBooking(user, change, booking, mail.CustomerSupport)
+		}
+
+	case change.Rescheduled:
+		subject = "SCHEDULE CHANGE"
+		notificationType = schema.NOTIFY_BOOKING_RESCHEDULED
+		message = func(user schema.User) string {
+			return template.RescheduledBooking(user, change, booking, mail.CustomerSupport)
+		}
+
+	default:
+		return nil
+	}
+
+	// ********************************************************************* //
+	// ******************** Sending email to the client ******************** //
+	// ********************************************************************* //
+	// The e-mail of the booking and the route change is only sent once
+	var notice = schema.Notification{UserID: booking.UserID, Type: notificationType, BookingID: booking.ID, ChangeID: change.ID}
+
+	sent, err := query.IsNotificationSent(ctx, notice)
+	if err != nil {
+		return err
+	}
+
+	if sent {
+		return nil
+	}
+
+	// Fetch the user account record
+	user, err := query.GetUserAccountById(ctx, booking.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Set the email content
+	mail.Content.To = append(append([]string{}, mail.Content.To...), user.Email)
+	mail.Content.Subject = fmt.Sprintf("%s: %s to %s [%s]", subject, change.Route.FromRoute, change.Route.ToRoute, booking.TravelDate)
+	mail.Content.Message = message(user)
+
+	notice = schema.NewNotification(user, notificationType, booking.ID)
+	notice.ChangeID = change.ID
+
+	// Send email to the client
+	return notification.Send(ctx, mail, notice)
+}
+
+// errNotChanged is returned when the booking is not handled by the route change, e.g. it
+// has been cancelled by the passenger since the bus route was deactivated.
+var errNotChanged = errors.New("the booking is not changed by the bus route change")
+
+// cancelBooking cancels the booking with a full refund. A booking that has already been
+// cancelled by the route change is not cancelled again, while a booking that has been
+// cancelled otherwise returns errNotChanged. A booking that has been modified since it
+// was fetched returns ErrVersionMismatch, so that it is cancelled on the retry.
+func cancelBooking(ctx context.Context, change schema.BusRouteChange, booking *schema.Bookings) error {
+	booking.Cancelled = schema.BookingCancelled{
+		ID:            uuid.NewString(),
+		BookingID:     booking.ID,
+		Reason:        change.Reason,
+		CancelledBy:   change.ChangedBy,
+		DateCancelled: time.Now().Format("2006-01-02 15:04:05"),
+		RefundPolicy:  change.RefundPolicy,
+		RefundAmount:  booking.TotalAmount,
+	}
+
+	if booking.Status == booking.Status.Cancelled() {
+		if booking.RouteChangeID == change.ID {
+			return nil
+		}
+
+		return errNotChanged
+	}
+
+	var cancelled = true
+
+	booking.Status = booking.Status.Cancelled()
+	booking.IsCancelled = &cancelled
+	booking.RouteChangeID = change.ID
+
+	// The booking is only cancelled if it has not been modified since it was fetched
+	err := query.CancelBooking(ctx, *booking)
+	if !errors.Is(err, query.ErrVersionMismatch) {
+		return err
+	}
+
+	// Check if the booking has been cancelled by a concurrent delivery of the route change
+	// or by the passenger.
+	records, _, err := query.GetBookingRecords(ctx, booking.ID, booking.BusRouteID, query.Page{})
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 || records[0].ID == "" {
+		return errNotChanged
+	}
+
+	if records[0].Status == records[0].Status.Cancelled() {
+		if records[0].RouteChangeID == change.ID {
+			return nil
+		}
+
+		booking.Error(query.ErrVersionMismatch, "DynamoDBError", "the booking has been cancelled since the bus route was deactivated")
+		return errNotChanged
+	}
+
+	return query.ErrVersionMismatch
+}
@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
@@ -21,6 +23,15 @@ func main() {
 // request body, saves the validated request body to the DynamoDB Table, and
 // responds with a 200 OK HTTP Status.
 //
+// An OPERATOR can only create the bus routes of its own bus line, otherwise it responds
+// with a 403 Forbidden HTTP Status. The "bus_id" defaults to the bus line of the OPERATOR,
+// and the bus unit must belong to the bus line. The bus route has the amenities of the
+// bus unit.
+//
+// The "fares" are the fares of the seat classes of the seat layout of the bus unit, the
+// "rate" is charged for the seats of the other classes. A negative fare responds with a
+// 400 Bad Request HTTP Status.
+//
 // Method: POST
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-route/create
@@ -28,6 +39,10 @@ func main() {
 // Sample API Payload:
 // 	{
 // 	  "rate": 120,
+// 	  "fares": {
+// 	    "PREMIUM": 180,
+// 	    "SLEEPER": 250
+// 	  },
 // 	  "active": true,
 // 	  "currency_code": "PHP",
 // 	  "bus_id": "SNRSBSS-875011",
@@ -47,6 +62,46 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	// Check if the caller can create the bus route of the bus line, the bus line
+	// of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	resource, err := identity.Restrict(auth.BUS_ROUTE_CREATE, auth.Resource{BusID: route.BusID})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_ROUTE_CREATE, err)
+	}
+
+	route.BusID = resource.BusID
+	if route.BusID == "" || route.BusUnitID == "" {
+		err := errors.New("bus_id and bus_unit_id are required")
+		route.Error(err, "APIError", "the bus line or the bus unit of the bus route is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	// The bus unit must belong to the bus line of the bus route
+	units, _, err := query.GetBusUnitRecords(ctx, route.BusUnitID, route.BusID, query.Page{})
+	if err != nil {
+		route.Error(err, "DynamoDBError", "failed to fetch the bus unit of the bus route")
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(units) == 0 || units[0].Code == "" {
+		err := fmt.Errorf("the bus unit %s does not belong to the bus line %s", route.BusUnitID, route.BusID)
+		route.Error(err, "APIError", "the bus unit does not belong to the bus line")
+
+		return api.StatusBadRequest(err)
+	}
+
+	// The amenities are copied from the bus unit, so that the bus routes can be
+	// filtered by them.
+	route.Amenities = units[0].Amenities
+
+	err = route.SetFares()
+	if err != nil {
+		route.Error(err, "APIError", "invalid fares of the bus route")
+		return api.StatusBadRequest(err)
+	}
+
 	routeExist, err := validate.IsBusRouteExisting(ctx, route.SetFilter())
 	if err != nil {
 		route.Error(err, "IsBusRouteExisting", "failed to validate bus route if it exist")
@@ -65,6 +120,11 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 
 	// Inserts a new bus route record to the DynamoDB
 	err = query.CreateBusRoute(ctx, route)
+	if errors.Is(err, query.ErrAlreadyExists) {
+		route.Error(err, "DynamoDBError", "the bus route ID has already been taken")
+		return api.StatusConflict(err)
+	}
+
 	if err != nil {
 		route.Error(err, "DynamoDBError", "failed to create a new bus route record")
 		return api.StatusInternalServerError(err)
@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -20,29 +21,40 @@ func main() {
 // It receives the Amazon API Gateway event record as input, fetches the
 // bus unit route records, and responds with a 200 OK HTTP Status.
 //
+// Every bus route has the amenities of its bus unit. The "amenities" are comma-separated
+// and the bus unit of a bus route must have every one of them, an invalid amenity
+// responds with a 400 Bad Request HTTP Status.
+//
 // Method: GET
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-route/search?bus_id=xxxxxx
 //
 // Sample API Params:
 //  bus_id=SNRSBSS-875011
+//  amenities=AC,WIFI
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "id": "RTBRTC15001900880102",
-// 	    "bus_id": "SNRSBSS-875011",
-// 	    "bus_unit_id": "SNRSBSSBUS002",
-// 	    "currency_code": "PHP",
-// 	    "rate": 90,
-// 	    "active": true,
-// 	    "departure_time": "15:00",
-// 	    "arrival_time": "19:00",
-// 	    "from_route": "Route B",
-// 	    "to_route": "Route C",
-// 	    "date_created": "1688010233"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "id": "RTBRTC15001900880102",
+// 	      "bus_id": "SNRSBSS-875011",
+// 	      "bus_unit_id": "SNRSBSSBUS002",
+// 	      "currency_code": "PHP",
+// 	      "rate": 90,
+// 	      "active": true,
+// 	      "departure_time": "15:00",
+// 	      "arrival_time": "19:00",
+// 	      "from_route": "Route B",
+// 	      "to_route": "Route C",
+// 	      "amenities": ["AC", "TOILET", "WIFI"],
+// 	      "date_created": "1688010233"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		active       *bool
@@ -50,6 +62,13 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		active_query = request.QueryStringParameters["active"]
 	)
 
+	// Anyone can search the bus routes, even without an access token
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_ROUTE_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_ROUTE_READ, err)
+	}
+
 	// Convert the 'active' query into a boolean value
 	// if it is present in the request parameters.
 	if active_query != "" {
@@ -70,11 +89,27 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 	route.ToRoute = request.QueryStringParameters["to_route"]
 	route.FromRoute = request.QueryStringParameters["from_route"]
 
-	listOfBusRoute, err := query.FilterBusRoute(ctx, route)
+	amenities, err := schema.ParseAmenities(request.QueryStringParameters["amenities"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	route.Amenities = amenities
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	listOfBusRoute, nextToken, err := query.FilterBusRoute(ctx, route, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to filter the bus route")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(listOfBusRoute)
+	return api.StatusOKWithPage(listOfBusRoute, len(listOfBusRoute), nextToken)
 }
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -25,30 +27,51 @@ func main() {
 // Sample API Params:
 //  bus_id=SNRSBSS-875011
 //  id=RTRTC15001900877753
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "rate": 120,
-// 	    "active": true,
-// 	    "currency_code": "PHP",
-// 	    "id": "RTRTC15001900877753",
-// 	    "bus_id": "SNRSBSS-875011",
-// 	    "bus_unit_id": "SNRSBSSBUS002",
-// 	    "departure_time": "15:00",
-// 	    "arrival_time": "17:00",
-// 	    "from_route": "Route A",
-// 	    "to_route": "Route B"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "rate": 120,
+// 	      "active": true,
+// 	      "currency_code": "PHP",
+// 	      "id": "RTRTC15001900877753",
+// 	      "bus_id": "SNRSBSS-875011",
+// 	      "bus_unit_id": "SNRSBSSBUS002",
+// 	      "departure_time": "15:00",
+// 	      "arrival_time": "17:00",
+// 	      "from_route": "Route A",
+// 	      "to_route": "Route B"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		id_query    = request.QueryStringParameters["id"]
 		busId_query = request.QueryStringParameters["bus_id"]
 	)
 
+	// Anyone can fetch the bus routes, even without an access token
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_ROUTE_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_ROUTE_READ, err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch the existing bus route record
-	routes, err := query.GetBusRouteRecords(ctx, id_query, busId_query)
+	routes, nextToken, err := query.GetBusRouteRecords(ctx, id_query, busId_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to fetch the bus route record", utility.KVP{Key: "id", Value: id_query},
 			utility.KVP{Key: "bus_id", Value: busId_query})
@@ -60,5 +83,13 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusOK(api.Message{Custom: "no record(s) found"})
 	}
 
-	return api.StatusOK(routes)
+	response, err := api.StatusOKWithPage(routes, len(routes), nextToken)
+
+	// Set the "ETag" header to the record version if a specific record is fetched,
+	// it is sent back as the "If-Match" header when updating the record.
+	if id_query != "" && busId_query != "" && len(routes) == 1 {
+		response.Headers["ETag"] = api.ETag(routes[0].Version)
+	}
+
+	return response, err
 }
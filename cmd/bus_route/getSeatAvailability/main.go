@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, counts the seats of every seat class of the bus unit that are
+// booked on the trip of the bus route on the travel date, and responds with a
+// 200 OK HTTP Status.
+//
+// A bus unit without a seat layout has STANDARD seats up to its "max_capacity",
+// and every class is charged its fare or the "rate" of the bus route.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-route/availability?id=xxxxx&bus_id=xxxxx&travel_date=xxxxx
+//
+// Sample API Params:
+//  id=RTRTB15001900-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  bus_id=BCBSCMPN-875011
+//  travel_date=2023-07-06
+//
+// Sample API Response:
+// 	{
+// 	  "bus_route_id": "RTRTB15001900-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	  "travel_date": "2023-07-06",
+// 	  "currency_code": "PHP",
+// 	  "classes": [
+// 	    {
+// 	      "class": "PREMIUM",
+// 	      "fare": 180,
+// 	      "capacity": 2,
+// 	      "booked": 1,
+// 	      "available": 1,
+// 	      "seats": ["1B"]
+// 	    },
+// 	    {
+// 	      "class": "STANDARD",
+// 	      "fare": 120,
+// 	      "capacity": 5,
+// 	      "booked": 0,
+// 	      "available": 5,
+// 	      "seats": ["1C", "2A", "2B", "2C", "2D"]
+// 	    }
+// 	  ]
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		id_query         = request.QueryStringParameters["id"]
+		busId_query      = request.QueryStringParameters["bus_id"]
+		travelDate_query = request.QueryStringParameters["travel_date"]
+	)
+
+	// Anyone can check the available seats, even without an access token
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_ROUTE_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_ROUTE_READ, err)
+	}
+
+	_, err = time.Parse("2006-01-02", travelDate_query)
+	if err != nil {
+		err := errors.New("invalid 'travel_date' value [format: YYYY-MM-DD]")
+		return api.StatusBadRequest(err)
+	}
+
+	// Fetch the existing bus route record
+	routes, _, err := query.GetBusRouteRecords(ctx, id_query, busId_query, query.Page{})
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the bus route record", utility.KVP{Key: "id", Value: id_query},
+			utility.KVP{Key: "bus_id", Value: busId_query})
+
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(routes) == 0 || routes[0].ID != id_query {
+		err := errors.New("the bus route of the trip does not exist")
+		utility.Error(err, "APIError", "the bus route does not exist", utility.KVP{Key: "id", Value: id_query},
+			utility.KVP{Key: "bus_id", Value: busId_query})
+
+		return api.StatusBadRequest(err)
+	}
+	route := routes[0]
+
+	// Fetch the bus unit of the bus route and its seat layout
+	units, _, err := query.GetBusUnitRecords(ctx, route.BusUnitID, route.BusID, query.Page{})
+	if err != nil {
+		route.Error(err, "DynamoDBError", "failed to fetch the bus unit record")
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(units) == 0 {
+		err := errors.New("the bus unit of the bus route does not exist")
+		route.Error(err, "APIError", "the bus unit does not exist")
+
+		return api.StatusBadRequest(err)
+	}
+	unit := units[0]
+
+	var layout schema.SeatLayout
+	if unit.LayoutID != "" {
+		layout, err = query.GetSeatLayoutById(ctx, unit.BusID, unit.LayoutID)
+		if err != nil {
+			unit.Error(err, "DynamoDBError", "failed to fetch the seat layout of the bus unit")
+			return api.StatusInternalServerError(err)
+		}
+	}
+
+	// The bookings of the trip that are not cancelled
+	bookings, err := query.GetTripBookings(ctx, route.BusID, route.ID, travelDate_query)
+	if err != nil {
+		route.Error(err, "DynamoDBError", "failed to fetch the bookings of the trip")
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOK(schema.NewSeatAvailability(route, unit, layout, travelDate_query, bookings))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, fetches the crew that is assigned to the trip of the bus route on
+// the travel date and the bookings of the trip that are not cancelled, and responds
+// with a 200 OK HTTP Status.
+//
+// An OPERATOR can only fetch the manifest of the trips of its own bus line, otherwise
+// it responds with a 403 Forbidden HTTP Status.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-route/manifest?id=xxxxx&bus_id=xxxxx&travel_date=xxxxx
+//
+// Sample API Params:
+//  id=BCBSCMPNBUS002-01H4Z3KX8Q9V7W5T2N6M4R3P1D
+//  bus_id=BCBSCMPN-875011
+//  travel_date=2023-07-06
+//
+// Sample API Response:
+// 	{
+// 	  "bus_route": {
+// 	    "id": "BCBSCMPNBUS002-01H4Z3KX8Q9V7W5T2N6M4R3P1D",
+// 	    "bus_id": "BCBSCMPN-875011",
+// 	    "bus_unit_id": "BCBSCMPNBUS002",
+// 	    "departure_time": "15:00",
+// 	    "arrival_time": "17:00",
+// 	    "from_route": "Route A",
+// 	    "to_route": "Route B",
+// 	    ...
+// 	  },
+// 	  "travel_date": "2023-07-06",
+// 	  "crew": [
+// 	    {
+// 	      "crew": {
+// 	        "bus_id": "BCBSCMPN-875011",
+// 	        "id": "CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	        "role": "DRIVER",
+// 	        "first_name": "Juan",
+// 	        "last_name": "Dela Cruz",
+// 	        ...
+// 	      },
+// 	      "assignment": {
+// 	        "crew_id": "CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	        "id": "ASN-01H4Z3KX8Q9V7W5T2N6M4R3P1E",
+// 	        ...
+// 	      }
+// 	    }
+// 	  ],
+// 	  "bookings": [
+// 	    {
+// 	      "id": "36bc8bc5-44d6-447b-a63b-039b99658b78",
+// 	      "status": "CONFIRMED",
+// 	      "seat_number": "A1,A2",
+// 	      "travel_date": "2023-07-06 15:00",
+// 	      ...
+// 	    }
+// 	  ],
+// 	  "seats": 2
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		id_query         = request.QueryStringParameters["id"]
+		busId_query      = request.QueryStringParameters["bus_id"]
+		travelDate_query = request.QueryStringParameters["travel_date"]
+	)
+
+	// Check if the caller can fetch the crew of the bus line
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.CREW_READ, auth.Resource{BusID: busId_query})
+	if err != nil {
+		return auth.Deny(identity, auth.CREW_READ, err)
+	}
+
+	_, err = time.Parse("2006-01-02", travelDate_query)
+	if err != nil {
+		err := errors.New("invalid 'travel_date' value [format: YYYY-MM-DD]")
+		return api.StatusBadRequest(err)
+	}
+
+	// Fetch the existing bus route record
+	routes, _, err := query.GetBusRouteRecords(ctx, id_query, busId_query, query.Page{})
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the bus route record", utility.KVP{Key: "id", Value: id_query},
+			utility.KVP{Key: "bus_id", Value: busId_query})
+
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(routes) == 0 || routes[0].ID != id_query {
+		err := errors.New("the bus route of the trip does not exist")
+		utility.Error(err, "APIError", "the bus route does not exist", utility.KVP{Key: "id", Value: id_query},
+			utility.KVP{Key: "bus_id", Value: busId_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	var manifest = schema.TripManifest{
+		BusRoute:   routes[0],
+		TravelDate: travelDate_query,
+		Crew:       []schema.ManifestCrew{},
+		Bookings:   []schema.Bookings{},
+	}
+
+	// The crew that is assigned to every trip of the bus route or to this trip
+	assignments, err := query.GetCrewAssignments(ctx, busId_query, "", id_query)
+	if err != nil {
+		manifest.BusRoute.Error(err, "DynamoDBError", "failed to fetch the crew assignments of the bus route")
+		return api.StatusInternalServerError(err)
+	}
+
+	for _, assignment := range assignments {
+		if !assignment.Covers(travelDate_query) {
+			continue
+		}
+
+		crew, err := query.GetCrewById(ctx, assignment.BusID, assignment.CrewID)
+		if err != nil {
+			assignment.Error(err, "DynamoDBError", "failed to fetch the crew record")
+			return api.StatusInternalServerError(err)
+		}
+
+		manifest.Crew = append(manifest.Crew, schema.ManifestCrew{Crew: crew, Assignment: assignment})
+	}
+
+	// The bookings of the trip that are not cancelled
+	bookings, err := query.GetTripBookings(ctx, busId_query, id_query, travelDate_query)
+	if err != nil {
+		manifest.BusRoute.Error(err, "DynamoDBError", "failed to fetch the bookings of the trip")
+		return api.StatusInternalServerError(err)
+	}
+
+	for _, booking := range bookings {
+		manifest.AddBooking(booking)
+	}
+
+	return api.StatusOK(manifest)
+}
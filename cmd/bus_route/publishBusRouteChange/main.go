@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/app/routechange"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the scheduled event as input, and sends the pending change of every bus
+// route that failed to be sent when the bus route was updated to the event bus as a
+// "route:changed" event. The pending change is removed once it has been sent.
+//
+// A route change that fails to be sent is kept on the bus route and sent on the next
+// schedule.
+func handler(ctx context.Context, event events.CloudWatchEvent) error {
+	var eventbus = os.Getenv("EVENT_BUS")
+
+	// Check if the EventBridge Event Bus is configured
+	if eventbus == "" {
+		err := errors.New("eventbridge EVENT_BUS environment variable is not set")
+		utility.Error(err, "EventBridgeError", "eventbridge EVENT_BUS is not configured on the environment")
+
+		return err
+	}
+
+	changes, err := query.GetPendingBusRouteChanges(ctx)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the pending changes of the bus routes")
+		return err
+	}
+
+	var failed int
+	for _, change := range changes {
+		err := routechange.Publish(ctx, eventbus, change)
+		if err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		err := fmt.Errorf("failed to send %d of %d pending bus route changes", failed, len(changes))
+		utility.Error(err, "EventBridgeError", "failed to send the pending changes of the bus routes")
+
+		return err
+	}
+
+	utility.Info("PublishBusRouteChange", "Successfully sent the pending changes of the bus routes", utility.KVP{Key: "changes", Value: len(changes)})
+
+	return nil
+}
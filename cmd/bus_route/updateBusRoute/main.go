@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -10,7 +11,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/app/routechange"
 	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -21,15 +24,41 @@ func main() {
 
 // It receives the Amazon API Gateway event record data as input, validates the
 // request query and body, updates the bus route record and responds with a 200
-// OK HTTP Status.
+// OK HTTP Status and the "ETag" header of the new version.
+//
+// The "fares" replace the fares of the seat classes of the bus route, an empty object
+// removes them. A negative fare responds with a 400 Bad Request HTTP Status.
+//
+// If the bus route is deactivated or its "departure_time" or "arrival_time" is changed,
+// a "route:changed" event is sent to the event bus. The passengers of the upcoming
+// bookings of the bus route are informed of the new schedule, and the bookings of a
+// deactivated bus route are cancelled with a full refund if the "booking_action" is
+// CANCEL, or flagged to be rebooked to another bus route if it is REBOOK (default).
+// The "reason" is sent to the passengers, it defaults to the deactivation of the bus
+// route. An invalid "booking_action" responds with a 400 Bad Request HTTP Status.
+//
+// The route change is saved on the bus route by the same update and removed once it
+// has been sent. If it fails to be sent, it is sent by publishBusRouteChange or before
+// the next update of the bus route.
+//
+// The "If-Match" header is optional. If it is set and the bus route has been modified
+// since that version, it responds with a 412 Precondition Failed HTTP Status.
+//
+// An OPERATOR can only update the bus routes of its own bus line, otherwise it responds
+// with a 403 Forbidden HTTP Status.
 //
 // Method: POST
 //
-// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-route/update?id=xxxxx&bus_id=xxxxx
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-route/update?id=xxxxx&bus_id=xxxxx&booking_action=xxxxx&reason=xxxxx
 //
 // Sample API Params:
 //  id=RTRTB15001900880101
 //  bus_id=SNRSBSS-875011
+//  booking_action=CANCEL
+//  reason=The bus route has been discontinued
+//
+// Sample API Headers:
+//  If-Match: "1"
 //
 // Sample API Payload:
 // 	{
@@ -43,25 +72,53 @@ func main() {
 // 	  "bus_unit_id": "SNRSBSSBUS002",
 // 	  "currency_code": "PHP",
 // 	  "rate": 90,
+// 	  "fares": {
+// 	    "PREMIUM": 150
+// 	  },
 // 	  "active": false,
 // 	  "departure_time": "15:00",
 // 	  "arrival_time": "19:00",
 // 	  "from_route": "Route A",
 // 	  "to_route": "Route B",
-// 	  "date_created": "1688010114"
+// 	  "date_created": "1688010114",
+// 	  "version": 2
 // 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
-		route       schema.BusRoute
-		id_query    = request.QueryStringParameters["id"]
-		busId_query = request.QueryStringParameters["bus_id"]
+		route        schema.BusRoute
+		eventbus     = os.Getenv("EVENT_BUS")
+		id_query     = request.QueryStringParameters["id"]
+		busId_query  = request.QueryStringParameters["bus_id"]
+		action_query = request.QueryStringParameters["booking_action"]
+		reason_query = request.QueryStringParameters["reason"]
 	)
 
-	err := route.IsEmptyPayload(request.Body)
+	// Check if the caller can update the bus route
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_ROUTE_UPDATE, auth.Resource{BusID: busId_query})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_ROUTE_UPDATE, err)
+	}
+
+	err = route.IsEmptyPayload(request.Body)
 	if err != nil {
 		return api.StatusBadRequest(err)
 	}
 
+	// Check if the EventBridge Event Bus is configured
+	if eventbus == "" {
+		err := errors.New("eventbridge EVENT_BUS environment variable is not set")
+		route.Error(err, "EventBridgeError", "eventbridge EVENT_BUS is not configured on the environment")
+
+		return api.StatusInternalServerError(err)
+	}
+
+	bookingAction, err := schema.ParseBookingAction(action_query)
+	if err != nil {
+		route.Error(err, "APIError", "invalid booking action of the bus route", utility.KVP{Key: "booking_action", Value: action_query})
+		return api.StatusBadRequest(err)
+	}
+
 	// Unmarshal the received JSON-encoded data
 	err = utility.ParseJSON([]byte(request.Body), &route)
 	if err != nil {
@@ -71,21 +128,51 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	err = route.SetFares()
+	if err != nil {
+		route.Error(err, "APIError", "invalid fares of the bus route")
+		return api.StatusBadRequest(err)
+	}
+
+	// Get the version the update is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		route.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch the existing bus route record
-	busRoutes, err := query.GetBusRouteRecords(ctx, id_query, busId_query)
+	busRoutes, _, err := query.GetBusRouteRecords(ctx, id_query, busId_query, query.Page{})
 	if err != nil {
 		route.Error(err, "DynamoDBError", "failed to fetch the bus route record")
 		return api.StatusInternalServerError(err)
 	}
 
-	busRoute := busRoutes[0]
-	if busRoute == (schema.BusRoute{}) {
+	if len(busRoutes) == 0 || busRoutes[0].ID == "" {
 		err := errors.New("the bus route you're trying to update is non-existent")
 		route.Error(err, "APIError", "the bus route does not exist")
 
 		return api.StatusBadRequest(err)
 	}
 
+	busRoute := busRoutes[0]
+	version := busRoute.Version
+	if ifMatch != nil && *ifMatch != version {
+		busRoute.Error(query.ErrVersionMismatch, "APIError", "the bus route version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
+	// The pending change of a previous update that failed to be sent is sent first, so
+	// that the route changes are sent in order.
+	if busRoute.PendingChange != nil {
+		err := routechange.Publish(ctx, eventbus, *busRoute.PendingChange)
+		if err != nil {
+			return api.StatusInternalServerError(err)
+		}
+
+		busRoute.PendingChange = nil
+	}
+
 	// Create a composite key that has both the partition/primary key
 	// and the sort key of the item.
 	var compositeKey = map[string]types.AttributeValue{
@@ -94,6 +181,7 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 	}
 
 	// Construct the update builder
+	previous := busRoute
 	busRoute = validate.UpdateBusRouteFields(route, busRoute)
 	var update = expression.Set(expression.Name("currency_code"), expression.Value(busRoute.Currency)).
 		Set(expression.Name("rate"), expression.Value(busRoute.Rate)).
@@ -103,11 +191,44 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		Set(expression.Name("from_route"), expression.Value(busRoute.FromRoute)).
 		Set(expression.Name("to_route"), expression.Value(busRoute.ToRoute))
 
-	result, err := query.UpdateBusRoute(ctx, compositeKey, update)
+	if busRoute.Fares != nil {
+		update = update.Set(expression.Name("fares"), expression.Value(busRoute.Fares))
+	}
+
+	// The bookings of the bus route are only handled if it has been deactivated
+	// or rescheduled. The route change is kept on the bus route by the same update,
+	// so that it is not lost if it fails to be sent to the event bus.
+	busRoute.Version = version + 1
+	change := schema.NewBusRouteChange(previous, busRoute)
+	if change.IsChanged() {
+		err = change.SetValues(identity.UserID, bookingAction, reason_query)
+		if err != nil {
+			change.Error(err, "APIError", "failed to set the bus route change values")
+			return api.StatusInternalServerError(err)
+		}
+
+		update = update.Set(expression.Name("pending_change"), expression.Value(change))
+	}
+
+	result, err := query.UpdateBusRoute(ctx, compositeKey, update, version)
+	if errors.Is(err, query.ErrVersionMismatch) {
+		busRoute.Error(err, "DynamoDBError", "the bus route has been modified by another request")
+		return api.StatusPreconditionFailed(err)
+	}
+
 	if err != nil {
 		busRoute.Error(err, "DynamoDBError", "failed to update the bus route record")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(result)
+	// ********************************************************************* //
+	// ******************** Send events to the EventBus ******************** //
+	// ********************************************************************* //
+	// A route change that fails to be sent is still pending on the bus route, it is
+	// sent by publishBusRouteChange or the next update of the bus route.
+	if change.IsChanged() {
+		_ = routechange.Publish(ctx, eventbus, change)
+	}
+
+	return api.StatusOKWithETag(result, result.Version)
 }
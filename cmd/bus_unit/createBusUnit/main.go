@@ -2,14 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
-	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
@@ -19,7 +21,18 @@ func main() {
 
 // It receives the Amazon API Gateway event record data as input, validates the
 // request body, saves the validated request body to the DynamoDB Table, and
-// responds with a 200 OK HTTP Status.
+// responds with a 200 OK HTTP Status. If every bus unit that failed already exists,
+// it responds with a 409 Conflict HTTP Status, otherwise a 400 Bad Request HTTP
+// Status with the failed bus units.
+//
+// If the "layout_id" is set, the seat layout of the bus line or the template is attached
+// to the bus unit and its capacity is used as the "max_capacity". The "min_capacity"
+// is optional then, and defaults to the BUS_UNIT_MIN_CAPACITY, or to the capacity of
+// a seat layout that has fewer seats. A bus unit with an invalid amenity fails with
+// the reason of the amenity.
+//
+// An OPERATOR can only create the bus units of its own bus line, otherwise it responds
+// with a 403 Forbidden HTTP Status. The "bus_id" defaults to the bus line of the OPERATOR.
 //
 // Method: POST
 //
@@ -32,7 +45,15 @@ func main() {
 // 	    "code": "BCBSCMPNBUS001",
 // 	    "active": true,
 // 	    "min_capacity": 30,
-// 	    "max_capacity": 60
+// 	    "max_capacity": 60,
+// 	    "amenities": ["AC", "WIFI", "TOILET"]
+// 	  },
+// 	  {
+// 	    "bus_id": "BCBSCMPN-875011",
+// 	    "code": "BCBSCMPNBUS003",
+// 	    "active": true,
+// 	    "min_capacity": 30,
+// 	    "layout_id": "LYT-01H4Z3KX8Q9V7W5T2N6M4R3P1A"
 // 	  }
 // 	]
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
@@ -50,41 +71,117 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusBadRequest(err)
 	}
 
-	for _, unit := range unitList {
+	// Check if the caller can create the bus units of every bus line in the request,
+	// the bus line of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	for index, unit := range unitList {
+		resource, err := identity.Restrict(auth.BUS_UNIT_CREATE, auth.Resource{BusID: unit.BusID})
+		if err != nil {
+			return auth.Deny(identity, auth.BUS_UNIT_CREATE, err)
+		}
+
+		unitList[index].BusID = resource.BusID
+	}
+
+	var (
+		newUnits  []schema.BusUnit
+		indexes   []int                   // The index of the new bus unit in the request
+		keys      = make(map[string]bool) // The bus units that are already in the batch
+		conflicts int                     // The number of bus units that already exist
+	)
+
+	for index, unit := range unitList {
+		// The maximum capacity of a bus unit with a seat layout is derived from it
+		if unit.LayoutID != "" {
+			layout, err := query.GetSeatLayoutById(ctx, unit.BusID, unit.LayoutID)
+			if err != nil {
+				unit.Error(err, "DynamoDBError", "failed to fetch the seat layout record")
+				return api.StatusInternalServerError(err)
+			}
+
+			if layout.ID == "" {
+				err := fmt.Errorf("the seat layout %s does not exist", unit.LayoutID)
+				failedUnits.SetFailedUnits(index, unit, err.Error())
+				unit.Error(err, "APIError", "the seat layout of the bus unit does not exist")
+
+				continue
+			}
+
+			capacity := layout.Capacity
+			unit.MaxCapacity = &capacity
+
+			// The minimum capacity defaults to the requirement of the seat layout
+			if unit.MinCapacity == nil {
+				minimum := unit.MinimumCapacity()
+				unit.MinCapacity = &minimum
+			}
+		}
+
 		err = unit.ValidateMaximumCapacity(*unit.MinCapacity)
 		if err != nil {
-			failedUnits.SetFailedUnits(unit, err.Error())
+			failedUnits.SetFailedUnits(index, unit, err.Error())
 			unit.Error(err, "InvalidBusCapacity", "max_capacity is invalid")
 
 			continue
 		}
 
-		// Checks whether the bus unit exist or not
-		busUnitExist, err := validate.IsBusUnitExisting(ctx, unit.BusID, unit.Code)
+		err = unit.SetAmenities()
 		if err != nil {
-			failedUnits.SetFailedUnits(unit, "failed to validate bus unit if it exist")
-			unit.Error(err, "IsBusUnitExisting", "failed to validate bus unit if it exist")
+			failedUnits.SetFailedUnits(index, unit, err.Error())
+			unit.Error(err, "APIError", "the amenities of the bus unit are invalid")
 
 			continue
 		}
 
-		// If the bus unit exists, continue to the next item
-		if busUnitExist {
-			utility.Info("BusUnitExisting", "already existing bus unit", utility.KVP{Key: "unit", Value: unit})
+		// If the bus unit is already in the batch, continue to the next item
+		key := unit.Code + "/" + unit.BusID
+		if keys[key] {
+			failedUnits.SetFailedUnits(index, unit, query.ErrAlreadyExists.Error())
+			conflicts++
+
 			continue
 		}
 
 		// Set default values of the bus line information
 		unit.SetValues()
 
-		// Inserts a new bus unit record to the DynamoDB
-		err = query.CreateBusUnit(ctx, unit)
-		if err != nil {
-			failedUnits.SetFailedUnits(unit, "failed to create a new bus unit record")
-			unit.Error(err, "DynamoDBError", "failed to create a new bus unit record")
+		keys[key] = true
+		newUnits = append(newUnits, unit)
+		indexes = append(indexes, index)
+	}
+
+	// Inserts the new bus unit records to the DynamoDB in batches
+	failed, err := query.CreateBusUnits(ctx, newUnits)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to create the new bus unit records",
+			utility.KVP{Key: "Integration", Value: "Bus Ticketing – Bus Unit"})
+
+		return api.StatusInternalServerError(err)
+	}
 
+	for i, unit := range newUnits {
+		err, ok := failed[i]
+		if !ok {
 			continue
 		}
+
+		reason := "failed to create a new bus unit record"
+		switch {
+		case errors.Is(err, query.ErrAlreadyExists):
+			reason = err.Error()
+			conflicts++
+
+		case errors.Is(err, query.ErrUnprocessedItem):
+			reason = err.Error()
+		}
+
+		failedUnits.SetFailedUnits(indexes[i], unit, reason)
+		unit.Error(err, "DynamoDBError", reason)
+	}
+
+	// If every failed bus unit already exists, return a 409 Conflict HTTP Status
+	if len(failedUnits.Failed) > 0 && len(failedUnits.Failed) == conflicts {
+		return api.Response(http.StatusConflict, failedUnits), nil
 	}
 
 	if len(failedUnits.Failed) > 0 {
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+type response struct {
+	Maintenance schema.BusUnitMaintenance `json:"maintenance"`
+	Flagged     int                       `json:"flagged_bookings"`
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request body, schedules the maintenance of the bus unit, flags the bookings of
+// its bus routes whose travel date is within the maintenance, and responds with a
+// 200 OK HTTP Status.
+//
+// The bus routes of the bus unit cannot be booked on the days of the maintenance.
+// The flagged bookings are not cancelled, they are listed on the maintenance
+// conflicts so that they are reassigned to another bus unit. If the maintenance
+// overlaps another maintenance of the bus unit, it responds with a 409 Conflict
+// HTTP Status.
+//
+// An OPERATOR can only schedule the maintenance of the bus units of its own bus line,
+// otherwise it responds with a 403 Forbidden HTTP Status. The "bus_id" defaults to the
+// bus line of the OPERATOR.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-unit/maintenance/create
+//
+// Sample API Payload:
+// 	{
+// 	  "bus_id": "BCBSCMPN-875011",
+// 	  "bus_unit_id": "BCBSCMPNBUS002",
+// 	  "start_date": "2023-07-10",
+// 	  "end_date": "2023-07-12",
+// 	  "reason": "engine repair"
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "maintenance": {
+// 	    "bus_id": "BCBSCMPN-875011",
+// 	    "id": "MNT-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	    "bus_unit_id": "BCBSCMPNBUS002",
+// 	    "start_date": "2023-07-10",
+// 	    "end_date": "2023-07-12",
+// 	    "reason": "engine repair",
+// 	    "created_by": "OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	    "date_created": "1688091891"
+// 	  },
+// 	  "flagged_bookings": 2
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var maintenance schema.BusUnitMaintenance
+
+	if request.Body == "" {
+		err := errors.New("request payload is empty")
+		return api.StatusBadRequest(err)
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err := utility.ParseJSON([]byte(request.Body), &maintenance)
+	if err != nil {
+		maintenance.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data", utility.KVP{Key: "payload", Value: request.Body})
+		return api.StatusBadRequest(err)
+	}
+
+	// Check if the caller can update the bus unit of the bus line, the bus line
+	// of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	resource, err := identity.Restrict(auth.BUS_UNIT_UPDATE, auth.Resource{BusID: maintenance.BusID})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_UPDATE, err)
+	}
+
+	maintenance.BusID = resource.BusID
+	err = maintenance.Validate()
+	if err != nil {
+		maintenance.Error(err, "APIError", "invalid bus unit maintenance")
+		return api.StatusBadRequest(err)
+	}
+
+	// The bus unit must belong to the bus line
+	unitExist, err := validate.IsBusUnitExisting(ctx, maintenance.BusID, maintenance.BusUnitID)
+	if err != nil {
+		maintenance.Error(err, "IsBusUnitExisting", "failed to validate the bus unit of the maintenance")
+		return api.StatusInternalServerError(err)
+	}
+
+	if !unitExist {
+		err := fmt.Errorf("the bus unit %s does not belong to the bus line %s", maintenance.BusUnitID, maintenance.BusID)
+		maintenance.Error(err, "APIError", "the bus unit does not belong to the bus line")
+
+		return api.StatusBadRequest(err)
+	}
+
+	// The maintenance of a bus unit must not overlap
+	list, err := query.GetBusUnitMaintenance(ctx, maintenance.BusID, maintenance.BusUnitID)
+	if err != nil {
+		maintenance.Error(err, "DynamoDBError", "failed to fetch the maintenance of the bus unit")
+		return api.StatusInternalServerError(err)
+	}
+
+	for _, existing := range list {
+		if maintenance.Overlaps(existing) {
+			err := fmt.Errorf("the maintenance overlaps the maintenance %s of the bus unit", existing.ID)
+			maintenance.Error(err, "APIError", "the maintenance of the bus unit overlaps", utility.KVP{Key: "existing", Value: existing})
+
+			return api.StatusConflict(err)
+		}
+	}
+
+	err = maintenance.SetValues(identity.UserID)
+	if err != nil {
+		maintenance.Error(err, "IDError", "failed to generate the maintenance ID")
+		return api.StatusInternalServerError(err)
+	}
+
+	err = query.CreateBusUnitMaintenance(ctx, maintenance)
+	if errors.Is(err, query.ErrAlreadyExists) {
+		maintenance.Error(err, "DynamoDBError", "the maintenance ID has already been taken")
+		return api.StatusConflict(err)
+	}
+
+	if err != nil {
+		maintenance.Error(err, "DynamoDBError", "failed to create a new bus unit maintenance")
+		return api.StatusInternalServerError(err)
+	}
+
+	// The maintenance is kept if flagging the bookings fails, since the bus routes
+	// already cannot be booked. It can be deleted and scheduled again to flag them.
+	flagged, err := query.FlagMaintenanceBookings(ctx, maintenance)
+	if err != nil {
+		maintenance.Error(err, "DynamoDBError", "failed to flag the bookings of the bus unit maintenance")
+		return api.StatusInternalServerError(err)
+	}
+
+	utility.Info("BusUnitMaintenance", "the maintenance of the bus unit has been scheduled", utility.KVP{Key: "maintenance", Value: maintenance},
+		utility.KVP{Key: "flagged_bookings", Value: len(flagged)})
+
+	return api.StatusOK(response{Maintenance: maintenance, Flagged: len(flagged)})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request body, saves the seat layout to the DynamoDB Table, and responds with a
+// 200 OK HTTP Status. The capacity of the seat layout is the number of its seats, and
+// a seat without a class is a STANDARD seat.
+//
+// A seat layout with TEMPLATE as the "bus_id" is shared by every bus line and can
+// only be created by an ADMIN. An OPERATOR can only create the seat layouts of its
+// own bus line, otherwise it responds with a 403 Forbidden HTTP Status. The "bus_id"
+// defaults to the bus line of the OPERATOR.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-unit/layout/create
+//
+// Sample API Payload:
+// 	{
+// 	  "bus_id": "BCBSCMPN-875011",
+// 	  "name": "2x2 Regular",
+// 	  "rows": [
+// 	    [{"type": "DRIVER"}, {"type": "EMPTY"}, {"type": "AISLE"}, {"type": "DOOR"}, {"type": "DOOR"}],
+// 	    [{"label": "1A", "type": "SEAT", "class": "PREMIUM"}, {"label": "1B", "type": "SEAT", "class": "PREMIUM"}, {"type": "AISLE"}, {"label": "1C", "type": "SEAT"}, {"label": "1D", "type": "BLOCKED"}],
+// 	    [{"label": "2A", "type": "SEAT"}, {"label": "2B", "type": "SEAT"}, {"type": "AISLE"}, {"label": "2C", "type": "SEAT"}, {"label": "2D", "type": "SEAT"}]
+// 	  ]
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "bus_id": "BCBSCMPN-875011",
+// 	  "id": "LYT-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	  "name": "2x2 Regular",
+// 	  "rows": [...],
+// 	  "capacity": 7,
+// 	  "classes": {"PREMIUM": 2, "STANDARD": 5},
+// 	  "created_by": "OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	  "date_created": "1688091891"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var layout schema.SeatLayout
+
+	err := layout.IsEmptyPayload(request.Body)
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err = utility.ParseJSON([]byte(request.Body), &layout)
+	if err != nil {
+		layout.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data", utility.KVP{Key: "payload", Value: request.Body})
+		return api.StatusInternalServerError(err)
+	}
+
+	// Check if the caller can create the seat layouts of the bus line, the bus line
+	// of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	resource, err := identity.Restrict(auth.BUS_UNIT_CREATE, auth.Resource{BusID: layout.BusID})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_CREATE, err)
+	}
+
+	layout.BusID = resource.BusID
+	if layout.BusID == "" {
+		err := errors.New("bus_id is required")
+		layout.Error(err, "APIError", "the bus line of the seat layout is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	err = layout.Validate()
+	if err != nil {
+		layout.Error(err, "APIError", "invalid seat layout")
+		return api.StatusBadRequest(err)
+	}
+
+	// The seat layout must belong to an existing bus line if it is not a template
+	if !layout.IsTemplate() {
+		bus, err := query.GetBusLineById(ctx, layout.BusID)
+		if err != nil {
+			layout.Error(err, "DynamoDBError", "failed to fetch the bus line record")
+			return api.StatusInternalServerError(err)
+		}
+
+		if bus.ID == "" {
+			err := fmt.Errorf("the bus line %s does not exist", layout.BusID)
+			layout.Error(err, "APIError", "the bus line of the seat layout does not exist")
+
+			return api.StatusBadRequest(err)
+		}
+	}
+
+	err = layout.SetValues(identity.UserID)
+	if err != nil {
+		layout.Error(err, "IDError", "failed to generate the seat layout ID")
+		return api.StatusInternalServerError(err)
+	}
+
+	err = query.CreateSeatLayout(ctx, layout)
+	if errors.Is(err, query.ErrAlreadyExists) {
+		layout.Error(err, "DynamoDBError", "the seat layout ID has already been taken")
+		return api.StatusConflict(err)
+	}
+
+	if err != nil {
+		layout.Error(err, "DynamoDBError", "failed to create a new seat layout record")
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOK(layout)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, removes the flag of the bookings of the maintenance, deletes the
+// maintenance of the bus unit, and responds with a 200 OK HTTP Status. The bus
+// routes of the bus unit can be booked again on the days of the maintenance.
+//
+// An OPERATOR can only delete the maintenance of the bus units of its own bus line,
+// otherwise it responds with a 403 Forbidden HTTP Status.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-unit/maintenance/delete?bus_id=xxxxx&id=xxxxx
+//
+// Sample API Params:
+//  bus_id=BCBSCMPN-875011
+//  id=MNT-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//
+// Sample API Response:
+// 	{
+// 	  "message": "the maintenance of the bus unit has been deleted"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		id_query    = request.QueryStringParameters["id"]
+		busId_query = request.QueryStringParameters["bus_id"]
+	)
+
+	if id_query == "" || busId_query == "" {
+		err := errors.New("id and bus_id are required")
+		utility.Error(err, "APIError", "the maintenance ID or the bus ID is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	// Check if the caller can update the bus unit
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_UNIT_UPDATE, auth.Resource{BusID: busId_query})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_UPDATE, err)
+	}
+
+	// Fetch the existing maintenance record
+	maintenance, err := query.GetBusUnitMaintenanceById(ctx, busId_query, id_query)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the bus unit maintenance", utility.KVP{Key: "bus_id", Value: busId_query},
+			utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusInternalServerError(err)
+	}
+
+	if maintenance.ID == "" {
+		err := errors.New("the maintenance you're trying to delete is non-existent")
+		utility.Error(err, "APIError", "the bus unit maintenance does not exist", utility.KVP{Key: "bus_id", Value: busId_query},
+			utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	// The flags are removed first, so that a failed deletion can be requested
+	// again while the maintenance still exists.
+	err = query.ClearMaintenanceBookings(ctx, maintenance)
+	if err != nil {
+		maintenance.Error(err, "DynamoDBError", "failed to remove the flag of the bookings of the bus unit maintenance")
+		return api.StatusInternalServerError(err)
+	}
+
+	err = query.DeleteBusUnitMaintenance(ctx, maintenance.BusID, maintenance.ID)
+	if err != nil {
+		maintenance.Error(err, "DynamoDBError", "failed to delete the bus unit maintenance")
+		return api.StatusInternalServerError(err)
+	}
+
+	utility.Info("BusUnitMaintenance", "the maintenance of the bus unit has been deleted", utility.KVP{Key: "maintenance", Value: maintenance},
+		utility.KVP{Key: "deleted_by", Value: identity.UserID})
+
+	return api.StatusOK(api.Message{Custom: "the maintenance of the bus unit has been deleted"})
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -19,32 +21,43 @@ func main() {
 // It receives the Amazon API Gateway event record as input, fetches the
 // bus unit's record, and responds with a 200 OK HTTP Status.
 //
+// The "amenities" are comma-separated and a bus unit must have every one of them,
+// an invalid amenity responds with a 400 Bad Request HTTP Status.
+//
 // Method: GET
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-unit/search?bus_id=xxxxxx
 //
 // Sample API Params:
 //  bus_id=BCBSCMPN-875011
+//  amenities=AC,WIFI
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "bus_id": "BCBSCMPN-875011",
-// 	    "code": "BCBSCMPNBUS001",
-// 	    "active": true,
-// 	    "min_capacity": 30,
-// 	    "max_capacity": 60,
-// 	    "date_created": "1687501761"
-// 	  },
-// 	  {
-// 	    "bus_id": "BCBSCMPN-875011",
-// 	    "code": "BCBSCMPNBUS003",
-// 	    "active": true,
-// 	    "min_capacity": 45,
-// 	    "max_capacity": 70,
-// 	    "date_created": "1687501761"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "bus_id": "BCBSCMPN-875011",
+// 	      "code": "BCBSCMPNBUS001",
+// 	      "active": true,
+// 	      "min_capacity": 30,
+// 	      "max_capacity": 60,
+// 	      "amenities": ["AC", "TOILET", "WIFI"],
+// 	      "date_created": "1687501761"
+// 	    },
+// 	    {
+// 	      "bus_id": "BCBSCMPN-875011",
+// 	      "code": "BCBSCMPNBUS003",
+// 	      "active": true,
+// 	      "min_capacity": 45,
+// 	      "max_capacity": 70,
+// 	      "amenities": ["AC", "WIFI"],
+// 	      "date_created": "1687501761"
+// 	    }
+// 	  ],
+// 	  "count": 2
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		active       *bool
@@ -53,6 +66,13 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		active_query = request.QueryStringParameters["active"]
 	)
 
+	// Check if the caller can fetch the bus units
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_UNIT_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_READ, err)
+	}
+
 	// Convert the 'active' query into a boolean value
 	// if it is present in the request parameters.
 	if active_query != "" {
@@ -65,11 +85,25 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		active = &value
 	}
 
-	listOfBusUnit, err := query.FilterBusUnit(ctx, code_query, busId_query, active)
+	amenities, err := schema.ParseAmenities(request.QueryStringParameters["amenities"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	listOfBusUnit, nextToken, err := query.FilterBusUnit(ctx, code_query, busId_query, active, amenities, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to filter the bus unit")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(listOfBusUnit)
+	return api.StatusOKWithPage(listOfBusUnit, len(listOfBusUnit), nextToken)
 }
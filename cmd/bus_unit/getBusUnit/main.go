@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -25,25 +27,46 @@ func main() {
 // Sample API Params:
 //  bus_id=BCBSCMPN-875011
 //  code=BCBSCMPNBUS002
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "bus_id": "BCBSCMPN-875011",
-// 	    "code": "BCBSCMPNBUS002",
-// 	    "active": true,
-// 	    "min_capacity": 30,
-// 	    "max_capacity": 60
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "bus_id": "BCBSCMPN-875011",
+// 	      "code": "BCBSCMPNBUS002",
+// 	      "active": true,
+// 	      "min_capacity": 30,
+// 	      "max_capacity": 60
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		code_query  = request.QueryStringParameters["code"]
 		busId_query = request.QueryStringParameters["bus_id"]
 	)
 
+	// Check if the caller can fetch the bus units
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_UNIT_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_READ, err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch the existing bus unit record
-	units, err := query.GetBusUnitRecords(ctx, code_query, busId_query)
+	units, nextToken, err := query.GetBusUnitRecords(ctx, code_query, busId_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to fetch the bus unit record", utility.KVP{Key: "code", Value: code_query}, utility.KVP{Key: "bus_id", Value: busId_query})
 
@@ -54,5 +77,13 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusOK(api.Message{Custom: "no record(s) found"})
 	}
 
-	return api.StatusOK(units)
+	response, err := api.StatusOKWithPage(units, len(units), nextToken)
+
+	// Set the "ETag" header to the record version if a specific record is fetched,
+	// it is sent back as the "If-Match" header when updating the record.
+	if code_query != "" && busId_query != "" && len(units) == 1 {
+		response.Headers["ETag"] = api.ETag(units[0].Version)
+	}
+
+	return response, err
 }
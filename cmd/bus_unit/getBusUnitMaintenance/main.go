@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, fetches the maintenance of the bus units of the bus line, and
+// responds with a 200 OK HTTP Status. If the "bus_unit_id" is set, it only returns
+// the maintenance of the bus unit.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-unit/maintenance/get?bus_id=xxxxx
+//
+// Sample API Params:
+//  bus_id=BCBSCMPN-875011
+//  bus_unit_id=BCBSCMPNBUS002
+//
+// Sample API Response:
+// 	[
+// 	  {
+// 	    "bus_id": "BCBSCMPN-875011",
+// 	    "id": "MNT-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	    "bus_unit_id": "BCBSCMPNBUS002",
+// 	    "start_date": "2023-07-10",
+// 	    "end_date": "2023-07-12",
+// 	    "reason": "engine repair",
+// 	    "created_by": "OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	    "date_created": "1688091891"
+// 	  }
+// 	]
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		busId_query     = request.QueryStringParameters["bus_id"]
+		busUnitId_query = request.QueryStringParameters["bus_unit_id"]
+	)
+
+	// Check if the caller can fetch the bus units
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_UNIT_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_READ, err)
+	}
+
+	if busId_query == "" {
+		err := errors.New("bus_id is required")
+		return api.StatusBadRequest(err)
+	}
+
+	list, err := query.GetBusUnitMaintenance(ctx, busId_query, busUnitId_query)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the maintenance of the bus units", utility.KVP{Key: "bus_id", Value: busId_query},
+			utility.KVP{Key: "bus_unit_id", Value: busUnitId_query})
+
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(list) == 0 {
+		return api.StatusOK(api.Message{Custom: "no record(s) found"})
+	}
+
+	return api.StatusOK(list)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, fetches the bookings that are flagged by the maintenance of the
+// bus units together with their maintenance, and responds with a 200 OK HTTP Status.
+// The cancelled bookings are not listed since they no longer need to be reassigned.
+//
+// An ADMIN can list the conflicts of every bus line, while an OPERATOR can only list
+// the conflicts of its own bus line, the "bus_id" defaults to it.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-unit/maintenance/conflicts
+//
+// Sample API Params:
+//  bus_id=BCBSCMPN-875011
+//  maintenance_id=MNT-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  limit=25
+//  next_token=xxxxxx
+//
+// Sample API Response:
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "maintenance": {
+// 	        "bus_id": "BCBSCMPN-875011",
+// 	        "id": "MNT-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	        "bus_unit_id": "BCBSCMPNBUS002",
+// 	        "start_date": "2023-07-10",
+// 	        "end_date": "2023-07-12",
+// 	        "reason": "engine repair",
+// 	        "created_by": "OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	        "date_created": "1688091891"
+// 	      },
+// 	      "booking": {
+// 	        "id": "36bc8bc5-44d6-447b-a63b-039b99658b78",
+// 	        "user_id": "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1C",
+// 	        "bus_id": "BCBSCMPN-875011",
+// 	        "bus_route_id": "BCBSCMPNBUS002-01H4Z3KX8Q9V7W5T2N6M4R3P1D",
+// 	        "status": "CONFIRMED",
+// 	        "seat_number": "A1,A2",
+// 	        "travel_date": "2023-07-11 19:30",
+// 	        "maintenance_id": "MNT-01H4Z3KX8Q9V7W5T2N6M4R3P1A"
+// 	      }
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var maintenanceId_query = request.QueryStringParameters["maintenance_id"]
+
+	// Check if the caller can update the bus units of the bus line, the bus line
+	// of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	resource, err := identity.Restrict(auth.BUS_UNIT_UPDATE, auth.Resource{BusID: request.QueryStringParameters["bus_id"]})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_UPDATE, err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	bookings, nextToken, err := query.FilterMaintenanceConflicts(ctx, resource.BusID, maintenanceId_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the bookings of the bus unit maintenance", utility.KVP{Key: "bus_id", Value: resource.BusID})
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(bookings) == 0 && nextToken == "" {
+		return api.StatusOK(api.Message{Custom: "no record(s) found"})
+	}
+
+	// The bookings of a page are mostly flagged by the same maintenance
+	var (
+		conflicts    = []schema.MaintenanceConflict{}
+		maintenances = make(map[string]schema.BusUnitMaintenance)
+	)
+
+	for _, booking := range bookings {
+		maintenance, ok := maintenances[booking.MaintenanceID]
+		if !ok {
+			maintenance, err = query.GetBusUnitMaintenanceById(ctx, booking.BusID, booking.MaintenanceID)
+			if err != nil {
+				booking.Error(err, "DynamoDBError", "failed to fetch the maintenance of the booking")
+				return api.StatusInternalServerError(err)
+			}
+
+			maintenances[booking.MaintenanceID] = maintenance
+		}
+
+		conflicts = append(conflicts, schema.MaintenanceConflict{Maintenance: maintenance, Booking: booking})
+	}
+
+	return api.StatusOKWithPage(conflicts, len(conflicts), nextToken)
+}
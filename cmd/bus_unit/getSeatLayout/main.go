@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, fetches the seat layout(s) of the bus line, and responds with a
+// 200 OK HTTP Status. The templates are fetched with TEMPLATE as the "bus_id", and
+// a specific seat layout falls back to the templates if the bus line has no seat
+// layout with the "id".
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/bus-unit/layout/get?bus_id=xxxxx
+//
+// Sample API Params:
+//  bus_id=BCBSCMPN-875011
+//  id=LYT-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  limit=25
+//  next_token=xxxxxx
+//
+// Sample API Response:
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "bus_id": "BCBSCMPN-875011",
+// 	      "id": "LYT-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	      "name": "2x2 Regular",
+// 	      "rows": [
+// 	        [{"type": "DRIVER"}, {"type": "EMPTY"}, {"type": "AISLE"}, {"type": "DOOR"}, {"type": "DOOR"}],
+// 	        [{"label": "1A", "type": "SEAT", "class": "PREMIUM"}, {"label": "1B", "type": "SEAT", "class": "PREMIUM"}, {"type": "AISLE"}, {"label": "1C", "type": "SEAT", "class": "STANDARD"}, {"label": "1D", "type": "BLOCKED", "class": "STANDARD"}]
+// 	      ],
+// 	      "capacity": 3,
+// 	      "classes": {"PREMIUM": 2, "STANDARD": 1},
+// 	      "created_by": "OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	      "date_created": "1688091891"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		id_query    = request.QueryStringParameters["id"]
+		busId_query = request.QueryStringParameters["bus_id"]
+	)
+
+	// Check if the caller can fetch the bus units
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_UNIT_READ, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_READ, err)
+	}
+
+	if busId_query == "" {
+		err := errors.New("bus_id is required")
+		return api.StatusBadRequest(err)
+	}
+
+	// ********** Fetching a specific seat layout record ********** //
+	if id_query != "" {
+		layout, err := query.GetSeatLayoutById(ctx, busId_query, id_query)
+		if err != nil {
+			layout.Error(err, "DynamoDBError", "failed to fetch the seat layout record", utility.KVP{Key: "bus_id", Value: busId_query},
+				utility.KVP{Key: "id", Value: id_query})
+
+			return api.StatusInternalServerError(err)
+		}
+
+		if layout.ID == "" {
+			return api.StatusOK(api.Message{Custom: "no record(s) found"})
+		}
+
+		return api.StatusOKWithPage([]schema.SeatLayout{layout}, 1, "")
+	}
+
+	// **************** List of seat layout records **************** //
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	list, nextToken, err := query.GetSeatLayouts(ctx, busId_query, "", page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the seat layout records", utility.KVP{Key: "bus_id", Value: busId_query})
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(list) == 0 {
+		return api.StatusOK(api.Message{Custom: "no record(s) found"})
+	}
+
+	return api.StatusOKWithPage(list, len(list), nextToken)
+}
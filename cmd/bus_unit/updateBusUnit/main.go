@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -10,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
@@ -21,7 +23,24 @@ func main() {
 
 // It receives the Amazon API Gateway event record data as input, validates the
 // request query and body, updates the bus unit's record and responds with a 200
-// OK HTTP Status.
+// OK HTTP Status and the "ETag" header of the new version.
+//
+// The "If-Match" header is optional. If it is set and the bus unit has been modified
+// since that version, it responds with a 412 Precondition Failed HTTP Status.
+//
+// If the "layout_id" is set, the seat layout of the bus line or the template is attached
+// to the bus unit and its capacity is used as the "max_capacity". The "max_capacity" of
+// a bus unit with a seat layout cannot be set, otherwise it responds with a 400 Bad
+// Request HTTP Status. The "min_capacity" must be at least the BUS_UNIT_MIN_CAPACITY,
+// or the capacity of a seat layout that has fewer seats.
+//
+// The "amenities" replace the amenities of the bus unit, an empty list removes them. An
+// invalid amenity responds with a 400 Bad Request HTTP Status. They are also copied to
+// the bus routes of the bus unit, if they fail to be copied it responds with a 500
+// Internal Server Error HTTP Status and the update can be sent again to copy them.
+//
+// An OPERATOR can only update the bus units of its own bus line, otherwise it responds
+// with a 403 Forbidden HTTP Status.
 //
 // Method: POST
 //
@@ -31,6 +50,9 @@ func main() {
 //  bus_id=BCBSCMPN-875011
 //  code=BCBSCMPNBUS002
 //
+// Sample API Headers:
+//  If-Match: "1"
+//
 // Sample API Payload:
 // 	{
 // 		"active": false
@@ -43,7 +65,8 @@ func main() {
 // 	  "active": false,
 // 	  "min_capacity": 30,
 // 	  "max_capacity": 60,
-// 	  "date_created": "1687501761"
+// 	  "date_created": "1687501761",
+// 	  "version": 2
 // 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
@@ -52,7 +75,14 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		busId_query = request.QueryStringParameters["bus_id"]
 	)
 
-	err := unit.IsEmptyPayload(request.Body)
+	// Check if the caller can update the bus unit
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.BUS_UNIT_UPDATE, auth.Resource{BusID: busId_query})
+	if err != nil {
+		return auth.Deny(identity, auth.BUS_UNIT_UPDATE, err)
+	}
+
+	err = unit.IsEmptyPayload(request.Body)
 	if err != nil {
 		return api.StatusBadRequest(err)
 	}
@@ -66,28 +96,90 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	err = unit.SetAmenities()
+	if err != nil {
+		unit.Error(err, "APIError", "the amenities of the bus unit are invalid")
+		return api.StatusBadRequest(err)
+	}
+
+	// Get the version the update is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		unit.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch the existing bus unit record
-	busUnits, err := query.GetBusUnitRecords(ctx, code_query, busId_query)
+	busUnits, _, err := query.GetBusUnitRecords(ctx, code_query, busId_query, query.Page{})
 	if err != nil {
 		unit.Error(err, "DynamoDBError", "failed to fetch the bus unit record")
 		return api.StatusInternalServerError(err)
 	}
 
-	busUnit := busUnits[0]
-	if busUnit == (schema.BusUnit{}) {
+	if len(busUnits) == 0 || busUnits[0].Code == "" {
 		err := errors.New("the bus unit you're trying to update is non-existent")
 		unit.Error(err, "APIError", "the bus unit does not exist")
 
 		return api.StatusBadRequest(err)
 	}
 
-	err = unit.ValidateMinimumCapacity()
-	if err != nil {
-		unit.Error(err, "APIError", "the minimum capacity is less than the required capacity (25)")
+	busUnit := busUnits[0]
+	version := busUnit.Version
+	if ifMatch != nil && *ifMatch != version {
+		busUnit.Error(query.ErrVersionMismatch, "APIError", "the bus unit version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
+	// The maximum capacity of a bus unit with a seat layout is derived from it
+	switch {
+	case unit.LayoutID != "":
+		layout, err := query.GetSeatLayoutById(ctx, busId_query, unit.LayoutID)
+		if err != nil {
+			unit.Error(err, "DynamoDBError", "failed to fetch the seat layout record")
+			return api.StatusInternalServerError(err)
+		}
+
+		if layout.ID == "" {
+			err := fmt.Errorf("the seat layout %s does not exist", unit.LayoutID)
+			unit.Error(err, "APIError", "the seat layout of the bus unit does not exist")
+
+			return api.StatusBadRequest(err)
+		}
+
+		capacity := layout.Capacity
+		unit.MaxCapacity = &capacity
+
+	case busUnit.LayoutID != "" && unit.MaxCapacity != nil:
+		err := errors.New("max_capacity is derived from the seat layout of the bus unit")
+		unit.Error(err, "APIError", "the max capacity of a bus unit with a seat layout cannot be set")
+
 		return api.StatusBadRequest(err)
 	}
 
-	err = unit.ValidateMaximumCapacity(*busUnit.MinCapacity)
+	// The capacity of the bus unit after the update, the minimum capacity requirement
+	// of a bus unit with a seat layout is derived from it.
+	updated := busUnit
+	if unit.LayoutID != "" {
+		updated.LayoutID = unit.LayoutID
+	}
+
+	if unit.MinCapacity != nil {
+		updated.MinCapacity = unit.MinCapacity
+	}
+
+	if unit.MaxCapacity != nil {
+		updated.MaxCapacity = unit.MaxCapacity
+	}
+
+	if unit.MinCapacity != nil {
+		err = updated.ValidateMinimumCapacity()
+		if err != nil {
+			unit.Error(err, "APIError", "the minimum capacity is less than the required capacity")
+			return api.StatusBadRequest(err)
+		}
+	}
+
+	err = updated.ValidateMaximumCapacity(*updated.MinCapacity)
 	if err != nil {
 		unit.Error(err, "APIError", "the max capacity is less than the minimum capacity")
 		return api.StatusBadRequest(err)
@@ -106,11 +198,33 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		Set(expression.Name("min_capacity"), expression.Value(busUnit.MinCapacity)).
 		Set(expression.Name("max_capacity"), expression.Value(busUnit.MaxCapacity))
 
-	result, err := query.UpdateBusUnit(ctx, compositeKey, update)
+	if busUnit.LayoutID != "" {
+		update = update.Set(expression.Name("layout_id"), expression.Value(busUnit.LayoutID))
+	}
+
+	if busUnit.Amenities != nil {
+		update = update.Set(expression.Name("amenities"), expression.Value(busUnit.Amenities))
+	}
+
+	result, err := query.UpdateBusUnit(ctx, compositeKey, update, version)
+	if errors.Is(err, query.ErrVersionMismatch) {
+		busUnit.Error(err, "DynamoDBError", "the bus unit has been modified by another request")
+		return api.StatusPreconditionFailed(err)
+	}
+
 	if err != nil {
 		busUnit.Error(err, "DynamoDBError", "failed to update the bus unit record")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(result)
+	// The bus routes of the bus unit are filtered by the amenities of the bus unit
+	if unit.Amenities != nil {
+		err = query.SetBusRouteAmenities(ctx, result)
+		if err != nil {
+			result.Error(err, "DynamoDBError", "failed to copy the amenities of the bus unit to its bus routes")
+			return api.StatusInternalServerError(err)
+		}
+	}
+
+	return api.StatusOKWithETag(result, result.Version)
 }
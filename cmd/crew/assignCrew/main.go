@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request body, assigns the crew to the trips of the bus route, and responds with
+// a 200 OK HTTP Status. The crew is assigned to the trip on the "travel_date" if it
+// is set, otherwise to every trip of the bus route.
+//
+// The crew must be active and belong to the bus line of the bus route, and a DRIVER
+// must have a licence that has not expired on the travel date (or today). If the trips
+// overlap the other trips of the crew, or the total duty of the crew on a day exceeds
+// the CREW_MAX_DUTY (defaults to 10h), it responds with a 409 Conflict HTTP Status.
+//
+// An OPERATOR can only assign the crew of its own bus line, otherwise it responds with
+// a 403 Forbidden HTTP Status. The "bus_id" defaults to the bus line of the OPERATOR.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/crew/assign
+//
+// Sample API Payload:
+// 	{
+// 	  "crew_id": "CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	  "bus_id": "BCBSCMPN-875011",
+// 	  "bus_route_id": "BCBSCMPNBUS002-01H4Z3KX8Q9V7W5T2N6M4R3P1D",
+// 	  "travel_date": "2023-07-06"
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "crew_id": "CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	  "id": "ASN-01H4Z3KX8Q9V7W5T2N6M4R3P1E",
+// 	  "bus_id": "BCBSCMPN-875011",
+// 	  "bus_route_id": "BCBSCMPNBUS002-01H4Z3KX8Q9V7W5T2N6M4R3P1D",
+// 	  "role": "DRIVER",
+// 	  "travel_date": "2023-07-06",
+// 	  "departure_time": "15:00",
+// 	  "arrival_time": "17:00",
+// 	  "assigned_by": "OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	  "date_created": "1688091891"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var assignment schema.CrewAssignment
+
+	if request.Body == "" {
+		err := errors.New("request payload is empty")
+		return api.StatusBadRequest(err)
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err := utility.ParseJSON([]byte(request.Body), &assignment)
+	if err != nil {
+		assignment.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data", utility.KVP{Key: "payload", Value: request.Body})
+		return api.StatusInternalServerError(err)
+	}
+
+	// Check if the caller can assign the crew of the bus line, the bus line
+	// of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	resource, err := identity.Restrict(auth.CREW_MANAGE, auth.Resource{BusID: assignment.BusID})
+	if err != nil {
+		return auth.Deny(identity, auth.CREW_MANAGE, err)
+	}
+
+	assignment.BusID = resource.BusID
+	err = assignment.Validate()
+	if err != nil {
+		assignment.Error(err, "APIError", "invalid crew assignment")
+		return api.StatusBadRequest(err)
+	}
+
+	// A trip that has already departed cannot be assigned
+	today := time.Now().UTC().Format("2006-01-02")
+	if assignment.TravelDate != "" && assignment.TravelDate < today {
+		err := errors.New("the travel date has already passed")
+		assignment.Error(err, "APIError", "the travel date of the crew assignment has passed")
+
+		return api.StatusBadRequest(err)
+	}
+
+	crew, err := query.GetCrewById(ctx, assignment.BusID, assignment.CrewID)
+	if err != nil {
+		assignment.Error(err, "DynamoDBError", "failed to fetch the crew record")
+		return api.StatusInternalServerError(err)
+	}
+
+	if crew.ID == "" || !crew.IsActive() {
+		err := fmt.Errorf("the crew %s is not an active crew of the bus line %s", assignment.CrewID, assignment.BusID)
+		assignment.Error(err, "APIError", "the crew does not exist or is not active")
+
+		return api.StatusBadRequest(err)
+	}
+
+	day := assignment.TravelDate
+	if day == "" {
+		day = today
+	}
+
+	if !crew.HasValidLicence(day) {
+		err := fmt.Errorf("the licence of the driver expires on %s", crew.LicenceExpiry)
+		crew.Error(err, "APIError", "the licence of the driver has expired on the travel date")
+
+		return api.StatusBadRequest(err)
+	}
+
+	// The bus route must belong to the bus line of the crew
+	routes, _, err := query.GetBusRouteRecords(ctx, assignment.BusRouteID, assignment.BusID, query.Page{})
+	if err != nil {
+		assignment.Error(err, "DynamoDBError", "failed to fetch the bus route record")
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(routes) == 0 || routes[0].ID != assignment.BusRouteID {
+		err := fmt.Errorf("the bus route %s does not belong to the bus line %s", assignment.BusRouteID, assignment.BusID)
+		assignment.Error(err, "APIError", "the bus route does not belong to the bus line")
+
+		return api.StatusBadRequest(err)
+	}
+
+	err = assignment.SetValues(crew, routes[0], identity.UserID)
+	if err != nil {
+		assignment.Error(err, "IDError", "failed to generate the crew assignment ID")
+		return api.StatusInternalServerError(err)
+	}
+
+	// The trips of the crew must not overlap and must not exceed its maximum duty
+	maxDuty, err := config.GetMaxDuty()
+	if err != nil {
+		assignment.Error(err, "ConfigError", "failed to get the maximum duty of the crew")
+		return api.StatusInternalServerError(err)
+	}
+
+	existing, err := query.GetCrewAssignments(ctx, "", crew.ID, "")
+	if err != nil {
+		assignment.Error(err, "DynamoDBError", "failed to fetch the assignments of the crew")
+		return api.StatusInternalServerError(err)
+	}
+
+	// The trips that have already departed are not counted
+	err = assignment.CheckSchedule(existing, today, maxDuty)
+
+	var conflict schema.CrewConflictError
+	if errors.As(err, &conflict) {
+		assignment.Error(err, "APIError", "the crew assignment overlaps or exceeds the maximum duty")
+		return api.StatusConflict(err)
+	}
+
+	if err != nil {
+		assignment.Error(err, "APIError", "invalid schedule of the crew assignment")
+		return api.StatusBadRequest(err)
+	}
+
+	err = query.CreateCrewAssignment(ctx, assignment)
+	if errors.Is(err, query.ErrAlreadyExists) {
+		assignment.Error(err, "DynamoDBError", "the crew assignment ID has already been taken")
+		return api.StatusConflict(err)
+	}
+
+	if err != nil {
+		assignment.Error(err, "DynamoDBError", "failed to create a new crew assignment")
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOK(assignment)
+}
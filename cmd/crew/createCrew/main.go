@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request body, saves the crew to the DynamoDB Table, and responds with a 200 OK
+// HTTP Status and the "ETag" header of its version.
+//
+// A DRIVER must have the number and the expiry date of its licence. An OPERATOR can
+// only create the crew of its own bus line, otherwise it responds with a 403 Forbidden
+// HTTP Status. The "bus_id" defaults to the bus line of the OPERATOR.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/crew/create
+//
+// Sample API Payload:
+// 	{
+// 	  "bus_id": "BCBSCMPN-875011",
+// 	  "role": "DRIVER",
+// 	  "first_name": "Juan",
+// 	  "last_name": "Dela Cruz",
+// 	  "mobile_number": "09171234567",
+// 	  "licence_number": "N01-23-456789",
+// 	  "licence_type": "D",
+// 	  "licence_expiry": "2026-05-31"
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "bus_id": "BCBSCMPN-875011",
+// 	  "id": "CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	  "role": "DRIVER",
+// 	  "first_name": "Juan",
+// 	  "last_name": "Dela Cruz",
+// 	  "mobile_number": "09171234567",
+// 	  "licence_number": "N01-23-456789",
+// 	  "licence_type": "D",
+// 	  "licence_expiry": "2026-05-31",
+// 	  "active": true,
+// 	  "date_created": "1688091891",
+// 	  "version": 1
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var crew schema.Crew
+
+	err := crew.IsEmptyPayload(request.Body)
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err = utility.ParseJSON([]byte(request.Body), &crew)
+	if err != nil {
+		crew.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data", utility.KVP{Key: "payload", Value: request.Body})
+		return api.StatusInternalServerError(err)
+	}
+
+	// Check if the caller can create the crew of the bus line, the bus line
+	// of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	resource, err := identity.Restrict(auth.CREW_MANAGE, auth.Resource{BusID: crew.BusID})
+	if err != nil {
+		return auth.Deny(identity, auth.CREW_MANAGE, err)
+	}
+
+	crew.BusID = resource.BusID
+	if crew.BusID == "" {
+		err := errors.New("bus_id is required")
+		crew.Error(err, "APIError", "the bus line of the crew is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	err = crew.Validate()
+	if err != nil {
+		crew.Error(err, "APIError", "invalid crew")
+		return api.StatusBadRequest(err)
+	}
+
+	// The crew must belong to an existing bus line
+	bus, err := query.GetBusLineById(ctx, crew.BusID)
+	if err != nil {
+		crew.Error(err, "DynamoDBError", "failed to fetch the bus line record")
+		return api.StatusInternalServerError(err)
+	}
+
+	if bus.ID == "" {
+		err := fmt.Errorf("the bus line %s does not exist", crew.BusID)
+		crew.Error(err, "APIError", "the bus line of the crew does not exist")
+
+		return api.StatusBadRequest(err)
+	}
+
+	err = crew.SetValues()
+	if err != nil {
+		crew.Error(err, "IDError", "failed to generate the crew ID")
+		return api.StatusInternalServerError(err)
+	}
+
+	err = query.CreateCrew(ctx, crew)
+	if errors.Is(err, query.ErrAlreadyExists) {
+		crew.Error(err, "DynamoDBError", "the crew ID has already been taken")
+		return api.StatusConflict(err)
+	}
+
+	if err != nil {
+		crew.Error(err, "DynamoDBError", "failed to create a new crew record")
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOKWithETag(crew, crew.Version)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, fetches the crew record(s) of the bus line, and responds with a
+// 200 OK HTTP Status.
+//
+// An OPERATOR can only fetch the crew of its own bus line, otherwise it responds with
+// a 403 Forbidden HTTP Status. The "bus_id" defaults to the bus line of the OPERATOR.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/crew/get
+//
+// Sample API Params:
+//  bus_id=BCBSCMPN-875011
+//  id=CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  limit=25
+//  next_token=xxxxxx
+//
+// Sample API Response:
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "bus_id": "BCBSCMPN-875011",
+// 	      "id": "CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	      "role": "DRIVER",
+// 	      "first_name": "Juan",
+// 	      "last_name": "Dela Cruz",
+// 	      "mobile_number": "09171234567",
+// 	      "licence_number": "N01-23-456789",
+// 	      "licence_type": "D",
+// 	      "licence_expiry": "2026-05-31",
+// 	      "active": true,
+// 	      "date_created": "1688091891",
+// 	      "version": 1
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var id_query = request.QueryStringParameters["id"]
+
+	// Check if the caller can fetch the crew of the bus line, the bus line
+	// of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	resource, err := identity.Restrict(auth.CREW_READ, auth.Resource{BusID: request.QueryStringParameters["bus_id"]})
+	if err != nil {
+		return auth.Deny(identity, auth.CREW_READ, err)
+	}
+
+	if resource.BusID == "" {
+		err := errors.New("bus_id is required")
+		return api.StatusBadRequest(err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	list, nextToken, err := query.GetCrewRecords(ctx, resource.BusID, id_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the crew record", utility.KVP{Key: "bus_id", Value: resource.BusID}, utility.KVP{Key: "id", Value: id_query})
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(list) == 0 {
+		return api.StatusOK(api.Message{Custom: "no record(s) found"})
+	}
+
+	response, err := api.StatusOKWithPage(list, len(list), nextToken)
+
+	// Set the "ETag" header to the record version if a specific record is fetched,
+	// it is sent back as the "If-Match" header when updating the record.
+	if id_query != "" && len(list) == 1 {
+		response.Headers["ETag"] = api.ETag(list[0].Version)
+	}
+
+	return response, err
+}
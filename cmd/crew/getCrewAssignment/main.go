@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, fetches the assignments of the crew or of the bus route, and
+// responds with a 200 OK HTTP Status. Either the "crew_id" or the "bus_route_id"
+// is required.
+//
+// An OPERATOR can only fetch the assignments of its own bus line, otherwise it
+// responds with a 403 Forbidden HTTP Status. The "bus_id" defaults to the bus line
+// of the OPERATOR.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/crew/assignment
+//
+// Sample API Params:
+//  bus_id=BCBSCMPN-875011
+//  crew_id=CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  bus_route_id=BCBSCMPNBUS002-01H4Z3KX8Q9V7W5T2N6M4R3P1D
+//  limit=25
+//  next_token=xxxxxx
+//
+// Sample API Response:
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "crew_id": "CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	      "id": "ASN-01H4Z3KX8Q9V7W5T2N6M4R3P1E",
+// 	      "bus_id": "BCBSCMPN-875011",
+// 	      "bus_route_id": "BCBSCMPNBUS002-01H4Z3KX8Q9V7W5T2N6M4R3P1D",
+// 	      "role": "DRIVER",
+// 	      "travel_date": "2023-07-06",
+// 	      "departure_time": "15:00",
+// 	      "arrival_time": "17:00",
+// 	      "assigned_by": "OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	      "date_created": "1688091891"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		crewId_query  = request.QueryStringParameters["crew_id"]
+		routeId_query = request.QueryStringParameters["bus_route_id"]
+	)
+
+	// Check if the caller can fetch the crew of the bus line, the bus line
+	// of an OPERATOR is used if it is not set.
+	identity := auth.GetIdentity(request)
+	resource, err := identity.Restrict(auth.CREW_READ, auth.Resource{BusID: request.QueryStringParameters["bus_id"]})
+	if err != nil {
+		return auth.Deny(identity, auth.CREW_READ, err)
+	}
+
+	if crewId_query == "" && routeId_query == "" {
+		err := errors.New("crew_id or bus_route_id is required")
+		return api.StatusBadRequest(err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	list, nextToken, err := query.FilterCrewAssignments(ctx, resource.BusID, crewId_query, routeId_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the crew assignments", utility.KVP{Key: "bus_id", Value: resource.BusID},
+			utility.KVP{Key: "crew_id", Value: crewId_query}, utility.KVP{Key: "bus_route_id", Value: routeId_query})
+
+		return api.StatusInternalServerError(err)
+	}
+
+	if len(list) == 0 && nextToken == "" {
+		return api.StatusOK(api.Message{Custom: "no record(s) found"})
+	}
+
+	return api.StatusOKWithPage(list, len(list), nextToken)
+}
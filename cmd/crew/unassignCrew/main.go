@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, removes the assignment of the crew, and responds with a 200 OK
+// HTTP Status.
+//
+// An OPERATOR can only remove the assignments of the crew of its own bus line,
+// otherwise it responds with a 403 Forbidden HTTP Status.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/crew/unassign?crew_id=xxxxx&id=xxxxx
+//
+// Sample API Params:
+//  crew_id=CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  id=ASN-01H4Z3KX8Q9V7W5T2N6M4R3P1E
+//
+// Sample API Response:
+// 	{
+// 	  "message": "the crew has been unassigned"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		id_query     = request.QueryStringParameters["id"]
+		crewId_query = request.QueryStringParameters["crew_id"]
+	)
+
+	// Check if the caller can assign the crew
+	identity := auth.GetIdentity(request)
+	_, err := identity.Scope(auth.CREW_MANAGE)
+	if err != nil {
+		return auth.Deny(identity, auth.CREW_MANAGE, err)
+	}
+
+	// Fetch the existing crew assignment record
+	assignment, err := query.GetCrewAssignmentById(ctx, crewId_query, id_query)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the crew assignment", utility.KVP{Key: "crew_id", Value: crewId_query},
+			utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusInternalServerError(err)
+	}
+
+	if assignment.ID == "" {
+		err := errors.New("the crew assignment you're trying to remove is non-existent")
+		utility.Error(err, "APIError", "the crew assignment does not exist", utility.KVP{Key: "crew_id", Value: crewId_query},
+			utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	// Check if the caller can assign the crew of the bus line
+	err = identity.Authorize(auth.CREW_MANAGE, auth.Resource{BusID: assignment.BusID})
+	if err != nil {
+		return auth.Deny(identity, auth.CREW_MANAGE, err)
+	}
+
+	err = query.DeleteCrewAssignment(ctx, assignment.CrewID, assignment.ID)
+	if err != nil {
+		assignment.Error(err, "DynamoDBError", "failed to delete the crew assignment")
+		return api.StatusInternalServerError(err)
+	}
+
+	utility.Info("CrewUnassigned", "the crew has been unassigned", utility.KVP{Key: "assignment", Value: assignment},
+		utility.KVP{Key: "unassigned_by", Value: identity.UserID})
+
+	return api.StatusOK(api.Message{Custom: "the crew has been unassigned"})
+}
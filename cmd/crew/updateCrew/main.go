@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query and body, updates the crew record and responds with a 200 OK HTTP
+// Status and the "ETag" header of the new version. A crew that is not active can no
+// longer be assigned to the trips, its existing assignments are kept.
+//
+// The "If-Match" header is optional. If it is set and the crew has been modified since
+// that version, it responds with a 412 Precondition Failed HTTP Status.
+//
+// An OPERATOR can only update the crew of its own bus line, otherwise it responds with
+// a 403 Forbidden HTTP Status.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/crew/update?bus_id=xxxxx&id=xxxxx
+//
+// Sample API Params:
+//  bus_id=BCBSCMPN-875011
+//  id=CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//
+// Sample API Headers:
+//  If-Match: "1"
+//
+// Sample API Payload:
+// 	{
+// 	  "licence_expiry": "2031-05-31"
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "bus_id": "BCBSCMPN-875011",
+// 	  "id": "CRW-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	  "role": "DRIVER",
+// 	  "first_name": "Juan",
+// 	  "last_name": "Dela Cruz",
+// 	  "mobile_number": "09171234567",
+// 	  "licence_number": "N01-23-456789",
+// 	  "licence_type": "D",
+// 	  "licence_expiry": "2031-05-31",
+// 	  "active": true,
+// 	  "date_created": "1688091891",
+// 	  "version": 2
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		crew        schema.Crew
+		id_query    = request.QueryStringParameters["id"]
+		busId_query = request.QueryStringParameters["bus_id"]
+	)
+
+	// Check if the caller can update the crew of the bus line
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.CREW_MANAGE, auth.Resource{BusID: busId_query})
+	if err != nil {
+		return auth.Deny(identity, auth.CREW_MANAGE, err)
+	}
+
+	err = crew.IsEmptyPayload(request.Body)
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err = utility.ParseJSON([]byte(request.Body), &crew)
+	if err != nil {
+		crew.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data", utility.KVP{Key: "payload", Value: request.Body})
+		return api.StatusInternalServerError(err)
+	}
+
+	// Get the version the update is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		crew.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
+	// Fetch the existing crew record
+	old, err := query.GetCrewById(ctx, busId_query, id_query)
+	if err != nil {
+		crew.Error(err, "DynamoDBError", "failed to fetch the crew record")
+		return api.StatusInternalServerError(err)
+	}
+
+	if old.ID == "" {
+		err := errors.New("the crew you're trying to update is non-existent")
+		crew.Error(err, "APIError", "the crew does not exist")
+
+		return api.StatusBadRequest(err)
+	}
+
+	version := old.Version
+	if ifMatch != nil && *ifMatch != version {
+		old.Error(query.ErrVersionMismatch, "APIError", "the crew version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
+	crew = validate.UpdateCrewFields(crew, old)
+	crew.Role = strings.ToUpper(crew.Role)
+
+	err = crew.Validate()
+	if err != nil {
+		crew.Error(err, "APIError", "invalid crew")
+		return api.StatusBadRequest(err)
+	}
+
+	// Create a composite key that has both the partition/primary key
+	// and the sort key of the item.
+	var compositeKey = map[string]types.AttributeValue{
+		"bus_id": &types.AttributeValueMemberS{Value: old.BusID},
+		"id":     &types.AttributeValueMemberS{Value: old.ID},
+	}
+
+	// Construct the update builder
+	var update = expression.Set(expression.Name("role"), expression.Value(crew.Role)).
+		Set(expression.Name("first_name"), expression.Value(crew.FirstName)).
+		Set(expression.Name("last_name"), expression.Value(crew.LastName)).
+		Set(expression.Name("mobile_number"), expression.Value(crew.MobileNumber)).
+		Set(expression.Name("licence_number"), expression.Value(crew.LicenceNumber)).
+		Set(expression.Name("licence_type"), expression.Value(crew.LicenceType)).
+		Set(expression.Name("licence_expiry"), expression.Value(crew.LicenceExpiry)).
+		Set(expression.Name("active"), expression.Value(crew.Active))
+
+	result, err := query.UpdateCrew(ctx, compositeKey, update, version)
+	if errors.Is(err, query.ErrVersionMismatch) {
+		old.Error(err, "DynamoDBError", "the crew has been modified by another request")
+		return api.StatusPreconditionFailed(err)
+	}
+
+	if err != nil {
+		old.Error(err, "DynamoDBError", "failed to update the crew record")
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOKWithETag(result, result.Version)
+}
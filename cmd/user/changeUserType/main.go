@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query and body, changes the user type of the user account, records the
+// change on its audit trail, and responds with a 200 OK HTTP Status and the updated
+// user account.
+//
+// An OPERATOR user account must manage an existing bus line, the bus line of the
+// other user types is removed. The tokens that were issued before are revoked so
+// that the new user type applies on the next login. The prefix of the user ID is
+// kept since the ID is part of the primary key and of the records of the user account.
+//
+// Only an ADMIN can change the user type, otherwise it responds with a 403 Forbidden
+// HTTP Status, and it cannot change its own user type. If the "If-Match" header is
+// set and the user account has been modified since that version, it responds with a
+// 412 Precondition Failed HTTP Status.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/account/type?id=xxxxx
+//
+// Sample API Params:
+//  id=CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//
+// Sample API Headers:
+//  If-Match: "2"
+//
+// Sample API Payload:
+// 	{
+// 	  "user_type": "3",
+// 	  "bus_id": "RLBSW-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	  "reason": "joined the operator of Yellow Sunshine"
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "id": "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	  "user_type": "OPERATOR",
+// 	  "bus_id": "RLBSW-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	  "first_name": "Emily",
+// 	  "last_name": "Davis",
+// 	  "username": "emilydavis",
+// 	  "address": "321 Cedar Road",
+// 	  "email": "emilydavis@example.com",
+// 	  "mobile_number": "4449876543",
+// 	  "date_created": "1685498070",
+// 	  "version": 3,
+// 	  "mfa_enabled": false
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		id_query = request.QueryStringParameters["id"]
+		body     struct {
+			UserType string `json:"user_type"`
+			BusID    string `json:"bus_id"`
+			Reason   string `json:"reason"`
+		}
+	)
+
+	// Check if the caller can change the user type of the user accounts
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.USER_MANAGE, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.USER_MANAGE, err)
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err = utility.ParseJSON([]byte(request.Body), &body)
+	if err != nil {
+		utility.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data", utility.KVP{Key: "payload", Value: request.Body})
+		return api.StatusBadRequest(err)
+	}
+
+	if id_query == "" {
+		err := errors.New("id is required")
+		utility.Error(err, "APIError", "the user account ID is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	if id_query == identity.UserID {
+		err := errors.New("you cannot change the user type of your own user account")
+		utility.Error(err, "APIError", "the caller tried to change its own user type", utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	// Check if the user type is valid, an OPERATOR must have a bus line
+	var change = schema.User{UserType: body.UserType, BusID: body.BusID}
+	err = change.IsValidUserType()
+	if err != nil {
+		utility.Error(err, "APIError", "invalid user type", utility.KVP{Key: "payload", Value: request.Body})
+		return api.StatusBadRequest(err)
+	}
+
+	change.UserType, _ = schema.ParseUserType(body.UserType)
+	if change.UserType != schema.OPRTR {
+		change.BusID = ""
+	}
+
+	// Get the version the update is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		utility.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
+	// Fetch the existing user account record
+	account, err := query.GetUserAccountById(ctx, id_query)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "id", Value: id_query})
+		return api.StatusInternalServerError(err)
+	}
+
+	if account.ID == "" || account.IsErased() {
+		err := errors.New("the account you're trying to update is non-existent")
+		utility.Error(err, "APIError", "the account does not exist", utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	if ifMatch != nil && *ifMatch != account.Version {
+		account.Error(query.ErrVersionMismatch, "APIError", "the account version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
+	if account.UserType == change.UserType && account.BusID == change.BusID {
+		account.Password = ""
+		account.MFA = nil
+
+		return api.StatusOKWithETag(account, account.Version)
+	}
+
+	// An OPERATOR account must manage an existing bus line
+	if change.UserType == schema.OPRTR {
+		bus, err := query.GetBusLineById(ctx, change.BusID)
+		if err != nil {
+			account.Error(err, "DynamoDBError", "failed to fetch the bus line record")
+			return api.StatusInternalServerError(err)
+		}
+
+		if bus.ID == "" {
+			err := fmt.Errorf("the bus line %s does not exist", change.BusID)
+			account.Error(err, "APIError", "the bus line of the operator does not exist")
+
+			return api.StatusBadRequest(err)
+		}
+	}
+
+	// The session version is incremented so that the tokens that were
+	// issued with the previous user type are no longer valid.
+	update := expression.Set(expression.Name("user_type"), expression.Value(change.UserType)).
+		Set(expression.Name("session_version"), expression.Plus(expression.IfNotExists(expression.Name("session_version"), expression.Value(0)), expression.Value(1)))
+
+	if change.BusID != "" {
+		update = update.Set(expression.Name("bus_id"), expression.Value(change.BusID))
+	} else {
+		update = update.Remove(expression.Name("bus_id"))
+	}
+
+	audit, err := schema.NewUserAudit(account, schema.AUDIT_USER_TYPE_CHANGED, identity.UserID, role(account), role(change), body.Reason)
+	if err != nil {
+		account.Error(err, "IDError", "failed to generate the audit ID")
+		return api.StatusInternalServerError(err)
+	}
+
+	user, err := query.UpdateUserAccountWithAudit(ctx, account, update, audit)
+	if errors.Is(err, query.ErrVersionMismatch) {
+		account.Error(err, "DynamoDBError", "the user account has been modified by another request")
+		return api.StatusPreconditionFailed(err)
+	}
+
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to change the user type of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	utility.Info("UserTypeChanged", "the user type of the user account has been changed", utility.KVP{Key: "id", Value: account.ID},
+		utility.KVP{Key: "from", Value: audit.From}, utility.KVP{Key: "to", Value: audit.To}, utility.KVP{Key: "changed_by", Value: identity.UserID})
+
+	return api.StatusOKWithETag(user, user.Version)
+}
+
+// role returns the user type of the user account, and the bus line it manages
+// if it is an OPERATOR.
+//
+// Example:
+//  OPERATOR:RLBSW-01H4Z3KX8Q9V7W5T2N6M4R3P1B
+func role(user schema.User) string {
+	if user.BusID == "" {
+		return user.UserType
+	}
+
+	return user.UserType + ":" + user.BusID
+}
@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
+	"github.com/rmarasigan/bus-ticketing/internal/app/verification"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
@@ -18,8 +22,20 @@ func main() {
 }
 
 // It receives the Amazon API Gateway event record data as input, validates the
-// request body, saves the validated request body to the DynamoDB Table, and
-// responds with a 200 OK HTTP Status.
+// request body, saves the validated request body to the DynamoDB Table with the
+// hash of the password, and responds with a 200 OK HTTP Status. If the username
+// is already taken, it responds with a 409 Conflict HTTP Status.
+//
+// The e-mail address of the new account is not verified yet, a verification link
+// is sent to it. A CUSTOMER cannot book until it is verified on user/email/verify.
+//
+// Anyone can create a CUSTOMER account. An ADMIN or OPERATOR account can only be
+// created with the access token of an ADMIN, otherwise it responds with a 401
+// Unauthorized or 403 Forbidden HTTP Status. An OPERATOR account manages the bus
+// line of its bus_id.
+//
+// User Types:
+//  1: ADMIN, 2: CUSTOMER, 3: OPERATOR
 //
 // Method: POST
 //
@@ -48,6 +64,46 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	// Validate the user type before it is converted
+	err = user.IsValidUserType()
+	if err != nil {
+		user.Error(err, "APIError", "invalid user type")
+		return api.StatusBadRequest(err)
+	}
+
+	// Set default values of user account information
+	user.SetValues()
+
+	// Only an ADMIN can create the other ADMIN and OPERATOR accounts
+	if user.UserType != schema.CSTMR {
+		identity, err := authenticate(ctx, request)
+		if err != nil {
+			user.Error(err, "AuthError", "failed to authenticate the request")
+			return api.StatusInternalServerError(err)
+		}
+
+		err = identity.Authorize(auth.USER_CREATE, auth.Resource{})
+		if err != nil {
+			return auth.Deny(identity, auth.USER_CREATE, err)
+		}
+	}
+
+	// An OPERATOR account must manage an existing bus line
+	if user.UserType == schema.OPRTR {
+		bus, err := query.GetBusLineById(ctx, user.BusID)
+		if err != nil {
+			user.Error(err, "DynamoDBError", "failed to fetch the bus line record")
+			return api.StatusInternalServerError(err)
+		}
+
+		if bus.ID == "" {
+			err := fmt.Errorf("the bus line %s does not exist", user.BusID)
+			user.Error(err, "APIError", "the bus line of the operator does not exist")
+
+			return api.StatusBadRequest(err)
+		}
+	}
+
 	// Checks whether the username exist or not
 	usernameExist, err := validate.IsUsernameExisting(ctx, user.Username)
 	if err != nil {
@@ -55,23 +111,74 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
-	// If the username exists, return a 400 BadRequest HTTP Status
+	// If the username exists, return a 409 Conflict HTTP Status
 	if usernameExist {
 		err := fmt.Errorf("%s username already exist", user.Username)
 		user.Error(err, "IsUsernameExisting", "already existing username")
 
+		return api.StatusConflict(err)
+	}
+
+	// Validate the password before it is hashed
+	err = auth.ValidatePassword(user.Password)
+	if err != nil {
+		user.Error(err, "APIError", "invalid password")
 		return api.StatusBadRequest(err)
 	}
 
-	// Set default values of user account information
-	user.SetValues()
+	// Only the salted hash of the password is stored
+	user.Password, err = auth.HashPassword(user.Password)
+	if err != nil {
+		user.Error(err, "HashError", "failed to hash the password")
+		return api.StatusInternalServerError(err)
+	}
+
+	// Inserts a new user account to the DynamoDB, the username might have been
+	// taken by another request since it was checked.
+	err = query.CreateUserAccount(ctx, *user)
+	if errors.Is(err, query.ErrAlreadyExists) {
+		err := fmt.Errorf("%s username already exist", user.Username)
+		user.Error(err, "DynamoDBError", "already existing username")
+
+		return api.StatusConflict(err)
+	}
 
-	// Inserts a new user account to the DynamoDB
-	err = query.CreateUserAccount(ctx, user)
 	if err != nil {
 		user.Error(err, "DynamoDBError", "failed to create a new account")
 		return api.StatusInternalServerError(err)
 	}
 
+	// The account is already created if the verification link fails to be
+	// sent, the user can request a new one.
+	err = verification.SendEmail(ctx, *user, verification.VerifyURL(request))
+	if err != nil {
+		user.Error(err, "EmailVerification", "failed to send the e-mail verification link")
+	}
+
 	return api.StatusOKWithoutBody()
 }
+
+// authenticate returns the identity of the access token of the "Authorization: Bearer"
+// header. The endpoint is public so the token is not validated by the Lambda authorizer,
+// and the identity is empty if the token is missing or invalid.
+func authenticate(ctx context.Context, request events.APIGatewayProxyRequest) (auth.Identity, error) {
+	var identity auth.Identity
+
+	token, ok := auth.BearerToken(api.Header(request, "Authorization"))
+	if !ok {
+		return identity, nil
+	}
+
+	cfg, err := config.GetTokenConfig(ctx)
+	if err != nil {
+		return identity, err
+	}
+
+	claims, err := auth.ParseToken(cfg, token, auth.ACCESS_TOKEN)
+	if err != nil {
+		utility.Info("AuthError", err.Error())
+		return identity, nil
+	}
+
+	return claims.Identity(), nil
+}
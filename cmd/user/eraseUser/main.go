@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, erases the personal
+// data of the user account, and responds with a 200 OK HTTP Status.
+//
+// The user account is anonymized: its name, address, e-mail address, mobile number
+// and username are removed, and it can no longer log in since its password, MFA and
+// tokens are revoked. The reason of its cancelled bookings, the e-mails that have
+// been sent to it and its failed login attempts are removed too. The bookings and
+// the rest of the cancellation records are kept for accounting, they are still tied
+// to the user ID.
+//
+// Only an ADMIN can erase the other user accounts, the other user types always erase
+// their own user account. If the "If-Match" header is set and the user account has
+// been modified since that version, it responds with a 412 Precondition Failed HTTP
+// Status.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/account/erase?id=xxxxx
+//
+// Sample API Params:
+//  id=CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//
+// Sample API Headers:
+//  If-Match: "2"
+//
+// Sample API Response:
+// 	{
+// 	  "message": "the personal data of the user account has been erased"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var id_query = request.QueryStringParameters["id"]
+
+	// Check if the caller can erase the other user accounts
+	identity := auth.GetIdentity(request)
+	scope, err := identity.Scope(auth.USER_ERASE)
+	if err != nil {
+		return auth.Deny(identity, auth.USER_ERASE, err)
+	}
+
+	if scope != auth.SCOPE_ALL || id_query == "" {
+		id_query = identity.UserID
+	}
+
+	// Get the version the erasure is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		utility.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
+	// Fetch the existing user account record
+	account, err := query.GetUserAccountById(ctx, id_query)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "id", Value: id_query})
+		return api.StatusInternalServerError(err)
+	}
+
+	if account.ID == "" {
+		err := errors.New("the account you're trying to erase is non-existent")
+		utility.Error(err, "APIError", "the account does not exist", utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	if account.IsErased() {
+		return api.StatusOK(api.Message{Custom: "the personal data of the user account has already been erased"})
+	}
+
+	if ifMatch != nil && *ifMatch != account.Version {
+		account.Error(query.ErrVersionMismatch, "APIError", "the account version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
+	// The records that are tied to the user ID are erased first, so that a failed
+	// erasure can be requested again while the user account still exists.
+	bookings, err := query.GetUserBookings(ctx, account.ID)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to fetch the bookings of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	cancellations, err := query.GetUserCancellations(ctx, bookings)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to fetch the cancelled bookings of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	err = query.EraseCancellationReasons(ctx, cancellations)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to erase the reason of the cancelled bookings")
+		return api.StatusInternalServerError(err)
+	}
+
+	err = query.DeleteUserNotifications(ctx, account.ID)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to delete the notifications of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	err = query.DeleteLoginAttempt(ctx, schema.UsernameAttemptKey(account.Username))
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to delete the failed login attempts of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	// Replace the user account with the anonymized user account
+	err = query.EraseUserAccount(ctx, account, account.Anonymize(time.Now()))
+	if errors.Is(err, query.ErrVersionMismatch) {
+		account.Error(err, "DynamoDBError", "the user account has been modified by another request")
+		return api.StatusPreconditionFailed(err)
+	}
+
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to erase the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	utility.Info("UserErased", "the personal data of the user account has been erased", utility.KVP{Key: "id", Value: account.ID},
+		utility.KVP{Key: "bookings", Value: len(bookings)}, utility.KVP{Key: "erased_by", Value: identity.UserID})
+
+	return api.StatusOK(api.Message{Custom: "the personal data of the user account has been erased"})
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, fetches every record
+// that is tied to the user ID: the user account, its bookings, the cancellation records
+// of its cancelled bookings and the e-mails that have been sent to it, and responds
+// with a 200 OK HTTP Status and the JSON archive as an attachment.
+//
+// Only an ADMIN can export the other user accounts, the other user types always
+// export their own user account.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/account/export?id=xxxxx
+//
+// Sample API Params:
+//  id=CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//
+// Sample API Response:
+// 	{
+// 	  "exported_at": "1688091891",
+// 	  "user": {
+// 	    "id": "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	    "user_type": "CUSTOMER",
+// 	    "first_name": "Emily",
+// 	    "last_name": "Davis",
+// 	    "username": "emilydavis",
+// 	    "address": "321 Cedar Road",
+// 	    "email": "emilydavis@example.com",
+// 	    "mobile_number": "4449876543",
+// 	    "date_created": "1685498070",
+// 	    "version": 2,
+// 	    "mfa_enabled": false
+// 	  },
+// 	  "bookings": [
+// 	    {
+// 	      "id": "36bc8bc5-44d6-447b-a63b-039b99658b78",
+// 	      "user_id": "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	      "bus_id": "RLBSW-01H4Z3KX8Q9V7W5T2N6M4R3P1B",
+// 	      "bus_route_id": "RLBSWMN-01H4Z3KX8Q9V7W5T2N6M4R3P1C",
+// 	      "status": "CANCELLED",
+// 	      "seat_number": "",
+// 	      "travel_date": "2023-07-02",
+// 	      "date_created": "2023-06-30 10:24:51",
+// 	      "version": 2,
+// 	      "is_cancelled": true,
+// 	      "cancelled": {...},
+// 	      "timestamp": "2023-06-30 10:24:51"
+// 	    }
+// 	  ],
+// 	  "cancellations": [
+// 	    {
+// 	      "id": "3a0e6c1d-7a52-4e0e-a5a3-6ed23b0d4e57",
+// 	      "booking_id": "36bc8bc5-44d6-447b-a63b-039b99658b78",
+// 	      "reason": "change of plans",
+// 	      "cancelled_by": "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	      "date_cancelled": "2023-07-01 08:10:12",
+// 	      "released_seats": "A1,A2"
+// 	    }
+// 	  ],
+// 	  "notifications": [
+// 	    {
+// 	      "user_id": "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	      "id": "NTF-01H4Z3KX8Q9V7W5T2N6M4R3P1D",
+// 	      "type": "BOOKING_CANCELLED",
+// 	      "booking_id": "36bc8bc5-44d6-447b-a63b-039b99658b78",
+// 	      "recipient": "emilydavis@example.com",
+// 	      "subject": "CANCELLED BOOKING: Manila to Baguio [2023-07-02]",
+// 	      "date_sent": "1688199012"
+// 	    }
+// 	  ]
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var id_query = request.QueryStringParameters["id"]
+
+	// Check if the caller can export the other user accounts
+	identity := auth.GetIdentity(request)
+	scope, err := identity.Scope(auth.USER_READ)
+	if err != nil {
+		return auth.Deny(identity, auth.USER_READ, err)
+	}
+
+	if scope != auth.SCOPE_ALL || id_query == "" {
+		id_query = identity.UserID
+	}
+
+	// Fetch the existing user account record
+	account, err := query.GetUserAccountById(ctx, id_query)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "id", Value: id_query})
+		return api.StatusInternalServerError(err)
+	}
+
+	if account.ID == "" {
+		err := errors.New("the user account does not exist")
+		utility.Error(err, "APIError", "the user account to be exported does not exist", utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	// The password hash and the MFA are never returned
+	account.Password = ""
+	account.MFA = nil
+
+	bookings, err := query.GetUserBookings(ctx, account.ID)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to fetch the bookings of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	cancellations, err := query.GetUserCancellations(ctx, bookings)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to fetch the cancelled bookings of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	notifications, err := query.GetUserNotifications(ctx, account.ID)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to fetch the notifications of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	var export = schema.UserDataExport{
+		ExportedAt:    fmt.Sprint(time.Now().Unix()),
+		User:          account,
+		Bookings:      bookings,
+		Cancellations: cancellations,
+		Notifications: notifications,
+	}
+
+	utility.Info("UserExported", "the personal data of the user account has been exported", utility.KVP{Key: "id", Value: account.ID},
+		utility.KVP{Key: "exported_by", Value: identity.UserID})
+
+	response, err := api.StatusOK(export)
+	response.Headers["Content-Disposition"] = fmt.Sprintf("attachment; filename=\"%s.json\"", account.ID)
+
+	return response, err
+}
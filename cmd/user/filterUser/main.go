@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, fetches the user accounts that match every query parameter that
+// is set, and responds with a 200 OK HTTP Status.
+//
+// The "name" matches a part of the first name or the last name, and the "email"
+// matches a part of the e-mail address, both are case-sensitive. The "user_type"
+// is either its name or its number (1, 2 or 3), the "bus_id" returns the OPERATOR
+// user accounts of the bus line, and the "created_from" and "created_to" dates
+// are included.
+//
+// Only an ADMIN can search the user accounts, otherwise it responds with a 403
+// Forbidden HTTP Status.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/account/search?name=xxxxx&user_type=xxxxx
+//
+// Sample API Params:
+//  name=Davis
+//  email=example.com
+//  user_type=CUSTOMER
+//  bus_id=RLBSW-01H4Z3KX8Q9V7W5T2N6M4R3P1B
+//  created_from=2023-05-01
+//  created_to=2023-06-30
+//  limit=25
+//  next_token=xxxxxx
+//
+// Sample API Response:
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "id": "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	      "user_type": "CUSTOMER",
+// 	      "first_name": "Emily",
+// 	      "last_name": "Davis",
+// 	      "username": "emilydavis",
+// 	      "address": "321 Cedar Road",
+// 	      "email": "emilydavis@example.com",
+// 	      "mobile_number": "4449876543",
+// 	      "date_created": "1685498070",
+// 	      "version": 2,
+// 	      "mfa_enabled": false
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		filter = schema.UserFilter{
+			Name:  request.QueryStringParameters["name"],
+			Email: request.QueryStringParameters["email"],
+			BusID: request.QueryStringParameters["bus_id"],
+		}
+		userType_query = request.QueryStringParameters["user_type"]
+	)
+
+	// Check if the caller can search the user accounts
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.USER_MANAGE, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.USER_MANAGE, err)
+	}
+
+	if userType_query != "" {
+		filter.UserType, err = schema.ParseUserType(userType_query)
+		if err != nil {
+			utility.Error(err, "APIError", "invalid user type", utility.KVP{Key: "user_type", Value: userType_query})
+			return api.StatusBadRequest(err)
+		}
+	}
+
+	err = filter.SetCreated(request.QueryStringParameters["created_from"], request.QueryStringParameters["created_to"])
+	if err != nil {
+		utility.Error(err, "APIError", "invalid creation date", utility.KVP{Key: "params", Value: request.QueryStringParameters})
+		return api.StatusBadRequest(err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	// Fetch a list of user account information
+	users, nextToken, err := query.FilterUserAccounts(ctx, filter, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to filter the user accounts", utility.KVP{Key: "filter", Value: filter})
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOKWithPage(users, len(users), nextToken)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/email/template"
+	"github.com/rmarasigan/bus-ticketing/internal/app/notification"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// FORGOT_PASSWORD_MESSAGE is the response message whether the user account exists or not,
+// so that it cannot be used to find out which usernames are taken.
+const FORGOT_PASSWORD_MESSAGE = "if the account exists, a password reset token has been sent to its e-mail address"
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request body, issues a single-use password reset token to the user account,
+// sends it to the user e-mail, and responds with a 200 OK HTTP Status.
+//
+// Only the hash of the token is stored, and it expires after the RESET_TOKEN_TTL
+// (1 hour by default). The response is the same whether the user account exists
+// or not.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/password/forgot
+//
+// Sample API Payload:
+// 	{
+// 		"username": "emilydavis"
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "message": "if the account exists, a password reset token has been sent to its e-mail address"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var body struct {
+		Username string `json:"username"`
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err := utility.ParseJSON([]byte(request.Body), &body)
+	if err != nil {
+		utility.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data")
+		return api.StatusBadRequest(err)
+	}
+
+	if body.Username == "" {
+		err := errors.New("username is required")
+		utility.Error(err, "APIError", "the username is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	ttl, err := config.GetResetTokenTTL()
+	if err != nil {
+		utility.Error(err, "ConfigError", "failed to get the password reset token lifetime")
+		return api.StatusInternalServerError(err)
+	}
+
+	account, err := query.GetUserAccountByUsername(ctx, body.Username)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "username", Value: body.Username})
+		return api.StatusInternalServerError(err)
+	}
+
+	if account.ID == "" {
+		utility.Info("ForgotPassword", "the user account does not exist", utility.KVP{Key: "username", Value: body.Username})
+		return api.StatusOK(api.Message{Custom: FORGOT_PASSWORD_MESSAGE})
+	}
+
+	// The failures after this point are only logged, otherwise the response
+	// would tell that the user account exists.
+	err = sendResetToken(ctx, account, ttl)
+	if err != nil {
+		account.Error(err, "ForgotPassword", "failed to send the password reset token")
+	}
+
+	return api.StatusOK(api.Message{Custom: FORGOT_PASSWORD_MESSAGE})
+}
+
+// sendResetToken issues a new password reset token to the user account, stores its hash,
+// and sends the token to the user e-mail.
+func sendResetToken(ctx context.Context, account schema.User, ttl time.Duration) error {
+	token, hash, err := auth.NewOneTimeToken()
+	if err != nil {
+		return err
+	}
+
+	reset := schema.NewPasswordReset(account, hash, ttl)
+
+	err = query.CreatePasswordReset(ctx, reset)
+	if err != nil {
+		reset.Error(err, "DynamoDBError", "failed to save the password reset")
+		return err
+	}
+
+	// Fetch email configuration
+	email, err := config.GetEmailConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Set the email content
+	email.Content.To = append(email.Content.To, account.Email)
+	email.Content.Subject = "PASSWORD RESET REQUEST"
+	email.Content.Message = template.PasswordReset(account, token, ttl, email.CustomerSupport)
+
+	// Send email to the client
+	err = notification.Send(ctx, email, schema.NewNotification(account, schema.NOTIFY_PASSWORD_RESET, ""))
+	if err != nil {
+		email.Error(err, "EmailError", "failed to send email to client")
+		return err
+	}
+	utility.Info("ForgotPassword", "Successfully sent the password reset token", utility.KVP{Key: "id", Value: account.ID})
+
+	return nil
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
@@ -18,6 +20,9 @@ func main() {
 // request query, fetches the user account record(s), and responds with
 // a 200 OK HTTP Status.
 //
+// Only an ADMIN can fetch the other user accounts, the other user types
+// always fetch their own user account.
+//
 // Method: GET
 //
 // Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/account/get
@@ -25,28 +30,53 @@ func main() {
 // Sample API Params:
 //  id=ADMN-878495
 //  username=passwordabc
+//  limit=25
+//  next_token=xxxxxx
 //
 // Sample API Response:
-// 	[
-// 	  {
-// 	    "id": "ADMN-878495",
-// 	    "user_type": "ADMIN",
-// 	    "first_name": "Emily",
-// 	    "last_name": "Davis",
-// 	    "username": "emilydavis",
-// 	    "address": "321 Cedar Road",
-// 	    "email": "emilydavis@example.com",
-// 	    "mobile_number": "(407) 435-6841"
-// 	  }
-// 	]
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "id": "ADMN-878495",
+// 	      "user_type": "ADMIN",
+// 	      "first_name": "Emily",
+// 	      "last_name": "Davis",
+// 	      "username": "emilydavis",
+// 	      "address": "321 Cedar Road",
+// 	      "email": "emilydavis@example.com",
+// 	      "mobile_number": "(407) 435-6841"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
 		id_query       = request.QueryStringParameters["id"]
 		username_query = request.QueryStringParameters["username"]
 	)
 
+	// Check if the caller can fetch the other user accounts
+	identity := auth.GetIdentity(request)
+	scope, err := identity.Scope(auth.USER_READ)
+	if err != nil {
+		return auth.Deny(identity, auth.USER_READ, err)
+	}
+
+	if scope != auth.SCOPE_ALL {
+		id_query, username_query = identity.UserID, identity.Username
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch the existing user account record
-	accounts, err := query.GetUserAccountRecords(ctx, id_query, username_query)
+	accounts, nextToken, err := query.GetUserAccountRecords(ctx, id_query, username_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
 	if err != nil {
 		utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "username", Value: username_query})
 		return api.StatusInternalServerError(err)
@@ -56,5 +86,13 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusOK(api.Message{Custom: "no record(s) found"})
 	}
 
-	return api.StatusOK(accounts)
+	response, err := api.StatusOKWithPage(accounts, len(accounts), nextToken)
+
+	// Set the "ETag" header to the record version if a specific record is fetched,
+	// it is sent back as the "If-Match" header when updating the record.
+	if id_query != "" && username_query != "" && len(accounts) == 1 {
+		response.Headers["ETag"] = api.ETag(accounts[0].Version)
+	}
+
+	return response, err
 }
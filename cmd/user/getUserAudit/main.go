@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query, fetches the changes that were made to the user account by an
+// ADMIN, the most recent first, and responds with a 200 OK HTTP Status.
+//
+// Only an ADMIN can fetch the audit trail, otherwise it responds with a 403
+// Forbidden HTTP Status.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/account/audit?id=xxxxx
+//
+// Sample API Params:
+//  id=OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//  limit=25
+//  next_token=xxxxxx
+//
+// Sample API Response:
+// 	{
+// 	  "items": [
+// 	    {
+// 	      "user_id": "OPRTR-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	      "id": "AUD-01H5A2B3C4D5E6F7G8H9J0K1M2",
+// 	      "action": "USER_TYPE_CHANGED",
+// 	      "changed_by": "ADMN-878495",
+// 	      "from": "CUSTOMER",
+// 	      "to": "OPERATOR",
+// 	      "reason": "joined the operator of Yellow Sunshine",
+// 	      "date_created": "1688091891"
+// 	    }
+// 	  ],
+// 	  "count": 1
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var id_query = request.QueryStringParameters["id"]
+
+	// Check if the caller can fetch the audit trail of the user accounts
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.USER_MANAGE, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.USER_MANAGE, err)
+	}
+
+	if id_query == "" {
+		err := errors.New("id is required")
+		utility.Error(err, "APIError", "the user account ID is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	page, err := query.NewPage(request.QueryStringParameters["limit"], request.QueryStringParameters["next_token"])
+	if err != nil {
+		return api.StatusBadRequest(err)
+	}
+
+	audits, nextToken, err := query.GetUserAudits(ctx, id_query, page)
+	if errors.Is(err, query.ErrInvalidNextToken) {
+		return api.StatusBadRequest(err)
+	}
+
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the audit trail of the user account", utility.KVP{Key: "id", Value: id_query})
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOKWithPage(audits, len(audits), nextToken)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -10,6 +11,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/lockout"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
@@ -22,7 +26,29 @@ func main() {
 // It receives the Amazon API Gateway event record data as input, validates the
 // request body, checks the validated request body if the user credentials are
 // valid or not, updates the user account’s last login, and responds with a 200
-// OK HTTP Status.
+// OK HTTP Status, the user account, and its access and refresh token.
+//
+// The access token is sent as the "Authorization: Bearer" header of the API
+// requests, and the refresh token is exchanged for new tokens on user/token/refresh.
+//
+// The password of an account that is stored in plaintext, or hashed with a lower
+// cost, is replaced with a new hash after a successful login.
+//
+// The failed login attempts are tracked per username and per source IP. After every
+// failure the next attempt is delayed exponentially, and the username or source IP
+// is locked temporarily after the maximum failures of the lockout policy. A login
+// during the delay or the lockout responds with a 429 Too Many Requests HTTP Status
+// and the "Retry-After" header, and the owner of a locked account is notified by e-mail.
+// Every login is counted as a failure before the credentials are verified and released
+// if they are valid, so the concurrent logins are delayed as well.
+//
+// If the MFA is enabled on the user account, it responds with an MFA token instead,
+// and the tokens are issued on user/login/mfa with a TOTP code or a recovery code.
+// If the MFA is required on the user type but not enabled yet, the tokens can only
+// enroll in MFA until it is enabled.
+//
+// A user account that has been deactivated by an ADMIN responds with a 403 Forbidden
+// HTTP Status even with valid credentials.
 //
 // Method: POST
 //
@@ -43,7 +69,20 @@ func main() {
 //	  "username": "emilydavis",
 //	  "address": "321 Cedar Road",
 //	  "email": "emilydavis@example.com",
-//	  "mobile_number": "4449876543"
+//	  "mobile_number": "4449876543",
+//	  "version": 1,
+//	  "mfa_enabled": false,
+//	  "access_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...",
+//	  "refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...",
+//	  "token_type": "Bearer",
+//	  "expires_in": 900
+//	}
+//
+// Sample API Response (MFA enabled):
+//	{
+//	  "mfa_required": true,
+//	  "mfa_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...",
+//	  "expires_in": 300
 //	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var user = new(schema.User)
@@ -57,10 +96,36 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	policy, err := config.GetLockoutPolicy()
+	if err != nil {
+		user.Error(err, "ConfigError", "failed to get the lockout policy")
+		return api.StatusInternalServerError(err)
+	}
+
+	// The failed login attempts are tracked per username and per source IP
+	var (
+		now      = time.Now()
+		sourceIP = request.RequestContext.Identity.SourceIP
+	)
+
+	// The login attempt is counted as a failure before the credentials are verified,
+	// so that the concurrent login attempts are throttled as well.
+	reservations, retryAfter, err := lockout.Reserve(ctx, policy, lockout.Keys(user.Username, sourceIP), now)
+	if err != nil {
+		return api.StatusInternalServerError(err)
+	}
+
+	// The credentials are not verified during the backoff or the lockout
+	if retryAfter > 0 {
+		return api.StatusTooManyRequests(auth.ErrTooManyAttempts, retryAfter)
+	}
+
 	// Checks whether the user credentials are valid or not
 	existing, account, err := validate.UserAccountExists(ctx, user.Username, user.Password)
 	if err != nil {
 		user.Error(err, "UserAccountExists", "failed to validate user account credentials")
+		lockout.Release(ctx, reservations)
+
 		return api.StatusInternalServerError(err)
 	}
 
@@ -69,9 +134,22 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		err := errors.New("the username or password you entered is incorrect")
 		account.Error(err, "UserAccountExists", "incorrect credentials")
 
+		for _, reservation := range reservations {
+			lockout.RecordFailure(ctx, reservation, policy, user.Username, sourceIP, now)
+		}
+
 		return api.StatusBadRequest(err)
 	}
 
+	// The credentials are valid, the login attempt is not a failure
+	lockout.Release(ctx, reservations)
+
+	// The user account has been deactivated by an ADMIN
+	if account.Deactivated {
+		account.Error(auth.ErrDeactivated, "AuthError", "the user account has been deactivated")
+		return api.StatusForbidden(auth.ErrDeactivated)
+	}
+
 	// Create a composite key that has both the partition/primary key
 	// and the sort key of the item.
 	var compositKey = map[string]types.AttributeValue{
@@ -79,15 +157,75 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		"username": &types.AttributeValueMemberS{Value: account.Username},
 	}
 
+	// Rehash the legacy plaintext password, the login still succeeds if
+	// it fails since it is done again on the next login.
+	if auth.NeedsRehash(account.Password) {
+		hash, err := auth.HashPassword(user.Password)
+		if err == nil {
+			err = query.UpdateUserPassword(ctx, compositKey, hash, account.Password)
+		}
+
+		if err != nil {
+			account.Error(err, "RehashError", "failed to rehash the user password")
+		}
+	}
+
+	// The password hash is never returned
+	account.Password = ""
+
+	cfg, err := config.GetTokenConfig(ctx)
+	if err != nil {
+		account.Error(err, "ConfigError", "failed to get the token configuration")
+		return api.StatusInternalServerError(err)
+	}
+
+	var identity = auth.Identity{UserID: account.ID, Username: account.Username, UserType: account.UserType, BusID: account.BusID, Session: account.SessionVersion}
+
+	// The tokens are issued on the second step of the login, the failures of the
+	// username are kept until then so that the TOTP code cannot be guessed.
+	if account.MFAEnabled {
+		challenge, err := auth.NewMFAChallenge(cfg, identity)
+		if err != nil {
+			account.Error(err, "AuthError", "failed to issue the MFA token")
+			return api.StatusInternalServerError(err)
+		}
+
+		return api.StatusOK(challenge)
+	}
+
+	// The failures of the username start over, the failures of the source IP
+	// are kept since it might be guessing the other usernames.
+	err = query.DeleteLoginAttempt(ctx, schema.UsernameAttemptKey(account.Username))
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to reset the failed login attempts")
+	}
+
+	// The tokens can only enroll in MFA if it is required but not enabled yet
+	identity.MFAEnrollment, err = config.IsMFARequired(account.UserType)
+	if err != nil {
+		account.Error(err, "ConfigError", "failed to get the MFA requirement")
+		return api.StatusInternalServerError(err)
+	}
+
 	// Construct the update builder
 	update := expression.Set(expression.Name("last_login"), expression.Value(account.LastLogIn()))
 
 	// Update the User’s Last Login into the DynamoDB Table
-	_, err = query.UpdateUserAcccount(ctx, compositKey, update)
+	err = query.UpdateUserAttributes(ctx, compositKey, update)
 	if err != nil {
 		account.Error(err, "DynamoDBError", "failed to update the user last login")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(account)
+	// Issue the access and refresh token of the user account
+	tokens, err := auth.NewTokens(cfg, identity)
+	if err != nil {
+		account.Error(err, "AuthError", "failed to issue the tokens")
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOK(struct {
+		schema.User
+		auth.Tokens
+	}{account, tokens})
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/lockout"
+	"github.com/rmarasigan/bus-ticketing/internal/app/mfa"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the MFA
+// token that was issued on user/login, verifies the TOTP code or the recovery code
+// of the request body, updates the user account’s last login, and responds with a
+// 200 OK HTTP Status, the user account, and its access and refresh token.
+//
+// If the MFA token is invalid or expired, or the password has been reset since it
+// was issued, it responds with a 401 Unauthorized HTTP Status. An incorrect code is
+// a failed login attempt of the username and the source IP, and a code cannot be
+// used twice.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/login/mfa
+//
+// Sample API Payload:
+// 	{
+// 		"mfa_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...",
+// 		"code": "492039"
+// 	}
+//
+// Sample API Response:
+//	{
+//	  "id": "ADMN-878495",
+//	  "user_type": "ADMIN",
+//	  "first_name": "Emily",
+//	  "last_name": "Davis",
+//	  "username": "emilydavis",
+//	  "address": "321 Cedar Road",
+//	  "email": "emilydavis@example.com",
+//	  "mobile_number": "4449876543",
+//	  "version": 1,
+//	  "mfa_enabled": true,
+//	  "access_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...",
+//	  "refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...",
+//	  "token_type": "Bearer",
+//	  "expires_in": 900
+//	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var body struct {
+		MFAToken string `json:"mfa_token"`
+		mfa.Code
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err := utility.ParseJSON([]byte(request.Body), &body)
+	if err != nil {
+		utility.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data")
+		return api.StatusBadRequest(err)
+	}
+
+	if body.MFAToken == "" || body.IsEmpty() {
+		err := errors.New("mfa_token and either code or recovery_code are required")
+		utility.Error(err, "APIError", "the MFA token or code is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	cfg, err := config.GetTokenConfig(ctx)
+	if err != nil {
+		utility.Error(err, "ConfigError", "failed to get the token configuration")
+		return api.StatusInternalServerError(err)
+	}
+
+	claims, err := auth.ParseToken(cfg, body.MFAToken, auth.MFA_TOKEN)
+	if err != nil {
+		utility.Error(err, "AuthError", "invalid MFA token")
+		return api.StatusUnauthorized(err)
+	}
+
+	policy, err := config.GetLockoutPolicy()
+	if err != nil {
+		utility.Error(err, "ConfigError", "failed to get the lockout policy")
+		return api.StatusInternalServerError(err)
+	}
+
+	// The incorrect codes are failed login attempts of the username and the source IP
+	var (
+		now      = time.Now()
+		sourceIP = request.RequestContext.Identity.SourceIP
+	)
+
+	// The code is counted as a failure before it is verified, so that the
+	// concurrent codes are throttled as well.
+	reservations, retryAfter, err := lockout.Reserve(ctx, policy, lockout.Keys(claims.Username, sourceIP), now)
+	if err != nil {
+		return api.StatusInternalServerError(err)
+	}
+
+	if retryAfter > 0 {
+		return api.StatusTooManyRequests(auth.ErrTooManyAttempts, retryAfter)
+	}
+
+	account, err := query.GetUserAccountById(ctx, claims.Subject)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "id", Value: claims.Subject})
+		lockout.Release(ctx, reservations)
+
+		return api.StatusInternalServerError(err)
+	}
+
+	// The password of the user account has been reset since it was issued
+	if account.ID == "" || claims.Session != account.SessionVersion {
+		err := errors.New("the session has been revoked")
+		utility.Error(err, "AuthError", "the MFA token was issued before the password was reset", utility.KVP{Key: "id", Value: claims.Subject})
+		lockout.Release(ctx, reservations)
+
+		return api.StatusUnauthorized(err)
+	}
+
+	verified, err := mfa.Verify(ctx, account, body.Code, now)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to verify the MFA code")
+		lockout.Release(ctx, reservations)
+
+		return api.StatusInternalServerError(err)
+	}
+
+	if !verified {
+		account.Error(auth.ErrInvalidMFA, "AuthError", "incorrect MFA code")
+
+		for _, reservation := range reservations {
+			lockout.RecordFailure(ctx, reservation, policy, account.Username, sourceIP, now)
+		}
+
+		return api.StatusBadRequest(auth.ErrInvalidMFA)
+	}
+
+	// The code is valid, the login attempt is not a failure
+	lockout.Release(ctx, reservations)
+
+	// The failures of the username start over, the failures of the source IP
+	// are kept since it might be guessing the other usernames.
+	err = query.DeleteLoginAttempt(ctx, schema.UsernameAttemptKey(account.Username))
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to reset the failed login attempts")
+	}
+
+	// Create a composite key that has both the partition/primary key
+	// and the sort key of the item.
+	var compositKey = map[string]types.AttributeValue{
+		"id":       &types.AttributeValueMemberS{Value: account.ID},
+		"username": &types.AttributeValueMemberS{Value: account.Username},
+	}
+
+	// Construct the update builder
+	update := expression.Set(expression.Name("last_login"), expression.Value(account.LastLogIn()))
+
+	// Update the User’s Last Login into the DynamoDB Table
+	err = query.UpdateUserAttributes(ctx, compositKey, update)
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to update the user last login")
+		return api.StatusInternalServerError(err)
+	}
+
+	// Issue the access and refresh token of the user account
+	tokens, err := auth.NewTokens(cfg, auth.Identity{UserID: account.ID, Username: account.Username, UserType: account.UserType, BusID: account.BusID, Session: account.SessionVersion, MFA: true})
+	if err != nil {
+		account.Error(err, "AuthError", "failed to issue the tokens")
+		return api.StatusInternalServerError(err)
+	}
+
+	// The password hash is never returned
+	account.Password = ""
+
+	return api.StatusOK(struct {
+		schema.User
+		auth.Tokens
+	}{account, tokens})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// refresh token of the request body, issues a new access and refresh token to
+// the user account, and responds with a 200 OK HTTP Status. If the refresh token
+// is invalid or expired, or the user account no longer exists, it responds with
+// a 401 Unauthorized HTTP Status. The refresh tokens that were issued before the
+// password was reset or the MFA was enabled are no longer valid, and the new tokens
+// can only enroll in MFA if it is required on the user type but not enabled yet.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/token/refresh
+//
+// Sample API Payload:
+// 	{
+// 		"refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI..."
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "access_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...",
+// 	  "refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...",
+// 	  "token_type": "Bearer",
+// 	  "expires_in": 900
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err := utility.ParseJSON([]byte(request.Body), &body)
+	if err != nil {
+		utility.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data")
+		return api.StatusBadRequest(err)
+	}
+
+	if body.RefreshToken == "" {
+		err := errors.New("refresh_token is required")
+		utility.Error(err, "APIError", "the refresh token is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	cfg, err := config.GetTokenConfig(ctx)
+	if err != nil {
+		utility.Error(err, "ConfigError", "failed to get the token configuration")
+		return api.StatusInternalServerError(err)
+	}
+
+	claims, err := auth.ParseToken(cfg, body.RefreshToken, auth.REFRESH_TOKEN)
+	if err != nil {
+		utility.Error(err, "AuthError", "invalid refresh token")
+		return api.StatusUnauthorized(err)
+	}
+
+	// The new tokens have the current user type of the user account
+	account, err := query.GetUserAccountById(ctx, claims.Subject)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "id", Value: claims.Subject})
+		return api.StatusInternalServerError(err)
+	}
+
+	if account.ID == "" {
+		err := errors.New("the user account does not exist")
+		utility.Error(err, "AuthError", "the user account of the refresh token does not exist", utility.KVP{Key: "id", Value: claims.Subject})
+
+		return api.StatusUnauthorized(err)
+	}
+
+	// The password of the user account has been reset since it was issued
+	if claims.Session != account.SessionVersion {
+		err := errors.New("the session has been revoked")
+		account.Error(err, "AuthError", "the refresh token was issued before the password was reset")
+
+		return api.StatusUnauthorized(err)
+	}
+
+	// The MFA has been enabled since it was issued without it
+	if account.MFAEnabled && !claims.MFA {
+		err := errors.New("the session has been revoked")
+		account.Error(err, "AuthError", "the refresh token was issued without MFA")
+
+		return api.StatusUnauthorized(err)
+	}
+
+	// The MFA might be required on the user type since it was issued
+	required, err := config.IsMFARequired(account.UserType)
+	if err != nil {
+		account.Error(err, "ConfigError", "failed to get the MFA requirement")
+		return api.StatusInternalServerError(err)
+	}
+
+	tokens, err := auth.NewTokens(cfg, auth.Identity{UserID: account.ID, Username: account.Username, UserType: account.UserType, BusID: account.BusID, Session: account.SessionVersion,
+		MFA: claims.MFA, MFAEnrollment: required && !account.MFAEnabled})
+	if err != nil {
+		account.Error(err, "AuthError", "failed to issue the tokens")
+		return api.StatusInternalServerError(err)
+	}
+
+	return api.StatusOK(tokens)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// ErrInvalidResetToken is returned when the password reset token does not exist, has
+// expired, or has already been used.
+var ErrInvalidResetToken = errors.New("the password reset token is invalid or has expired")
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request body, replaces the password of the user account of the reset token with
+// the new password, and responds with a 200 OK HTTP Status.
+//
+// The reset token can only be used once. Once the password is reset, the access and
+// refresh tokens and the other reset tokens that were issued to the user account are
+// no longer valid, and the user has to log in again.
+//
+// If the reset token does not exist, has expired, or has already been used, it responds
+// with a 401 Unauthorized HTTP Status.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/password/reset
+//
+// Sample API Payload:
+// 	{
+// 		"token": "3q2-7wX0Yd9pJk1nQm8rVt5uLsZa6bCe4fGh2iKj0lM",
+// 		"password": "newpassword123"
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "message": "the password has been reset"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err := utility.ParseJSON([]byte(request.Body), &body)
+	if err != nil {
+		utility.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data")
+		return api.StatusBadRequest(err)
+	}
+
+	if body.Token == "" {
+		err := errors.New("token is required")
+		utility.Error(err, "APIError", "the password reset token is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	// The password is validated before the token is used, so that the
+	// token can still be used with a valid password.
+	err = auth.ValidatePassword(body.Password)
+	if err != nil {
+		utility.Error(err, "APIError", "invalid password")
+		return api.StatusBadRequest(err)
+	}
+
+	// The password reset is deleted, so that the token cannot be used again
+	reset, err := query.ConsumePasswordReset(ctx, auth.HashOneTimeToken(body.Token))
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the password reset")
+		return api.StatusInternalServerError(err)
+	}
+
+	if reset.UserID == "" {
+		utility.Info("ResetPassword", ErrInvalidResetToken.Error())
+		return api.StatusUnauthorized(ErrInvalidResetToken)
+	}
+
+	hash, err := auth.HashPassword(body.Password)
+	if err != nil {
+		reset.Error(err, "HashError", "failed to hash the password")
+		return api.StatusInternalServerError(err)
+	}
+
+	// Create a composite key that has both the partition/primary key
+	// and the sort key of the item.
+	var compositKey = map[string]types.AttributeValue{
+		"id":       &types.AttributeValueMemberS{Value: reset.UserID},
+		"username": &types.AttributeValueMemberS{Value: reset.Username},
+	}
+
+	// The password is only reset if it has not been reset since the token
+	// was issued, otherwise the token is no longer valid.
+	err = query.ResetUserPassword(ctx, compositKey, hash, reset.SessionVersion)
+	if err != nil {
+		if errors.Is(err, query.ErrVersionMismatch) {
+			reset.Error(ErrInvalidResetToken, "ResetPassword", "the password has been reset since the token was issued")
+			return api.StatusUnauthorized(ErrInvalidResetToken)
+		}
+
+		reset.Error(err, "DynamoDBError", "failed to reset the user password")
+		return api.StatusInternalServerError(err)
+	}
+	utility.Info("ResetPassword", "Successfully reset the user password", utility.KVP{Key: "id", Value: reset.UserID})
+
+	return api.StatusOK(api.Message{Custom: "the password has been reset"})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, deletes the failed
+// login attempts and the lockout of the username or the source IP of the query
+// parameters, and responds with a 200 OK HTTP Status. At least one of them must
+// be present.
+//
+// Only an ADMIN can unlock, otherwise it responds with a 403 Forbidden HTTP Status.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/account/unlock?username=xxxxx&source_ip=xxxxx
+//
+// Sample API Params:
+//  username=emilydavis
+//  source_ip=203.0.113.7
+//
+// Sample API Response:
+// 	{
+// 	  "message": "the login attempts have been unlocked"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		username = request.QueryStringParameters["username"]
+		sourceIP = request.QueryStringParameters["source_ip"]
+	)
+
+	// Check if the caller can unlock the login attempts
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.USER_UNLOCK, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.USER_UNLOCK, err)
+	}
+
+	var keys []string
+	if username != "" {
+		keys = append(keys, schema.UsernameAttemptKey(username))
+	}
+
+	if sourceIP != "" {
+		keys = append(keys, schema.SourceIPAttemptKey(sourceIP))
+	}
+
+	if len(keys) == 0 {
+		err := errors.New("username or source_ip is required")
+		utility.Error(err, "APIError", "the unlock query parameters are empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	for _, key := range keys {
+		err := query.DeleteLoginAttempt(ctx, key)
+		if err != nil {
+			utility.Error(err, "DynamoDBError", "failed to unlock the login attempts", utility.KVP{Key: "key", Value: key})
+			return api.StatusInternalServerError(err)
+		}
+
+		utility.Info("LoginUnlocked", "the login attempts have been unlocked", utility.KVP{Key: "key", Value: key},
+			utility.KVP{Key: "unlocked_by", Value: identity.UserID})
+	}
+
+	return api.StatusOK(api.Message{Custom: "the login attempts have been unlocked"})
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/rmarasigan/bus-ticketing/api"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	"github.com/rmarasigan/bus-ticketing/internal/app/validate"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
@@ -21,7 +22,17 @@ func main() {
 
 // It receives the Amazon API Gateway event record data as input, validates the
 // request query and body, updates the user account’s information/record and responds
-// with a 200 OK HTTP Status.
+// with a 200 OK HTTP Status and the "ETag" header of the new version.
+//
+// The "If-Match" header is optional. If it is set and the user account has been
+// modified since that version, it responds with a 412 Precondition Failed HTTP
+// Status.
+//
+// Only an ADMIN can update the other user accounts, otherwise it responds with a
+// 403 Forbidden HTTP Status.
+//
+// If the e-mail address is changed, it is no longer verified and a new verification
+// link is requested on user/email/verify/resend.
 //
 // Method: POST
 //
@@ -31,6 +42,9 @@ func main() {
 //  id=ADMN-878495
 //  username=passwordabc
 //
+// Sample API Headers:
+//  If-Match: "1"
+//
 // Sample API Payload:
 // 	{
 // 		"address": "Långbro, Stockholm",
@@ -44,11 +58,11 @@ func main() {
 // 	  "first_name": "Emily",
 // 	  "last_name": "Davis",
 // 	  "username": "emilydavis",
-// 	  "password": "passwordabc",
 // 	  "address": "Långbro, Stockholm",
 // 	  "email": "emilydavis@example.com",
 // 	  "mobile_number": "0586-4404205",
-// 	  "date_created": "1687849585"
+// 	  "date_created": "1687849585",
+// 	  "version": 2
 // 	}
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	var (
@@ -57,7 +71,14 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		username_query = request.QueryStringParameters["username"]
 	)
 
-	err := user.IsEmptyPayload(request.Body)
+	// Check if the caller can update the user account
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.USER_UPDATE, auth.Resource{UserID: id_query})
+	if err != nil {
+		return auth.Deny(identity, auth.USER_UPDATE, err)
+	}
+
+	err = user.IsEmptyPayload(request.Body)
 	if err != nil {
 		return api.StatusBadRequest(err)
 	}
@@ -70,21 +91,34 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		return api.StatusInternalServerError(err)
 	}
 
+	// Get the version the update is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		user.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
 	// Fetch the existing user account record
-	accounts, err := query.GetUserAccountRecords(ctx, id_query, username_query)
+	accounts, _, err := query.GetUserAccountRecords(ctx, id_query, username_query, query.Page{})
 	if err != nil {
 		user.Error(err, "DynamoDBError", "failed to fetch the user account record")
 		return api.StatusInternalServerError(err)
 	}
 
-	account := accounts[0]
-	if account == (schema.User{}) {
+	if len(accounts) == 0 || accounts[0] == (schema.User{}) {
 		err := errors.New("the account you're trying to update is non-existent")
 		user.Error(err, "APIError", "the account does not exist")
 
 		return api.StatusBadRequest(err)
 	}
 
+	account := accounts[0]
+	version := account.Version
+	if ifMatch != nil && *ifMatch != version {
+		account.Error(query.ErrVersionMismatch, "APIError", "the account version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
 	// Create a composite key that has both the partition/primary key
 	// and the sort key of the item.
 	var compositKey = map[string]types.AttributeValue{
@@ -93,6 +127,7 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 	}
 
 	// Construct the update builder
+	var previousEmail = account.Email
 	account = validate.UpdateUserAccountFields(*user, account)
 	var update = expression.Set(expression.Name("first_name"), expression.Value(account.FirstName)).
 		Set(expression.Name("last_name"), expression.Value(account.LastName)).
@@ -100,11 +135,21 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*event
 		Set(expression.Name("email"), expression.Value(account.Email)).
 		Set(expression.Name("mobile_number"), expression.Value(account.MobileNumber))
 
-	result, err := query.UpdateUserAcccount(ctx, compositKey, update)
+	// A new e-mail address has to be verified again
+	if account.Email != previousEmail {
+		update = update.Set(expression.Name("email_verified"), expression.Value(false))
+	}
+
+	result, err := query.UpdateUserAcccount(ctx, compositKey, update, version)
+	if errors.Is(err, query.ErrVersionMismatch) {
+		account.Error(err, "DynamoDBError", "the user account has been modified by another request")
+		return api.StatusPreconditionFailed(err)
+	}
+
 	if err != nil {
 		account.Error(err, "DynamoDBError", "failed to update the user account record")
 		return api.StatusInternalServerError(err)
 	}
 
-	return api.StatusOK(result)
+	return api.StatusOKWithETag(result, result.Version)
 }
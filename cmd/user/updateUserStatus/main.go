@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// request query and body, either deactivates or reactivates the user account,
+// records the change on its audit trail, and responds with a 200 OK HTTP Status
+// and the updated user account.
+//
+// A deactivated user account can no longer log in, and the tokens that were issued
+// before are revoked. Reactivating it allows it to log in again.
+//
+// Only an ADMIN can deactivate a user account, otherwise it responds with a 403
+// Forbidden HTTP Status, and it cannot deactivate its own user account. If the
+// "If-Match" header is set and the user account has been modified since that
+// version, it responds with a 412 Precondition Failed HTTP Status.
+//
+// Method: POST
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/account/status?id=xxxxx
+//
+// Sample API Params:
+//  id=CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+//
+// Sample API Headers:
+//  If-Match: "2"
+//
+// Sample API Payload:
+// 	{
+// 	  "deactivated": true,
+// 	  "reason": "fraudulent bookings"
+// 	}
+//
+// Sample API Response:
+// 	{
+// 	  "id": "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A",
+// 	  "user_type": "CUSTOMER",
+// 	  "first_name": "Emily",
+// 	  "last_name": "Davis",
+// 	  "username": "emilydavis",
+// 	  "address": "321 Cedar Road",
+// 	  "email": "emilydavis@example.com",
+// 	  "mobile_number": "4449876543",
+// 	  "date_created": "1685498070",
+// 	  "version": 3,
+// 	  "mfa_enabled": false,
+// 	  "deactivated": true,
+// 	  "date_deactivated": "1688091891"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var (
+		id_query = request.QueryStringParameters["id"]
+		body     struct {
+			Deactivated *bool  `json:"deactivated"`
+			Reason      string `json:"reason"`
+		}
+	)
+
+	// Check if the caller can deactivate the user accounts
+	identity := auth.GetIdentity(request)
+	err := identity.Authorize(auth.USER_MANAGE, auth.Resource{})
+	if err != nil {
+		return auth.Deny(identity, auth.USER_MANAGE, err)
+	}
+
+	// Unmarshal the received JSON-encoded data
+	err = utility.ParseJSON([]byte(request.Body), &body)
+	if err != nil {
+		utility.Error(err, "JSONError", "failed to unmarshal the JSON-encoded data", utility.KVP{Key: "payload", Value: request.Body})
+		return api.StatusBadRequest(err)
+	}
+
+	if id_query == "" || body.Deactivated == nil {
+		err := errors.New("id and deactivated are required")
+		utility.Error(err, "APIError", "the user account ID or the status is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	if id_query == identity.UserID {
+		err := errors.New("you cannot change the status of your own user account")
+		utility.Error(err, "APIError", "the caller tried to change its own status", utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	// Get the version the update is based on
+	ifMatch, err := api.IfMatch(request)
+	if err != nil {
+		utility.Error(err, "APIError", "invalid If-Match header", utility.KVP{Key: "headers", Value: request.Headers})
+		return api.StatusBadRequest(err)
+	}
+
+	// Fetch the existing user account record
+	account, err := query.GetUserAccountById(ctx, id_query)
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "id", Value: id_query})
+		return api.StatusInternalServerError(err)
+	}
+
+	if account.ID == "" || account.IsErased() {
+		err := errors.New("the account you're trying to update is non-existent")
+		utility.Error(err, "APIError", "the account does not exist", utility.KVP{Key: "id", Value: id_query})
+
+		return api.StatusBadRequest(err)
+	}
+
+	if ifMatch != nil && *ifMatch != account.Version {
+		account.Error(query.ErrVersionMismatch, "APIError", "the account version does not match", utility.KVP{Key: "if_match", Value: *ifMatch})
+		return api.StatusPreconditionFailed(query.ErrVersionMismatch)
+	}
+
+	if account.Deactivated == *body.Deactivated {
+		account.Password = ""
+		account.MFA = nil
+
+		return api.StatusOKWithETag(account, account.Version)
+	}
+
+	var (
+		action = schema.AUDIT_USER_REACTIVATED
+		update = expression.Remove(expression.Name("deactivated")).Remove(expression.Name("date_deactivated"))
+	)
+
+	// The session version is incremented so that the tokens that were
+	// issued before are no longer valid.
+	if *body.Deactivated {
+		action = schema.AUDIT_USER_DEACTIVATED
+		update = expression.Set(expression.Name("deactivated"), expression.Value(true)).
+			Set(expression.Name("date_deactivated"), expression.Value(fmt.Sprint(time.Now().Unix()))).
+			Set(expression.Name("session_version"), expression.Plus(expression.IfNotExists(expression.Name("session_version"), expression.Value(0)), expression.Value(1)))
+	}
+
+	audit, err := schema.NewUserAudit(account, action, identity.UserID, "", "", body.Reason)
+	if err != nil {
+		account.Error(err, "IDError", "failed to generate the audit ID")
+		return api.StatusInternalServerError(err)
+	}
+
+	user, err := query.UpdateUserAccountWithAudit(ctx, account, update, audit)
+	if errors.Is(err, query.ErrVersionMismatch) {
+		account.Error(err, "DynamoDBError", "the user account has been modified by another request")
+		return api.StatusPreconditionFailed(err)
+	}
+
+	if err != nil {
+		account.Error(err, "DynamoDBError", "failed to update the status of the user account")
+		return api.StatusInternalServerError(err)
+	}
+
+	utility.Info("UserStatusChanged", "the status of the user account has been changed", utility.KVP{Key: "id", Value: account.ID},
+		utility.KVP{Key: "action", Value: action}, utility.KVP{Key: "changed_by", Value: identity.UserID})
+
+	return api.StatusOKWithETag(user, user.Version)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// ErrInvalidVerifyToken is returned when the e-mail verification token does not exist,
+// has expired, or has already been used.
+var ErrInvalidVerifyToken = errors.New("the e-mail verification token is invalid or has expired")
+
+func main() {
+	lambda.Start(handler)
+}
+
+// It receives the Amazon API Gateway event record data as input, validates the
+// verification token of the query parameter, marks the e-mail address of the
+// user account as verified, and responds with a 200 OK HTTP Status. It is the
+// link that is sent to the e-mail address when the account is created.
+//
+// The verification token can only be used once. If it does not exist, has expired,
+// has already been used, or the e-mail address of the user account has been changed
+// since it was sent, it responds with a 401 Unauthorized HTTP Status.
+//
+// Method: GET
+//
+// Endpoint: https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/email/verify?token=xxxxx
+//
+// Sample API Params:
+//  token=3q2-7wX0Yd9pJk1nQm8rVt5uLsZa6bCe4fGh2iKj0lM
+//
+// Sample API Response:
+// 	{
+// 	  "message": "the e-mail address has been verified"
+// 	}
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	var token = request.QueryStringParameters["token"]
+
+	if token == "" {
+		err := errors.New("token is required")
+		utility.Error(err, "APIError", "the e-mail verification token is empty")
+
+		return api.StatusBadRequest(err)
+	}
+
+	// The e-mail verification is deleted, so that the token cannot be used again
+	verification, err := query.ConsumeEmailVerification(ctx, auth.HashOneTimeToken(token))
+	if err != nil {
+		utility.Error(err, "DynamoDBError", "failed to fetch the e-mail verification")
+		return api.StatusInternalServerError(err)
+	}
+
+	if verification.UserID == "" {
+		utility.Info("VerifyEmail", ErrInvalidVerifyToken.Error())
+		return api.StatusUnauthorized(ErrInvalidVerifyToken)
+	}
+
+	// Create a composite key that has both the partition/primary key
+	// and the sort key of the item.
+	var compositKey = map[string]types.AttributeValue{
+		"id":       &types.AttributeValueMemberS{Value: verification.UserID},
+		"username": &types.AttributeValueMemberS{Value: verification.Username},
+	}
+
+	// The e-mail address is only verified if it is still the e-mail address
+	// that the token was sent to.
+	err = query.VerifyUserEmail(ctx, compositKey, verification.Email)
+	if err != nil {
+		if errors.Is(err, query.ErrVersionMismatch) {
+			verification.Error(ErrInvalidVerifyToken, "VerifyEmail", "the e-mail address has been changed since the token was sent")
+			return api.StatusUnauthorized(ErrInvalidVerifyToken)
+		}
+
+		verification.Error(err, "DynamoDBError", "failed to verify the user e-mail address")
+		return api.StatusInternalServerError(err)
+	}
+	utility.Info("VerifyEmail", "Successfully verified the user e-mail address", utility.KVP{Key: "id", Value: verification.UserID})
+
+	return api.StatusOK(api.Message{Custom: "the e-mail address has been verified"})
+}
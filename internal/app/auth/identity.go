@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Identity is the caller of an API request. It is set by the Lambda authorizer on the
+// request context of the API Gateway, and is empty on the endpoints that are public.
+type Identity struct {
+	UserID   string `json:"user_id"`   // The ID of the user account
+	Username string `json:"username"`  // The username of the user account
+	UserType string `json:"user_type"` // The type of the user account
+	BusID    string `json:"bus_id"`    // The bus line of an OPERATOR user account
+	Session  int    `json:"-"`         // The session version of the user account, it is only on the tokens
+
+	MFA           bool `json:"mfa"`            // Whether the login was verified with a second factor
+	MFAEnrollment bool `json:"mfa_enrollment"` // Whether the caller can only enroll in MFA
+}
+
+// Context returns the context of the Lambda authorizer response, it is passed to
+// the request context of the integration.
+func (identity Identity) Context() map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":   identity.UserID,
+		"username":  identity.Username,
+		"user_type": identity.UserType,
+		"bus_id":    identity.BusID,
+
+		"mfa":            identity.MFA,
+		"mfa_enrollment": identity.MFAEnrollment,
+	}
+}
+
+// IsAuthenticated checks if the request has a caller.
+func (identity Identity) IsAuthenticated() bool {
+	return identity.UserID != ""
+}
+
+// GetIdentity returns the identity of the caller that is set by the Lambda authorizer on
+// the request context.
+func GetIdentity(request events.APIGatewayProxyRequest) Identity {
+	var authorizer = request.RequestContext.Authorizer
+
+	value := func(key string) string {
+		if value, ok := authorizer[key]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+
+		return ""
+	}
+
+	return Identity{
+		UserID:   value("user_id"),
+		Username: value("username"),
+		UserType: value("user_type"),
+		BusID:    value("bus_id"),
+
+		MFA:           value("mfa") == "true",
+		MFAEnrollment: value("mfa_enrollment") == "true",
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+)
+
+const (
+	MAX_LOGIN_FAILURES     = 5                // The failed login attempts of a username before it is locked if LOGIN_MAX_FAILURES is not set
+	MAX_SOURCE_IP_FAILURES = 20               // The failed login attempts of a source IP before it is locked if LOGIN_MAX_IP_FAILURES is not set
+	LOCKOUT_DURATION       = 15 * time.Minute // How long a username or source IP is locked if LOGIN_LOCKOUT_DURATION is not set
+	BACKOFF_BASE           = time.Second      // The delay after the first failed login attempt, it doubles on every failure
+	BACKOFF_MAX            = time.Minute      // The longest delay between the failed login attempts
+	FAILURE_WINDOW         = time.Hour        // The failed login attempts are forgotten after this long without a failure
+)
+
+// ErrTooManyAttempts is returned when a login is attempted during the backoff or the
+// lockout of the username or the source IP.
+var ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+
+// LockoutPolicy is when the failed login attempts of a username or a source IP are
+// delayed and locked.
+type LockoutPolicy struct {
+	MaxFailures         int           // The failed login attempts of a username before it is locked
+	MaxSourceIPFailures int           // The failed login attempts of a source IP before it is locked
+	Lockout             time.Duration // How long a username or source IP is locked
+	BackoffBase         time.Duration // The delay after the first failed login attempt
+	BackoffMax          time.Duration // The longest delay between the failed login attempts
+	Window              time.Duration // The failed login attempts are forgotten after this long without a failure
+}
+
+// DefaultLockoutPolicy returns the lockout policy of the constants.
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		MaxFailures:         MAX_LOGIN_FAILURES,
+		MaxSourceIPFailures: MAX_SOURCE_IP_FAILURES,
+		Lockout:             LOCKOUT_DURATION,
+		BackoffBase:         BACKOFF_BASE,
+		BackoffMax:          BACKOFF_MAX,
+		Window:              FAILURE_WINDOW,
+	}
+}
+
+// IsStale checks if the failed login attempts have been forgotten at the time.
+func (policy LockoutPolicy) IsStale(attempt schema.LoginAttempt, now time.Time) bool {
+	return now.Sub(time.Unix(attempt.LastFailure, 0)) >= policy.Window
+}
+
+// Backoff returns the delay after the number of failed login attempts. It doubles on
+// every failure up to the BackoffMax.
+//
+// Example:
+//  1: 1s, 2: 2s, 3: 4s, 4: 8s, ... 7: 1m
+func (policy LockoutPolicy) Backoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	delay := policy.BackoffBase
+	for i := 1; i < failures && delay < policy.BackoffMax; i++ {
+		delay *= 2
+	}
+
+	if delay > policy.BackoffMax {
+		return policy.BackoffMax
+	}
+
+	return delay
+}
+
+// RetryAfter returns how long to wait before the next login attempt, or 0 if it
+// is allowed at the time. It is the rest of the lockout, or of the backoff after
+// the last failed login attempt.
+func (policy LockoutPolicy) RetryAfter(attempt schema.LoginAttempt, now time.Time) time.Duration {
+	if attempt.IsLocked(now) {
+		return time.Unix(attempt.LockedUntil, 0).Sub(now)
+	}
+
+	if attempt.Failures == 0 || policy.IsStale(attempt, now) {
+		return 0
+	}
+
+	wait := time.Unix(attempt.LastFailure, 0).Add(policy.Backoff(attempt.Failures)).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
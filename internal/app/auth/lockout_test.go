@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+)
+
+func TestBackoff(t *testing.T) {
+	var policy = DefaultLockoutPolicy()
+
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{failures: -1, delay: 0},
+		{failures: 0, delay: 0},
+		{failures: 1, delay: time.Second},
+		{failures: 2, delay: 2 * time.Second},
+		{failures: 3, delay: 4 * time.Second},
+		{failures: 4, delay: 8 * time.Second},
+		{failures: 5, delay: 16 * time.Second},
+		{failures: 6, delay: 32 * time.Second},
+		{failures: 7, delay: time.Minute},
+		{failures: 100, delay: time.Minute},
+	}
+
+	for _, tt := range tests {
+		delay := policy.Backoff(tt.failures)
+		if delay != tt.delay {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.failures, delay, tt.delay)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var (
+		policy = DefaultLockoutPolicy()
+		now    = time.Unix(1688091891, 0)
+	)
+
+	tests := []struct {
+		name    string
+		attempt schema.LoginAttempt
+		wait    time.Duration
+	}{
+		{
+			name:    "no failures",
+			attempt: schema.LoginAttempt{},
+		},
+		{
+			name:    "during the backoff",
+			attempt: schema.LoginAttempt{Failures: 3, LastFailure: now.Add(-time.Second).Unix()},
+			wait:    3 * time.Second,
+		},
+		{
+			name:    "after the backoff",
+			attempt: schema.LoginAttempt{Failures: 3, LastFailure: now.Add(-5 * time.Second).Unix()},
+		},
+		{
+			name:    "during the lockout",
+			attempt: schema.LoginAttempt{Failures: 5, LastFailure: now.Unix(), LockedUntil: now.Add(10 * time.Minute).Unix()},
+			wait:    10 * time.Minute,
+		},
+		{
+			name:    "after the lockout",
+			attempt: schema.LoginAttempt{Failures: 5, LastFailure: now.Add(-LOCKOUT_DURATION).Unix(), LockedUntil: now.Unix()},
+		},
+		{
+			name:    "failures are forgotten after the window",
+			attempt: schema.LoginAttempt{Failures: 50, LastFailure: now.Add(-FAILURE_WINDOW).Unix()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait := policy.RetryAfter(tt.attempt, now)
+			if wait != tt.wait {
+				t.Errorf("RetryAfter() = %s, want %s", wait, tt.wait)
+			}
+		})
+	}
+}
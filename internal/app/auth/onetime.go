@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	ONE_TIME_TOKEN_SIZE = 32             // The number of random bytes of a password reset or e-mail verification token
+	RESET_TOKEN_TTL     = time.Hour      // The lifetime of a password reset token if RESET_TOKEN_TTL is not set
+	VERIFY_TOKEN_TTL    = 24 * time.Hour // The lifetime of an e-mail verification token if VERIFY_TOKEN_TTL is not set
+)
+
+// NewOneTimeToken returns a random token that can only be used once, such as a password
+// reset or an e-mail verification token, and its hash. The token is only sent to the
+// user, and the hash is what is stored.
+//
+// Example:
+//  token: 3q2-7wX0Yd9pJk1nQm8rVt5uLsZa6bCe4fGh2iKj0lM
+//  hash: 9b74c9897bac770ffc029102a200c5de6a3b1f1d1a5e4b1a7b3c3d2e1f0a9b8c
+func NewOneTimeToken() (string, string, error) {
+	buffer := make([]byte, ONE_TIME_TOKEN_SIZE)
+
+	_, err := rand.Read(buffer)
+	if err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buffer)
+	return token, HashOneTimeToken(token), nil
+}
+
+// HashOneTimeToken returns the hex-encoded SHA-256 hash of the one-time token. The
+// token is random, so it does not need a salt or a slow hash.
+func HashOneTimeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PASSWORD_COST       = 12 // The bcrypt cost of the password hashes
+	PASSWORD_MIN_LENGTH = 8  // The minimum number of characters of a password
+	PASSWORD_MAX_LENGTH = 72 // The maximum number of bytes of a password that bcrypt accepts
+)
+
+var (
+	ErrPasswordTooShort = errors.New("the password must have at least 8 characters")
+	ErrPasswordTooLong  = errors.New("the password must not be longer than 72 bytes")
+)
+
+// dummyHash is compared against when there is no password hash, so that an unknown
+// username takes as long to verify as a wrong password.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("bus-ticketing"), PASSWORD_COST)
+
+// ValidatePassword checks if the password can be hashed and is long enough.
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < PASSWORD_MIN_LENGTH {
+		return ErrPasswordTooShort
+	}
+
+	if len(password) > PASSWORD_MAX_LENGTH {
+		return ErrPasswordTooLong
+	}
+
+	return nil
+}
+
+// HashPassword returns the salted bcrypt hash of the password.
+//
+// Example:
+//  $2a$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PASSWORD_COST)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// VerifyPassword checks if the password matches the stored password. The stored
+// password is either a bcrypt hash, or the plaintext password of an account that
+// was created before the passwords were hashed. Both are compared in constant time.
+func VerifyPassword(stored, password string) bool {
+	if stored == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	if !IsPasswordHash(stored) {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+}
+
+// NeedsRehash checks if the stored password is a legacy plaintext password or a
+// hash with a lower cost than PASSWORD_COST, and should be hashed again.
+func NeedsRehash(stored string) bool {
+	if !IsPasswordHash(stored) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(stored))
+	return err != nil || cost < PASSWORD_COST
+}
+
+// IsPasswordHash checks if the stored password is a bcrypt hash.
+func IsPasswordHash(stored string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(stored, prefix) {
+			return len(stored) == 60
+		}
+	}
+
+	return false
+}
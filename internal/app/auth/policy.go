@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// Action is an operation of the API that a user type is allowed to perform.
+type Action string
+
+const (
+	USER_CREATE      Action = "user:create"      // Create an ADMIN or OPERATOR user account
+	USER_READ        Action = "user:read"        // Fetch the user accounts
+	USER_UPDATE      Action = "user:update"      // Update a user account
+	USER_UNLOCK      Action = "user:unlock"      // Unlock a username or source IP after too many failed logins
+	USER_MFA         Action = "user:mfa"         // Enroll, disable and manage the MFA of a user account
+	USER_ERASE       Action = "user:erase"       // Erase the personal data of a user account
+	USER_MANAGE      Action = "user:manage"      // Search, deactivate and change the type of the user accounts
+	BUS_CREATE       Action = "bus:create"       // Create the bus lines
+	BUS_READ         Action = "bus:read"         // Fetch and search the bus lines
+	BUS_UPDATE       Action = "bus:update"       // Update a bus line
+	BUS_UNIT_CREATE  Action = "bus_unit:create"  // Create the bus units
+	BUS_UNIT_READ    Action = "bus_unit:read"    // Fetch and search the bus units
+	BUS_UNIT_UPDATE  Action = "bus_unit:update"  // Update a bus unit
+	BUS_ROUTE_CREATE Action = "bus_route:create" // Create a bus route
+	BUS_ROUTE_READ   Action = "bus_route:read"   // Fetch and search the bus routes
+	BUS_ROUTE_UPDATE Action = "bus_route:update" // Update a bus route
+	BOOKING_CREATE   Action = "booking:create"   // Book the seats of a bus route
+	BOOKING_READ     Action = "booking:read"     // Fetch and search the bookings and their cancellation
+	BOOKING_CONFIRM  Action = "booking:confirm"  // Confirm a booking
+	BOOKING_CANCEL   Action = "booking:cancel"   // Cancel a booking
+	CREW_READ        Action = "crew:read"        // Fetch the crew, their assignments and the trip manifests
+	CREW_MANAGE      Action = "crew:manage"      // Create and update the crew and assign them to the trips
+)
+
+// Scope is the records of an action that a user type is allowed to access.
+type Scope int
+
+const (
+	SCOPE_NONE Scope = iota // The action is not allowed
+	SCOPE_OWN               // Only the records of the user account itself
+	SCOPE_BUS               // Only the records of the bus line of the OPERATOR
+	SCOPE_ALL               // Every record
+)
+
+var (
+	ErrUnauthenticated = errors.New("the request has no access token")
+	ErrForbidden       = errors.New("you are not allowed to perform this action")
+	ErrDeactivated     = errors.New("the user account has been deactivated")
+)
+
+// publicActions are the actions that anyone can perform on every record, even
+// without an access token.
+var publicActions = map[Action]bool{
+	BUS_ROUTE_READ: true,
+}
+
+// policies are the scope of every action that a user type is allowed to perform,
+// the actions that are not listed are not allowed.
+var policies = map[string]map[Action]Scope{
+	schema.ADMN: {
+		USER_CREATE:      SCOPE_ALL,
+		USER_READ:        SCOPE_ALL,
+		USER_UPDATE:      SCOPE_ALL,
+		USER_UNLOCK:      SCOPE_ALL,
+		USER_MFA:         SCOPE_OWN,
+		USER_ERASE:       SCOPE_ALL,
+		USER_MANAGE:      SCOPE_ALL,
+		BUS_CREATE:       SCOPE_ALL,
+		BUS_READ:         SCOPE_ALL,
+		BUS_UPDATE:       SCOPE_ALL,
+		BUS_UNIT_CREATE:  SCOPE_ALL,
+		BUS_UNIT_READ:    SCOPE_ALL,
+		BUS_UNIT_UPDATE:  SCOPE_ALL,
+		BUS_ROUTE_CREATE: SCOPE_ALL,
+		BUS_ROUTE_UPDATE: SCOPE_ALL,
+		BOOKING_CREATE:   SCOPE_ALL,
+		BOOKING_READ:     SCOPE_ALL,
+		BOOKING_CONFIRM:  SCOPE_ALL,
+		BOOKING_CANCEL:   SCOPE_ALL,
+		CREW_READ:        SCOPE_ALL,
+		CREW_MANAGE:      SCOPE_ALL,
+	},
+	schema.OPRTR: {
+		USER_READ:        SCOPE_OWN,
+		USER_UPDATE:      SCOPE_OWN,
+		USER_MFA:         SCOPE_OWN,
+		USER_ERASE:       SCOPE_OWN,
+		BUS_READ:         SCOPE_ALL,
+		BUS_UPDATE:       SCOPE_BUS,
+		BUS_UNIT_CREATE:  SCOPE_BUS,
+		BUS_UNIT_READ:    SCOPE_ALL,
+		BUS_UNIT_UPDATE:  SCOPE_BUS,
+		BUS_ROUTE_CREATE: SCOPE_BUS,
+		BUS_ROUTE_UPDATE: SCOPE_BUS,
+		BOOKING_CREATE:   SCOPE_BUS,
+		BOOKING_READ:     SCOPE_BUS,
+		BOOKING_CONFIRM:  SCOPE_BUS,
+		BOOKING_CANCEL:   SCOPE_BUS,
+		CREW_READ:        SCOPE_BUS,
+		CREW_MANAGE:      SCOPE_BUS,
+	},
+	schema.CSTMR: {
+		USER_READ:      SCOPE_OWN,
+		USER_UPDATE:    SCOPE_OWN,
+		USER_MFA:       SCOPE_OWN,
+		USER_ERASE:     SCOPE_OWN,
+		BUS_READ:       SCOPE_ALL,
+		BUS_UNIT_READ:  SCOPE_ALL,
+		BOOKING_CREATE: SCOPE_OWN,
+		BOOKING_READ:   SCOPE_OWN,
+		BOOKING_CANCEL: SCOPE_OWN,
+	},
+}
+
+// Resource is the owner of a record that an action is performed on.
+type Resource struct {
+	UserID string // The user account that owns the record
+	BusID  string // The bus line that the record belongs to
+}
+
+// Scope returns the records of the action that the caller is allowed to access. It
+// returns ErrUnauthenticated if the request has no caller, ErrMFARequired if the caller
+// has to enroll in MFA first, or ErrForbidden if the action is not allowed.
+func (identity Identity) Scope(action Action) (Scope, error) {
+	if publicActions[action] {
+		return SCOPE_ALL, nil
+	}
+
+	if !identity.IsAuthenticated() {
+		return SCOPE_NONE, ErrUnauthenticated
+	}
+
+	// The MFA is required on the user type but it is not enabled yet
+	if identity.MFAEnrollment && action != USER_MFA {
+		return SCOPE_NONE, ErrMFARequired
+	}
+
+	scope := policies[identity.UserType][action]
+	if scope == SCOPE_NONE {
+		return SCOPE_NONE, ErrForbidden
+	}
+
+	// An OPERATOR without a bus line has no records
+	if scope == SCOPE_BUS && identity.BusID == "" {
+		return SCOPE_NONE, ErrForbidden
+	}
+
+	return scope, nil
+}
+
+// Authorize checks if the caller is allowed to perform the action on the record of
+// the resource. It returns ErrUnauthenticated if the request has no caller, or
+// ErrForbidden if it is not allowed.
+func (identity Identity) Authorize(action Action, resource Resource) error {
+	scope, err := identity.Scope(action)
+	if err != nil {
+		return err
+	}
+
+	switch scope {
+	case SCOPE_OWN:
+		if resource.UserID == "" || resource.UserID != identity.UserID {
+			return ErrForbidden
+		}
+
+	case SCOPE_BUS:
+		if resource.BusID == "" || resource.BusID != identity.BusID {
+			return ErrForbidden
+		}
+	}
+
+	return nil
+}
+
+// Restrict returns the filter of a list of records of the action that is within the
+// scope of the caller. The empty user ID or bus ID of the filter is set to the caller's,
+// and a filter on the records of another user account or bus line is ErrForbidden.
+//
+// Example:
+//  identity: {user_id: OPRTR-854980, user_type: OPERATOR, bus_id: 9f8b3c2a}
+//  Restrict(BOOKING_READ, Resource{})
+//  Resource{BusID: 9f8b3c2a}
+func (identity Identity) Restrict(action Action, filter Resource) (Resource, error) {
+	scope, err := identity.Scope(action)
+	if err != nil {
+		return filter, err
+	}
+
+	switch scope {
+	case SCOPE_OWN:
+		if filter.UserID == "" {
+			filter.UserID = identity.UserID
+		}
+
+	case SCOPE_BUS:
+		if filter.BusID == "" {
+			filter.BusID = identity.BusID
+		}
+	}
+
+	return filter, identity.Authorize(action, filter)
+}
+
+// Deny logs the denied action of the caller and returns a response of an HTTP
+// StatusUnauthorized if the request has no caller, or an HTTP StatusForbidden
+// otherwise.
+func Deny(identity Identity, action Action, err error) (*events.APIGatewayProxyResponse, error) {
+	utility.Info("AuthError", err.Error(), utility.KVP{Key: "action", Value: action},
+		utility.KVP{Key: "user_id", Value: identity.UserID}, utility.KVP{Key: "user_type", Value: identity.UserType})
+
+	if errors.Is(err, ErrUnauthenticated) {
+		return api.StatusUnauthorized(err)
+	}
+
+	return api.StatusForbidden(err)
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+)
+
+func TestScope(t *testing.T) {
+	var (
+		admin    = Identity{UserID: "ADMN-1", UserType: schema.ADMN}
+		operator = Identity{UserID: "OPRTR-1", UserType: schema.OPRTR, BusID: "BUS-1"}
+		customer = Identity{UserID: "CSTMR-1", UserType: schema.CSTMR}
+	)
+
+	// The scope of every action of the user types, the actions that are not listed are
+	// not allowed.
+	tests := []struct {
+		name     string
+		identity Identity
+		scopes   map[Action]Scope
+	}{
+		{
+			name:     "ADMIN",
+			identity: admin,
+			scopes: map[Action]Scope{
+				USER_CREATE:      SCOPE_ALL,
+				USER_READ:        SCOPE_ALL,
+				USER_UPDATE:      SCOPE_ALL,
+				USER_UNLOCK:      SCOPE_ALL,
+				USER_MFA:         SCOPE_OWN,
+				USER_ERASE:       SCOPE_ALL,
+				USER_MANAGE:      SCOPE_ALL,
+				BUS_CREATE:       SCOPE_ALL,
+				BUS_READ:         SCOPE_ALL,
+				BUS_UPDATE:       SCOPE_ALL,
+				BUS_UNIT_CREATE:  SCOPE_ALL,
+				BUS_UNIT_READ:    SCOPE_ALL,
+				BUS_UNIT_UPDATE:  SCOPE_ALL,
+				BUS_ROUTE_CREATE: SCOPE_ALL,
+				BUS_ROUTE_READ:   SCOPE_ALL,
+				BUS_ROUTE_UPDATE: SCOPE_ALL,
+				BOOKING_CREATE:   SCOPE_ALL,
+				BOOKING_READ:     SCOPE_ALL,
+				BOOKING_CONFIRM:  SCOPE_ALL,
+				BOOKING_CANCEL:   SCOPE_ALL,
+				CREW_READ:        SCOPE_ALL,
+				CREW_MANAGE:      SCOPE_ALL,
+			},
+		},
+		{
+			name:     "OPERATOR",
+			identity: operator,
+			scopes: map[Action]Scope{
+				USER_READ:        SCOPE_OWN,
+				USER_UPDATE:      SCOPE_OWN,
+				USER_MFA:         SCOPE_OWN,
+				USER_ERASE:       SCOPE_OWN,
+				BUS_READ:         SCOPE_ALL,
+				BUS_UPDATE:       SCOPE_BUS,
+				BUS_UNIT_CREATE:  SCOPE_BUS,
+				BUS_UNIT_READ:    SCOPE_ALL,
+				BUS_UNIT_UPDATE:  SCOPE_BUS,
+				BUS_ROUTE_CREATE: SCOPE_BUS,
+				BUS_ROUTE_READ:   SCOPE_ALL,
+				BUS_ROUTE_UPDATE: SCOPE_BUS,
+				BOOKING_CREATE:   SCOPE_BUS,
+				BOOKING_READ:     SCOPE_BUS,
+				BOOKING_CONFIRM:  SCOPE_BUS,
+				BOOKING_CANCEL:   SCOPE_BUS,
+				CREW_READ:        SCOPE_BUS,
+				CREW_MANAGE:      SCOPE_BUS,
+			},
+		},
+		{
+			name:     "CUSTOMER",
+			identity: customer,
+			scopes: map[Action]Scope{
+				USER_READ:      SCOPE_OWN,
+				USER_UPDATE:    SCOPE_OWN,
+				USER_MFA:       SCOPE_OWN,
+				USER_ERASE:     SCOPE_OWN,
+				BUS_READ:       SCOPE_ALL,
+				BUS_UNIT_READ:  SCOPE_ALL,
+				BUS_ROUTE_READ: SCOPE_ALL,
+				BOOKING_CREATE: SCOPE_OWN,
+				BOOKING_READ:   SCOPE_OWN,
+				BOOKING_CANCEL: SCOPE_OWN,
+			},
+		},
+		{
+			name:     "anonymous",
+			identity: Identity{},
+			scopes: map[Action]Scope{
+				BUS_ROUTE_READ: SCOPE_ALL,
+			},
+		},
+	}
+
+	var actions = []Action{
+		USER_CREATE, USER_READ, USER_UPDATE, USER_UNLOCK, USER_MFA, USER_ERASE, USER_MANAGE,
+		BUS_CREATE, BUS_READ, BUS_UPDATE,
+		BUS_UNIT_CREATE, BUS_UNIT_READ, BUS_UNIT_UPDATE,
+		BUS_ROUTE_CREATE, BUS_ROUTE_READ, BUS_ROUTE_UPDATE,
+		BOOKING_CREATE, BOOKING_READ, BOOKING_CONFIRM, BOOKING_CANCEL,
+		CREW_READ, CREW_MANAGE,
+	}
+
+	for _, tt := range tests {
+		for _, action := range actions {
+			t.Run(tt.name+"/"+string(action), func(t *testing.T) {
+				want, allowed := tt.scopes[action]
+
+				var wantErr error
+				switch {
+				case allowed:
+				case !tt.identity.IsAuthenticated():
+					wantErr = ErrUnauthenticated
+				default:
+					wantErr = ErrForbidden
+				}
+
+				scope, err := tt.identity.Scope(action)
+				if scope != want || !errors.Is(err, wantErr) {
+					t.Errorf("Scope(%s) = %d, %v, want %d, %v", action, scope, err, want, wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestScopeRestrictions(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity Identity
+		action   Action
+		scope    Scope
+		err      error
+	}{
+		{
+			name:     "OPERATOR without a bus line",
+			identity: Identity{UserID: "OPRTR-1", UserType: schema.OPRTR},
+			action:   BOOKING_READ,
+			err:      ErrForbidden,
+		},
+		{
+			name:     "MFA enrollment only allows the MFA",
+			identity: Identity{UserID: "ADMN-1", UserType: schema.ADMN, MFAEnrollment: true},
+			action:   USER_READ,
+			err:      ErrMFARequired,
+		},
+		{
+			name:     "MFA enrollment",
+			identity: Identity{UserID: "ADMN-1", UserType: schema.ADMN, MFAEnrollment: true},
+			action:   USER_MFA,
+			scope:    SCOPE_OWN,
+		},
+		{
+			name:     "unknown user type",
+			identity: Identity{UserID: "X-1", UserType: "UNKNOWN"},
+			action:   BUS_READ,
+			err:      ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := tt.identity.Scope(tt.action)
+			if scope != tt.scope || !errors.Is(err, tt.err) {
+				t.Errorf("Scope(%s) = %d, %v, want %d, %v", tt.action, scope, err, tt.scope, tt.err)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	var (
+		operator = Identity{UserID: "OPRTR-1", UserType: schema.OPRTR, BusID: "BUS-1"}
+		customer = Identity{UserID: "CSTMR-1", UserType: schema.CSTMR}
+	)
+
+	tests := []struct {
+		name     string
+		identity Identity
+		action   Action
+		resource Resource
+		err      error
+	}{
+		{name: "own record", identity: customer, action: BOOKING_CANCEL, resource: Resource{UserID: "CSTMR-1"}},
+		{name: "record of another user", identity: customer, action: BOOKING_CANCEL, resource: Resource{UserID: "CSTMR-2"}, err: ErrForbidden},
+		{name: "record without an owner", identity: customer, action: BOOKING_CANCEL, err: ErrForbidden},
+		{name: "record of the bus line", identity: operator, action: BOOKING_CONFIRM, resource: Resource{BusID: "BUS-1"}},
+		{name: "record of another bus line", identity: operator, action: BOOKING_CONFIRM, resource: Resource{BusID: "BUS-2"}, err: ErrForbidden},
+		{name: "every record", identity: operator, action: BUS_READ, resource: Resource{BusID: "BUS-2"}},
+		{name: "action that is not allowed", identity: customer, action: BOOKING_CONFIRM, resource: Resource{UserID: "CSTMR-1"}, err: ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.identity.Authorize(tt.action, tt.resource)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Authorize() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
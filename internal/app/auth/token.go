@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TOKEN_ISSUER      = "bus-ticketing"    // The issuer of the tokens
+	TOKEN_TYPE        = "Bearer"           // The type of the access token on the Authorization header
+	ACCESS_TOKEN      = "access"           // The use of the access tokens
+	REFRESH_TOKEN     = "refresh"          // The use of the refresh tokens
+	ACCESS_TOKEN_TTL  = 15 * time.Minute   // The lifetime of an access token if ACCESS_TOKEN_TTL is not set
+	REFRESH_TOKEN_TTL = 7 * 24 * time.Hour // The lifetime of a refresh token if REFRESH_TOKEN_TTL is not set
+)
+
+var (
+	ErrInvalidToken = errors.New("the token is invalid")
+	ErrExpiredToken = errors.New("the token has expired")
+)
+
+// tokenHeader is the JOSE header of the tokens, only HS256 is supported.
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Configuration contains the signing key of the tokens and their lifetime.
+type Configuration struct {
+	SigningKey      string        `json:"signing_key"` // The HMAC key that signs the tokens
+	AccessTokenTTL  time.Duration `json:"-"`           // The lifetime of an access token
+	RefreshTokenTTL time.Duration `json:"-"`           // The lifetime of a refresh token
+}
+
+// Claims are the registered and private claims of a JSON Web Token (JWT).
+type Claims struct {
+	ID        string `json:"jti"`              // The unique ID of the token
+	Issuer    string `json:"iss"`              // The issuer of the token
+	Subject   string `json:"sub"`              // The user ID
+	Username  string `json:"username"`         // The username of the user account
+	UserType  string `json:"user_type"`        // The type of the user account
+	BusID     string `json:"bus_id,omitempty"` // The bus line of an OPERATOR user account
+	Session   int    `json:"sv"`               // The session version of the user account
+	TokenUse  string `json:"token_use"`        // Either access, refresh or mfa
+	IssuedAt  int64  `json:"iat"`              // The time it was issued in seconds since the Unix epoch
+	ExpiresAt int64  `json:"exp"`              // The time it expires in seconds since the Unix epoch
+
+	// Whether the login was verified with a TOTP code or a recovery code
+	MFA bool `json:"mfa,omitempty"`
+
+	// Whether the token can only enroll in MFA, since it is required on the user type
+	// of the user account but it is not enabled yet
+	MFAEnrollment bool `json:"mfa_enroll,omitempty"`
+}
+
+// Identity returns the identity of the user the token was issued to.
+func (claims Claims) Identity() Identity {
+	return Identity{
+		UserID:   claims.Subject,
+		Username: claims.Username,
+		UserType: claims.UserType,
+		BusID:    claims.BusID,
+		Session:  claims.Session,
+
+		MFA:           claims.MFA,
+		MFAEnrollment: claims.MFAEnrollment,
+	}
+}
+
+// Tokens are the tokens that are issued on login and when they are refreshed.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`  // The token that is sent as the "Authorization: Bearer" header
+	RefreshToken string `json:"refresh_token"` // The token that is exchanged for new tokens
+	TokenType    string `json:"token_type"`    // The type of the access token
+	ExpiresIn    int64  `json:"expires_in"`    // The lifetime of the access token in seconds
+
+	// Whether the tokens can only enroll in MFA until it is enabled on the user account
+	MFAEnrollment bool `json:"mfa_enrollment_required,omitempty"`
+}
+
+// MFAChallenge is the response of a login of a user account that has MFA enabled, the
+// MFA token is exchanged for the tokens with a TOTP code or a recovery code.
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"` // Always true
+	MFAToken    string `json:"mfa_token"`    // The token of the second step of the login
+	ExpiresIn   int64  `json:"expires_in"`   // The lifetime of the MFA token in seconds
+}
+
+// NewTokens issues a new access and refresh token to the identity.
+func NewTokens(cfg Configuration, identity Identity) (Tokens, error) {
+	var tokens = Tokens{TokenType: TOKEN_TYPE, ExpiresIn: int64(cfg.AccessTokenTTL.Seconds()), MFAEnrollment: identity.MFAEnrollment}
+
+	accessToken, err := SignToken(cfg, newClaims(identity, ACCESS_TOKEN, cfg.AccessTokenTTL))
+	if err != nil {
+		return tokens, err
+	}
+
+	refreshToken, err := SignToken(cfg, newClaims(identity, REFRESH_TOKEN, cfg.RefreshTokenTTL))
+	if err != nil {
+		return tokens, err
+	}
+
+	tokens.AccessToken = accessToken
+	tokens.RefreshToken = refreshToken
+
+	return tokens, nil
+}
+
+// NewMFAChallenge issues a new MFA token to the identity whose password has been
+// verified, it can only be exchanged for the tokens on the second step of the login.
+func NewMFAChallenge(cfg Configuration, identity Identity) (MFAChallenge, error) {
+	var challenge = MFAChallenge{MFARequired: true, ExpiresIn: int64(MFA_TOKEN_TTL.Seconds())}
+
+	// The MFA token never carries the MFA claims of the identity
+	identity.MFA = false
+	identity.MFAEnrollment = false
+
+	token, err := SignToken(cfg, newClaims(identity, MFA_TOKEN, MFA_TOKEN_TTL))
+	if err != nil {
+		return challenge, err
+	}
+
+	challenge.MFAToken = token
+	return challenge, nil
+}
+
+// newClaims returns the claims of a token of the identity that expires after the ttl.
+func newClaims(identity Identity, use string, ttl time.Duration) Claims {
+	var now = time.Now()
+
+	return Claims{
+		ID:        uuid.NewString(),
+		Issuer:    TOKEN_ISSUER,
+		Subject:   identity.UserID,
+		Username:  identity.Username,
+		UserType:  identity.UserType,
+		BusID:     identity.BusID,
+		Session:   identity.Session,
+		TokenUse:  use,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+
+		MFA:           identity.MFA,
+		MFAEnrollment: identity.MFAEnrollment,
+	}
+}
+
+// SignToken returns the JWT of the claims that is signed with HMAC SHA-256.
+//
+// Example:
+//  eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJqdGkiOiI...In0.Xb4v...
+func SignToken(cfg Configuration, claims Claims) (string, error) {
+	if cfg.SigningKey == "" {
+		return "", errors.New("the token signing key is not set")
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signature(cfg.SigningKey, unsigned), nil
+}
+
+// ParseToken verifies the signature, the issuer, the expiration and the use of the token,
+// and returns its claims. The signature is compared in constant time.
+func ParseToken(cfg Configuration, token, use string) (Claims, error) {
+	var claims Claims
+
+	if cfg.SigningKey == "" {
+		return claims, errors.New("the token signing key is not set")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return claims, ErrInvalidToken
+	}
+
+	expected := signature(cfg.SigningKey, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+
+	err = json.Unmarshal(payload, &claims)
+	if err != nil || claims.Issuer != TOKEN_ISSUER || claims.TokenUse != use || claims.Subject == "" {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+// BearerToken returns the token of the "Authorization: Bearer" header value.
+func BearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, TOKEN_TYPE) {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// signature returns the base64url-encoded HMAC SHA-256 of the value.
+func signature(key, value string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	var (
+		cfg      = Configuration{SigningKey: "signing-key"}
+		identity = Identity{UserID: "CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A", Username: "emilydavis", UserType: "CUSTOMER", Session: 3}
+	)
+
+	sign := func(t *testing.T, cfg Configuration, claims Claims) string {
+		token, err := SignToken(cfg, claims)
+		if err != nil {
+			t.Fatalf("SignToken() error = %v", err)
+		}
+
+		return token
+	}
+
+	valid := newClaims(identity, ACCESS_TOKEN, time.Minute)
+
+	expired := newClaims(identity, ACCESS_TOKEN, time.Minute)
+	expired.ExpiresAt = time.Now().Add(-time.Second).Unix()
+
+	otherIssuer := newClaims(identity, ACCESS_TOKEN, time.Minute)
+	otherIssuer.Issuer = "other"
+
+	noSubject := newClaims(Identity{}, ACCESS_TOKEN, time.Minute)
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+		use   string
+		err   error
+	}{
+		{
+			name:  "valid access token",
+			token: func(t *testing.T) string { return sign(t, cfg, valid) },
+			use:   ACCESS_TOKEN,
+		},
+		{
+			name:  "access token used as a refresh token",
+			token: func(t *testing.T) string { return sign(t, cfg, valid) },
+			use:   REFRESH_TOKEN,
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "refresh token used as an access token",
+			token: func(t *testing.T) string { return sign(t, cfg, newClaims(identity, REFRESH_TOKEN, time.Hour)) },
+			use:   ACCESS_TOKEN,
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "MFA token used as an access token",
+			token: func(t *testing.T) string { return sign(t, cfg, newClaims(identity, MFA_TOKEN, time.Minute)) },
+			use:   ACCESS_TOKEN,
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "expired token",
+			token: func(t *testing.T) string { return sign(t, cfg, expired) },
+			use:   ACCESS_TOKEN,
+			err:   ErrExpiredToken,
+		},
+		{
+			name:  "signed with another key",
+			token: func(t *testing.T) string { return sign(t, Configuration{SigningKey: "other-key"}, valid) },
+			use:   ACCESS_TOKEN,
+			err:   ErrInvalidToken,
+		},
+		{
+			name: "tampered payload",
+			token: func(t *testing.T) string {
+				parts := strings.Split(sign(t, cfg, valid), ".")
+
+				tampered := valid
+				tampered.UserType = "ADMIN"
+				parts[1] = strings.Split(sign(t, cfg, tampered), ".")[1]
+
+				return strings.Join(parts, ".")
+			},
+			use: ACCESS_TOKEN,
+			err: ErrInvalidToken,
+		},
+		{
+			name: "another algorithm in the header",
+			token: func(t *testing.T) string {
+				parts := strings.Split(sign(t, cfg, valid), ".")
+				parts[0] = "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0"
+
+				return strings.Join(parts, ".")
+			},
+			use: ACCESS_TOKEN,
+			err: ErrInvalidToken,
+		},
+		{
+			name:  "another issuer",
+			token: func(t *testing.T) string { return sign(t, cfg, otherIssuer) },
+			use:   ACCESS_TOKEN,
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "no subject",
+			token: func(t *testing.T) string { return sign(t, cfg, noSubject) },
+			use:   ACCESS_TOKEN,
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "not a JWT",
+			token: func(t *testing.T) string { return "not-a-token" },
+			use:   ACCESS_TOKEN,
+			err:   ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseToken(cfg, tt.token(t), tt.use)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseToken() error = %v, want %v", err, tt.err)
+			}
+
+			if tt.err != nil {
+				if claims != (Claims{}) {
+					t.Errorf("ParseToken() claims = %+v, want none", claims)
+				}
+
+				return
+			}
+
+			if claims.Identity() != identity {
+				t.Errorf("ParseToken() identity = %+v, want %+v", claims.Identity(), identity)
+			}
+		})
+	}
+}
+
+func TestParseTokenWithoutSigningKey(t *testing.T) {
+	_, err := ParseToken(Configuration{}, "a.b.c", ACCESS_TOKEN)
+	if err == nil {
+		t.Fatal("ParseToken() without a signing key, want an error")
+	}
+
+	_, err = SignToken(Configuration{}, Claims{})
+	if err == nil {
+		t.Fatal("SignToken() without a signing key, want an error")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{header: "Bearer abc.def.ghi", token: "abc.def.ghi", ok: true},
+		{header: "bearer  abc.def.ghi ", token: "abc.def.ghi", ok: true},
+		{header: "Basic dXNlcjpwYXNz"},
+		{header: "Bearer "},
+		{header: "abc.def.ghi"},
+		{header: ""},
+	}
+
+	for _, tt := range tests {
+		token, ok := BearerToken(tt.header)
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("BearerToken(%q) = %q, %v, want %q, %v", tt.header, token, ok, tt.token, tt.ok)
+		}
+	}
+}
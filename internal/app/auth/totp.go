@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TOTP_DIGITS         = 6                // The number of digits of a TOTP code
+	TOTP_PERIOD         = 30 * time.Second // The time step of a TOTP code
+	TOTP_SKEW           = 1                // The time steps before and after the current one that are accepted
+	TOTP_SECRET_SIZE    = 20               // The number of random bytes of a TOTP secret
+	RECOVERY_CODES      = 10               // The number of recovery codes that are issued
+	RECOVERY_CODE_SIZE  = 10               // The number of characters of a recovery code
+	MFA_TOKEN           = "mfa"            // The use of the tokens of the second step of the login
+	MFA_TOKEN_TTL       = 5 * time.Minute  // The lifetime of an MFA token
+	MFA_TOTP_ALGORITHM  = "SHA1"           // The HMAC algorithm of the TOTP codes on the authenticator app
+	RECOVERY_CODE_GROUP = 5                // The characters of a recovery code before the dash
+)
+
+var (
+	ErrMFARequired   = errors.New("multi-factor authentication must be enabled on this account")
+	ErrInvalidMFA    = errors.New("the verification code is incorrect")
+	ErrMFAEnabled    = errors.New("multi-factor authentication is already enabled")
+	ErrMFANotEnabled = errors.New("multi-factor authentication is not enabled")
+)
+
+// totpEncoding is the base32 encoding of the TOTP secrets, the authenticator apps
+// expect it without padding.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a new random TOTP secret that is encoded in base32.
+func NewTOTPSecret() (string, error) {
+	secret := make([]byte, TOTP_SECRET_SIZE)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPURI returns the "otpauth://" URI of the secret that is scanned as a QR code
+// by the authenticator apps.
+//
+// Example:
+//  otpauth://totp/bus-ticketing:emilydavis?algorithm=SHA1&digits=6&issuer=bus-ticketing&period=30&secret=JBSWY3DPEHPK3PXP...
+func TOTPURI(secret, username string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", TOKEN_ISSUER)
+	params.Set("algorithm", MFA_TOTP_ALGORITHM)
+	params.Set("digits", fmt.Sprint(TOTP_DIGITS))
+	params.Set("period", fmt.Sprint(int(TOTP_PERIOD.Seconds())))
+
+	label := url.PathEscape(TOKEN_ISSUER + ":" + username)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPStep returns the time step of the time since the Unix epoch.
+func TOTPStep(now time.Time) int64 {
+	return now.Unix() / int64(TOTP_PERIOD.Seconds())
+}
+
+// TOTPCode returns the RFC 6238 code of the secret at the time step, it is the
+// HOTP (RFC 4226) of the time step.
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation of the HMAC
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < TOTP_DIGITS; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TOTP_DIGITS, value%modulo), nil
+}
+
+// VerifyTOTP checks the code against the time steps around the time, and returns
+// the time step that it matches. A time step that is not after the last step that
+// was used is not accepted, so that a code cannot be used twice.
+func VerifyTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTP_DIGITS {
+		return 0, false
+	}
+
+	current := TOTPStep(now)
+	for step := current - TOTP_SKEW; step <= current+TOTP_SKEW; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// NewRecoveryCodes returns new single-use recovery codes and their hashes. Only the
+// hashes are stored, the codes are shown to the user once.
+//
+// Example:
+//  codes: [7kq2m-x9vtd, ...]
+func NewRecoveryCodes() ([]string, []string, error) {
+	var (
+		codes  []string
+		hashes []string
+		random = make([]byte, RECOVERY_CODE_SIZE)
+	)
+
+	for i := 0; i < RECOVERY_CODES; i++ {
+		_, err := rand.Read(random)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(totpEncoding.EncodeToString(random))[:RECOVERY_CODE_SIZE]
+		codes = append(codes, code[:RECOVERY_CODE_GROUP]+"-"+code[RECOVERY_CODE_GROUP:])
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the hash of the recovery code, the case, dashes and spaces
+// are ignored.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	return HashOneTimeToken(code)
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 secret of the test vectors of RFC 6238, "12345678901234567890"
+// encoded in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC 6238 test vectors have 8 digits, the codes are their last 6 digits
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{time: 59, code: "287082"},          // 94287082
+		{time: 1111111109, code: "081804"},  // 07081804
+		{time: 1111111111, code: "050471"},  // 14050471
+		{time: 1234567890, code: "005924"},  // 89005924
+		{time: 2000000000, code: "279037"},  // 69279037
+		{time: 20000000000, code: "353130"}, // 65353130
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.time, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode() at %d error = %v", tt.time, err)
+		}
+
+		if code != tt.code {
+			t.Errorf("TOTPCode() at %d = %s, want %s", tt.time, code, tt.code)
+		}
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	_, err := TOTPCode("not base32!", 1)
+	if err == nil {
+		t.Fatal("TOTPCode() of an invalid secret, want an error")
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	var (
+		now     = time.Unix(1234567890, 0)
+		current = TOTPStep(now)
+	)
+
+	codeAt := func(t *testing.T, step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     func(t *testing.T) string
+		lastStep int64
+		step     int64
+		ok       bool
+	}{
+		{
+			name: "current step",
+			code: func(t *testing.T) string { return codeAt(t, current) },
+			step: current,
+			ok:   true,
+		},
+		{
+			name: "previous step within the skew",
+			code: func(t *testing.T) string { return codeAt(t, current-TOTP_SKEW) },
+			step: current - TOTP_SKEW,
+			ok:   true,
+		},
+		{
+			name: "next step within the skew",
+			code: func(t *testing.T) string { return codeAt(t, current+TOTP_SKEW) },
+			step: current + TOTP_SKEW,
+			ok:   true,
+		},
+		{
+			name: "step before the skew",
+			code: func(t *testing.T) string { return codeAt(t, current-TOTP_SKEW-1) },
+		},
+		{
+			name: "step after the skew",
+			code: func(t *testing.T) string { return codeAt(t, current+TOTP_SKEW+1) },
+		},
+		{
+			name:     "code of the last step that was used",
+			code:     func(t *testing.T) string { return codeAt(t, current) },
+			lastStep: current,
+		},
+		{
+			name:     "code of a step before the last step that was used",
+			code:     func(t *testing.T) string { return codeAt(t, current-1) },
+			lastStep: current,
+		},
+		{
+			name: "surrounding spaces",
+			code: func(t *testing.T) string { return " " + codeAt(t, current) + " " },
+			step: current,
+			ok:   true,
+		},
+		{
+			name: "wrong number of digits",
+			code: func(t *testing.T) string { return "89005924" },
+		},
+		{
+			name: "wrong code",
+			code: func(t *testing.T) string { return "000000" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := VerifyTOTP(rfc6238Secret, tt.code(t), now, tt.lastStep)
+			if step != tt.step || ok != tt.ok {
+				t.Errorf("VerifyTOTP() = %d, %v, want %d, %v", step, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
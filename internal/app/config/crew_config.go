@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// DefaultMaxDuty is the maximum duty of a crew on a day if it is not configured.
+const DefaultMaxDuty = 10 * time.Hour
+
+// GetMaxDuty returns the maximum total duration of the trips that a crew can be
+// assigned to on a day.
+//
+// Environment variables:
+//  CREW_MAX_DUTY: the maximum duty of a day as a duration (e.g. 10h)
+func GetMaxDuty() (time.Duration, error) {
+	value := os.Getenv("CREW_MAX_DUTY")
+	if value == "" {
+		return DefaultMaxDuty, nil
+	}
+
+	duty, err := time.ParseDuration(value)
+	if err != nil || duty <= 0 {
+		err := fmt.Errorf("invalid CREW_MAX_DUTY environment variable value: %s", value)
+		utility.Error(err, "ConfigError", "invalid maximum duty of the crew")
+
+		return 0, err
+	}
+
+	return duty, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// GetLockoutPolicy returns the lockout policy of the failed login attempts, it is the
+// auth.DefaultLockoutPolicy with the environment variables that are set.
+//
+// Environment variables:
+//  LOGIN_MAX_FAILURES: the failed login attempts of a username before it is locked (e.g. 5)
+//  LOGIN_MAX_IP_FAILURES: the failed login attempts of a source IP before it is locked (e.g. 20)
+//  LOGIN_LOCKOUT_DURATION: how long a username or source IP is locked as a duration (e.g. 15m)
+func GetLockoutPolicy() (auth.LockoutPolicy, error) {
+	var (
+		err    error
+		policy = auth.DefaultLockoutPolicy()
+	)
+
+	policy.MaxFailures, err = maxFailures("LOGIN_MAX_FAILURES", policy.MaxFailures)
+	if err != nil {
+		return policy, err
+	}
+
+	policy.MaxSourceIPFailures, err = maxFailures("LOGIN_MAX_IP_FAILURES", policy.MaxSourceIPFailures)
+	if err != nil {
+		return policy, err
+	}
+
+	policy.Lockout, err = tokenTTL("LOGIN_LOCKOUT_DURATION", policy.Lockout)
+	if err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+// maxFailures returns the positive number of the environment variable, or the fallback
+// if it is not set.
+func maxFailures(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	failures, err := strconv.Atoi(value)
+	if err != nil || failures <= 0 {
+		err := fmt.Errorf("invalid %s environment variable value: %s", key, value)
+		utility.Error(err, "ConfigError", "invalid number of failed login attempts")
+
+		return 0, err
+	}
+
+	return failures, nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// IsMFARequired checks if the MFA is required on the user type. It is always required
+// on an ADMIN, and on an OPERATOR if the environment variable is set.
+//
+// Environment variables:
+//  MFA_REQUIRED_OPERATOR: whether the MFA is required on an OPERATOR (e.g. true)
+func IsMFARequired(userType string) (bool, error) {
+	switch userType {
+	case schema.ADMN:
+		return true, nil
+
+	case schema.OPRTR:
+		value := os.Getenv("MFA_REQUIRED_OPERATOR")
+		if value == "" {
+			return false, nil
+		}
+
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			err := fmt.Errorf("invalid MFA_REQUIRED_OPERATOR environment variable value: %s", value)
+			utility.Error(err, "ConfigError", "invalid MFA requirement of the OPERATOR")
+
+			return false, err
+		}
+
+		return required, nil
+	}
+
+	return false, nil
+}
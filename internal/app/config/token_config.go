@@ -0,0 +1,143 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+var (
+	tokenConfig      *auth.Configuration
+	tokenConfigMutex sync.Mutex
+)
+
+// GetTokenConfig checks if the Token Secrets Manager is configured on the environment,
+// fetches the token signing key, and returns the token configuration. It is fetched once
+// and reused by the next invocations of the Lambda Function.
+//
+// Environment variables:
+//  TOKEN_SECRET: the secret that has the "signing_key" of the tokens
+//  ACCESS_TOKEN_TTL: the lifetime of an access token as a duration (e.g. 15m)
+//  REFRESH_TOKEN_TTL: the lifetime of a refresh token as a duration (e.g. 168h)
+func GetTokenConfig(ctx context.Context) (auth.Configuration, error) {
+	tokenConfigMutex.Lock()
+	defer tokenConfigMutex.Unlock()
+
+	if tokenConfig != nil {
+		return *tokenConfig, nil
+	}
+
+	var (
+		cfg         auth.Configuration
+		tokensecret = os.Getenv("TOKEN_SECRET")
+	)
+
+	// Check if the Token SecretsManager is configured
+	if tokensecret == "" {
+		err := errors.New("secretsmanager TOKEN_SECRET environment variable is not set")
+		trail.Error("secretsmanager TOKEN_SECRET is not configured on the environment")
+
+		return cfg, err
+	}
+
+	var err error
+	cfg.AccessTokenTTL, err = tokenTTL("ACCESS_TOKEN_TTL", auth.ACCESS_TOKEN_TTL)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.RefreshTokenTTL, err = tokenTTL("REFRESH_TOKEN_TTL", auth.REFRESH_TOKEN_TTL)
+	if err != nil {
+		return cfg, err
+	}
+
+	// Get the token secret values
+	result, err := awswrapper.SecretGetValue(ctx, tokensecret)
+	if err != nil {
+		utility.Error(err, "SMError", "failed to fetch the token secret")
+		return cfg, err
+	}
+
+	// Unmarshal the token secret values
+	err = utility.ParseJSON([]byte(*result.SecretString), &cfg)
+	if err != nil {
+		utility.Error(err, "JSONError", "failed to unmarshal the JSON-encoded secret")
+		return cfg, err
+	}
+
+	if cfg.SigningKey == "" {
+		err := errors.New("the token secret has no signing_key")
+		utility.Error(err, "SMError", "the token signing key is not set")
+
+		return cfg, err
+	}
+
+	tokenConfig = &cfg
+	return cfg, nil
+}
+
+// GetResetTokenTTL returns the lifetime of a password reset token.
+//
+// Environment variables:
+//  RESET_TOKEN_TTL: the lifetime of a password reset token as a duration (e.g. 1h)
+func GetResetTokenTTL() (time.Duration, error) {
+	return tokenTTL("RESET_TOKEN_TTL", auth.RESET_TOKEN_TTL)
+}
+
+// GetVerifyTokenTTL returns the lifetime of an e-mail verification token.
+//
+// Environment variables:
+//  VERIFY_TOKEN_TTL: the lifetime of an e-mail verification token as a duration (e.g. 24h)
+func GetVerifyTokenTTL() (time.Duration, error) {
+	return tokenTTL("VERIFY_TOKEN_TTL", auth.VERIFY_TOKEN_TTL)
+}
+
+// GetVerificationGrace returns how long a CUSTOMER can still book after its user account
+// was created without verifying its e-mail address. The bookings are blocked right away
+// if it is not set.
+//
+// Environment variables:
+//  EMAIL_VERIFICATION_GRACE: the grace period as a duration (e.g. 24h), 0 to block right away
+func GetVerificationGrace() (time.Duration, error) {
+	value := os.Getenv("EMAIL_VERIFICATION_GRACE")
+	if value == "" {
+		return 0, nil
+	}
+
+	grace, err := time.ParseDuration(value)
+	if err != nil || grace < 0 {
+		err := fmt.Errorf("invalid EMAIL_VERIFICATION_GRACE environment variable value: %s", value)
+		utility.Error(err, "ConfigError", "invalid e-mail verification grace period")
+
+		return 0, err
+	}
+
+	return grace, nil
+}
+
+// tokenTTL returns the positive duration of the environment variable, or the fallback
+// if it is not set.
+func tokenTTL(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		err := fmt.Errorf("invalid %s environment variable value: %s", key, value)
+		utility.Error(err, "ConfigError", "invalid token lifetime")
+
+		return 0, err
+	}
+
+	return ttl, nil
+}
@@ -2,6 +2,7 @@ package email
 
 import (
 	"fmt"
+	"mime/quotedprintable"
 	"net/smtp"
 	"strings"
 	"time"
@@ -53,12 +54,24 @@ func (email Configuration) message() []byte {
 	msg += fmt.Sprintf("Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
 	msg += fmt.Sprintf("Subject: %s\r\n", email.Content.Subject)
 	msg += MIME + "\r\n"
-	msg += email.Content.Message
+	msg += quotedPrintable(email.Content.Message)
 	msg += "\r\n\r\n"
 
 	return []byte(msg)
 }
 
+// quotedPrintable encodes the message as the Content-Transfer-Encoding of the MIME,
+// otherwise the "=" of the links would be decoded as an escape sequence.
+func quotedPrintable(message string) string {
+	var buffer strings.Builder
+
+	writer := quotedprintable.NewWriter(&buffer)
+	_, _ = writer.Write([]byte(message))
+	_ = writer.Close()
+
+	return buffer.String()
+}
+
 // Send authenticates and connects to the server (e.g. smtp.gmail.com:587) and
 // sends an e-mail from address "from", to addresses "to", with message.
 func (email Configuration) Send() error {
@@ -0,0 +1,29 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+)
+
+// AccountLocked returns e-mail content for the user account that has been locked
+// temporarily after too many failed login attempts.
+func AccountLocked(user schema.User, sourceIP string, until time.Time, customerSupport string) string {
+	var msg string
+
+	msg = fmt.Sprintf("Hello %s,\n", user.FirstName)
+	msg += fmt.Sprintf("Your account <b>%s</b> has been temporarily locked after too many failed login attempts with the following details:\n\n", user.Username)
+
+	msg += fmt.Sprintf("\t\tSource IP: %s\n", sourceIP)
+	msg += fmt.Sprintf("\t\tLocked Until: %s\n\n", until.UTC().Format("02 Jan 2006 15:04:05 MST"))
+
+	msg += "You can log in again once the lockout ends. If these attempts were not made by you, we recommend that you reset your password as soon as possible.\n\n"
+	msg += fmt.Sprintf("If you need the account to be unlocked sooner, or have any questions, please feel free to contact our customer support team at %s.\n", customerSupport)
+
+	msg = strings.ReplaceAll(msg, "\n", "<br/>")
+	msg = strings.ReplaceAll(msg, "\t", "&nbsp;&nbsp;&nbsp;&nbsp;")
+
+	return msg
+}
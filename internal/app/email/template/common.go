@@ -13,7 +13,13 @@ func bookingDetails(user schema.User, route schema.BusRoute, booking schema.Book
 
 	details += fmt.Sprintf("<b>Passenger Name</b>: %s %s\n", user.FirstName, user.LastName)
 	details += fmt.Sprintf("<b>Bus Number</b>: %s\n", route.BusUnitID)
-	details += fmt.Sprintf("<b>Seat Number(s)</b>: %s\n\n", booking.SeatNumber)
+	details += fmt.Sprintf("<b>Seat Number(s)</b>: %s\n", booking.SeatNumber)
+
+	if booking.Currency != "" {
+		details += fmt.Sprintf("<b>Total Amount</b>: %s %.2f\n", booking.Currency, booking.TotalAmount)
+	}
+
+	details += "\n"
 
 	// *********************************************************** //
 	// ******************** Departure Detials ******************** //
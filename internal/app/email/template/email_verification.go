@@ -0,0 +1,28 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+)
+
+// EmailVerification returns e-mail content for the verification of the e-mail address
+// of the user account, it has the verification link and how long it is valid for.
+func EmailVerification(user schema.User, link string, ttl time.Duration, customerSupport string) string {
+	var msg string
+
+	msg = fmt.Sprintf("Hello %s,\n", user.FirstName)
+	msg += fmt.Sprintf("Thank you for creating your account <b>%s</b>. Please verify your e-mail address by opening the following link:\n\n", user.Username)
+
+	msg += fmt.Sprintf("\t\t<a href=\"%s\">%s</a>\n\n", link, link)
+
+	msg += fmt.Sprintf("The link can only be used once and it expires in %.0f hours. You will not be able to book a trip until your e-mail address is verified.\n\n", ttl.Hours())
+	msg += fmt.Sprintf("If you did not create this account, you can ignore this e-mail. If you have any questions, please feel free to contact our customer support team at %s.\n", customerSupport)
+
+	msg = strings.ReplaceAll(msg, "\n", "<br/>")
+	msg = strings.ReplaceAll(msg, "\t", "&nbsp;&nbsp;&nbsp;&nbsp;")
+
+	return msg
+}
@@ -0,0 +1,28 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+)
+
+// PasswordReset returns e-mail content for the password reset request of the
+// user account, it has the reset token and how long it is valid for.
+func PasswordReset(user schema.User, token string, ttl time.Duration, customerSupport string) string {
+	var msg string
+
+	msg = fmt.Sprintf("Hello %s,\n", user.FirstName)
+	msg += fmt.Sprintf("We have received a request to reset the password of your account <b>%s</b>. Please use the following token to set a new password:\n\n", user.Username)
+
+	msg += fmt.Sprintf("\t\t<b>%s</b>\n\n", token)
+
+	msg += fmt.Sprintf("The token can only be used once and it expires in %.0f minutes. Once your password is reset, you will be signed out of every device.\n\n", ttl.Minutes())
+	msg += fmt.Sprintf("If you did not request a password reset, you can ignore this e-mail and your password will stay the same. If you have any questions, please feel free to contact our customer support team at %s.\n", customerSupport)
+
+	msg = strings.ReplaceAll(msg, "\n", "<br/>")
+	msg = strings.ReplaceAll(msg, "\t", "&nbsp;&nbsp;&nbsp;&nbsp;")
+
+	return msg
+}
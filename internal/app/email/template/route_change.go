@@ -0,0 +1,77 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+)
+
+// RescheduledBooking returns e-mail content for the booking whose bus route has a new
+// departure or arrival time.
+func RescheduledBooking(user schema.User, change schema.BusRouteChange, booking schema.Bookings, customerSupport string) string {
+	var msg string
+
+	msg = fmt.Sprintf("Hello %s,\n", user.FirstName)
+	msg += "We would like to inform you that the schedule of your bus trip has been changed. Your booking has the following new details:\n\n"
+
+	msg += bookingDetails(user, change.Route, booking)
+
+	msg += "<b>Previous Schedule</b>\n"
+	msg += fmt.Sprintf("\t\tDeparture: %s\n", change.PreviousDepartureTime)
+	msg += fmt.Sprintf("\t\tArrival:&nbsp;&nbsp;&nbsp;\t%s\n\n", change.PreviousArrivalTime)
+
+	msg += "Your booking remains valid on the new schedule, and there is nothing you need to do. We apologize for any inconvenience this change may cause.\n"
+	msg += fmt.Sprintf("If the new schedule does not suit your travel plans, please contact our customer support team at %s.\n", customerSupport)
+
+	msg = strings.ReplaceAll(msg, "\n", "<br/>")
+	msg = strings.ReplaceAll(msg, "\t", "&nbsp;&nbsp;&nbsp;&nbsp;")
+
+	return msg
+}
+
+// RouteCancelledBooking returns e-mail content for the booking that is cancelled
+// because its bus route has been deactivated.
+func RouteCancelledBooking(user schema.User, change schema.BusRouteChange, booking schema.Bookings, customerSupport string) string {
+	var msg string
+
+	msg = fmt.Sprintf("Hello %s,\n", user.FirstName)
+	msg += "We regret to inform you that your bus booking with the following details has been cancelled, because the bus route is no longer in service:\n\n"
+
+	msg += bookingDetails(user, change.Route, booking)
+
+	msg += fmt.Sprintf("<b>Reason</b>: %s\n", change.Reason)
+	if booking.Cancelled.RefundPolicy == schema.REFUND_FULL && booking.Currency != "" {
+		msg += fmt.Sprintf("<b>Refund</b>: %s %.2f (full refund)\n", booking.Currency, booking.Cancelled.RefundAmount)
+	}
+	msg += "\n"
+
+	msg += "We apologize for any inconvenience caused by this cancellation. The full amount of your booking will be refunded to you.\n"
+	msg += fmt.Sprintf("If you have any further questions or require assistance, please feel free to contact our customer support team at %s.\n", customerSupport)
+
+	msg = strings.ReplaceAll(msg, "\n", "<br/>")
+	msg = strings.ReplaceAll(msg, "\t", "&nbsp;&nbsp;&nbsp;&nbsp;")
+
+	return msg
+}
+
+// RebookBooking returns e-mail content for the booking that has to be rebooked to
+// another bus route because its bus route has been deactivated.
+func RebookBooking(user schema.User, change schema.BusRouteChange, booking schema.Bookings, customerSupport string) string {
+	var msg string
+
+	msg = fmt.Sprintf("Hello %s,\n", user.FirstName)
+	msg += "We regret to inform you that the bus route of your booking with the following details is no longer in service:\n\n"
+
+	msg += bookingDetails(user, change.Route, booking)
+
+	msg += fmt.Sprintf("<b>Reason</b>: %s\n\n", change.Reason)
+
+	msg += "Your booking has not been cancelled. Our team will rebook you on another bus route to your destination and will contact you with the new details.\n"
+	msg += fmt.Sprintf("If you would rather cancel your booking or require assistance, please contact our customer support team at %s.\n", customerSupport)
+
+	msg = strings.ReplaceAll(msg, "\n", "<br/>")
+	msg = strings.ReplaceAll(msg, "\t", "&nbsp;&nbsp;&nbsp;&nbsp;")
+
+	return msg
+}
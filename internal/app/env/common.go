@@ -3,10 +3,20 @@ package env
 import "os"
 
 var (
-	BUS_TABLE               = os.Getenv("BUS_TABLE")
-	USERS_TABLE             = os.Getenv("USERS_TABLE")
-	BUS_UNIT                = os.Getenv("BUS_UNIT_TABLE")
-	BUS_ROUTE_TABLE         = os.Getenv("BUS_ROUTE_TABLE")
-	BOOKING_TABLE           = os.Getenv("BOOKING_TABLE")
-	BOOKING_CANCELLED_TABLE = os.Getenv("BOOKING_CANCELLED_TABLE")
+	BUS_TABLE                = os.Getenv("BUS_TABLE")
+	USERS_TABLE              = os.Getenv("USERS_TABLE")
+	BUS_UNIT                 = os.Getenv("BUS_UNIT_TABLE")
+	BUS_ROUTE_TABLE          = os.Getenv("BUS_ROUTE_TABLE")
+	BOOKING_TABLE            = os.Getenv("BOOKING_TABLE")
+	BOOKING_CANCELLED_TABLE  = os.Getenv("BOOKING_CANCELLED_TABLE")
+	UNIQUE_KEY_TABLE         = os.Getenv("UNIQUE_KEY_TABLE")
+	PASSWORD_RESET_TABLE     = os.Getenv("PASSWORD_RESET_TABLE")
+	EMAIL_VERIFICATION_TABLE = os.Getenv("EMAIL_VERIFICATION_TABLE")
+	LOGIN_ATTEMPT_TABLE      = os.Getenv("LOGIN_ATTEMPT_TABLE")
+	NOTIFICATION_TABLE       = os.Getenv("NOTIFICATION_TABLE")
+	USER_AUDIT_TABLE         = os.Getenv("USER_AUDIT_TABLE")
+	MAINTENANCE_TABLE        = os.Getenv("MAINTENANCE_TABLE")
+	SEAT_LAYOUT_TABLE        = os.Getenv("SEAT_LAYOUT_TABLE")
+	CREW_TABLE               = os.Getenv("CREW_TABLE")
+	CREW_ASSIGNMENT_TABLE    = os.Getenv("CREW_ASSIGNMENT_TABLE")
 )
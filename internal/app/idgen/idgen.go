@@ -0,0 +1,145 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ULID_SIZE   = 26                                 // The number of characters of a ULID
+	TIME_SIZE   = 10                                 // The characters of the timestamp of a ULID
+	RANDOM_SIZE = 10                                 // The random bytes of a ULID
+	SEPARATOR   = "-"                                // The separator between the prefix and the ULID
+	ENCODING    = "0123456789ABCDEFGHJKMNPQRSTVWXYZ" // Crockford's base32 alphabet of a ULID
+)
+
+// ErrInvalidID is returned when the ID does not end with a ULID.
+var ErrInvalidID = errors.New("the ID is invalid")
+
+// generator keeps the last timestamp and random bytes, so that the ULIDs of the same
+// millisecond are still sorted in the order they were generated.
+var generator struct {
+	sync.Mutex
+	lastTime   uint64
+	lastRandom [RANDOM_SIZE]byte
+}
+
+// New returns a new ID with the prefix, it is the prefix and a ULID. The IDs of the
+// same prefix are sorted by the time they were generated.
+//
+// Example:
+//  New("CSTMR")
+//  CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+func New(prefix string) (string, error) {
+	ulid, err := NewULID(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	if prefix == "" {
+		return ulid, nil
+	}
+
+	return prefix + SEPARATOR + ulid, nil
+}
+
+// NewULID returns a new ULID of the time, it is a 48-bit timestamp in milliseconds
+// and 80 random bits that are encoded in Crockford's base32. The random bits of a
+// ULID of the same millisecond as the last one are incremented, so that it is still
+// greater than the last one.
+func NewULID(now time.Time) (string, error) {
+	generator.Lock()
+	defer generator.Unlock()
+
+	var (
+		random    [RANDOM_SIZE]byte
+		timestamp = uint64(now.UnixMilli())
+	)
+
+	if timestamp == generator.lastTime && increment(&generator.lastRandom) {
+		random = generator.lastRandom
+	} else {
+		_, err := rand.Read(random[:])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	generator.lastTime = timestamp
+	generator.lastRandom = random
+
+	return encode(timestamp, random), nil
+}
+
+// Time returns the time that the ID was generated at.
+func Time(id string) (time.Time, error) {
+	if len(id) < ULID_SIZE {
+		return time.Time{}, ErrInvalidID
+	}
+
+	var timestamp uint64
+	for _, char := range id[len(id)-ULID_SIZE : len(id)-ULID_SIZE+TIME_SIZE] {
+		value := strings.IndexRune(ENCODING, char)
+		if value < 0 {
+			return time.Time{}, ErrInvalidID
+		}
+
+		timestamp = timestamp<<5 | uint64(value)
+	}
+
+	return time.UnixMilli(int64(timestamp)), nil
+}
+
+// Prefix returns the prefix of the ID, it is empty if the ID has no prefix.
+//
+// Example:
+//  Prefix("CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A")
+//  CSTMR
+func Prefix(id string) string {
+	if len(id) <= ULID_SIZE+len(SEPARATOR) {
+		return ""
+	}
+
+	return strings.TrimSuffix(id[:len(id)-ULID_SIZE], SEPARATOR)
+}
+
+// increment adds one to the random bytes, and returns false if they overflow.
+func increment(random *[RANDOM_SIZE]byte) bool {
+	for i := RANDOM_SIZE - 1; i >= 0; i-- {
+		random[i]++
+		if random[i] != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encode returns the Crockford's base32 of the 48-bit timestamp and the 80 random bits,
+// five bits per character from the most significant bit.
+func encode(timestamp uint64, random [RANDOM_SIZE]byte) string {
+	var ulid [ULID_SIZE]byte
+
+	for i := TIME_SIZE - 1; i >= 0; i-- {
+		ulid[i] = ENCODING[timestamp&0x1f]
+		timestamp >>= 5
+	}
+
+	// The 80 random bits are encoded as two 40-bit halves of 8 characters
+	for half := 0; half < 2; half++ {
+		var bits uint64
+		for _, b := range random[half*5 : half*5+5] {
+			bits = bits<<8 | uint64(b)
+		}
+
+		for i := 7; i >= 0; i-- {
+			ulid[TIME_SIZE+half*8+i] = ENCODING[bits&0x1f]
+			bits >>= 5
+		}
+	}
+
+	return string(ulid[:])
+}
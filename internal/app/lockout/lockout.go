@@ -0,0 +1,170 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/email/template"
+	"github.com/rmarasigan/bus-ticketing/internal/app/notification"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// Keys returns the keys of the failed login attempts of the username and the source
+// IP of the request.
+func Keys(username, sourceIP string) []string {
+	var keys = []string{schema.UsernameAttemptKey(username)}
+
+	if sourceIP != "" {
+		keys = append(keys, schema.SourceIPAttemptKey(sourceIP))
+	}
+
+	return keys
+}
+
+// Reservation is a login attempt that has been counted as a failure of its key before the
+// credentials are verified, so that the concurrent login attempts cannot get around the
+// backoff. It is released if the credentials turn out to be valid.
+type Reservation struct {
+	Previous schema.LoginAttempt // The failed login attempts before the reservation
+	Attempt  schema.LoginAttempt // The failed login attempts that count the reservation
+}
+
+// Reserve fetches the failed login attempts of the keys, and counts the login attempt
+// as a failure of every key before the credentials are verified. It returns how long the
+// login has to wait during the backoff or the lockout, or while a concurrent login attempt
+// has been counted first. The credentials are not verified while it is more than zero.
+func Reserve(ctx context.Context, policy auth.LockoutPolicy, keys []string, now time.Time) ([]Reservation, time.Duration, error) {
+	var reservations []Reservation
+
+	for _, key := range keys {
+		attempt, err := query.GetLoginAttempt(ctx, key)
+		if err != nil {
+			utility.Error(err, "DynamoDBError", "failed to fetch the login attempts", utility.KVP{Key: "key", Value: key})
+			Release(ctx, reservations)
+
+			return nil, 0, err
+		}
+
+		// The key is set even if there have been no failures yet
+		attempt.Key = key
+
+		retryAfter := policy.RetryAfter(attempt, now)
+		if retryAfter <= 0 {
+			reserved, err := query.ReserveLoginAttempt(ctx, attempt, policy, now)
+			if err == nil {
+				reservations = append(reservations, Reservation{Previous: attempt, Attempt: reserved})
+				continue
+			}
+
+			if !errors.Is(err, query.ErrVersionMismatch) {
+				attempt.Error(err, "DynamoDBError", "failed to reserve the login attempt")
+				Release(ctx, reservations)
+
+				return nil, 0, err
+			}
+
+			// A concurrent login attempt has been counted first
+			retryAfter = policy.Backoff(attempt.Failures + 1)
+		}
+
+		utility.Info("LoginThrottled", auth.ErrTooManyAttempts.Error(), utility.KVP{Key: "key", Value: key},
+			utility.KVP{Key: "retry_after", Value: retryAfter.String()})
+
+		// The login attempt is not counted on the other keys either
+		Release(ctx, reservations)
+
+		return nil, retryAfter, nil
+	}
+
+	return reservations, 0, nil
+}
+
+// Release restores the failed login attempts of the reservations, once the credentials
+// of the login attempt turn out to be valid. The errors are only logged, the login
+// attempt then stays counted as a failure.
+func Release(ctx context.Context, reservations []Reservation) {
+	for _, reservation := range reservations {
+		_, err := query.ReleaseLoginAttempt(ctx, reservation.Previous, reservation.Attempt)
+		if err != nil {
+			reservation.Attempt.Error(err, "DynamoDBError", "failed to release the login attempt")
+		}
+	}
+}
+
+// RecordFailure locks the username or the source IP of the reservation once it has
+// reached the maximum failures of the lockout policy, the failed login attempt has
+// already been counted by Reserve. The lockout is logged, and the owner of the user
+// account of the username is notified by e-mail. The errors are only logged so that
+// the response stays the same.
+func RecordFailure(ctx context.Context, reservation Reservation, policy auth.LockoutPolicy, username, sourceIP string, now time.Time) {
+	var (
+		attempt     = reservation.Attempt
+		isUsername  = attempt.Key == schema.UsernameAttemptKey(username)
+		maxFailures = policy.MaxSourceIPFailures
+	)
+
+	if isUsername {
+		maxFailures = policy.MaxFailures
+	}
+
+	if attempt.Failures < maxFailures {
+		return
+	}
+
+	until := now.Add(policy.Lockout)
+
+	locked, err := query.LockLoginAttempt(ctx, attempt.Key, until, now)
+	if err != nil || !locked {
+		if err != nil {
+			attempt.Error(err, "DynamoDBError", "failed to lock the login attempts")
+		}
+
+		return
+	}
+
+	utility.Info("LoginLocked", "too many failed login attempts", utility.KVP{Key: "key", Value: attempt.Key},
+		utility.KVP{Key: "failures", Value: attempt.Failures}, utility.KVP{Key: "source_ip", Value: sourceIP},
+		utility.KVP{Key: "locked_until", Value: until.Unix()})
+
+	if isUsername {
+		notifyLockout(ctx, username, sourceIP, until)
+	}
+}
+
+// notifyLockout sends an e-mail to the owner of the user account of the username that
+// has been locked. There is no e-mail if the username does not exist.
+func notifyLockout(ctx context.Context, username, sourceIP string, until time.Time) {
+	account, err := query.GetUserAccountByUsername(ctx, username)
+	if err != nil || account.ID == "" || account.Email == "" {
+		if err != nil {
+			utility.Error(err, "DynamoDBError", "failed to fetch the user account record", utility.KVP{Key: "username", Value: username})
+		}
+
+		return
+	}
+
+	// Fetch email configuration
+	email, err := config.GetEmailConfig(ctx)
+	if err != nil {
+		account.Error(err, "EmailError", "failed to fetch and set the email configuration")
+		return
+	}
+
+	// Set the email content
+	email.Content.To = append(email.Content.To, account.Email)
+	email.Content.Subject = "ACCOUNT TEMPORARILY LOCKED"
+	email.Content.Message = template.AccountLocked(account, sourceIP, until, email.CustomerSupport)
+
+	// Send email to the client
+	err = notification.Send(ctx, email, schema.NewNotification(account, schema.NOTIFY_ACCOUNT_LOCKED, ""))
+	if err != nil {
+		email.Error(err, "EmailError", "failed to send email to client")
+		return
+	}
+	utility.Info("LoginLocked", "Successfully notified the owner of the locked user account", utility.KVP{Key: "id", Value: account.ID})
+}
@@ -0,0 +1,63 @@
+package mfa
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// Code is the second factor of a request, either a TOTP code of the authenticator app
+// or a recovery code.
+type Code struct {
+	Code         string `json:"code"`          // The TOTP code of the authenticator app
+	RecoveryCode string `json:"recovery_code"` // A recovery code that has not been used
+}
+
+// IsEmpty checks if the request has no second factor.
+func (code Code) IsEmpty() bool {
+	return code.Code == "" && code.RecoveryCode == ""
+}
+
+// Verify checks the TOTP code or the recovery code against the MFA of the user account,
+// and marks it as used so that it cannot be used twice. The user account must have been
+// fetched with its MFA, e.g. by query.GetUserAccountById.
+func Verify(ctx context.Context, account schema.User, code Code, now time.Time) (bool, error) {
+	if !account.MFAEnabled || account.MFA == nil || account.MFA.Secret == "" {
+		return false, nil
+	}
+
+	// Create a composite key that has both the partition/primary key
+	// and the sort key of the item.
+	var compositKey = map[string]types.AttributeValue{
+		"id":       &types.AttributeValueMemberS{Value: account.ID},
+		"username": &types.AttributeValueMemberS{Value: account.Username},
+	}
+
+	if code.Code != "" {
+		step, ok := auth.VerifyTOTP(account.MFA.Secret, code.Code, now, account.MFA.LastStep)
+		if !ok {
+			return false, nil
+		}
+
+		return query.UseUserTOTP(ctx, compositKey, step)
+	}
+
+	if code.RecoveryCode == "" {
+		return false, nil
+	}
+
+	used, remaining, err := query.UseUserRecoveryCode(ctx, compositKey, auth.HashRecoveryCode(code.RecoveryCode))
+	if err != nil || !used {
+		return false, err
+	}
+
+	utility.Info("MFARecoveryCode", "a recovery code has been used", utility.KVP{Key: "id", Value: account.ID},
+		utility.KVP{Key: "remaining", Value: remaining})
+
+	return true, nil
+}
@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/email"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// Send sends the e-mail to the user account of the notification and records it, so
+// that it is part of the personal data export of the user account. A failure to
+// record it is only logged since the e-mail has already been sent.
+//
+// There is no e-mail if it has no recipient, e.g. the user account has been erased.
+func Send(ctx context.Context, mail email.Configuration, notification schema.Notification) error {
+	var recipients []string
+	for _, to := range mail.Content.To {
+		if to != "" {
+			recipients = append(recipients, to)
+		}
+	}
+
+	mail.Content.To = recipients
+	if len(recipients) == 0 {
+		utility.Info("Notification", "the user account has no e-mail address", utility.KVP{Key: "user_id", Value: notification.UserID},
+			utility.KVP{Key: "type", Value: notification.Type})
+
+		return nil
+	}
+
+	err := mail.Send()
+	if err != nil {
+		return err
+	}
+
+	err = notification.SetSent(mail.Content.To, mail.Content.Subject)
+	if err != nil {
+		notification.Error(err, "Notification", "failed to set the notification that has been sent")
+		return nil
+	}
+
+	err = query.CreateNotification(ctx, notification)
+	if err != nil {
+		notification.Error(err, "DynamoDBError", "failed to record the notification that has been sent")
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// TRANSACT_WRITE_LIMIT is the maximum number of items of a single TransactWriteItems request.
+const TRANSACT_WRITE_LIMIT = 100
+
+// ErrUnprocessedItem is returned for an item that has still not been processed
+// after all of the retries of the batch write.
+var ErrUnprocessedItem = awswrapper.ErrDynamoDBUnprocessedItem
+
+// batchItem is an item of the batch write and its index in the list of items.
+type batchItem struct {
+	index      int
+	values     map[string]types.AttributeValue
+	uniqueKeys []string
+}
+
+// BatchInsertItems converts every item into a DynamoDB AttributeValue map and writes them
+// in chunks of DYNAMODB_BATCH_WRITE_LIMIT items. The items that were not processed are retried with
+// an exponential backoff.
+//
+// If the mode is create-only, every chunk is written with the DynamoDB TransactWriteItems
+// operation so that an existing item is never replaced, and the items that already exist
+// fail with ErrAlreadyExists. Otherwise, the DynamoDB BatchWriteItem operation is used.
+// The unique keys of the mode are not supported since they are the same for every item,
+// but the values of its unique attributes are recorded in the UNIQUE_KEY_TABLE together
+// with their item.
+//
+// It returns the error of every item that was not inserted by the index of the item.
+// The items must not contain the same primary key more than once.
+func BatchInsertItems(ctx context.Context, tablename string, data []interface{}, mode InsertMode) map[int]error {
+	var (
+		items  []batchItem
+		failed = make(map[int]error)
+	)
+
+	for i, item := range data {
+		values, err := awswrapper.DynamoDBMarshalMap(item)
+		if err != nil {
+			trail.Error("failed to marshal data to a map of AttributeValues")
+			failed[i] = err
+
+			continue
+		}
+
+		items = append(items, batchItem{index: i, values: values, uniqueKeys: InsertMode{UniqueAttributes: mode.UniqueAttributes}.uniqueKeys(values)})
+	}
+
+	if mode.PartitionKey == "" {
+		batchInsert(ctx, tablename, items, failed)
+		return failed
+	}
+
+	// Every unique attribute of an item is another item of the transaction
+	size := awswrapper.DYNAMODB_BATCH_WRITE_LIMIT
+	if TRANSACT_WRITE_LIMIT/(1+len(mode.UniqueAttributes)) < size {
+		size = TRANSACT_WRITE_LIMIT / (1 + len(mode.UniqueAttributes))
+	}
+
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+
+		transactInsertChunk(ctx, tablename, items[start:end], mode.PartitionKey, failed)
+	}
+
+	return failed
+}
+
+// batchInsert inserts the items with the DynamoDB BatchWriteItem operation, and sets
+// the error of every item that was not inserted.
+func batchInsert(ctx context.Context, tablename string, items []batchItem, failed map[int]error) {
+	var (
+		requests []types.WriteRequest
+		pending  = make(map[string]int) // The index of the item by its fingerprint
+	)
+
+	for _, item := range items {
+		pending[itemFingerprint(item.values)] = item.index
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item.values}})
+	}
+
+	unprocessed, err := awswrapper.DynamoDBBatchWrite(ctx, tablename, requests)
+	if err != nil {
+		trail.Error("%d item(s) of %s were not processed", len(unprocessed), tablename)
+	}
+
+	for _, request := range unprocessed {
+		if request.PutRequest != nil {
+			failed[pending[itemFingerprint(request.PutRequest.Item)]] = err
+		}
+	}
+}
+
+// transactInsertChunk creates a chunk of items with the DynamoDB TransactWriteItems
+// operation, and sets the error of every item of the chunk that was not created. The
+// unique keys of every item are created in the UNIQUE_KEY_TABLE in the same transaction.
+//
+// The transaction is cancelled as a whole if one of the items already exists, so
+// the items that already exist are removed and the rest of the chunk is retried.
+func transactInsertChunk(ctx context.Context, tablename string, items []batchItem, partitionKey string, failed map[int]error) {
+	var keyExpr expression.Expression
+
+	expr, err := notExists(partitionKey)
+	if err == nil {
+		keyExpr, err = notExists("key")
+	}
+
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+
+		for _, item := range items {
+			failed[item.index] = err
+		}
+
+		return
+	}
+
+	var uniqueTable = env.UNIQUE_KEY_TABLE
+
+	// Check if the DynamoDB Table is configured
+	for _, item := range items {
+		if uniqueTable == "" && len(item.uniqueKeys) > 0 {
+			trail.Error("dynamodb UNIQUE_KEY_TABLE is not configured on the environment")
+			err := errors.New("dynamodb UNIQUE_KEY_TABLE environment variable is not set")
+
+			for _, item := range items {
+				failed[item.index] = err
+			}
+
+			return
+		}
+	}
+
+	for retry := 0; len(items) > 0; retry++ {
+		if retry > 0 {
+			err := awswrapper.DynamoDBBackoff(ctx, retry)
+			if err != nil {
+				trail.Error("%d item(s) of %s were not processed", len(items), tablename)
+
+				for _, item := range items {
+					failed[item.index] = err
+				}
+
+				return
+			}
+		}
+
+		var (
+			transactItems []types.TransactWriteItem
+			owners        []int // The item of every item of the transaction
+		)
+
+		for i, item := range items {
+			transactItems = append(transactItems, types.TransactWriteItem{
+				Put: &types.Put{
+					Item:                     item.values,
+					TableName:                aws.String(tablename),
+					ConditionExpression:      expr.Condition(),
+					ExpressionAttributeNames: expr.Names(),
+				},
+			})
+			owners = append(owners, i)
+
+			for _, key := range item.uniqueKeys {
+				transactItems = append(transactItems, types.TransactWriteItem{
+					Put: &types.Put{
+						Item:                     map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+						TableName:                aws.String(uniqueTable),
+						ConditionExpression:      keyExpr.Condition(),
+						ExpressionAttributeNames: keyExpr.Names(),
+					},
+				})
+				owners = append(owners, i)
+			}
+		}
+
+		_, err := awswrapper.DynamoDBTransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: transactItems})
+		if err == nil {
+			return
+		}
+
+		var cancelled *types.TransactionCanceledException
+		if !errors.As(err, &cancelled) || len(cancelled.CancellationReasons) != len(transactItems) {
+			trail.Error("failed to write the items of %s", tablename)
+
+			for _, item := range items {
+				failed[item.index] = err
+			}
+
+			return
+		}
+
+		// The cancellation reasons are in the same order as the items of the
+		// transaction, the rest of the items are retried without the items that
+		// already exist or whose unique keys have been taken.
+		var exists = make(map[int]bool)
+		for i, reason := range cancelled.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				exists[owners[i]] = true
+			}
+		}
+
+		var rest []batchItem
+		for i, item := range items {
+			if exists[i] {
+				failed[item.index] = ErrAlreadyExists
+				continue
+			}
+
+			rest = append(rest, item)
+		}
+
+		items = rest
+	}
+}
+
+// BatchDeleteItems deletes the items of the primary keys with the DynamoDB BatchWriteItem
+// operation. The items that were not processed are retried with an exponential backoff,
+// and it returns ErrUnprocessedItem if some of them have still not been deleted after all
+// of the retries.
+func BatchDeleteItems(ctx context.Context, tablename string, keys []map[string]types.AttributeValue) error {
+	var requests []types.WriteRequest
+	for _, key := range keys {
+		requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: key}})
+	}
+
+	unprocessed, err := awswrapper.DynamoDBBatchWrite(ctx, tablename, requests)
+	if err != nil {
+		trail.Error("%d item(s) of %s were not deleted", len(unprocessed), tablename)
+		return err
+	}
+
+	return nil
+}
+
+// itemFingerprint returns a value that identifies the item in the unprocessed
+// items of the DynamoDB BatchWriteItem output.
+func itemFingerprint(item map[string]types.AttributeValue) string {
+	var value map[string]interface{}
+
+	err := awswrapper.DynamoDBUnmarshalMap(&value, item)
+	if err != nil {
+		return ""
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
@@ -3,6 +3,7 @@ package query
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
@@ -14,6 +15,15 @@ import (
 	"github.com/rmarasigan/bus-ticketing/internal/trail"
 )
 
+// bookingIndexes are the key schemas of the BOOKING_TABLE that can be queried.
+var bookingIndexes = []Index{
+	{PartitionKey: "id", SortKey: "bus_route_id"},
+	{Name: "bus_route_id-status-index", PartitionKey: "bus_route_id", SortKey: "status"},
+	{Name: "bus_id-status-index", PartitionKey: "bus_id", SortKey: "status"},
+	{Name: "user_id-status-index", PartitionKey: "user_id", SortKey: "status"},
+	{Name: "status-index", PartitionKey: "status", SortKey: "date_created"},
+}
+
 // getBooking returns the specific booking information.
 func getBooking(ctx context.Context, tablename, id, busRouteId string) (schema.Bookings, error) {
 	var booking schema.Bookings
@@ -53,33 +63,34 @@ func getBooking(ctx context.Context, tablename, id, busRouteId string) (schema.B
 	return booking, nil
 }
 
-// getBookingList returns all the booking information.
-func getBookingList(ctx context.Context, tablename string) ([]schema.Bookings, error) {
+// getBookingList returns a page of the booking information and the next page token.
+func getBookingList(ctx context.Context, tablename string, page Page) ([]schema.Bookings, string, error) {
 	var bookings []schema.Bookings
 
 	// Use the build expression to populate the DynamoDB Scan API
 	var params = &dynamodb.ScanInput{TableName: aws.String(tablename)}
 
-	result, err := awswrapper.DynamoDBScan(ctx, params)
+	items, nextToken, err := ScanItems(ctx, params, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual booking struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&bookings, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&bookings, items)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
-	return bookings, nil
+	return bookings, nextToken, nil
 }
 
 // GetBookingRecords checks if the DynamoDB Table is configured on the environment, and
-// returns either a specific booking record or a list of booking records.
-func GetBookingRecords(ctx context.Context, id, busRouteId string) ([]schema.Bookings, error) {
+// returns either a specific booking record or a page of booking records and the next page
+// token.
+func GetBookingRecords(ctx context.Context, id, busRouteId string, page Page) ([]schema.Bookings, string, error) {
 	var tablename = env.BOOKING_TABLE
 
 	// Check if the DynamoDB Table is configured
@@ -87,7 +98,7 @@ func GetBookingRecords(ctx context.Context, id, busRouteId string) ([]schema.Boo
 		trail.Error("dynamodb BOOKING_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BOOKING_TABLE environment variable is not set")
 
-		return nil, err
+		return nil, "", err
 	}
 
 	// ********** Fetching a specific booking record ********** //
@@ -96,19 +107,55 @@ func GetBookingRecords(ctx context.Context, id, busRouteId string) ([]schema.Boo
 
 		booking, err := getBooking(ctx, tablename, id, busRouteId)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		if booking == (schema.Bookings{}) {
-			return bookings, nil
+			return bookings, "", nil
 		}
 
 		bookings = append(bookings, booking)
-		return bookings, nil
+		return bookings, "", nil
 	}
 
 	// **************** List of booking records **************** //
-	return getBookingList(ctx, tablename)
+	return getBookingList(ctx, tablename, page)
+}
+
+// GetBookingById checks if the DynamoDB Table is configured on the environment, and
+// returns the booking record of the booking ID regardless of its bus route.
+func GetBookingById(ctx context.Context, id string) (schema.Bookings, error) {
+	var (
+		booking   schema.Bookings
+		tablename = env.BOOKING_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BOOKING_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BOOKING_TABLE environment variable is not set")
+
+		return booking, err
+	}
+
+	// WHERE id = id_value
+	filters := []Filter{{Name: "id", Value: id}}
+
+	items, _, err := FindItems(ctx, tablename, bookingIndexes, filters, Page{Limit: 1})
+	if err != nil {
+		return booking, err
+	}
+
+	if len(items) > 0 {
+		// Unmarshal a map into actual Booking struct which front-end can
+		// understand as a JSON
+		err = awswrapper.DynamoDBUnmarshalMap(&booking, items[0])
+		if err != nil {
+			return booking, err
+		}
+	}
+
+	return booking, nil
 }
 
 // CreateBooking checks if the DynamoDB Table is configured on the environment, and
@@ -125,7 +172,7 @@ func CreateBooking(ctx context.Context, data interface{}) error {
 	}
 
 	// Save the Booking record into the DynamoDB Table
-	err := InsertItem(ctx, tablename, data)
+	err := InsertItem(ctx, tablename, data, Upsert)
 	if err != nil {
 		trail.Error("failed to insert a new booking record")
 		return err
@@ -135,13 +182,12 @@ func CreateBooking(ctx context.Context, data interface{}) error {
 }
 
 // FilterBookings checks if the DynamoDB Table is configured on the environment,
-// fetches and returns a list of bookings information.
-func FilterBookings(ctx context.Context, busId, routeId, status string) ([]schema.Bookings, error) {
+// fetches and returns a page of bookings information and the next page token.
+func FilterBookings(ctx context.Context, userId, busId, routeId, status string, page Page) ([]schema.Bookings, string, error) {
 	var (
-		bookings   []schema.Bookings
-		tablename  = env.BOOKING_TABLE
-		filter     expression.ConditionBuilder
-		filterList []expression.ConditionBuilder
+		bookings  []schema.Bookings
+		filters   []Filter
+		tablename = env.BOOKING_TABLE
 	)
 
 	// Check if the DynamoDB Table is configured
@@ -149,49 +195,53 @@ func FilterBookings(ctx context.Context, busId, routeId, status string) ([]schem
 		trail.Error("dynamodb BOOKING_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BOOKING_TABLE environment variable is not set")
 
-		return nil, err
+		return nil, "", err
 	}
 
-	// Check if the "bus_id" and "route_id" query parameters are not set and
-	// if the "status" query parameter is set to fetch ALL records.
-	if busId == "" && routeId == "" && status == "ALL" {
-		return getBookingList(ctx, tablename)
+	// Check if the "user_id", "bus_id" and "route_id" query parameters are not set
+	// and if the "status" query parameter is set to fetch ALL records.
+	if userId == "" && busId == "" && routeId == "" && status == "ALL" {
+		return getBookingList(ctx, tablename, page)
 	}
 
-	if busId != "" {
-		filterList = append(filterList, expression.Name("bus_id").Equal(expression.Value(busId)))
+	if status != "ALL" {
+		filters = append(filters, Filter{Name: "status", Value: status})
 	}
 
-	if routeId != "" {
-		filterList = append(filterList, expression.Name("bus_route_id").Equal(expression.Value(routeId)))
+	if userId != "" {
+		filters = append(filters, Filter{Name: "user_id", Value: userId})
 	}
 
-	if len(filterList) > 0 {
-		filter = expression.And(expression.Name("status").Equal(expression.Value(status)), filterList[0], filterList[len(filterList)-1:]...)
-	} else {
-		filter = expression.Name("status").Equal(expression.Value(status))
+	if busId != "" {
+		filters = append(filters, Filter{Name: "bus_id", Value: busId})
 	}
 
-	result, err := FilterItems(ctx, tablename, filter)
+	if routeId != "" {
+		filters = append(filters, Filter{Name: "bus_route_id", Value: routeId})
+	}
+
+	items, nextToken, err := FindItems(ctx, tablename, bookingIndexes, filters, page)
 	if err != nil {
-		return bookings, err
+		return bookings, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual bus route struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&bookings, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&bookings, items)
 		if err != nil {
-			return bookings, err
+			return bookings, "", err
 		}
 	}
 
-	return bookings, nil
+	return bookings, nextToken, nil
 }
 
 // UpdateBooking checks if the DynamoDB Table is configured on the environment and
 // updates the booking record.
-func UpdateBooking(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder) (schema.Bookings, error) {
+// It only updates the record if it is still on the version, otherwise it
+// returns ErrVersionMismatch.
+func UpdateBooking(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder, version int) (schema.Bookings, error) {
 	var (
 		booking   schema.Bookings
 		tablename = env.BOOKING_TABLE
@@ -205,7 +255,7 @@ func UpdateBooking(ctx context.Context, key map[string]types.AttributeValue, upd
 		return booking, err
 	}
 
-	result, err := UpdateItem(ctx, tablename, key, update)
+	result, err := UpdateVersionedItem(ctx, tablename, key, update, version)
 	if err != nil {
 		trail.Error("failed to update the booking record")
 		return booking, err
@@ -260,8 +310,9 @@ func getCancelledBooking(ctx context.Context, tablename, bookingId string) (sche
 }
 
 // GetCancelledBookingRecords checks if the DynamoDB Table is configured on the environment, and
-// returns either a specific cancelled booking or a list of cancelled bookings.
-func GetCancelledBookingRecords(ctx context.Context, bookingId string) ([]schema.BookingCancelled, error) {
+// returns either a specific cancelled booking or a page of cancelled bookings and the next
+// page token.
+func GetCancelledBookingRecords(ctx context.Context, bookingId string, page Page) ([]schema.BookingCancelled, string, error) {
 	var (
 		bookings  []schema.BookingCancelled
 		tablename = env.BOOKING_CANCELLED_TABLE
@@ -272,73 +323,186 @@ func GetCancelledBookingRecords(ctx context.Context, bookingId string) ([]schema
 		trail.Error("dynamodb BOOKING_CANCELLED_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BOOKING_CANCELLED_TABLE environment is not set")
 
-		return bookings, err
+		return bookings, "", err
 	}
 
 	// ********** Fetching a specific cancelled booking record ********** //
 	if bookingId != "" {
 		booking, err := getCancelledBooking(ctx, tablename, bookingId)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		if booking == (schema.BookingCancelled{}) {
-			return bookings, nil
+			return bookings, "", nil
 		}
 
 		bookings = append(bookings, booking)
-		return bookings, nil
+		return bookings, "", nil
 	}
 
 	// **************** List of booking records **************** //
 	// Use the build expression to populate the DynamoDB Scan API
 	var params = &dynamodb.ScanInput{TableName: aws.String(tablename)}
 
-	result, err := awswrapper.DynamoDBScan(ctx, params)
+	items, nextToken, err := ScanItems(ctx, params, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual Booking Cancelled struct which the
 		// front-end can understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&bookings, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&bookings, items)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
-	return bookings, nil
+	return bookings, nextToken, nil
 }
 
-// RecordBookingCancelled updates the existing item's attribute or adds a new item
-// to the table if it does not exist.
-func RecordBookingCancelled(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder) (schema.BookingCancelled, error) {
+// CancelBooking checks if the DynamoDB Tables are configured on the environment and commits
+// the booking status change, the cancellation record and the release of the booked seats
+// together. Either all of them are applied or none of them.
+//
+// The booking is only cancelled if it is still on its version, otherwise it returns
+// ErrVersionMismatch. If the booking already has a cancellation record, the original
+// reason and date of the cancellation are kept.
+func CancelBooking(ctx context.Context, booking schema.Bookings) error {
 	var (
-		booking   schema.BookingCancelled
-		tablename = env.BOOKING_CANCELLED_TABLE
+		bookingTable   = env.BOOKING_TABLE
+		cancelledTable = env.BOOKING_CANCELLED_TABLE
 	)
 
-	// Check if the DynamoDB Table is configured
-	if tablename == "" {
+	// Check if the DynamoDB Tables are configured
+	if bookingTable == "" {
+		trail.Error("dynamodb BOOKING_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BOOKING_TABLE environment variable is not set")
+
+		return err
+	}
+
+	if cancelledTable == "" {
 		trail.Error("dynamodb BOOKING_CANCELLED_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BOOKING_CANCELLED_TABLE environment is not set")
 
-		return booking, err
+		return err
 	}
 
-	result, err := UpdateItem(ctx, tablename, key, update)
-	if err != nil {
-		trail.Error("failed to update the booking record")
-		return booking, err
+	// 1. Change the booking status and release the booked seats
+	var bookingKey = map[string]types.AttributeValue{
+		"id":           &types.AttributeValueMemberS{Value: booking.ID},
+		"bus_route_id": &types.AttributeValueMemberS{Value: booking.BusRouteID},
 	}
 
-	// Unmarshal a map into actual booking struct which the front-end can
-	// understand as a JSON.
-	err = awswrapper.DynamoDBUnmarshalMap(&booking, result.Attributes)
+	var updateBooking = expression.Set(expression.Name("status"), expression.Value(booking.Status)).
+		Set(expression.Name("is_cancelled"), expression.Value(booking.IsCancelled)).
+		Set(expression.Name("date_confirmed"), expression.Value("")).
+		Remove(expression.Name("seat_number"))
+
+	if booking.RouteChangeID != "" {
+		updateBooking = updateBooking.Set(expression.Name("route_change_id"), expression.Value(booking.RouteChangeID))
+	}
+
+	// 2. Record the cancellation without overwriting an existing record
+	var cancelledKey = map[string]types.AttributeValue{
+		"booking_id": &types.AttributeValueMemberS{Value: booking.ID},
+	}
+
+	var updateCancelled = expression.Set(expression.Name("id"), ifNotExists("id", booking.Cancelled.ID)).
+		Set(expression.Name("reason"), ifNotExists("reason", booking.Cancelled.Reason)).
+		Set(expression.Name("cancelled_by"), ifNotExists("cancelled_by", booking.Cancelled.CancelledBy)).
+		Set(expression.Name("date_cancelled"), ifNotExists("date_cancelled", booking.Cancelled.DateCancelled)).
+		Set(expression.Name("released_seats"), ifNotExists("released_seats", booking.SeatNumber))
+
+	if booking.Cancelled.RefundPolicy != "" {
+		updateCancelled = updateCancelled.Set(expression.Name("refund_policy"), ifNotExists("refund_policy", booking.Cancelled.RefundPolicy)).
+			Set(expression.Name("refund_amount"), ifNotExists("refund_amount", booking.Cancelled.RefundAmount))
+	}
+
+	err := TransactWriteItems(ctx,
+		TransactUpdate{TableName: bookingTable, Key: bookingKey, Update: updateBooking, Version: &booking.Version},
+		TransactUpdate{TableName: cancelledTable, Key: cancelledKey, Update: updateCancelled},
+	)
 	if err != nil {
-		return booking, err
+		trail.Error("failed to cancel the booking record")
+		return err
 	}
 
-	return booking, nil
+	return nil
+}
+
+// ifNotExists returns the value of the attribute if it is already set, otherwise
+// the value.
+func ifNotExists(name string, value interface{}) expression.SetValueBuilder {
+	return expression.IfNotExists(expression.Name(name), expression.Value(value))
+}
+
+// GetTripBookings returns every booking of the bus route on the day of the travel date
+// that is not cancelled. Only the "2006-01-02" prefix of the travel date is compared.
+func GetTripBookings(ctx context.Context, busId, routeId, travelDate string) ([]schema.Bookings, error) {
+	var (
+		bookings = []schema.Bookings{}
+		page     = Page{Limit: MAX_PAGE_LIMIT}
+	)
+
+	for {
+		list, nextToken, err := FilterBookings(ctx, "", busId, routeId, "ALL", page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, booking := range list {
+			if booking.Status != booking.Status.Cancelled() && strings.HasPrefix(booking.TravelDate, travelDate) {
+				bookings = append(bookings, booking)
+			}
+		}
+
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bookings, nil
+}
+
+// GetUpcomingBookings returns every booking of the bus route that is not cancelled and
+// whose travel date is not before the day. Only the "2006-01-02" prefix of the travel
+// date is compared. The bookings that have been cancelled by the route change are also
+// returned, so that a retry of the route change still informs their passengers.
+func GetUpcomingBookings(ctx context.Context, busId, routeId, routeChangeId, day string) ([]schema.Bookings, error) {
+	var (
+		bookings = []schema.Bookings{}
+		page     = Page{Limit: MAX_PAGE_LIMIT}
+	)
+
+	for {
+		list, nextToken, err := FilterBookings(ctx, "", busId, routeId, "ALL", page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, booking := range list {
+			if booking.IsUpcoming(day) || booking.IsCancelledBy(routeChangeId, day) {
+				bookings = append(bookings, booking)
+			}
+		}
+
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bookings, nil
 }
@@ -14,6 +14,12 @@ import (
 	"github.com/rmarasigan/bus-ticketing/internal/trail"
 )
 
+// busLineIndexes are the key schemas of the BUS_TABLE that can be queried.
+var busLineIndexes = []Index{
+	{PartitionKey: "name", SortKey: "company"},
+	{Name: "id-index", PartitionKey: "id"},
+}
+
 // getBusLine returns the bus line information.
 func getBusLine(ctx context.Context, tablename, id, name string) (schema.Bus, error) {
 	var bus schema.Bus
@@ -30,9 +36,10 @@ func getBusLine(ctx context.Context, tablename, id, name string) (schema.Bus, er
 		expression.Name("address"),
 		expression.Name("company"),
 		expression.Name("mobile_number"),
+		expression.Name("version"),
 	}
 
-	// SELECT id, name, owner, email, address, company, mobile_number
+	// SELECT id, name, owner, email, address, company, mobile_number, version
 	projection := expression.NamesList(expression.Name("id"), namesList...)
 
 	// Construct the filter builder with a name and value.
@@ -73,8 +80,9 @@ func getBusLine(ctx context.Context, tablename, id, name string) (schema.Bus, er
 }
 
 // GetBusLineRecords checks if the DynamoDB Table is configured on the environment, and
-// returns either the specific bus line or a list of bus line records.
-func GetBusLineRecords(ctx context.Context, id, name string) ([]schema.Bus, error) {
+// returns either the specific bus line or a page of bus line records and the next page
+// token.
+func GetBusLineRecords(ctx context.Context, id, name string, page Page) ([]schema.Bus, string, error) {
 	var (
 		busList   []schema.Bus
 		tablename = env.BUS_TABLE
@@ -85,22 +93,22 @@ func GetBusLineRecords(ctx context.Context, id, name string) ([]schema.Bus, erro
 		trail.Error("dynamodb BUS_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BUS_TABLE environment variable is not set")
 
-		return busList, err
+		return busList, "", err
 	}
 
 	// ********** Fetching a specific bus record ********** //
 	if id != "" && name != "" {
 		bus, err := getBusLine(ctx, tablename, id, name)
 		if err != nil {
-			return busList, err
+			return busList, "", err
 		}
 
 		if bus == (schema.Bus{}) {
-			return busList, nil
+			return busList, "", nil
 		}
 
 		busList = append(busList, bus)
-		return busList, nil
+		return busList, "", nil
 	}
 
 	// **************** List of bus records **************** //
@@ -113,15 +121,16 @@ func GetBusLineRecords(ctx context.Context, id, name string) ([]schema.Bus, erro
 		expression.Name("address"),
 		expression.Name("company"),
 		expression.Name("mobile_number"),
+		expression.Name("version"),
 	}
 
-	// SELECT id, name, owner, email, address, company, mobile_number
+	// SELECT id, name, owner, email, address, company, mobile_number, version
 	projection := expression.NamesList(expression.Name("id"), namesList...)
 
 	// Build an expression to retrieve the item from the DynamoDB
 	expr, err := expression.NewBuilder().WithProjection(projection).Build()
 	if err != nil {
-		return busList, err
+		return busList, "", err
 	}
 
 	// Use the build expression to populate the DynamoDB Scan API
@@ -132,49 +141,95 @@ func GetBusLineRecords(ctx context.Context, id, name string) ([]schema.Bus, erro
 		ProjectionExpression:      expr.Projection(),
 	}
 
-	result, err := awswrapper.DynamoDBScan(ctx, params)
+	items, nextToken, err := ScanItems(ctx, params, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual bus struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&busList, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&busList, items)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
-	return busList, nil
+	return busList, nextToken, nil
 }
 
-// CreateBusLine checks if the DynamoDB Table is configured on the environment, and
-// creates a new bus line record.
-func CreateBusLine(ctx context.Context, data interface{}) error {
-	var tablename = env.BUS_TABLE
+// GetBusLineById checks if the DynamoDB Table is configured on the environment, and
+// returns the bus line of the bus ID.
+func GetBusLineById(ctx context.Context, id string) (schema.Bus, error) {
+	var (
+		bus       schema.Bus
+		tablename = env.BUS_TABLE
+	)
 
 	// Check if the DynamoDB Table is configured
 	if tablename == "" {
 		trail.Error("dynamodb BUS_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BUS_TABLE environment variable is not set")
 
-		return err
+		return bus, err
 	}
 
-	// Save the Bus Line information into the DynamoDB Table
-	err := InsertItem(ctx, tablename, data)
+	// WHERE id = id_value
+	filters := []Filter{{Name: "id", Value: id}}
+
+	items, _, err := FindItems(ctx, tablename, busLineIndexes, filters, Page{Limit: 1})
 	if err != nil {
-		trail.Error("failed to insert a new bus line")
-		return err
+		return bus, err
+	}
+
+	if len(items) > 0 {
+		// Unmarshal a map into actual bus struct which the front-end can
+		// understand as a JSON.
+		err = awswrapper.DynamoDBUnmarshalMap(&bus, items[0])
+		if err != nil {
+			return bus, err
+		}
 	}
 
-	return nil
+	return bus, nil
+}
+
+// CreateBusLines checks if the DynamoDB Table is configured on the environment, and
+// creates the new bus line records in batches.
+//
+// It returns the error of every bus line that was not created by its index in the list,
+// the bus lines that already exist fail with ErrAlreadyExists.
+func CreateBusLines(ctx context.Context, list []schema.Bus) (map[int]error, error) {
+	var tablename = env.BUS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BUS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BUS_TABLE environment variable is not set")
+
+		return nil, err
+	}
+
+	var data = make([]interface{}, len(list))
+	for i, item := range list {
+		data[i] = item
+	}
+
+	// Save the Bus Line records into the DynamoDB Table, the ID is recorded as a
+	// unique key since it is not part of the primary key.
+	failed := BatchInsertItems(ctx, tablename, data, CreateOnly("name").WithUniqueAttributes("id"))
+	if len(failed) > 0 {
+		trail.Error("failed to insert %d of %d bus line record(s)", len(failed), len(list))
+	}
+
+	return failed, nil
 }
 
 // UpdateBusLine checks if the DynamoDB Table is configured on the environment and
 // updates the bus line's information or record.
-func UpdateBusLine(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder) (schema.Bus, error) {
+// It only updates the record if it is still on the version, otherwise it
+// returns ErrVersionMismatch.
+func UpdateBusLine(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder, version int) (schema.Bus, error) {
 	var (
 		bus       schema.Bus
 		tablename = env.BUS_TABLE
@@ -188,7 +243,7 @@ func UpdateBusLine(ctx context.Context, key map[string]types.AttributeValue, upd
 		return bus, err
 	}
 
-	result, err := UpdateItem(ctx, tablename, key, update)
+	result, err := UpdateVersionedItem(ctx, tablename, key, update, version)
 	if err != nil {
 		trail.Error("failed to update the bus line record")
 		return bus, err
@@ -205,8 +260,8 @@ func UpdateBusLine(ctx context.Context, key map[string]types.AttributeValue, upd
 }
 
 // FilterBusLine checks if the DynamoDB Table is configured on the environment,
-// fetches and returns a list of bus line information.
-func FilterBusLine(ctx context.Context, name, company string) ([]schema.Bus, error) {
+// fetches and returns a page of bus line information and the next page token.
+func FilterBusLine(ctx context.Context, name, company string, page Page) ([]schema.Bus, string, error) {
 	var (
 		busList   []schema.Bus
 		tablename = env.BUS_TABLE
@@ -217,10 +272,11 @@ func FilterBusLine(ctx context.Context, name, company string) ([]schema.Bus, err
 		trail.Error("dynamodb BUS_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BUS_TABLE environment variable is not set")
 
-		return busList, err
+		return busList, "", err
 	}
 
 	// Construct the filter builder with a name that contains a specified value.
+	// Since it is a partial match, no index can serve it and it stays a Scan.
 	// WHERE name LIKE %name_value% OR company LIKE or %company_value%
 	var filter expression.ConditionBuilder
 	if name != "" && company != "" {
@@ -235,19 +291,19 @@ func FilterBusLine(ctx context.Context, name, company string) ([]schema.Bus, err
 		}
 	}
 
-	result, err := FilterItems(ctx, tablename, filter)
+	items, nextToken, err := FilterItems(ctx, tablename, filter, page)
 	if err != nil {
-		return busList, err
+		return busList, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual bus struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&busList, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&busList, items)
 		if err != nil {
-			return busList, err
+			return busList, "", err
 		}
 	}
 
-	return busList, nil
+	return busList, nextToken, nil
 }
@@ -14,6 +14,13 @@ import (
 	"github.com/rmarasigan/bus-ticketing/internal/trail"
 )
 
+// busRouteIndexes are the key schemas of the BUS_ROUTE_TABLE that can be queried.
+var busRouteIndexes = []Index{
+	{PartitionKey: "id", SortKey: "bus_id"},
+	{Name: "from_route-to_route-index", PartitionKey: "from_route", SortKey: "to_route"},
+	{Name: "bus_id-index", PartitionKey: "bus_id", SortKey: "bus_unit_id"},
+}
+
 // getBusRoute returns the bus unit route information.
 func getBusRoute(ctx context.Context, tablename, id, busId string) (schema.BusRoute, error) {
 	var route schema.BusRoute
@@ -29,15 +36,19 @@ func getBusRoute(ctx context.Context, tablename, id, busId string) (schema.BusRo
 		expression.Name("bus_unit_id"),
 		expression.Name("currency_code"),
 		expression.Name("rate"),
+		expression.Name("fares"),
 		expression.Name("active"),
 		expression.Name("departure_time"),
 		expression.Name("arrival_time"),
 		expression.Name("from_route"),
 		expression.Name("to_route"),
+		expression.Name("amenities"),
+		expression.Name("version"),
+		expression.Name("pending_change"),
 	}
 
-	// SELECT id, bus_id, bus_unit_id, currency_code, rate, active,
-	// departure_time, arrival_time, from_route, to_route
+	// SELECT id, bus_id, bus_unit_id, currency_code, rate, fares, active,
+	// departure_time, arrival_time, from_route, to_route, amenities, version, pending_change
 	projection := expression.NamesList(expression.Name("id"), namesList...)
 
 	// Build an expression to retrieve the item from the DynamoDB
@@ -73,8 +84,9 @@ func getBusRoute(ctx context.Context, tablename, id, busId string) (schema.BusRo
 }
 
 // GetBusRouteRecords checks if the DynamoDB Table is configured on the environment, and
-// returns either the specific bus line route or a list of bus line route records.
-func GetBusRouteRecords(ctx context.Context, id, busId string) ([]schema.BusRoute, error) {
+// returns either the specific bus line route or a page of bus line route records and the
+// next page token.
+func GetBusRouteRecords(ctx context.Context, id, busId string, page Page) ([]schema.BusRoute, string, error) {
 	var (
 		routes    []schema.BusRoute
 		tablename = env.BUS_ROUTE_TABLE
@@ -85,22 +97,22 @@ func GetBusRouteRecords(ctx context.Context, id, busId string) ([]schema.BusRout
 		trail.Error("dynamodb BUS_ROUTE_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BUS_ROUTE_TABLE environment variable is not set")
 
-		return routes, err
+		return routes, "", err
 	}
 
 	// ********** Fetching a specific bus route record ********** //
 	if id != "" && busId != "" {
 		route, err := getBusRoute(ctx, tablename, id, busId)
 		if err != nil {
-			return routes, err
+			return routes, "", err
 		}
 
-		if route == (schema.BusRoute{}) {
-			return routes, nil
+		if route.ID == "" {
+			return routes, "", nil
 		}
 
 		routes = append(routes, route)
-		return routes, nil
+		return routes, "", nil
 	}
 
 	// **************** List of bus route records **************** //
@@ -111,21 +123,24 @@ func GetBusRouteRecords(ctx context.Context, id, busId string) ([]schema.BusRout
 		expression.Name("bus_unit_id"),
 		expression.Name("currency_code"),
 		expression.Name("rate"),
+		expression.Name("fares"),
 		expression.Name("active"),
 		expression.Name("departure_time"),
 		expression.Name("arrival_time"),
 		expression.Name("from_route"),
 		expression.Name("to_route"),
+		expression.Name("amenities"),
+		expression.Name("version"),
 	}
 
-	// SELECT id, bus_id, bus_unit_id, currency_code, rate, active,
-	// departure_time, arrival_time, from_route, to_route
+	// SELECT id, bus_id, bus_unit_id, currency_code, rate, fares, active,
+	// departure_time, arrival_time, from_route, to_route, amenities, version
 	projection := expression.NamesList(expression.Name("id"), namesList...)
 
 	// Build an expression to retrieve the item from the DynamoDB
 	expr, err := expression.NewBuilder().WithProjection(projection).Build()
 	if err != nil {
-		return routes, err
+		return routes, "", err
 	}
 
 	// Use the build expression to populate the DynamoDB Scan API
@@ -136,25 +151,26 @@ func GetBusRouteRecords(ctx context.Context, id, busId string) ([]schema.BusRout
 		ProjectionExpression:      expr.Projection(),
 	}
 
-	result, err := awswrapper.DynamoDBScan(ctx, params)
+	items, nextToken, err := ScanItems(ctx, params, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual bus route struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&routes, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&routes, items)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
-	return routes, nil
+	return routes, nextToken, nil
 }
 
 // CreateBusRoute checks if the DynamoDB Table is configured on the environment, and
-// creates a new bus route record.
+// creates a new bus route record. It returns ErrAlreadyExists if the bus route ID has
+// already been taken.
 func CreateBusRoute(ctx context.Context, data interface{}) error {
 	var tablename = env.BUS_ROUTE_TABLE
 
@@ -166,8 +182,9 @@ func CreateBusRoute(ctx context.Context, data interface{}) error {
 		return err
 	}
 
-	// Save the Bus Route information into the DynamoDB Table
-	err := InsertItem(ctx, tablename, data)
+	// Save the Bus Route information into the DynamoDB Table, the ID is recorded
+	// as a unique key since the bus ID is part of the primary key.
+	err := InsertItem(ctx, tablename, data, CreateOnly("id").WithUniqueAttributes("id"))
 	if err != nil {
 		trail.Error("failed to insert a new bus route")
 		return err
@@ -178,7 +195,9 @@ func CreateBusRoute(ctx context.Context, data interface{}) error {
 
 // UpdateBusRoute checks if the DynamoDB Table is configured on the environment and
 // updates the bus routes information or record.
-func UpdateBusRoute(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder) (schema.BusRoute, error) {
+// It only updates the record if it is still on the version, otherwise it
+// returns ErrVersionMismatch.
+func UpdateBusRoute(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder, version int) (schema.BusRoute, error) {
 	var (
 		route     schema.BusRoute
 		tablename = env.BUS_ROUTE_TABLE
@@ -192,7 +211,7 @@ func UpdateBusRoute(ctx context.Context, key map[string]types.AttributeValue, up
 		return route, err
 	}
 
-	result, err := UpdateItem(ctx, tablename, key, update)
+	result, err := UpdateVersionedItem(ctx, tablename, key, update, version)
 	if err != nil {
 		trail.Error("failed to update the bus route record")
 		return route, err
@@ -209,13 +228,14 @@ func UpdateBusRoute(ctx context.Context, key map[string]types.AttributeValue, up
 }
 
 // FilterBusRoute checks if the DynamoDB Table is configured on the environment, fetches
-// and returns a list of bus routes information.
-func FilterBusRoute(ctx context.Context, route schema.BusRouteFilter) ([]schema.BusRoute, error) {
+// and returns a page of bus routes information and the next page token.
+//
+// The bus unit of a bus route must have every one of the amenities.
+func FilterBusRoute(ctx context.Context, route schema.BusRouteFilter, page Page) ([]schema.BusRoute, string, error) {
 	var (
-		routes     []schema.BusRoute
-		tablename  = env.BUS_ROUTE_TABLE
-		filter     expression.ConditionBuilder
-		filterList []expression.ConditionBuilder
+		routes    []schema.BusRoute
+		filters   []Filter
+		tablename = env.BUS_ROUTE_TABLE
 	)
 
 	// Check if the DynamoDB Table is configured
@@ -223,55 +243,119 @@ func FilterBusRoute(ctx context.Context, route schema.BusRouteFilter) ([]schema.
 		trail.Error("dynamodb BUS_ROUTE_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BUS_ROUTE_TABLE environment is not set")
 
-		return routes, err
+		return routes, "", err
+	}
+
+	// Construct the filters with the fields that are set.
+	if route.BusID != "" {
+		filters = append(filters, Filter{Name: "bus_id", Value: route.BusID})
+	}
+
+	if route.BusUnitID != "" {
+		filters = append(filters, Filter{Name: "bus_unit_id", Value: route.BusUnitID})
 	}
 
-	// Construct the filter builder with a name that contains a specified value.
 	if route.Active != nil {
-		filterList = append(filterList, expression.Name("active").Equal(expression.Value(route.Active)))
+		filters = append(filters, Filter{Name: "active", Value: route.Active})
 	}
 
 	if route.Departure != "" {
-		filterList = append(filterList, expression.Name("departure_time").Equal(expression.Value(route.Departure)))
+		filters = append(filters, Filter{Name: "departure_time", Value: route.Departure})
 	}
 
 	if route.Arrival != "" {
-		filterList = append(filterList, expression.Name("arrival_time").Equal(expression.Value(route.Arrival)))
+		filters = append(filters, Filter{Name: "arrival_time", Value: route.Arrival})
 	}
 
 	if route.FromRoute != "" {
-		filterList = append(filterList, expression.Name("from_route").Equal(expression.Value(route.FromRoute)))
+		filters = append(filters, Filter{Name: "from_route", Value: route.FromRoute})
 	}
 
 	if route.ToRoute != "" {
-		filterList = append(filterList, expression.Name("to_route").Equal(expression.Value(route.ToRoute)))
+		filters = append(filters, Filter{Name: "to_route", Value: route.ToRoute})
 	}
 
-	if route.BusUnitID != "" {
-		filter = expression.Name("bus_id").Equal(expression.Value(route.BusID)).And(expression.Name("bus_unit_id").Equal(expression.Value(route.BusUnitID)), filterList...)
-
-	} else {
-		if len(filterList) > 0 {
-			filter = expression.And(expression.Name("bus_id").Equal(expression.Value(route.BusID)), filterList[0], filterList[1:]...)
-
-		} else {
-			filter = expression.Name("bus_id").Equal(expression.Value(route.BusID))
-		}
+	// AND contains(amenities, amenity_value)
+	for _, amenity := range route.Amenities {
+		filters = append(filters, Filter{Name: "amenities", Value: amenity, Contains: true})
 	}
 
-	result, err := FilterItems(ctx, tablename, filter)
+	items, nextToken, err := FindItems(ctx, tablename, busRouteIndexes, filters, page)
 	if err != nil {
-		return routes, err
+		return routes, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual bus route struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&routes, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&routes, items)
 		if err != nil {
-			return routes, err
+			return routes, "", err
 		}
 	}
 
-	return routes, nil
+	return routes, nextToken, nil
+}
+
+// SetBusRouteAmenities checks if the DynamoDB Table is configured on the environment, and
+// copies the amenities of the bus unit to every one of its bus routes, so that the bus
+// routes can be filtered by them.
+func SetBusRouteAmenities(ctx context.Context, unit schema.BusUnit) error {
+	var tablename = env.BUS_ROUTE_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BUS_ROUTE_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BUS_ROUTE_TABLE environment variable is not set")
+
+		return err
+	}
+
+	var filters = []Filter{
+		{Name: "bus_id", Value: unit.BusID},
+		{Name: "bus_unit_id", Value: unit.Code},
+	}
+
+	var update = expression.Remove(expression.Name("amenities"))
+	if len(unit.Amenities) > 0 {
+		update = expression.Set(expression.Name("amenities"), expression.Value(unit.Amenities))
+	}
+
+	// Every page of the bus routes of the bus unit
+	var page Page
+	for {
+		items, nextToken, err := FindItems(ctx, tablename, busRouteIndexes, filters, page)
+		if err != nil {
+			return err
+		}
+
+		var routes []schema.BusRoute
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&routes, items)
+		if err != nil {
+			return err
+		}
+
+		for _, route := range routes {
+			var key = map[string]types.AttributeValue{
+				"id":     &types.AttributeValueMemberS{Value: route.ID},
+				"bus_id": &types.AttributeValueMemberS{Value: route.BusID},
+			}
+
+			// The bus route is not created again if it has been deleted
+			_, err = UpdateItemIf(ctx, tablename, key, update, expression.AttributeExists(expression.Name("id")))
+			if err != nil && !errors.Is(err, ErrVersionMismatch) {
+				trail.Error("failed to set the amenities of the bus route %s", route.ID)
+				return err
+			}
+		}
+
+		if nextToken == "" {
+			return nil
+		}
+
+		page, err = NewPage("", nextToken)
+		if err != nil {
+			return err
+		}
+	}
 }
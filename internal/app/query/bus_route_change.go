@@ -0,0 +1,140 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// FlagRebookBooking checks if the DynamoDB Table is configured on the environment, and
+// flags the booking of the deactivated bus route with the route change, so that it is
+// rebooked to another bus route. It is only flagged if it is not cancelled,
+// otherwise it returns ErrVersionMismatch.
+//
+// The flag is maintained by the system, it does not change the version of the booking.
+func FlagRebookBooking(ctx context.Context, booking schema.Bookings, change schema.BusRouteChange) error {
+	var tablename = env.BOOKING_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BOOKING_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BOOKING_TABLE environment variable is not set")
+
+		return err
+	}
+
+	var key = map[string]types.AttributeValue{
+		"id":           &types.AttributeValueMemberS{Value: booking.ID},
+		"bus_route_id": &types.AttributeValueMemberS{Value: booking.BusRouteID},
+	}
+
+	// WHERE id IS SET AND status <> CANCELLED
+	update := expression.Set(expression.Name("route_change_id"), expression.Value(change.ID))
+	condition := expression.AttributeExists(expression.Name("id")).
+		And(expression.Name("status").NotEqual(expression.Value(booking.Status.Cancelled())))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if err != nil {
+		trail.Error("failed to flag the booking of the bus route change")
+		return err
+	}
+
+	return nil
+}
+
+// ClearBusRouteChange checks if the DynamoDB Table is configured on the environment, and
+// removes the pending change of the bus route once it has been sent to the event bus. It
+// is only removed if it is still the same route change.
+//
+// The pending change is maintained by the system, it does not change the version of the
+// bus route.
+func ClearBusRouteChange(ctx context.Context, change schema.BusRouteChange) error {
+	var tablename = env.BUS_ROUTE_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BUS_ROUTE_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BUS_ROUTE_TABLE environment variable is not set")
+
+		return err
+	}
+
+	var key = map[string]types.AttributeValue{
+		"id":     &types.AttributeValueMemberS{Value: change.Route.ID},
+		"bus_id": &types.AttributeValueMemberS{Value: change.Route.BusID},
+	}
+
+	// WHERE pending_change.id = change_id
+	update := expression.Remove(expression.Name("pending_change"))
+	condition := expression.Name("pending_change.id").Equal(expression.Value(change.ID))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if errors.Is(err, ErrVersionMismatch) {
+		return nil
+	}
+
+	if err != nil {
+		trail.Error("failed to remove the pending change of the bus route")
+		return err
+	}
+
+	return nil
+}
+
+// GetPendingBusRouteChanges checks if the DynamoDB Table is configured on the environment,
+// and returns the pending change of every bus route that has not been sent to the event
+// bus yet.
+func GetPendingBusRouteChanges(ctx context.Context) ([]schema.BusRouteChange, error) {
+	var (
+		changes   = []schema.BusRouteChange{}
+		page      = Page{Limit: MAX_PAGE_LIMIT}
+		tablename = env.BUS_ROUTE_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BUS_ROUTE_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BUS_ROUTE_TABLE environment variable is not set")
+
+		return nil, err
+	}
+
+	// WHERE pending_change IS SET
+	filter := expression.AttributeExists(expression.Name("pending_change"))
+
+	for {
+		items, nextToken, err := FilterItems(ctx, tablename, filter, page)
+		if err != nil {
+			return nil, err
+		}
+
+		var routes []schema.BusRoute
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&routes, items)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, route := range routes {
+			if route.PendingChange != nil {
+				changes = append(changes, *route.PendingChange)
+			}
+		}
+
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
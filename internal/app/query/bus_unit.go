@@ -14,6 +14,12 @@ import (
 	"github.com/rmarasigan/bus-ticketing/internal/trail"
 )
 
+// busUnitIndexes are the key schemas of the BUS_UNIT_TABLE that can be queried.
+var busUnitIndexes = []Index{
+	{PartitionKey: "code", SortKey: "bus_id"},
+	{Name: "bus_id-index", PartitionKey: "bus_id", SortKey: "code"},
+}
+
 // getBusUnit returns the bus line unit information.
 func getBusUnit(ctx context.Context, tablename, code, busId string) (schema.BusUnit, error) {
 	var unit schema.BusUnit
@@ -28,9 +34,12 @@ func getBusUnit(ctx context.Context, tablename, code, busId string) (schema.BusU
 		expression.Name("active"),
 		expression.Name("min_capacity"),
 		expression.Name("max_capacity"),
+		expression.Name("layout_id"),
+		expression.Name("amenities"),
+		expression.Name("version"),
 	}
 
-	// SELECT code, bus_id, active, min_capacity, max_capacity
+	// SELECT code, bus_id, active, min_capacity, max_capacity, layout_id, amenities, version
 	projection := expression.NamesList(expression.Name("code"), namesList...)
 
 	// Build an expression to retrieve the item from the DynamoDB
@@ -65,8 +74,9 @@ func getBusUnit(ctx context.Context, tablename, code, busId string) (schema.BusU
 }
 
 // GetBusUnitRecords checks if the DynamoDB Table is configured on the environment, and
-// returns either the specific bus line unit or a list of bus line unit records.
-func GetBusUnitRecords(ctx context.Context, code, busId string) ([]schema.BusUnit, error) {
+// returns either the specific bus line unit or a page of bus line unit records and the
+// next page token.
+func GetBusUnitRecords(ctx context.Context, code, busId string, page Page) ([]schema.BusUnit, string, error) {
 	var (
 		units     []schema.BusUnit
 		tablename = env.BUS_UNIT
@@ -77,22 +87,22 @@ func GetBusUnitRecords(ctx context.Context, code, busId string) ([]schema.BusUni
 		trail.Error("dynamodb BUS_UNIT_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BUS_UNIT_TABLE environment variable is not set")
 
-		return units, err
+		return units, "", err
 	}
 
 	// ********** Fetching a specific bus unit record ********** //
 	if code != "" && busId != "" {
 		unit, err := getBusUnit(ctx, tablename, code, busId)
 		if err != nil {
-			return units, err
+			return units, "", err
 		}
 
-		if unit == (schema.BusUnit{}) {
-			return units, nil
+		if unit.Code == "" {
+			return units, "", nil
 		}
 
 		units = append(units, unit)
-		return units, nil
+		return units, "", nil
 	}
 
 	// **************** List of bus unit records **************** //
@@ -103,15 +113,18 @@ func GetBusUnitRecords(ctx context.Context, code, busId string) ([]schema.BusUni
 		expression.Name("active"),
 		expression.Name("min_capacity"),
 		expression.Name("max_capacity"),
+		expression.Name("layout_id"),
+		expression.Name("amenities"),
+		expression.Name("version"),
 	}
 
-	// SELECT code, bus_id, active, min_capacity, max_capacity
+	// SELECT code, bus_id, active, min_capacity, max_capacity, layout_id, amenities, version
 	projection := expression.NamesList(expression.Name("code"), namesList...)
 
 	// Build an expression to retrieve the item from the DynamoDB
 	expr, err := expression.NewBuilder().WithProjection(projection).Build()
 	if err != nil {
-		return units, err
+		return units, "", err
 	}
 
 	// Use the build expression to populate the DynamoDB Scan API
@@ -122,26 +135,29 @@ func GetBusUnitRecords(ctx context.Context, code, busId string) ([]schema.BusUni
 		ProjectionExpression:      expr.Projection(),
 	}
 
-	result, err := awswrapper.DynamoDBScan(ctx, params)
+	items, nextToken, err := ScanItems(ctx, params, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual bus unit struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&units, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&units, items)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
-	return units, nil
+	return units, nextToken, nil
 }
 
-// CreateBusUnit checks if the DynamoDB Table is configured on the environment, and
-// creates a new bus unit record.
-func CreateBusUnit(ctx context.Context, data interface{}) error {
+// CreateBusUnits checks if the DynamoDB Table is configured on the environment, and
+// creates the new bus unit records in batches.
+//
+// It returns the error of every bus unit that was not created by its index in the list,
+// the bus units that already exist fail with ErrAlreadyExists.
+func CreateBusUnits(ctx context.Context, list []schema.BusUnit) (map[int]error, error) {
 	var tablename = env.BUS_UNIT
 
 	// Check if the DynamoDB Table is configured
@@ -149,22 +165,28 @@ func CreateBusUnit(ctx context.Context, data interface{}) error {
 		trail.Error("dynamodb BUS_UNIT_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BUS_UNIT_TABLE environment variable is not set")
 
-		return err
+		return nil, err
 	}
 
-	// Save the Bus Unit information into the DynamoDB Table
-	err := InsertItem(ctx, tablename, data)
-	if err != nil {
-		trail.Error("failed to insert bus unit")
-		return err
+	var data = make([]interface{}, len(list))
+	for i, item := range list {
+		data[i] = item
+	}
+
+	// Save the Bus Unit records into the DynamoDB Table
+	failed := BatchInsertItems(ctx, tablename, data, CreateOnly("code"))
+	if len(failed) > 0 {
+		trail.Error("failed to insert %d of %d bus unit record(s)", len(failed), len(list))
 	}
 
-	return nil
+	return failed, nil
 }
 
 // UpdateBusUnit checks if the DynamoDB Table is configured on the environment and
 // updates the bus unit's information or record.
-func UpdateBusUnit(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder) (schema.BusUnit, error) {
+// It only updates the record if it is still on the version, otherwise it
+// returns ErrVersionMismatch.
+func UpdateBusUnit(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder, version int) (schema.BusUnit, error) {
 	var (
 		unit      schema.BusUnit
 		tablename = env.BUS_UNIT
@@ -178,7 +200,7 @@ func UpdateBusUnit(ctx context.Context, key map[string]types.AttributeValue, upd
 		return unit, err
 	}
 
-	result, err := UpdateItem(ctx, tablename, key, update)
+	result, err := UpdateVersionedItem(ctx, tablename, key, update, version)
 	if err != nil {
 		trail.Error("failed to update the bus unit record")
 		return unit, err
@@ -195,8 +217,9 @@ func UpdateBusUnit(ctx context.Context, key map[string]types.AttributeValue, upd
 }
 
 // FilterBusUnit checks if the DynamoDB Table is configured on the environment,
-// fetches and returns a list of bus unit information.
-func FilterBusUnit(ctx context.Context, code, busId string, active *bool) ([]schema.BusUnit, error) {
+// fetches and returns a page of bus unit information and the next page token.
+// A bus unit must have every one of the amenities.
+func FilterBusUnit(ctx context.Context, code, busId string, active *bool, amenities []string, page Page) ([]schema.BusUnit, string, error) {
 	var (
 		unitList  []schema.BusUnit
 		tablename = env.BUS_UNIT
@@ -207,42 +230,38 @@ func FilterBusUnit(ctx context.Context, code, busId string, active *bool) ([]sch
 		trail.Error("dynamodb BUS_UNIT_TABLE is not configured on the environment")
 		err := errors.New("dynamodb BUS_UNIT_TABLE environment variable is not set")
 
-		return unitList, err
+		return unitList, "", err
 	}
 
-	// Construct the filter builder with a name that contains a specified value.
-	var (
-		filter          expression.ConditionBuilder
-		busIdExpression = expression.Name("bus_id").Equal(expression.Value(busId))
-	)
-
-	switch {
-	case code != "":
-		filter = busIdExpression.And(expression.Name("code").Equal(expression.Value(code)))
+	// WHERE bus_id = bus_id_value AND code = code_value AND active = active_value
+	var filters = []Filter{{Name: "bus_id", Value: busId}}
 
-	case active != nil:
-		filter = busIdExpression.And(expression.Name("active").Equal(expression.Value(active)))
+	if code != "" {
+		filters = append(filters, Filter{Name: "code", Value: code})
+	}
 
-	case code != "" && active != nil:
-		filter = busIdExpression.And(expression.Name("active").Equal(expression.Value(active)), expression.Name("code").Equal(expression.Value(code)))
+	if active != nil {
+		filters = append(filters, Filter{Name: "active", Value: active})
+	}
 
-	default:
-		filter = busIdExpression
+	// AND contains(amenities, amenity_value)
+	for _, amenity := range amenities {
+		filters = append(filters, Filter{Name: "amenities", Value: amenity, Contains: true})
 	}
 
-	result, err := FilterItems(ctx, tablename, filter)
+	items, nextToken, err := FindItems(ctx, tablename, busUnitIndexes, filters, page)
 	if err != nil {
-		return unitList, err
+		return unitList, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
 		// Unmarshal a map into actual bus unit struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&unitList, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&unitList, items)
 		if err != nil {
-			return unitList, err
+			return unitList, "", err
 		}
 	}
 
-	return unitList, nil
+	return unitList, nextToken, nil
 }
@@ -0,0 +1,393 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// CreateBusUnitMaintenance checks if the DynamoDB Table is configured on the environment,
+// and creates a new maintenance of the bus unit.
+func CreateBusUnitMaintenance(ctx context.Context, maintenance schema.BusUnitMaintenance) error {
+	var tablename = env.MAINTENANCE_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb MAINTENANCE_TABLE is not configured on the environment")
+		err := errors.New("dynamodb MAINTENANCE_TABLE environment variable is not set")
+
+		return err
+	}
+
+	err := InsertItem(ctx, tablename, maintenance, CreateOnly("bus_id"))
+	if err != nil {
+		trail.Error("failed to insert a new bus unit maintenance")
+		return err
+	}
+
+	return nil
+}
+
+// GetBusUnitMaintenance checks if the DynamoDB Table is configured on the environment, and
+// returns every maintenance of the bus units of the bus line in the order they were
+// scheduled. If the bus unit is set, it only returns the maintenance of the bus unit.
+func GetBusUnitMaintenance(ctx context.Context, busId, busUnitId string) ([]schema.BusUnitMaintenance, error) {
+	var (
+		list      = []schema.BusUnitMaintenance{}
+		tablename = env.MAINTENANCE_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb MAINTENANCE_TABLE is not configured on the environment")
+		err := errors.New("dynamodb MAINTENANCE_TABLE environment variable is not set")
+
+		return nil, err
+	}
+
+	// WHERE bus_id = bus_id_value
+	key := expression.Key("bus_id").Equal(expression.Value(busId))
+	builder := expression.NewBuilder().WithKeyCondition(key)
+
+	// AND bus_unit_id = bus_unit_id_value
+	if busUnitId != "" {
+		builder = builder.WithFilter(expression.Name("bus_unit_id").Equal(expression.Value(busUnitId)))
+	}
+
+	// Build an expression to retrieve the items from the DynamoDB
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+	}
+
+	var page = Page{Limit: MAX_PAGE_LIMIT}
+	for {
+		items, nextToken, err := QueryItems(ctx, params, page)
+		if err != nil {
+			return nil, err
+		}
+
+		var maintenance []schema.BusUnitMaintenance
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&maintenance, items)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, maintenance...)
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+// GetBusUnitMaintenanceById checks if the DynamoDB Table is configured on the environment,
+// and returns the maintenance of the bus line. It returns an empty maintenance if it does
+// not exist.
+func GetBusUnitMaintenanceById(ctx context.Context, busId, id string) (schema.BusUnitMaintenance, error) {
+	var (
+		maintenance schema.BusUnitMaintenance
+		tablename   = env.MAINTENANCE_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb MAINTENANCE_TABLE is not configured on the environment")
+		err := errors.New("dynamodb MAINTENANCE_TABLE environment variable is not set")
+
+		return maintenance, err
+	}
+
+	// Create a composite key expression
+	key := expression.KeyAnd(expression.Key("bus_id").Equal(expression.Value(busId)), expression.Key("id").Equal(expression.Value(id)))
+
+	// Build an expression to retrieve the item from the DynamoDB
+	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
+	if err != nil {
+		return maintenance, err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	result, err := awswrapper.DynamoDBQuery(ctx, params)
+	if err != nil {
+		return maintenance, err
+	}
+
+	if result.Count > 0 {
+		err := awswrapper.DynamoDBUnmarshalMap(&maintenance, result.Items[0])
+		if err != nil {
+			return maintenance, err
+		}
+	}
+
+	return maintenance, nil
+}
+
+// DeleteBusUnitMaintenance checks if the DynamoDB Table is configured on the environment,
+// and deletes the maintenance of the bus line.
+func DeleteBusUnitMaintenance(ctx context.Context, busId, id string) error {
+	var tablename = env.MAINTENANCE_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb MAINTENANCE_TABLE is not configured on the environment")
+		err := errors.New("dynamodb MAINTENANCE_TABLE environment variable is not set")
+
+		return err
+	}
+
+	var key = map[string]types.AttributeValue{
+		"bus_id": &types.AttributeValueMemberS{Value: busId},
+		"id":     &types.AttributeValueMemberS{Value: id},
+	}
+
+	_, err := ConsumeItem(ctx, tablename, key, expression.AttributeExists(expression.Name("id")))
+	if err != nil {
+		trail.Error("failed to delete the bus unit maintenance")
+		return err
+	}
+
+	return nil
+}
+
+// getBusUnitRoutes returns every bus route of the bus unit.
+func getBusUnitRoutes(ctx context.Context, busId, busUnitId string) ([]schema.BusRoute, error) {
+	var (
+		routes []schema.BusRoute
+		page   = Page{Limit: MAX_PAGE_LIMIT}
+	)
+
+	for {
+		list, nextToken, err := FilterBusRoute(ctx, schema.BusRouteFilter{BusID: busId, BusUnitID: busUnitId}, page)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, list...)
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return routes, nil
+}
+
+// FlagMaintenanceBookings checks if the DynamoDB Table is configured on the environment,
+// and flags the bookings of the bus routes of the bus unit whose travel date is within
+// the maintenance, so that they are reassigned to another bus unit. The cancelled
+// bookings are not flagged. It returns the bookings that have been flagged.
+//
+// The flag is maintained by the system, it does not change the version of the bookings.
+func FlagMaintenanceBookings(ctx context.Context, maintenance schema.BusUnitMaintenance) ([]schema.Bookings, error) {
+	var (
+		flagged   = []schema.Bookings{}
+		tablename = env.BOOKING_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BOOKING_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BOOKING_TABLE environment variable is not set")
+
+		return nil, err
+	}
+
+	routes, err := getBusUnitRoutes(ctx, maintenance.BusID, maintenance.BusUnitID)
+	if err != nil {
+		trail.Error("failed to fetch the bus routes of the bus unit")
+		return nil, err
+	}
+
+	for _, route := range routes {
+		var page = Page{Limit: MAX_PAGE_LIMIT}
+
+		for {
+			bookings, nextToken, err := FilterBookings(ctx, "", maintenance.BusID, route.ID, "ALL", page)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, booking := range bookings {
+				if booking.Status == booking.Status.Cancelled() || !maintenance.Covers(booking.TravelDate) {
+					continue
+				}
+
+				var key = map[string]types.AttributeValue{
+					"id":           &types.AttributeValueMemberS{Value: booking.ID},
+					"bus_route_id": &types.AttributeValueMemberS{Value: booking.BusRouteID},
+				}
+
+				update := expression.Set(expression.Name("maintenance_id"), expression.Value(maintenance.ID))
+				condition := expression.AttributeExists(expression.Name("id"))
+
+				_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+				if errors.Is(err, ErrVersionMismatch) {
+					continue
+				}
+
+				if err != nil {
+					trail.Error("failed to flag the booking of the bus unit maintenance")
+					return nil, err
+				}
+
+				booking.MaintenanceID = maintenance.ID
+				flagged = append(flagged, booking)
+			}
+
+			if nextToken == "" {
+				break
+			}
+
+			page.StartKey, err = decodeNextToken(nextToken)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return flagged, nil
+}
+
+// FilterMaintenanceConflicts checks if the DynamoDB Table is configured on the environment,
+// fetches and returns a page of the bookings that are flagged by a maintenance and are not
+// cancelled, and the next page token. If the bus line is set, it only returns the bookings
+// of the bus line, and if the maintenance is set, only the bookings that it flagged.
+func FilterMaintenanceConflicts(ctx context.Context, busId, maintenanceId string, page Page) ([]schema.Bookings, string, error) {
+	var (
+		bookings  []schema.Bookings
+		tablename = env.BOOKING_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BOOKING_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BOOKING_TABLE environment variable is not set")
+
+		return nil, "", err
+	}
+
+	// WHERE maintenance_id IS SET AND status <> CANCELLED
+	var status schema.BookingStatus
+	condition := expression.AttributeExists(expression.Name("maintenance_id")).
+		And(expression.Name("status").NotEqual(expression.Value(status.Cancelled())))
+
+	if busId != "" {
+		condition = condition.And(expression.Name("bus_id").Equal(expression.Value(busId)))
+	}
+
+	if maintenanceId != "" {
+		condition = condition.And(expression.Name("maintenance_id").Equal(expression.Value(maintenanceId)))
+	}
+
+	items, nextToken, err := FilterItems(ctx, tablename, condition, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(items) > 0 {
+		// Unmarshal a map into actual booking struct which the front-end can
+		// understand as a JSON.
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&bookings, items)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return bookings, nextToken, nil
+}
+
+// ClearMaintenanceBookings checks if the DynamoDB Table is configured on the environment,
+// and removes the flag of the bookings that were flagged by the maintenance, including
+// the cancelled ones.
+func ClearMaintenanceBookings(ctx context.Context, maintenance schema.BusUnitMaintenance) error {
+	var tablename = env.BOOKING_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BOOKING_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BOOKING_TABLE environment variable is not set")
+
+		return err
+	}
+
+	// WHERE bus_id = bus_id_value AND maintenance_id = maintenance_id_value
+	filter := expression.Name("bus_id").Equal(expression.Value(maintenance.BusID)).
+		And(expression.Name("maintenance_id").Equal(expression.Value(maintenance.ID)))
+
+	var page = Page{Limit: MAX_PAGE_LIMIT}
+	for {
+		items, nextToken, err := FilterItems(ctx, tablename, filter, page)
+		if err != nil {
+			return err
+		}
+
+		var bookings []schema.Bookings
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&bookings, items)
+		if err != nil {
+			return err
+		}
+
+		for _, booking := range bookings {
+			var key = map[string]types.AttributeValue{
+				"id":           &types.AttributeValueMemberS{Value: booking.ID},
+				"bus_route_id": &types.AttributeValueMemberS{Value: booking.BusRouteID},
+			}
+
+			// WHERE maintenance_id = maintenance_id_value
+			update := expression.Remove(expression.Name("maintenance_id"))
+			condition := expression.Name("maintenance_id").Equal(expression.Value(maintenance.ID))
+
+			_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+			if err != nil && !errors.Is(err, ErrVersionMismatch) {
+				trail.Error("failed to clear the flag of the booking")
+				return err
+			}
+		}
+
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,18 +2,84 @@ package query
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
 	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
 	"github.com/rmarasigan/bus-ticketing/internal/trail"
 )
 
+// ErrVersionMismatch is returned when the item has been modified since the
+// version that the update is based on.
+var ErrVersionMismatch = errors.New("the record has been modified, fetch the latest version and try again")
+
+// ErrAlreadyExists is returned by a create-only insert when an item with the same
+// primary key or with one of the same unique keys already exists.
+var ErrAlreadyExists = errors.New("the record already exists")
+
+// InsertMode specifies whether InsertItem may replace an existing item. The zero
+// value replaces the existing item that has the same primary key.
+type InsertMode struct {
+	PartitionKey     string   // The partition key attribute name, if it is set the item is only created if it does not exist yet
+	UniqueKeys       []string // The values that must not be taken by another item, e.g. "username#emilydavis"
+	UniqueAttributes []string // The attributes whose values must not be taken by another item of any table, e.g. "id"
+}
+
+// Upsert creates the item, or replaces the existing item that has the same primary key.
+var Upsert = InsertMode{}
+
+// CreateOnly only creates the item if there is no item with the same primary key yet,
+// and none of the unique keys have been taken by another item.
+//
+// The unique keys are for the values that are not part of the primary key, they are
+// recorded in the UNIQUE_KEY_TABLE together with the item.
+func CreateOnly(partitionKey string, uniqueKeys ...string) InsertMode {
+	return InsertMode{PartitionKey: partitionKey, UniqueKeys: uniqueKeys}
+}
+
+// WithUniqueAttributes returns the mode that also records the values of the attributes
+// of the item in the UNIQUE_KEY_TABLE, so that an ID is never taken twice even if it is
+// not the partition key.
+//
+// Example:
+//  CreateOnly("username").WithUniqueAttributes("id")
+//  id#CSTMR-01H4Z3KX8Q9V7W5T2N6M4R3P1A
+func (mode InsertMode) WithUniqueAttributes(attributes ...string) InsertMode {
+	mode.UniqueAttributes = append(append([]string{}, mode.UniqueAttributes...), attributes...)
+	return mode
+}
+
+// uniqueKeys returns the unique keys of the mode together with the unique keys of the
+// values of its unique attributes.
+func (mode InsertMode) uniqueKeys(values map[string]types.AttributeValue) []string {
+	var keys = append([]string{}, mode.UniqueKeys...)
+
+	for _, attribute := range mode.UniqueAttributes {
+		if value, ok := values[attribute].(*types.AttributeValueMemberS); ok && value.Value != "" {
+			keys = append(keys, attribute+"#"+value.Value)
+		}
+	}
+
+	return keys
+}
+
+// notExists returns the condition that there is no item with the same primary key
+// yet, the item of a primary key always has its partition key attribute.
+func notExists(partitionKey string) (expression.Expression, error) {
+	condition := expression.AttributeNotExists(expression.Name(partitionKey))
+	return expression.NewBuilder().WithCondition(condition).Build()
+}
+
 // InsertItem converts the data into a map of AttributeValues and performs DynamoDB Put
 // Item Operation to create the new item into the DynamoDB Table.
-func InsertItem(ctx context.Context, tablename string, data interface{}) error {
+//
+// If the mode is create-only, it returns ErrAlreadyExists if an item with the same
+// primary key or with one of the same unique keys already exists.
+func InsertItem(ctx context.Context, tablename string, data interface{}, mode InsertMode) error {
 	// Marshal the user to a map of AttributeValues
 	values, err := awswrapper.DynamoDBMarshalMap(data)
 	if err != nil {
@@ -26,15 +92,119 @@ func InsertItem(ctx context.Context, tablename string, data interface{}) error {
 		TableName: aws.String(tablename),
 	}
 
-	// Save the item into the DynamoDB Table
+	if mode.PartitionKey == "" {
+		// Save the item into the DynamoDB Table
+		_, err = awswrapper.DynamoDBPutItem(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	expr, err := notExists(mode.PartitionKey)
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return err
+	}
+
+	uniqueKeys := mode.uniqueKeys(values)
+	if len(uniqueKeys) > 0 {
+		return insertUniqueItem(ctx, tablename, values, expr, uniqueKeys)
+	}
+
+	params.ConditionExpression = expr.Condition()
+	params.ExpressionAttributeNames = expr.Names()
+
+	// Save the item into the DynamoDB Table only if it does not exist yet
 	_, err = awswrapper.DynamoDBPutItem(ctx, params)
 	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrAlreadyExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// insertUniqueItem creates the item and records its unique keys in the UNIQUE_KEY_TABLE
+// together. Either all of them are created or none of them.
+func insertUniqueItem(ctx context.Context, tablename string, values map[string]types.AttributeValue, expr expression.Expression, uniqueKeys []string) error {
+	var uniqueTable = env.UNIQUE_KEY_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if uniqueTable == "" {
+		trail.Error("dynamodb UNIQUE_KEY_TABLE is not configured on the environment")
+		err := errors.New("dynamodb UNIQUE_KEY_TABLE environment variable is not set")
+
+		return err
+	}
+
+	keyExpr, err := notExists("key")
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return err
+	}
+
+	var items = []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				Item:                     values,
+				TableName:                aws.String(tablename),
+				ConditionExpression:      expr.Condition(),
+				ExpressionAttributeNames: expr.Names(),
+			},
+		},
+	}
+
+	for _, key := range uniqueKeys {
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				Item:                     map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+				TableName:                aws.String(uniqueTable),
+				ConditionExpression:      keyExpr.Condition(),
+				ExpressionAttributeNames: keyExpr.Names(),
+			},
+		})
+	}
+
+	_, err = awswrapper.DynamoDBTransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		if isConditionFailed(err) {
+			return ErrAlreadyExists
+		}
+
 		return err
 	}
 
 	return nil
 }
 
+// isConditionFailed returns true if the condition of the DynamoDB write failed, or if
+// the DynamoDB transaction was cancelled because the condition of one of its items failed.
+func isConditionFailed(err error) bool {
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return true
+	}
+
+	var cancelled *types.TransactionCanceledException
+	if !errors.As(err, &cancelled) {
+		return false
+	}
+
+	for _, reason := range cancelled.CancellationReasons {
+		if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			return true
+		}
+	}
+
+	return false
+}
+
 // IsExisting creates an expression, performs DyanmoDB Query Operation, and returns
 // if the item from the DynamoDB Table exist or not.
 func IsExisting(ctx context.Context, tablename string, key expression.KeyConditionBuilder) (bool, error) {
@@ -88,28 +258,245 @@ func UpdateItem(ctx context.Context, tablename string, key map[string]types.Attr
 	return result, nil
 }
 
-// FilterItems creates an expression with ConditionBuilder, performs the DynamoDB Scan
-// operation, and returns a list of attributes of the items.
-func FilterItems(ctx context.Context, tablename string, filter expression.ConditionBuilder) (*dynamodb.ScanOutput, error) {
-	// Using the update expression to create a DynamoDB Expression
-	expr, err := expression.NewBuilder().WithCondition(filter).Build()
+// UpdateItemIf edits an existing item's attributes only if the condition is met, otherwise
+// it returns ErrVersionMismatch.
+func UpdateItemIf(ctx context.Context, tablename string, key map[string]types.AttributeValue, update expression.UpdateBuilder, condition expression.ConditionBuilder) (*dynamodb.UpdateItemOutput, error) {
+	// Using the update and condition expression to create a DynamoDB Expression
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
 	if err != nil {
 		trail.Error("failed to build DynamoDB Expression")
 		return nil, err
 	}
 
-	// Use the build expression to populate the DynamoDB Scan API
-	var params = &dynamodb.ScanInput{
+	// Use the build expression to populate the DynamoDB Update Item API
+	var params = &dynamodb.UpdateItemInput{
+		Key:                       key,
 		TableName:                 aws.String(tablename),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
-		FilterExpression:          expr.Condition(),
+		ReturnValues:              types.ReturnValueAllNew,
+	}
+
+	result, err := awswrapper.DynamoDBUpdateItem(ctx, params)
+	if isConditionFailed(err) {
+		return nil, ErrVersionMismatch
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ConsumeItem deletes the item of the key only if the condition is met, and returns its
+// attributes. It returns nil attributes if there is no item or the condition is not met,
+// so that an item such as a one-time token can only be consumed once.
+func ConsumeItem(ctx context.Context, tablename string, key map[string]types.AttributeValue, condition expression.ConditionBuilder) (map[string]types.AttributeValue, error) {
+	// Using the condition expression to create a DynamoDB Expression
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return nil, err
+	}
+
+	// Use the build expression to populate the DynamoDB Delete Item API
+	var params = &dynamodb.DeleteItemInput{
+		Key:                       key,
+		TableName:                 aws.String(tablename),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllOld,
+	}
+
+	result, err := awswrapper.DynamoDBDeleteItem(ctx, params)
+	if isConditionFailed(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Attributes, nil
+}
+
+// ScanItems performs the DynamoDB Scan operation until the page limit is reached, the
+// table is exhausted or MAX_PAGE_READS is reached, and returns the items and the token
+// of the next page.
+//
+// The DynamoDB Scan applies the limit before the filter expression, so a page can have
+// fewer items than its limit even if it has a next token. A scan is not sorted, the
+// items are returned in the order they are stored in, which may change as items are
+// added, the next token continues after the last item that is returned.
+func ScanItems(ctx context.Context, params *dynamodb.ScanInput, page Page) ([]map[string]types.AttributeValue, string, error) {
+	return readPage(page, aws.ToString(params.TableName), params.IndexName, func(startKey map[string]types.AttributeValue, limit int32) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+		params.ExclusiveStartKey = startKey
+		params.Limit = aws.Int32(limit)
+
+		result, err := awswrapper.DynamoDBScan(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return result.Items, result.LastEvaluatedKey, nil
+	})
+}
+
+// QueryItems performs the DynamoDB Query operation until the page limit is reached, there
+// are no more matching items or MAX_PAGE_READS is reached, and returns the items and the
+// token of the next page. The items are sorted by the sort key of the table or index.
+func QueryItems(ctx context.Context, params *dynamodb.QueryInput, page Page) ([]map[string]types.AttributeValue, string, error) {
+	return readPage(page, aws.ToString(params.TableName), params.IndexName, func(startKey map[string]types.AttributeValue, limit int32) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+		params.ExclusiveStartKey = startKey
+		params.Limit = aws.Int32(limit)
+
+		result, err := awswrapper.DynamoDBQuery(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return result.Items, result.LastEvaluatedKey, nil
+	})
+}
+
+// readPage calls read until the page limit is reached, there is no last evaluated key
+// or MAX_PAGE_READS is reached, and returns the items and the token of the next page.
+//
+// Every read evaluates up to MAX_PAGE_LIMIT items. When the items go over the page limit,
+// the page is cut at its limit and the next token is the key of its last item. If the key
+// attributes of the table or index are not known, a read only evaluates the number of
+// items that are still missing from the page instead.
+func readPage(page Page, tablename string, indexName *string, read func(map[string]types.AttributeValue, int32) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error)) ([]map[string]types.AttributeValue, string, error) {
+	var (
+		items    []map[string]types.AttributeValue
+		startKey = page.StartKey
+		limit    = page.limit()
+		known    = len(keyAttributes(tablename, indexName)) > 0
+	)
+
+	// The next token must be a start key of the table or index that is read
+	err := checkStartKey(page, tablename, indexName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for reads := 0; reads < MAX_PAGE_READS; reads++ {
+		var evaluate int32 = MAX_PAGE_LIMIT
+		if !known {
+			evaluate = limit - int32(len(items))
+		}
+
+		result, lastKey, err := read(startKey, evaluate)
+		if err != nil {
+			return nil, "", err
+		}
+
+		items = append(items, result...)
+		startKey = lastKey
+
+		if int32(len(items)) > limit {
+			items = items[:limit]
+
+			key, ok := itemKey(items[limit-1], tablename, indexName)
+			if !ok {
+				trail.Error("the items of %s do not have the key attributes of the table", tablename)
+				return nil, "", errors.New("failed to get the start key of the next page")
+			}
+
+			startKey = key
+		}
+
+		if len(startKey) == 0 || int32(len(items)) >= limit {
+			break
+		}
+	}
+
+	nextToken, err := encodeNextToken(startKey)
+	if err != nil {
+		trail.Error("failed to encode the next page token")
+		return nil, "", err
+	}
+
+	return items, nextToken, nil
+}
+
+// versionedUpdate increments the version of the item and returns the condition that
+// the item still exists and it is on the same version that was read, otherwise a
+// concurrent update would be overwritten.
+func versionedUpdate(key map[string]types.AttributeValue, update expression.UpdateBuilder, version int) (expression.UpdateBuilder, expression.ConditionBuilder) {
+	var condition = expression.Name("version").Equal(expression.Value(version))
+	if version == 0 {
+		condition = expression.AttributeNotExists(expression.Name("version"))
+	}
+
+	for name := range key {
+		condition = condition.And(expression.AttributeExists(expression.Name(name)))
 	}
 
-	result, err := awswrapper.DynamoDBScan(ctx, params)
+	return update.Set(expression.Name("version"), expression.Value(version+1)), condition
+}
+
+// UpdateVersionedItem creates an expression with UpdateBuilder, performs the DynamoDB
+// UpdateItem operation only if the item is still on the version, increments the
+// version, and returns all of the attributes of the item.
+//
+// Items that were created before the "version" attribute existed are on version 0.
+// It returns ErrVersionMismatch if the item has been modified by another request.
+func UpdateVersionedItem(ctx context.Context, tablename string, key map[string]types.AttributeValue, update expression.UpdateBuilder, version int) (*dynamodb.UpdateItemOutput, error) {
+	update, condition := versionedUpdate(key, update, version)
+
+	// Using the update and condition expression to create a DynamoDB Expression
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
 	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return nil, err
+	}
+
+	// Use the build expression to populate the DynamoDB Update Item API
+	var params = &dynamodb.UpdateItemInput{
+		Key:                       key,
+		TableName:                 aws.String(tablename),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllNew,
+	}
+
+	result, err := awswrapper.DynamoDBUpdateItem(ctx, params)
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil, ErrVersionMismatch
+		}
+
 		return nil, err
 	}
 
 	return result, nil
 }
+
+// FilterItems creates an expression with ConditionBuilder, performs the DynamoDB Scan
+// operation, and returns a page of attributes of the items and the next page token.
+func FilterItems(ctx context.Context, tablename string, filter expression.ConditionBuilder, page Page) ([]map[string]types.AttributeValue, string, error) {
+	// Using the update expression to create a DynamoDB Expression
+	expr, err := expression.NewBuilder().WithCondition(filter).Build()
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return nil, "", err
+	}
+
+	// Use the build expression to populate the DynamoDB Scan API
+	var params = &dynamodb.ScanInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Condition(),
+	}
+
+	return ScanItems(ctx, params, page)
+}
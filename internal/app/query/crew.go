@@ -0,0 +1,136 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// CreateCrew checks if the DynamoDB Table is configured on the environment, and creates
+// a new crew record.
+func CreateCrew(ctx context.Context, crew schema.Crew) error {
+	var tablename = env.CREW_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb CREW_TABLE is not configured on the environment")
+		err := errors.New("dynamodb CREW_TABLE environment variable is not set")
+
+		return err
+	}
+
+	err := InsertItem(ctx, tablename, crew, CreateOnly("bus_id"))
+	if err != nil {
+		trail.Error("failed to insert a new crew record")
+		return err
+	}
+
+	return nil
+}
+
+// GetCrewRecords checks if the DynamoDB Table is configured on the environment, and
+// returns either the specific crew or a page of the crew records of the bus line and
+// the next page token.
+func GetCrewRecords(ctx context.Context, busId, id string, page Page) ([]schema.Crew, string, error) {
+	var (
+		list      []schema.Crew
+		tablename = env.CREW_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb CREW_TABLE is not configured on the environment")
+		err := errors.New("dynamodb CREW_TABLE environment variable is not set")
+
+		return nil, "", err
+	}
+
+	// WHERE bus_id = bus_id_value [AND id = id_value]
+	key := expression.Key("bus_id").Equal(expression.Value(busId))
+	if id != "" {
+		key = expression.KeyAnd(key, expression.Key("id").Equal(expression.Value(id)))
+	}
+
+	// Build an expression to retrieve the items from the DynamoDB
+	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	items, nextToken, err := QueryItems(ctx, params, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(items) > 0 {
+		// Unmarshal a map into actual crew struct which the front-end can
+		// understand as a JSON.
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&list, items)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return list, nextToken, nil
+}
+
+// GetCrewById returns the crew of the bus line. It returns an empty crew if it does
+// not exist.
+func GetCrewById(ctx context.Context, busId, id string) (schema.Crew, error) {
+	list, _, err := GetCrewRecords(ctx, busId, id, Page{})
+	if err != nil || len(list) == 0 {
+		return schema.Crew{}, err
+	}
+
+	return list[0], nil
+}
+
+// UpdateCrew checks if the DynamoDB Table is configured on the environment and updates
+// the crew record.
+// It only updates the record if it is still on the version, otherwise it
+// returns ErrVersionMismatch.
+func UpdateCrew(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder, version int) (schema.Crew, error) {
+	var (
+		crew      schema.Crew
+		tablename = env.CREW_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb CREW_TABLE is not configured on the environment")
+		err := errors.New("dynamodb CREW_TABLE environment variable is not set")
+
+		return crew, err
+	}
+
+	result, err := UpdateVersionedItem(ctx, tablename, key, update, version)
+	if err != nil {
+		trail.Error("failed to update the crew record")
+		return crew, err
+	}
+
+	// Unmarshal a map into actual crew struct which the front-end can
+	// understand as a JSON
+	err = awswrapper.DynamoDBUnmarshalMap(&crew, result.Attributes)
+	if err != nil {
+		return crew, err
+	}
+
+	return crew, nil
+}
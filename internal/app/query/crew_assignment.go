@@ -0,0 +1,292 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// crewAssignmentIndexes are the key schemas of the CREW_ASSIGNMENT_TABLE that can be queried.
+var crewAssignmentIndexes = []Index{
+	{PartitionKey: "crew_id", SortKey: "id"},
+	{Name: "bus_route_id-index", PartitionKey: "bus_route_id"},
+}
+
+// CreateCrewAssignment checks if the DynamoDB Table is configured on the environment,
+// and assigns the crew to the trips of the bus route.
+func CreateCrewAssignment(ctx context.Context, assignment schema.CrewAssignment) error {
+	var tablename = env.CREW_ASSIGNMENT_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb CREW_ASSIGNMENT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb CREW_ASSIGNMENT_TABLE environment variable is not set")
+
+		return err
+	}
+
+	err := InsertItem(ctx, tablename, assignment, CreateOnly("crew_id"))
+	if err != nil {
+		trail.Error("failed to insert a new crew assignment")
+		return err
+	}
+
+	return nil
+}
+
+// FilterCrewAssignments checks if the DynamoDB Table is configured on the environment,
+// fetches and returns a page of the assignments of the crew or of the bus route, and the
+// next page token. If the bus line is set, it only returns the assignments of the bus line.
+func FilterCrewAssignments(ctx context.Context, busId, crewId, routeId string, page Page) ([]schema.CrewAssignment, string, error) {
+	var (
+		filters     []Filter
+		assignments []schema.CrewAssignment
+		tablename   = env.CREW_ASSIGNMENT_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb CREW_ASSIGNMENT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb CREW_ASSIGNMENT_TABLE environment variable is not set")
+
+		return nil, "", err
+	}
+
+	if busId != "" {
+		filters = append(filters, Filter{Name: "bus_id", Value: busId})
+	}
+
+	if crewId != "" {
+		filters = append(filters, Filter{Name: "crew_id", Value: crewId})
+	}
+
+	if routeId != "" {
+		filters = append(filters, Filter{Name: "bus_route_id", Value: routeId})
+	}
+
+	items, nextToken, err := FindItems(ctx, tablename, crewAssignmentIndexes, filters, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(items) > 0 {
+		// Unmarshal a map into actual crew assignment struct which the front-end
+		// can understand as a JSON.
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&assignments, items)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return assignments, nextToken, nil
+}
+
+// GetCrewAssignments returns every assignment of the crew or of the bus route.
+func GetCrewAssignments(ctx context.Context, busId, crewId, routeId string) ([]schema.CrewAssignment, error) {
+	var (
+		assignments = []schema.CrewAssignment{}
+		page        = Page{Limit: MAX_PAGE_LIMIT}
+	)
+
+	for {
+		list, nextToken, err := FilterCrewAssignments(ctx, busId, crewId, routeId, page)
+		if err != nil {
+			return nil, err
+		}
+
+		assignments = append(assignments, list...)
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return assignments, nil
+}
+
+// GetCrewAssignmentById checks if the DynamoDB Table is configured on the environment,
+// and returns the assignment of the crew. It returns an empty assignment if it does not
+// exist.
+func GetCrewAssignmentById(ctx context.Context, crewId, id string) (schema.CrewAssignment, error) {
+	var (
+		assignment schema.CrewAssignment
+		tablename  = env.CREW_ASSIGNMENT_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb CREW_ASSIGNMENT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb CREW_ASSIGNMENT_TABLE environment variable is not set")
+
+		return assignment, err
+	}
+
+	// Create a composite key expression
+	key := expression.KeyAnd(expression.Key("crew_id").Equal(expression.Value(crewId)), expression.Key("id").Equal(expression.Value(id)))
+
+	// Build an expression to retrieve the item from the DynamoDB
+	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
+	if err != nil {
+		return assignment, err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	result, err := awswrapper.DynamoDBQuery(ctx, params)
+	if err != nil {
+		return assignment, err
+	}
+
+	if result.Count > 0 {
+		err := awswrapper.DynamoDBUnmarshalMap(&assignment, result.Items[0])
+		if err != nil {
+			return assignment, err
+		}
+	}
+
+	return assignment, nil
+}
+
+// DeleteCrewAssignment checks if the DynamoDB Table is configured on the environment,
+// and removes the assignment of the crew.
+func DeleteCrewAssignment(ctx context.Context, crewId, id string) error {
+	var tablename = env.CREW_ASSIGNMENT_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb CREW_ASSIGNMENT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb CREW_ASSIGNMENT_TABLE environment variable is not set")
+
+		return err
+	}
+
+	var key = map[string]types.AttributeValue{
+		"crew_id": &types.AttributeValueMemberS{Value: crewId},
+		"id":      &types.AttributeValueMemberS{Value: id},
+	}
+
+	_, err := ConsumeItem(ctx, tablename, key, expression.AttributeExists(expression.Name("id")))
+	if err != nil {
+		trail.Error("failed to delete the crew assignment")
+		return err
+	}
+
+	return nil
+}
+
+// RescheduleCrewAssignments checks if the DynamoDB Table is configured on the environment,
+// and sets the departure and arrival time of the bus route on the assignments of the bus
+// route whose trips have not departed before today. The trips of the dated assignments in
+// the past are kept as they were.
+//
+// The overlap and the duty of the crew are checked again with the new schedule, and an
+// assignment that conflicts with the other trips of the crew is flagged with the conflict,
+// so that the crew can be unassigned or assigned to other trips. The assignments that have
+// been deleted since they were fetched are skipped.
+func RescheduleCrewAssignments(ctx context.Context, route schema.BusRoute, today string, maxDuty time.Duration) error {
+	var tablename = env.CREW_ASSIGNMENT_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb CREW_ASSIGNMENT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb CREW_ASSIGNMENT_TABLE environment variable is not set")
+
+		return err
+	}
+
+	assignments, err := GetCrewAssignments(ctx, route.BusID, "", route.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, assignment := range assignments {
+		if assignment.TravelDate != "" && assignment.TravelDate < today {
+			continue
+		}
+
+		// The other trips of the crew, the trips of the bus route are on its new schedule
+		others, err := GetCrewAssignments(ctx, "", assignment.CrewID, "")
+		if err != nil {
+			return err
+		}
+
+		for i := range others {
+			if others[i].BusRouteID == route.ID {
+				others[i].DepartureTime = route.DepartureTime
+				others[i].ArrivalTime = route.ArrivalTime
+			}
+		}
+
+		var (
+			flag      string
+			conflict  schema.CrewConflictError
+			unchanged = assignment.DepartureTime == route.DepartureTime && assignment.ArrivalTime == route.ArrivalTime
+		)
+
+		assignment.DepartureTime = route.DepartureTime
+		assignment.ArrivalTime = route.ArrivalTime
+
+		err = assignment.CheckSchedule(others, today, maxDuty)
+		switch {
+		case errors.As(err, &conflict):
+			flag = conflict.Reason
+
+		case err != nil:
+			return err
+		}
+
+		if unchanged && flag == assignment.Conflict {
+			continue
+		}
+
+		update := expression.Set(expression.Name("departure_time"), expression.Value(route.DepartureTime)).
+			Set(expression.Name("arrival_time"), expression.Value(route.ArrivalTime))
+
+		if flag != "" {
+			update = update.Set(expression.Name("conflict"), expression.Value(flag))
+		} else {
+			update = update.Remove(expression.Name("conflict"))
+		}
+
+		var key = map[string]types.AttributeValue{
+			"crew_id": &types.AttributeValueMemberS{Value: assignment.CrewID},
+			"id":      &types.AttributeValueMemberS{Value: assignment.ID},
+		}
+
+		// WHERE id IS SET
+		_, err = UpdateItemIf(ctx, tablename, key, update, expression.AttributeExists(expression.Name("id")))
+		if errors.Is(err, ErrVersionMismatch) {
+			continue
+		}
+
+		if err != nil {
+			trail.Error("failed to reschedule the crew assignment")
+			return err
+		}
+
+		if flag != "" {
+			trail.Info("the crew assignment %s conflicts with the other trips of the crew: %s", assignment.ID, flag)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// CreateEmailVerification checks if the DynamoDB Table is configured on the environment,
+// and creates a new e-mail verification.
+func CreateEmailVerification(ctx context.Context, verification schema.EmailVerification) error {
+	var tablename = env.EMAIL_VERIFICATION_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb EMAIL_VERIFICATION_TABLE is not configured on the environment")
+		err := errors.New("dynamodb EMAIL_VERIFICATION_TABLE environment variable is not set")
+
+		return err
+	}
+
+	err := InsertItem(ctx, tablename, verification, CreateOnly("token_hash"))
+	if err != nil {
+		trail.Error("failed to insert a new e-mail verification")
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeEmailVerification checks if the DynamoDB Table is configured on the environment,
+// deletes the e-mail verification of the token hash, and returns it. The e-mail verification
+// is only returned if it has not expired yet, so that the token can only be used once.
+//
+// It returns an empty e-mail verification if there is none or if it has expired.
+func ConsumeEmailVerification(ctx context.Context, tokenHash string) (schema.EmailVerification, error) {
+	var (
+		verification schema.EmailVerification
+		tablename    = env.EMAIL_VERIFICATION_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb EMAIL_VERIFICATION_TABLE is not configured on the environment")
+		err := errors.New("dynamodb EMAIL_VERIFICATION_TABLE environment variable is not set")
+
+		return verification, err
+	}
+
+	attributes, err := consumeToken(ctx, tablename, tokenHash)
+	if err != nil {
+		trail.Error("failed to delete the e-mail verification")
+		return verification, err
+	}
+
+	if attributes == nil {
+		return verification, nil
+	}
+
+	err = awswrapper.DynamoDBUnmarshalMap(&verification, attributes)
+	if err != nil {
+		return verification, err
+	}
+
+	return verification, nil
+}
@@ -0,0 +1,217 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// loginAttemptKey returns the primary key of the login attempts of the key.
+func loginAttemptKey(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"key": &types.AttributeValueMemberS{Value: key},
+	}
+}
+
+// GetLoginAttempt checks if the DynamoDB Table is configured on the environment, and
+// returns the failed login attempts of the key. It returns an empty login attempt if
+// there have been no failures.
+func GetLoginAttempt(ctx context.Context, key string) (schema.LoginAttempt, error) {
+	var (
+		attempt   schema.LoginAttempt
+		tablename = env.LOGIN_ATTEMPT_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb LOGIN_ATTEMPT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb LOGIN_ATTEMPT_TABLE environment variable is not set")
+
+		return attempt, err
+	}
+
+	// Build an expression to retrieve the item from the DynamoDB
+	expr, err := expression.NewBuilder().WithKeyCondition(expression.Key("key").Equal(expression.Value(key))).Build()
+	if err != nil {
+		return attempt, err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	result, err := awswrapper.DynamoDBQuery(ctx, params)
+	if err != nil {
+		return attempt, err
+	}
+
+	if result.Count > 0 {
+		err := awswrapper.DynamoDBUnmarshalMap(&attempt, result.Items[0])
+		if err != nil {
+			return attempt, err
+		}
+	}
+
+	return attempt, nil
+}
+
+// ReserveLoginAttempt checks if the DynamoDB Table is configured on the environment,
+// and counts the login attempt of the key as a failure before its credentials are
+// verified. It is only counted if the failed login attempts have not changed since
+// they were fetched and the key is not locked, otherwise it returns ErrVersionMismatch
+// since a concurrent login attempt has been counted first.
+func ReserveLoginAttempt(ctx context.Context, attempt schema.LoginAttempt, policy auth.LockoutPolicy, now time.Time) (schema.LoginAttempt, error) {
+	var tablename = env.LOGIN_ATTEMPT_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb LOGIN_ATTEMPT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb LOGIN_ATTEMPT_TABLE environment variable is not set")
+
+		return attempt, err
+	}
+
+	update := expression.Set(expression.Name("last_failure"), expression.Value(now.Unix())).
+		Set(expression.Name("expires_at"), expression.Value(now.Add(policy.Window+policy.Lockout).Unix()))
+
+	if policy.IsStale(attempt, now) {
+		update = update.Set(expression.Name("failures"), expression.Value(1))
+	} else {
+		update = update.Add(expression.Name("failures"), expression.Value(1))
+	}
+
+	// WHERE (key NOT EXISTS OR (failures = attempt.Failures AND last_failure = attempt.LastFailure))
+	// AND (locked_until NOT EXISTS OR locked_until <= now)
+	condition := expression.AttributeNotExists(expression.Name("key")).
+		Or(expression.Name("failures").Equal(expression.Value(attempt.Failures)).
+			And(expression.Name("last_failure").Equal(expression.Value(attempt.LastFailure)))).
+		And(expression.AttributeNotExists(expression.Name("locked_until")).
+		Or(expression.Name("locked_until").LessThanEqual(expression.Value(now.Unix()))))
+
+	result, err := UpdateItemIf(ctx, tablename, loginAttemptKey(attempt.Key), update, condition)
+	if err != nil {
+		if !errors.Is(err, ErrVersionMismatch) {
+			trail.Error("failed to reserve the login attempt")
+		}
+
+		return attempt, err
+	}
+
+	var reserved schema.LoginAttempt
+	err = awswrapper.DynamoDBUnmarshalMap(&reserved, result.Attributes)
+	if err != nil {
+		return attempt, err
+	}
+
+	return reserved, nil
+}
+
+// ReleaseLoginAttempt checks if the DynamoDB Table is configured on the environment,
+// and restores the failed login attempts of the key from before the reservation, once
+// the login attempt turns out not to be a failure. It returns false if another login
+// attempt has been counted since the reservation, so that it is not lost.
+func ReleaseLoginAttempt(ctx context.Context, previous, reserved schema.LoginAttempt) (bool, error) {
+	var tablename = env.LOGIN_ATTEMPT_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb LOGIN_ATTEMPT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb LOGIN_ATTEMPT_TABLE environment variable is not set")
+
+		return false, err
+	}
+
+	// WHERE failures = reserved.Failures AND last_failure = reserved.LastFailure
+	condition := expression.Name("failures").Equal(expression.Value(reserved.Failures)).
+		And(expression.Name("last_failure").Equal(expression.Value(reserved.LastFailure)))
+
+	update := expression.Set(expression.Name("failures"), expression.Value(previous.Failures)).
+		Set(expression.Name("last_failure"), expression.Value(previous.LastFailure))
+
+	_, err := UpdateItemIf(ctx, tablename, loginAttemptKey(reserved.Key), update, condition)
+	if errors.Is(err, ErrVersionMismatch) {
+		return false, nil
+	}
+
+	if err != nil {
+		trail.Error("failed to release the login attempt")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// LockLoginAttempt checks if the DynamoDB Table is configured on the environment, and
+// locks the key until the time. The failures start over once the lockout ends. It
+// returns false if the key is already locked, so that a lockout is only reported once.
+func LockLoginAttempt(ctx context.Context, key string, until, now time.Time) (bool, error) {
+	var tablename = env.LOGIN_ATTEMPT_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb LOGIN_ATTEMPT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb LOGIN_ATTEMPT_TABLE environment variable is not set")
+
+		return false, err
+	}
+
+	// WHERE locked_until NOT EXISTS OR locked_until <= now
+	condition := expression.AttributeNotExists(expression.Name("locked_until")).
+		Or(expression.Name("locked_until").LessThanEqual(expression.Value(now.Unix())))
+
+	update := expression.Set(expression.Name("locked_until"), expression.Value(until.Unix())).
+		Set(expression.Name("failures"), expression.Value(0))
+
+	_, err := UpdateItemIf(ctx, tablename, loginAttemptKey(key), update, condition)
+	if errors.Is(err, ErrVersionMismatch) {
+		return false, nil
+	}
+
+	if err != nil {
+		trail.Error("failed to lock the login attempts")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteLoginAttempt checks if the DynamoDB Table is configured on the environment, and
+// deletes the failed login attempts and the lockout of the key.
+func DeleteLoginAttempt(ctx context.Context, key string) error {
+	var tablename = env.LOGIN_ATTEMPT_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb LOGIN_ATTEMPT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb LOGIN_ATTEMPT_TABLE environment variable is not set")
+
+		return err
+	}
+
+	params := &dynamodb.DeleteItemInput{
+		TableName: aws.String(tablename),
+		Key:       loginAttemptKey(key),
+	}
+
+	_, err := awswrapper.DynamoDBDeleteItem(ctx, params)
+	if err != nil {
+		trail.Error("failed to delete the login attempts")
+		return err
+	}
+
+	return nil
+}
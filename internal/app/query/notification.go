@@ -0,0 +1,183 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// CreateNotification checks if the DynamoDB Table is configured on the environment, and
+// records the notification that has been sent to the user account.
+func CreateNotification(ctx context.Context, notification schema.Notification) error {
+	var tablename = env.NOTIFICATION_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb NOTIFICATION_TABLE is not configured on the environment")
+		err := errors.New("dynamodb NOTIFICATION_TABLE environment variable is not set")
+
+		return err
+	}
+
+	err := InsertItem(ctx, tablename, notification, Upsert)
+	if err != nil {
+		trail.Error("failed to insert a new notification")
+		return err
+	}
+
+	return nil
+}
+
+// GetUserNotifications checks if the DynamoDB Table is configured on the environment, and
+// returns every notification that has been sent to the user account in the order they
+// were sent.
+func GetUserNotifications(ctx context.Context, userId string) ([]schema.Notification, error) {
+	var (
+		notifications = []schema.Notification{}
+		tablename     = env.NOTIFICATION_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb NOTIFICATION_TABLE is not configured on the environment")
+		err := errors.New("dynamodb NOTIFICATION_TABLE environment variable is not set")
+
+		return nil, err
+	}
+
+	// WHERE user_id = user_id_value
+	key := expression.Key("user_id").Equal(expression.Value(userId))
+
+	// Build an expression to retrieve the items from the DynamoDB
+	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	var page = Page{Limit: MAX_PAGE_LIMIT}
+	for {
+		items, nextToken, err := QueryItems(ctx, params, page)
+		if err != nil {
+			return nil, err
+		}
+
+		var list []schema.Notification
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&list, items)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, list...)
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return notifications, nil
+}
+
+// DeleteUserNotifications checks if the DynamoDB Table is configured on the environment,
+// and deletes every notification that has been sent to the user account.
+func DeleteUserNotifications(ctx context.Context, userId string) error {
+	var tablename = env.NOTIFICATION_TABLE
+
+	notifications, err := GetUserNotifications(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	var keys []map[string]types.AttributeValue
+	for _, notification := range notifications {
+		keys = append(keys, map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: notification.UserID},
+			"id":      &types.AttributeValueMemberS{Value: notification.ID},
+		})
+	}
+
+	err = BatchDeleteItems(ctx, tablename, keys)
+	if err != nil {
+		trail.Error("failed to delete the notifications of the user account")
+		return err
+	}
+
+	return nil
+}
+
+// IsNotificationSent checks if the DynamoDB Table is configured on the environment, and
+// checks if a notification of the same type about the same booking and bus route change
+// has already been sent to the user account, so that it is not sent again on a retry.
+func IsNotificationSent(ctx context.Context, notification schema.Notification) (bool, error) {
+	var tablename = env.NOTIFICATION_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb NOTIFICATION_TABLE is not configured on the environment")
+		err := errors.New("dynamodb NOTIFICATION_TABLE environment variable is not set")
+
+		return false, err
+	}
+
+	// WHERE user_id = user_id_value AND type = type_value AND booking_id = booking_id_value
+	// AND change_id = change_id_value
+	key := expression.Key("user_id").Equal(expression.Value(notification.UserID))
+	filter := expression.Name("type").Equal(expression.Value(notification.Type)).
+		And(expression.Name("booking_id").Equal(expression.Value(notification.BookingID)))
+
+	if notification.ChangeID != "" {
+		filter = filter.And(expression.Name("change_id").Equal(expression.Value(notification.ChangeID)))
+	}
+
+	// Build an expression to retrieve the items from the DynamoDB
+	expr, err := expression.NewBuilder().WithKeyCondition(key).WithFilter(filter).Build()
+	if err != nil {
+		return false, err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+	}
+
+	// A page can be empty even if there are more notifications to be read
+	var page = Page{Limit: 1}
+	for {
+		items, nextToken, err := QueryItems(ctx, params, page)
+		if err != nil {
+			return false, err
+		}
+
+		if len(items) > 0 || nextToken == "" {
+			return len(items) > 0, nil
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return false, err
+		}
+	}
+}
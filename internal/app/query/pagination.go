@@ -0,0 +1,231 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+)
+
+const (
+	DEFAULT_PAGE_LIMIT = 25
+	MAX_PAGE_LIMIT     = 100
+
+	// MAX_PAGE_READS is the maximum number of DynamoDB Scan or Query calls made to
+	// fill a single page, a page that is cut short by it still has a next token.
+	MAX_PAGE_READS = 5
+)
+
+// ErrInvalidNextToken is returned when the next token is not a start key of the table or
+// index that is read, e.g. it has been returned by another endpoint or search.
+var ErrInvalidNextToken = errors.New("invalid 'next_token' parameter value")
+
+// Page contains the pagination settings of a list or search request. The
+// zero value is the first page with the default page limit.
+type Page struct {
+	Limit    int32                           // The maximum number of items to be returned
+	StartKey map[string]types.AttributeValue // The primary key of the item where the page starts
+}
+
+// NewPage validates the "limit" and "next_token" query parameters and returns
+// the page to be fetched. An empty limit uses the DEFAULT_PAGE_LIMIT.
+//
+// Example:
+//  limit=25
+//  next_token=eyJpZCI6IkFETU4tODc4NDk1IiwidXNlcm5hbWUiOiJlbWlseWRhdmlzIn0
+func NewPage(limit, nextToken string) (Page, error) {
+	var page Page
+
+	if limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MAX_PAGE_LIMIT {
+			return page, errors.New("invalid 'limit' parameter value")
+		}
+
+		page.Limit = int32(value)
+	}
+
+	if nextToken != "" {
+		key, err := decodeNextToken(nextToken)
+		if err != nil {
+			return page, ErrInvalidNextToken
+		}
+
+		page.StartKey = key
+	}
+
+	return page, nil
+}
+
+// limit returns the page limit, or the DEFAULT_PAGE_LIMIT if it is not set.
+func (page Page) limit() int32 {
+	if page.Limit <= 0 {
+		return DEFAULT_PAGE_LIMIT
+	}
+
+	return page.Limit
+}
+
+// encodeNextToken converts the last evaluated key of the DynamoDB operation
+// into an opaque token that the client sends back to fetch the next page.
+func encodeNextToken(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	var value map[string]interface{}
+	err := awswrapper.DynamoDBUnmarshalMap(&value, key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeNextToken converts the token returned by encodeNextToken back into
+// the exclusive start key of the DynamoDB operation.
+func decodeNextToken(token string) (map[string]types.AttributeValue, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var value map[string]interface{}
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(value) == 0 {
+		return nil, errors.New("empty next token")
+	}
+
+	return awswrapper.DynamoDBMarshalMap(value)
+}
+
+// tableIndexes returns the key schemas of the DynamoDB Table, the key schema of the
+// table itself is the first. It returns nil if the table is not known.
+func tableIndexes(tablename string) []Index {
+	if tablename == "" {
+		return nil
+	}
+
+	switch tablename {
+	case env.USERS_TABLE:
+		return userIndexes
+
+	case env.BUS_TABLE:
+		return busLineIndexes
+
+	case env.BUS_UNIT:
+		return busUnitIndexes
+
+	case env.BUS_ROUTE_TABLE:
+		return busRouteIndexes
+
+	case env.BOOKING_TABLE:
+		return bookingIndexes
+
+	case env.BOOKING_CANCELLED_TABLE:
+		return []Index{{PartitionKey: "booking_id"}}
+
+	case env.CREW_ASSIGNMENT_TABLE:
+		return crewAssignmentIndexes
+
+	case env.NOTIFICATION_TABLE, env.USER_AUDIT_TABLE:
+		return []Index{{PartitionKey: "user_id", SortKey: "id"}}
+
+	case env.MAINTENANCE_TABLE, env.CREW_TABLE, env.SEAT_LAYOUT_TABLE:
+		return []Index{{PartitionKey: "bus_id", SortKey: "id"}}
+	}
+
+	return nil
+}
+
+// keyAttributes returns the key attribute names of the table, and of the index if
+// it is set, which are the attributes of its start keys. It returns nil if the
+// table or the index is not known.
+func keyAttributes(tablename string, indexName *string) map[string]bool {
+	var indexes = tableIndexes(tablename)
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	var keys = map[string]bool{indexes[0].PartitionKey: true}
+	if indexes[0].SortKey != "" {
+		keys[indexes[0].SortKey] = true
+	}
+
+	if indexName == nil || *indexName == "" {
+		return keys
+	}
+
+	for _, index := range indexes[1:] {
+		if index.Name != *indexName {
+			continue
+		}
+
+		keys[index.PartitionKey] = true
+		if index.SortKey != "" {
+			keys[index.SortKey] = true
+		}
+
+		return keys
+	}
+
+	return nil
+}
+
+// itemKey returns the start key of the page that continues after the item. It
+// returns false if the key attributes of the table or index are not known or the
+// item does not have them, e.g. they are not in the projection.
+func itemKey(item map[string]types.AttributeValue, tablename string, indexName *string) (map[string]types.AttributeValue, bool) {
+	var keys = keyAttributes(tablename, indexName)
+	if len(keys) == 0 {
+		return nil, false
+	}
+
+	var key = make(map[string]types.AttributeValue)
+	for name := range keys {
+		value, ok := item[name]
+		if !ok {
+			return nil, false
+		}
+
+		key[name] = value
+	}
+
+	return key, true
+}
+
+// checkStartKey checks if the start key of the page has exactly the key attributes of
+// the table, and of the index if it is set, as string values. Otherwise it returns
+// ErrInvalidNextToken, since DynamoDB would reject it as an invalid start key. The start
+// key of a table or index that is not known is not checked.
+func checkStartKey(page Page, tablename string, indexName *string) error {
+	var keys = keyAttributes(tablename, indexName)
+	if len(page.StartKey) == 0 || len(keys) == 0 {
+		return nil
+	}
+
+	if len(page.StartKey) != len(keys) {
+		return ErrInvalidNextToken
+	}
+
+	for name := range keys {
+		if _, ok := page.StartKey[name].(*types.AttributeValueMemberS); !ok {
+			return ErrInvalidNextToken
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// CreatePasswordReset checks if the DynamoDB Table is configured on the environment, and
+// creates a new password reset.
+func CreatePasswordReset(ctx context.Context, reset schema.PasswordReset) error {
+	var tablename = env.PASSWORD_RESET_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb PASSWORD_RESET_TABLE is not configured on the environment")
+		err := errors.New("dynamodb PASSWORD_RESET_TABLE environment variable is not set")
+
+		return err
+	}
+
+	err := InsertItem(ctx, tablename, reset, CreateOnly("token_hash"))
+	if err != nil {
+		trail.Error("failed to insert a new password reset")
+		return err
+	}
+
+	return nil
+}
+
+// ConsumePasswordReset checks if the DynamoDB Table is configured on the environment,
+// deletes the password reset of the token hash, and returns it. The password reset
+// is only returned if it has not expired yet, so that the token can only be used once.
+//
+// It returns an empty password reset if there is none or if it has expired.
+func ConsumePasswordReset(ctx context.Context, tokenHash string) (schema.PasswordReset, error) {
+	var (
+		reset     schema.PasswordReset
+		tablename = env.PASSWORD_RESET_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb PASSWORD_RESET_TABLE is not configured on the environment")
+		err := errors.New("dynamodb PASSWORD_RESET_TABLE environment variable is not set")
+
+		return reset, err
+	}
+
+	attributes, err := consumeToken(ctx, tablename, tokenHash)
+	if err != nil {
+		trail.Error("failed to delete the password reset")
+		return reset, err
+	}
+
+	if attributes == nil {
+		return reset, nil
+	}
+
+	err = awswrapper.DynamoDBUnmarshalMap(&reset, attributes)
+	if err != nil {
+		return reset, err
+	}
+
+	return reset, nil
+}
+
+// consumeToken deletes the item of the one-time token hash if it has not expired yet,
+// and returns its attributes. The expired items are deleted by the DynamoDB TTL some
+// time after they expire, so they are still checked for.
+func consumeToken(ctx context.Context, tablename, tokenHash string) (map[string]types.AttributeValue, error) {
+	var key = map[string]types.AttributeValue{
+		"token_hash": &types.AttributeValueMemberS{Value: tokenHash},
+	}
+
+	// WHERE token_hash EXISTS AND expires_at > now
+	condition := expression.AttributeExists(expression.Name("token_hash")).
+		And(expression.Name("expires_at").GreaterThan(expression.Value(time.Now().Unix())))
+
+	return ConsumeItem(ctx, tablename, key, condition)
+}
@@ -0,0 +1,167 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// Index is the key schema of a DynamoDB Table or one of its Global Secondary
+// Indexes. The Name is empty for the key schema of the table itself.
+type Index struct {
+	Name         string // The name of the Global Secondary Index
+	PartitionKey string // The partition key attribute name
+	SortKey      string // The sort key attribute name, if there is any
+}
+
+// Filter is an attribute that must be equal to the value, or a list attribute that
+// must contain the value if Contains is set.
+type Filter struct {
+	Name     string      // The attribute name
+	Value    interface{} // The value the attribute must be equal to
+	Contains bool        // Whether the attribute is a list that must contain the value
+}
+
+// planIndex returns the index that best matches the filters. An index matches
+// if its partition key is one of the filters, and it is preferred if its sort
+// key is also one of the filters. When more than one index has the same match,
+// the one listed first is used.
+func planIndex(indexes []Index, filters []Filter) (Index, bool) {
+	var (
+		best      Index
+		bestScore int
+		names     = make(map[string]bool)
+	)
+
+	for _, filter := range filters {
+		if !filter.Contains {
+			names[filter.Name] = true
+		}
+	}
+
+	for _, index := range indexes {
+		if !names[index.PartitionKey] {
+			continue
+		}
+
+		score := 1
+		if index.SortKey != "" && names[index.SortKey] {
+			score++
+		}
+
+		if score > bestScore {
+			best = index
+			bestScore = score
+		}
+	}
+
+	return best, (bestScore > 0)
+}
+
+// filterCondition joins the filters into a single condition with the AND
+// operator. It returns false if there are no filters.
+func filterCondition(filters []Filter) (expression.ConditionBuilder, bool) {
+	var conditions []expression.ConditionBuilder
+
+	for _, filter := range filters {
+		if filter.Contains {
+			conditions = append(conditions, expression.Name(filter.Name).Contains(fmt.Sprint(filter.Value)))
+			continue
+		}
+
+		conditions = append(conditions, expression.Name(filter.Name).Equal(expression.Value(filter.Value)))
+	}
+
+	switch len(conditions) {
+	case 0:
+		return expression.ConditionBuilder{}, false
+
+	case 1:
+		return conditions[0], true
+
+	default:
+		return expression.And(conditions[0], conditions[1], conditions[2:]...), true
+	}
+}
+
+// FindItems picks the index of the DynamoDB Table that best matches the filters,
+// performs the DynamoDB Query operation on it with the rest of the filters as the
+// filter expression, and returns a page of items and the next page token.
+//
+// It only falls back to the DynamoDB Scan operation when none of the indexes
+// matches the filters.
+func FindItems(ctx context.Context, tablename string, indexes []Index, filters []Filter, page Page) ([]map[string]types.AttributeValue, string, error) {
+	index, found := planIndex(indexes, filters)
+	if !found {
+		trail.Info("no index of %s matches the filter, falling back to scan", tablename)
+
+		condition, ok := filterCondition(filters)
+		if !ok {
+			return ScanItems(ctx, &dynamodb.ScanInput{TableName: aws.String(tablename)}, page)
+		}
+
+		return FilterItems(ctx, tablename, condition, page)
+	}
+
+	var (
+		rest []Filter
+		key  expression.KeyConditionBuilder
+	)
+
+	for _, filter := range filters {
+		if filter.Name == index.PartitionKey && !filter.Contains {
+			key = expression.Key(filter.Name).Equal(expression.Value(filter.Value))
+		}
+	}
+
+	for _, filter := range filters {
+		if filter.Contains {
+			rest = append(rest, filter)
+			continue
+		}
+
+		switch filter.Name {
+		case index.PartitionKey:
+			continue
+
+		case index.SortKey:
+			key = key.And(expression.Key(filter.Name).Equal(expression.Value(filter.Value)))
+
+		default:
+			rest = append(rest, filter)
+		}
+	}
+
+	// Build an expression with the key condition of the index and the
+	// rest of the filters.
+	builder := expression.NewBuilder().WithKeyCondition(key)
+	if condition, ok := filterCondition(rest); ok {
+		builder = builder.WithFilter(condition)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return nil, "", err
+	}
+
+	// Build the query params
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	if index.Name != "" {
+		params.IndexName = aws.String(index.Name)
+	}
+
+	return QueryItems(ctx, params, page)
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// CreateSeatLayout checks if the DynamoDB Table is configured on the environment, and
+// creates a new seat layout record.
+func CreateSeatLayout(ctx context.Context, layout schema.SeatLayout) error {
+	var tablename = env.SEAT_LAYOUT_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb SEAT_LAYOUT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb SEAT_LAYOUT_TABLE environment variable is not set")
+
+		return err
+	}
+
+	err := InsertItem(ctx, tablename, layout, CreateOnly("bus_id"))
+	if err != nil {
+		trail.Error("failed to insert a new seat layout record")
+		return err
+	}
+
+	return nil
+}
+
+// GetSeatLayouts checks if the DynamoDB Table is configured on the environment, and
+// returns either the specific seat layout or a page of the seat layouts of the bus
+// line and the next page token. The templates are fetched with TEMPLATE as the bus id.
+func GetSeatLayouts(ctx context.Context, busId, id string, page Page) ([]schema.SeatLayout, string, error) {
+	var (
+		list      []schema.SeatLayout
+		tablename = env.SEAT_LAYOUT_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb SEAT_LAYOUT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb SEAT_LAYOUT_TABLE environment variable is not set")
+
+		return nil, "", err
+	}
+
+	// WHERE bus_id = bus_id_value [AND id = id_value]
+	key := expression.Key("bus_id").Equal(expression.Value(busId))
+	if id != "" {
+		key = expression.KeyAnd(key, expression.Key("id").Equal(expression.Value(id)))
+	}
+
+	// Build an expression to retrieve the items from the DynamoDB
+	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	items, nextToken, err := QueryItems(ctx, params, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(items) > 0 {
+		// Unmarshal a map into actual seat layout struct which the front-end can
+		// understand as a JSON.
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&list, items)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return list, nextToken, nil
+}
+
+// GetSeatLayoutById returns the seat layout of the bus line, or the template if the bus
+// line has no seat layout with the ID. It returns an empty seat layout if neither exists.
+func GetSeatLayoutById(ctx context.Context, busId, id string) (schema.SeatLayout, error) {
+	for _, owner := range []string{busId, schema.SEAT_LAYOUT_TEMPLATE} {
+		list, _, err := GetSeatLayouts(ctx, owner, id, Page{})
+		if err != nil {
+			return schema.SeatLayout{}, err
+		}
+
+		if len(list) > 0 {
+			return list[0], nil
+		}
+	}
+
+	return schema.SeatLayout{}, nil
+}
+
+// GetBusUnitLayout returns the seat layout that is attached to the bus unit. It returns
+// an empty seat layout if the bus unit does not exist or has no seat layout.
+func GetBusUnitLayout(ctx context.Context, busId, code string) (schema.SeatLayout, error) {
+	if busId == "" || code == "" {
+		return schema.SeatLayout{}, nil
+	}
+
+	units, _, err := GetBusUnitRecords(ctx, code, busId, Page{})
+	if err != nil || len(units) == 0 || units[0].LayoutID == "" {
+		return schema.SeatLayout{}, err
+	}
+
+	return GetSeatLayoutById(ctx, busId, units[0].LayoutID)
+}
@@ -0,0 +1,76 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// TransactUpdate is an update of a single item that is committed together with
+// the other updates of the transaction.
+type TransactUpdate struct {
+	TableName string                          // The name of the DynamoDB Table
+	Key       map[string]types.AttributeValue // The primary key of the item
+	Update    expression.UpdateBuilder        // The attributes of the item to be updated
+	Version   *int                            // The version the item must still be on, if it is versioned
+}
+
+// TransactWriteItems creates an expression for every update and performs the DynamoDB
+// TransactWriteItems operation. Either all of the updates are applied or none of them.
+//
+// It returns ErrVersionMismatch if one of the versioned items has been modified by
+// another request.
+func TransactWriteItems(ctx context.Context, updates ...TransactUpdate) error {
+	var items []types.TransactWriteItem
+
+	for _, item := range updates {
+		var builder = expression.NewBuilder()
+
+		if item.Version != nil {
+			update, condition := versionedUpdate(item.Key, item.Update, *item.Version)
+			builder = builder.WithUpdate(update).WithCondition(condition)
+		} else {
+			builder = builder.WithUpdate(item.Update)
+		}
+
+		expr, err := builder.Build()
+		if err != nil {
+			trail.Error("failed to build DynamoDB Expression")
+			return err
+		}
+
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				Key:                       item.Key,
+				TableName:                 aws.String(item.TableName),
+				UpdateExpression:          expr.Update(),
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			},
+		})
+	}
+
+	_, err := awswrapper.DynamoDBTransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		// The cancellation reasons are in the same order as the updates
+		var cancelled *types.TransactionCanceledException
+		if errors.As(err, &cancelled) {
+			for i, reason := range cancelled.CancellationReasons {
+				if aws.ToString(reason.Code) == "ConditionalCheckFailed" && updates[i].Version != nil {
+					return ErrVersionMismatch
+				}
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -14,27 +14,50 @@ import (
 	"github.com/rmarasigan/bus-ticketing/internal/trail"
 )
 
-// getUserAccount returns the user account information.
-func getUserAccount(ctx context.Context, tablename, id, username string) (schema.User, error) {
-	var user schema.User
-
-	// Create a composite key expression
-	key := expression.KeyAnd(expression.Key("username").Equal(expression.Value(username)), expression.Key("id").Equal(expression.Value(id)))
+// userIndexes are the key schemas of the USERS_TABLE that can be queried.
+var userIndexes = []Index{
+	{PartitionKey: "username", SortKey: "id"},
+	{Name: "id-index", PartitionKey: "id"},
+	{Name: "user_type-index", PartitionKey: "user_type", SortKey: "date_created"},
+}
 
+// userProjection returns the list of the user account attribute names to be returned,
+// the password hash and the MFA secrets are never returned.
+func userProjection() expression.ProjectionBuilder {
 	// Create a names list representing the list of item attribute names
 	// to be returned.
 	var namesList = []expression.NameBuilder{
 		expression.Name("user_type"),
+		expression.Name("bus_id"),
 		expression.Name("first_name"),
 		expression.Name("last_name"),
 		expression.Name("username"),
 		expression.Name("email"),
+		expression.Name("email_verified"),
+		expression.Name("mfa_enabled"),
 		expression.Name("mobile_number"),
 		expression.Name("address"),
+		expression.Name("date_created"),
+		expression.Name("version"),
+		expression.Name("date_erased"),
+		expression.Name("deactivated"),
+		expression.Name("date_deactivated"),
 	}
 
-	// SELECT id, user_type, first_name, last_name, username, address, email, mobile_number
-	projection := expression.NamesList(expression.Name("id"), namesList...)
+	// SELECT id, user_type, bus_id, first_name, last_name, username, address, email, email_verified, mfa_enabled,
+	// mobile_number, date_created, version, date_erased, deactivated, date_deactivated
+	return expression.NamesList(expression.Name("id"), namesList...)
+}
+
+// getUserAccount returns the user account information.
+func getUserAccount(ctx context.Context, tablename, id, username string) (schema.User, error) {
+	var user schema.User
+
+	// Create a composite key expression
+	key := expression.KeyAnd(expression.Key("username").Equal(expression.Value(username)), expression.Key("id").Equal(expression.Value(id)))
+
+	// SELECT id, user_type, bus_id, first_name, last_name, username, ...
+	projection := userProjection()
 
 	// Build an expression to retrieve the item from the DynamoDB
 	expr, err := expression.NewBuilder().WithKeyCondition(key).WithProjection(projection).Build()
@@ -69,8 +92,9 @@ func getUserAccount(ctx context.Context, tablename, id, username string) (schema
 }
 
 // GetUserAccountRecords checks if the DynamoDB Table is configured on the environment, and
-// returns either the specific user account or a list of user account records.
-func GetUserAccountRecords(ctx context.Context, id, username string) ([]schema.User, error) {
+// returns either the specific user account or a page of user account records and the next
+// page token.
+func GetUserAccountRecords(ctx context.Context, id, username string, page Page) ([]schema.User, string, error) {
 	var (
 		users     []schema.User
 		tablename = env.USERS_TABLE
@@ -81,44 +105,32 @@ func GetUserAccountRecords(ctx context.Context, id, username string) ([]schema.U
 		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
 		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
 
-		return users, err
+		return users, "", err
 	}
 
 	// ********** Fetching a specific user account record ********** //
 	if id != "" && username != "" {
 		user, err := getUserAccount(ctx, tablename, id, username)
 		if err != nil {
-			return users, err
+			return users, "", err
 		}
 
 		if user == (schema.User{}) {
-			return users, nil
+			return users, "", nil
 		}
 
 		users = append(users, user)
-		return users, nil
+		return users, "", nil
 	}
 
 	// **************** List of user account records **************** //
-	// Create a names list representing the list of item attribute names
-	// to be returned.
-	var namesList = []expression.NameBuilder{
-		expression.Name("user_type"),
-		expression.Name("first_name"),
-		expression.Name("last_name"),
-		expression.Name("username"),
-		expression.Name("email"),
-		expression.Name("mobile_number"),
-		expression.Name("address"),
-	}
-
-	// SELECT id, user_type, first_name, last_name, username, address, email, mobile_number
-	projection := expression.NamesList(expression.Name("id"), namesList...)
+	// SELECT id, user_type, bus_id, first_name, last_name, username, ...
+	projection := userProjection()
 
 	// Build an expression to retrieve the item from the DynamoDB
 	expr, err := expression.NewBuilder().WithProjection(projection).Build()
 	if err != nil {
-		return users, err
+		return users, "", err
 	}
 
 	// Use the build expression to populate the DynamoDB Scan API
@@ -129,21 +141,151 @@ func GetUserAccountRecords(ctx context.Context, id, username string) ([]schema.U
 		ProjectionExpression:      expr.Projection(),
 	}
 
-	result, err := awswrapper.DynamoDBScan(ctx, params)
+	items, nextToken, err := ScanItems(ctx, params, page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
+		// Unmarshal a map into actual user struct which the front-end can
+		// understand as a JSON.
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&users, items)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return users, nextToken, nil
+}
+
+// FilterUserAccounts checks if the DynamoDB Table is configured on the environment, fetches
+// and returns a page of user account records that match the filter and the next page token.
+//
+// If the user type is set, it queries the "user_type-index" which is sorted by the date
+// the user accounts were created, otherwise it scans the whole table. The name and the
+// e-mail address are case-sensitive partial matches.
+func FilterUserAccounts(ctx context.Context, filter schema.UserFilter, page Page) ([]schema.User, string, error) {
+	var (
+		users      []schema.User
+		conditions []expression.ConditionBuilder
+		tablename  = env.USERS_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return users, "", err
+	}
+
+	// Construct the filters with the fields that are set.
+	if filter.Name != "" {
+		conditions = append(conditions, expression.Or(
+			expression.Contains(expression.Name("first_name"), filter.Name),
+			expression.Contains(expression.Name("last_name"), filter.Name),
+		))
+	}
+
+	if filter.Email != "" {
+		conditions = append(conditions, expression.Contains(expression.Name("email"), filter.Email))
+	}
+
+	if filter.BusID != "" {
+		conditions = append(conditions, expression.Name("bus_id").Equal(expression.Value(filter.BusID)))
+	}
+
+	var (
+		builder = expression.NewBuilder().WithProjection(userProjection())
+		created = expression.Key("date_created")
+	)
+
+	if filter.UserType != "" {
+		// WHERE user_type = user_type_value [AND date_created BETWEEN created_from AND created_to]
+		key := expression.Key("user_type").Equal(expression.Value(filter.UserType))
+
+		switch {
+		case filter.CreatedFrom != "" && filter.CreatedTo != "":
+			key = key.And(created.Between(expression.Value(filter.CreatedFrom), expression.Value(filter.CreatedTo)))
+
+		case filter.CreatedFrom != "":
+			key = key.And(created.GreaterThanEqual(expression.Value(filter.CreatedFrom)))
+
+		case filter.CreatedTo != "":
+			key = key.And(created.LessThanEqual(expression.Value(filter.CreatedTo)))
+		}
+
+		builder = builder.WithKeyCondition(key)
+	} else {
+		if filter.CreatedFrom != "" {
+			conditions = append(conditions, expression.Name("date_created").GreaterThanEqual(expression.Value(filter.CreatedFrom)))
+		}
+
+		if filter.CreatedTo != "" {
+			conditions = append(conditions, expression.Name("date_created").LessThanEqual(expression.Value(filter.CreatedTo)))
+		}
+	}
+
+	if len(conditions) == 1 {
+		builder = builder.WithFilter(conditions[0])
+	}
+
+	if len(conditions) > 1 {
+		builder = builder.WithFilter(expression.And(conditions[0], conditions[1], conditions[2:]...))
+	}
+
+	// Build an expression to retrieve the items from the DynamoDB
+	expr, err := builder.Build()
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return users, "", err
+	}
+
+	var (
+		items     []map[string]types.AttributeValue
+		nextToken string
+	)
+
+	if filter.UserType != "" {
+		// Build the query input parameter on the "user_type-index" of the userIndexes
+		params := &dynamodb.QueryInput{
+			TableName:                 aws.String(tablename),
+			IndexName:                 aws.String(userIndexes[2].Name),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			KeyConditionExpression:    expr.KeyCondition(),
+			FilterExpression:          expr.Filter(),
+			ProjectionExpression:      expr.Projection(),
+		}
+
+		items, nextToken, err = QueryItems(ctx, params, page)
+	} else {
+		// Use the build expression to populate the DynamoDB Scan API
+		params := &dynamodb.ScanInput{
+			TableName:                 aws.String(tablename),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			FilterExpression:          expr.Filter(),
+			ProjectionExpression:      expr.Projection(),
+		}
+
+		items, nextToken, err = ScanItems(ctx, params, page)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(items) > 0 {
 		// Unmarshal a map into actual user struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalListOfMaps(&users, result.Items)
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&users, items)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
-	return users, nil
+	return users, nextToken, nil
 }
 
 // GetUserAccountById checks if the DynamoDB Table is configured on the environment, and
@@ -162,19 +304,54 @@ func GetUserAccountById(ctx context.Context, id string) (schema.User, error) {
 		return user, err
 	}
 
-	// Construct the filter builder with a name that contains a specified value.
 	// WHERE id = id_value
-	filter := expression.Name("id").Equal(expression.Value(id))
+	filters := []Filter{{Name: "id", Value: id}}
 
-	result, err := FilterItems(ctx, tablename, filter)
+	items, _, err := FindItems(ctx, tablename, userIndexes, filters, Page{Limit: 1})
 	if err != nil {
 		return user, err
 	}
 
-	if result.Count > 0 {
+	if len(items) > 0 {
+		// Unmarshal a map into actual user struct which the front-end can
+		// understand as a JSON.
+		err = awswrapper.DynamoDBUnmarshalMap(&user, items[0])
+		if err != nil {
+			return user, err
+		}
+	}
+
+	return user, nil
+}
+
+// GetUserAccountByUsername checks if the DynamoDB Table is configured on the environment,
+// and fetch the user account by username and returns the user account information.
+func GetUserAccountByUsername(ctx context.Context, username string) (schema.User, error) {
+	var (
+		user      schema.User
+		tablename = env.USERS_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return user, err
+	}
+
+	// WHERE username = username_value
+	filters := []Filter{{Name: "username", Value: username}}
+
+	items, _, err := FindItems(ctx, tablename, userIndexes, filters, Page{Limit: 1})
+	if err != nil {
+		return user, err
+	}
+
+	if len(items) > 0 {
 		// Unmarshal a map into actual user struct which the front-end can
 		// understand as a JSON.
-		err = awswrapper.DynamoDBUnmarshalMap(&user, result.Items[0])
+		err = awswrapper.DynamoDBUnmarshalMap(&user, items[0])
 		if err != nil {
 			return user, err
 		}
@@ -184,8 +361,8 @@ func GetUserAccountById(ctx context.Context, id string) (schema.User, error) {
 }
 
 // CreateUserAccount checks if the DynamoDB Table is configured on the environment, and
-// creates a new user account.
-func CreateUserAccount(ctx context.Context, data interface{}) error {
+// creates a new user account. It returns ErrAlreadyExists if the username is taken.
+func CreateUserAccount(ctx context.Context, user schema.User) error {
 	var tablename = env.USERS_TABLE
 
 	// Check if the DynamoDB Table is configured
@@ -196,8 +373,10 @@ func CreateUserAccount(ctx context.Context, data interface{}) error {
 		return err
 	}
 
-	// Save the User information into the DynamoDB Table
-	err := InsertItem(ctx, tablename, data)
+	// Save the User information into the DynamoDB Table, the username and the
+	// user ID are recorded as unique keys since they are both part of the primary
+	// key.
+	err := InsertItem(ctx, tablename, user, CreateOnly("username", "username#"+user.Username).WithUniqueAttributes("id"))
 	if err != nil {
 		trail.Error("failed to insert a new user")
 		return err
@@ -208,7 +387,9 @@ func CreateUserAccount(ctx context.Context, data interface{}) error {
 
 // UpdateUserAcccount checks if the DynamoDB Table is configured on the environment and
 // updates the user account’s information or record.
-func UpdateUserAcccount(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder) (schema.User, error) {
+// It only updates the record if it is still on the version, otherwise it
+// returns ErrVersionMismatch.
+func UpdateUserAcccount(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder, version int) (schema.User, error) {
 	var (
 		user      schema.User
 		tablename = env.USERS_TABLE
@@ -222,7 +403,7 @@ func UpdateUserAcccount(ctx context.Context, key map[string]types.AttributeValue
 		return user, err
 	}
 
-	result, err := UpdateItem(ctx, tablename, key, update)
+	result, err := UpdateVersionedItem(ctx, tablename, key, update, version)
 	if err != nil {
 		trail.Error("failed to update the user account")
 		return user, err
@@ -235,5 +416,178 @@ func UpdateUserAcccount(ctx context.Context, key map[string]types.AttributeValue
 		return user, err
 	}
 
+	// The password hash is never returned
+	user.Password = ""
+
 	return user, nil
 }
+
+// UpdateUserAttributes checks if the DynamoDB Table is configured on the environment and
+// updates the attributes of the user account that are maintained by the system, such as
+// the last login. It does not change the version of the user account.
+func UpdateUserAttributes(ctx context.Context, key map[string]types.AttributeValue, update expression.UpdateBuilder) error {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	_, err := UpdateItem(ctx, tablename, key, update)
+	if err != nil {
+		trail.Error("failed to update the user account")
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUserPassword checks if the DynamoDB Table is configured on the environment and
+// replaces the stored password of the user account with the password hash. It is only
+// replaced if the stored password has not been changed, otherwise it returns
+// ErrVersionMismatch. It does not change the version of the user account.
+func UpdateUserPassword(ctx context.Context, key map[string]types.AttributeValue, hash, stored string) error {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	// WHERE password = stored_value
+	update := expression.Set(expression.Name("password"), expression.Value(hash))
+	condition := expression.Name("password").Equal(expression.Value(stored))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if err != nil {
+		trail.Error("failed to update the user password")
+		return err
+	}
+
+	return nil
+}
+
+// ResetUserPassword checks if the DynamoDB Table is configured on the environment,
+// replaces the stored password of the user account with the password hash, and
+// increments its session version so that the tokens and password resets that were
+// issued before are no longer valid. It is only reset if the user account is still
+// on the session version, otherwise it returns ErrVersionMismatch.
+func ResetUserPassword(ctx context.Context, key map[string]types.AttributeValue, hash string, session int) error {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	// The user accounts that were created before the session version
+	// existed are on session version 0.
+	// WHERE session_version = session_value
+	condition := expression.Name("session_version").Equal(expression.Value(session))
+	if session == 0 {
+		condition = condition.Or(expression.AttributeNotExists(expression.Name("session_version")))
+	}
+
+	for name := range key {
+		condition = condition.And(expression.AttributeExists(expression.Name(name)))
+	}
+
+	update := expression.Set(expression.Name("password"), expression.Value(hash)).
+		Set(expression.Name("session_version"), expression.Value(session+1))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if err != nil {
+		trail.Error("failed to reset the user password")
+		return err
+	}
+
+	return nil
+}
+
+// VerifyUserEmail checks if the DynamoDB Table is configured on the environment, and
+// marks the e-mail address of the user account as verified. It is only verified if
+// the user account still has the e-mail address, otherwise it returns ErrVersionMismatch.
+// It does not change the version of the user account.
+func VerifyUserEmail(ctx context.Context, key map[string]types.AttributeValue, email string) error {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	// WHERE email = email_value
+	condition := expression.Name("email").Equal(expression.Value(email))
+	for name := range key {
+		condition = condition.And(expression.AttributeExists(expression.Name(name)))
+	}
+
+	update := expression.Set(expression.Name("email_verified"), expression.Value(true))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if err != nil {
+		trail.Error("failed to verify the user e-mail address")
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUserAccountWithAudit checks if the DynamoDB Tables are configured on the environment,
+// updates the user account and records the audit of the change. Either both of them are
+// applied or none of them. It returns the updated user account information.
+//
+// It only updates the record if it is still on the version, otherwise it returns
+// ErrVersionMismatch.
+func UpdateUserAccountWithAudit(ctx context.Context, user schema.User, update expression.UpdateBuilder, audit schema.UserAudit) (schema.User, error) {
+	var (
+		tablename  = env.USERS_TABLE
+		auditTable = env.USER_AUDIT_TABLE
+	)
+
+	// Check if the DynamoDB Tables are configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return schema.User{}, err
+	}
+
+	if auditTable == "" {
+		trail.Error("dynamodb USER_AUDIT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USER_AUDIT_TABLE environment variable is not set")
+
+		return schema.User{}, err
+	}
+
+	var key = map[string]types.AttributeValue{
+		"id":       &types.AttributeValueMemberS{Value: user.ID},
+		"username": &types.AttributeValueMemberS{Value: user.Username},
+	}
+
+	auditKey, auditUpdate := userAuditUpdate(audit)
+
+	err := TransactWriteItems(ctx,
+		TransactUpdate{TableName: tablename, Key: key, Update: update, Version: &user.Version},
+		TransactUpdate{TableName: auditTable, Key: auditKey, Update: auditUpdate},
+	)
+
+	if err != nil {
+		trail.Error("failed to update the user account")
+		return schema.User{}, err
+	}
+
+	return getUserAccount(ctx, tablename, user.ID, user.Username)
+}
@@ -0,0 +1,92 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// userAuditUpdate returns the primary key of the user audit and the update that sets
+// the rest of its attributes, so that it can be created within a transaction.
+func userAuditUpdate(audit schema.UserAudit) (map[string]types.AttributeValue, expression.UpdateBuilder) {
+	var key = map[string]types.AttributeValue{
+		"user_id": &types.AttributeValueMemberS{Value: audit.UserID},
+		"id":      &types.AttributeValueMemberS{Value: audit.ID},
+	}
+
+	update := expression.Set(expression.Name("action"), expression.Value(audit.Action)).
+		Set(expression.Name("changed_by"), expression.Value(audit.ChangedBy)).
+		Set(expression.Name("date_created"), expression.Value(audit.DateCreated))
+
+	if audit.From != "" {
+		update = update.Set(expression.Name("from"), expression.Value(audit.From))
+	}
+
+	if audit.To != "" {
+		update = update.Set(expression.Name("to"), expression.Value(audit.To))
+	}
+
+	if audit.Reason != "" {
+		update = update.Set(expression.Name("reason"), expression.Value(audit.Reason))
+	}
+
+	return key, update
+}
+
+// GetUserAudits checks if the DynamoDB Table is configured on the environment, and returns
+// a page of the changes that were made to the user account, the most recent first, and
+// the next page token.
+func GetUserAudits(ctx context.Context, userId string, page Page) ([]schema.UserAudit, string, error) {
+	var (
+		audits    = []schema.UserAudit{}
+		tablename = env.USER_AUDIT_TABLE
+	)
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USER_AUDIT_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USER_AUDIT_TABLE environment variable is not set")
+
+		return nil, "", err
+	}
+
+	// WHERE user_id = user_id_value
+	key := expression.Key("user_id").Equal(expression.Value(userId))
+
+	// Build an expression to retrieve the items from the DynamoDB
+	expr, err := expression.NewBuilder().WithKeyCondition(key).Build()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the query input parameter
+	params := &dynamodb.QueryInput{
+		TableName:                 aws.String(tablename),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ScanIndexForward:          aws.Bool(false),
+	}
+
+	items, nextToken, err := QueryItems(ctx, params, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(items) > 0 {
+		err = awswrapper.DynamoDBUnmarshalListOfMaps(&audits, items)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return audits, nextToken, nil
+}
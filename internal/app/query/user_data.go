@@ -0,0 +1,200 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// GetUserBookings returns every booking of the user account, including the cancelled ones.
+func GetUserBookings(ctx context.Context, userId string) ([]schema.Bookings, error) {
+	var (
+		bookings = []schema.Bookings{}
+		page     = Page{Limit: MAX_PAGE_LIMIT}
+	)
+
+	for {
+		list, nextToken, err := FilterBookings(ctx, userId, "", "", "ALL", page)
+		if err != nil {
+			return nil, err
+		}
+
+		bookings = append(bookings, list...)
+		if nextToken == "" {
+			break
+		}
+
+		page.StartKey, err = decodeNextToken(nextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bookings, nil
+}
+
+// GetUserCancellations returns the cancellation records of the bookings that have been
+// cancelled.
+func GetUserCancellations(ctx context.Context, bookings []schema.Bookings) ([]schema.BookingCancelled, error) {
+	var cancellations = []schema.BookingCancelled{}
+
+	for _, booking := range bookings {
+		if booking.Status != booking.Status.Cancelled() && (booking.IsCancelled == nil || !*booking.IsCancelled) {
+			continue
+		}
+
+		list, _, err := GetCancelledBookingRecords(ctx, booking.ID, Page{})
+		if err != nil {
+			return nil, err
+		}
+
+		cancellations = append(cancellations, list...)
+	}
+
+	return cancellations, nil
+}
+
+// EraseCancellationReasons checks if the DynamoDB Table is configured on the environment,
+// and removes the reason of the cancellation records since it is written by the user.
+// The rest of the cancellation records are kept for the accounting of the bookings.
+func EraseCancellationReasons(ctx context.Context, cancellations []schema.BookingCancelled) error {
+	var tablename = env.BOOKING_CANCELLED_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb BOOKING_CANCELLED_TABLE is not configured on the environment")
+		err := errors.New("dynamodb BOOKING_CANCELLED_TABLE environment is not set")
+
+		return err
+	}
+
+	for _, cancelled := range cancellations {
+		var key = map[string]types.AttributeValue{
+			"booking_id": &types.AttributeValueMemberS{Value: cancelled.BookingID},
+		}
+
+		update := expression.Set(expression.Name("reason"), expression.Value(""))
+		condition := expression.AttributeExists(expression.Name("booking_id"))
+
+		_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+		if err != nil && !errors.Is(err, ErrVersionMismatch) {
+			trail.Error("failed to erase the reason of the cancelled booking")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EraseUserAccount checks if the DynamoDB Tables are configured on the environment, and
+// replaces the user account with the anonymized user account. Since the username is the
+// partition key, the user account is deleted and the anonymized user account is created
+// together with the swap of their unique usernames. Either all of them are applied or
+// none of them.
+//
+// The user account is only replaced if it is still on its version, otherwise it returns
+// ErrVersionMismatch.
+func EraseUserAccount(ctx context.Context, user, anonymized schema.User) error {
+	var (
+		tablename   = env.USERS_TABLE
+		uniqueTable = env.UNIQUE_KEY_TABLE
+	)
+
+	// Check if the DynamoDB Tables are configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	if uniqueTable == "" {
+		trail.Error("dynamodb UNIQUE_KEY_TABLE is not configured on the environment")
+		err := errors.New("dynamodb UNIQUE_KEY_TABLE environment variable is not set")
+
+		return err
+	}
+
+	values, err := awswrapper.DynamoDBMarshalMap(anonymized)
+	if err != nil {
+		trail.Error("failed to marshal data to a map of AttributeValues")
+		return err
+	}
+
+	// WHERE version = version_value
+	versionExpr, err := expression.NewBuilder().WithCondition(expression.Name("version").Equal(expression.Value(user.Version))).Build()
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return err
+	}
+
+	userExpr, err := notExists("username")
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return err
+	}
+
+	keyExpr, err := notExists("key")
+	if err != nil {
+		trail.Error("failed to build DynamoDB Expression")
+		return err
+	}
+
+	var items = []types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				Key: map[string]types.AttributeValue{
+					"id":       &types.AttributeValueMemberS{Value: user.ID},
+					"username": &types.AttributeValueMemberS{Value: user.Username},
+				},
+				TableName:                 aws.String(tablename),
+				ConditionExpression:       versionExpr.Condition(),
+				ExpressionAttributeNames:  versionExpr.Names(),
+				ExpressionAttributeValues: versionExpr.Values(),
+			},
+		},
+		{
+			Put: &types.Put{
+				Item:                     values,
+				TableName:                aws.String(tablename),
+				ConditionExpression:      userExpr.Condition(),
+				ExpressionAttributeNames: userExpr.Names(),
+			},
+		},
+		{
+			Delete: &types.Delete{
+				Key:       map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: "username#" + user.Username}},
+				TableName: aws.String(uniqueTable),
+			},
+		},
+		{
+			Put: &types.Put{
+				Item:                     map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: "username#" + anonymized.Username}},
+				TableName:                aws.String(uniqueTable),
+				ConditionExpression:      keyExpr.Condition(),
+				ExpressionAttributeNames: keyExpr.Names(),
+			},
+		},
+	}
+
+	_, err = awswrapper.DynamoDBTransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		trail.Error("failed to erase the user account")
+
+		if isConditionFailed(err) {
+			return ErrVersionMismatch
+		}
+
+		return err
+	}
+
+	return nil
+}
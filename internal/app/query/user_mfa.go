@@ -0,0 +1,220 @@
+package query
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/env"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+// mfaNotEnabled is the condition that the MFA of the user account of the key is not
+// enabled yet.
+func mfaNotEnabled(key map[string]types.AttributeValue) expression.ConditionBuilder {
+	// WHERE mfa_enabled NOT EXISTS OR mfa_enabled = false
+	condition := expression.AttributeNotExists(expression.Name("mfa_enabled")).
+		Or(expression.Name("mfa_enabled").Equal(expression.Value(false)))
+
+	for name := range key {
+		condition = condition.And(expression.AttributeExists(expression.Name(name)))
+	}
+
+	return condition
+}
+
+// SetUserMFAPendingSecret stores the TOTP secret of the user account that is enabled once
+// a TOTP code of it is confirmed. The previous pending secret is replaced, and it returns
+// ErrVersionMismatch if the MFA is already enabled.
+func SetUserMFAPendingSecret(ctx context.Context, key map[string]types.AttributeValue, secret string) error {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	update := expression.Set(expression.Name("mfa"), expression.Value(schema.UserMFA{PendingSecret: secret}))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, mfaNotEnabled(key))
+	if err != nil {
+		trail.Error("failed to set the pending MFA secret of the user account")
+		return err
+	}
+
+	return nil
+}
+
+// EnableUserMFA enables the MFA of the user account with the pending secret that has been
+// confirmed, the time step of the TOTP code that confirmed it, and the hashes of the new
+// recovery codes. The session version is incremented, so that the tokens that were issued
+// without MFA are no longer valid. It returns ErrVersionMismatch if the pending secret has
+// been replaced or the MFA is already enabled.
+func EnableUserMFA(ctx context.Context, key map[string]types.AttributeValue, secret string, step int64, recoveryCodes []string) error {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	// WHERE mfa.pending_secret = secret_value
+	condition := mfaNotEnabled(key).And(expression.Name("mfa.pending_secret").Equal(expression.Value(secret)))
+
+	mfa := schema.UserMFA{Secret: secret, LastStep: step, RecoveryCodes: recoveryCodes}
+	update := expression.Set(expression.Name("mfa"), expression.Value(mfa)).
+		Set(expression.Name("mfa_enabled"), expression.Value(true)).
+		Set(expression.Name("session_version"), expression.Plus(expression.IfNotExists(expression.Name("session_version"), expression.Value(0)), expression.Value(1)))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if err != nil {
+		trail.Error("failed to enable the MFA of the user account")
+		return err
+	}
+
+	return nil
+}
+
+// DisableUserMFA disables the MFA of the user account, and removes its TOTP secret and
+// recovery codes.
+func DisableUserMFA(ctx context.Context, key map[string]types.AttributeValue) error {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	var condition expression.ConditionBuilder
+	for name := range key {
+		if !condition.IsSet() {
+			condition = expression.AttributeExists(expression.Name(name))
+			continue
+		}
+
+		condition = condition.And(expression.AttributeExists(expression.Name(name)))
+	}
+
+	update := expression.Set(expression.Name("mfa_enabled"), expression.Value(false)).
+		Remove(expression.Name("mfa"))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if err != nil {
+		trail.Error("failed to disable the MFA of the user account")
+		return err
+	}
+
+	return nil
+}
+
+// UseUserTOTP records the time step of the TOTP code that was used by the user account. It
+// returns false if a TOTP code of the same or a later time step has already been used, so
+// that a code cannot be used twice.
+func UseUserTOTP(ctx context.Context, key map[string]types.AttributeValue, step int64) (bool, error) {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return false, err
+	}
+
+	// WHERE mfa_enabled = true AND (mfa.last_step NOT EXISTS OR mfa.last_step < step_value)
+	condition := expression.Name("mfa_enabled").Equal(expression.Value(true)).
+		And(expression.AttributeNotExists(expression.Name("mfa.last_step")).
+			Or(expression.Name("mfa.last_step").LessThan(expression.Value(step))))
+
+	update := expression.Set(expression.Name("mfa.last_step"), expression.Value(step))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if errors.Is(err, ErrVersionMismatch) {
+		return false, nil
+	}
+
+	if err != nil {
+		trail.Error("failed to record the TOTP code of the user account")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// UseUserRecoveryCode removes the hash of the recovery code from the user account, and
+// returns the number of recovery codes that are left. It returns false if the recovery
+// code does not exist or has already been used.
+func UseUserRecoveryCode(ctx context.Context, key map[string]types.AttributeValue, hash string) (bool, int, error) {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return false, 0, err
+	}
+
+	// WHERE mfa_enabled = true AND contains(mfa.recovery_codes, hash_value)
+	condition := expression.Name("mfa_enabled").Equal(expression.Value(true)).
+		And(expression.Contains(expression.Name("mfa.recovery_codes"), hash))
+
+	update := expression.Delete(expression.Name("mfa.recovery_codes"), expression.Value(&types.AttributeValueMemberSS{Value: []string{hash}}))
+
+	result, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if errors.Is(err, ErrVersionMismatch) {
+		return false, 0, nil
+	}
+
+	if err != nil {
+		trail.Error("failed to use the recovery code of the user account")
+		return false, 0, err
+	}
+
+	var user schema.User
+	err = awswrapper.DynamoDBUnmarshalMap(&user, result.Attributes)
+	if err != nil || user.MFA == nil {
+		return true, 0, nil
+	}
+
+	return true, len(user.MFA.RecoveryCodes), nil
+}
+
+// ReplaceUserRecoveryCodes replaces the recovery codes of the user account with the hashes
+// of the new recovery codes. It returns ErrVersionMismatch if the MFA is not enabled.
+func ReplaceUserRecoveryCodes(ctx context.Context, key map[string]types.AttributeValue, recoveryCodes []string) error {
+	var tablename = env.USERS_TABLE
+
+	// Check if the DynamoDB Table is configured
+	if tablename == "" {
+		trail.Error("dynamodb USERS_TABLE is not configured on the environment")
+		err := errors.New("dynamodb USERS_TABLE environment variable is not set")
+
+		return err
+	}
+
+	// WHERE mfa_enabled = true
+	condition := expression.Name("mfa_enabled").Equal(expression.Value(true))
+
+	update := expression.Set(expression.Name("mfa.recovery_codes"), expression.Value(&types.AttributeValueMemberSS{Value: recoveryCodes}))
+
+	_, err := UpdateItemIf(ctx, tablename, key, update, condition)
+	if err != nil {
+		trail.Error("failed to replace the recovery codes of the user account")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package routechange
+
+import (
+	"context"
+
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// Publish sends the pending change of the bus route to the event bus, and removes it
+// from the bus route. A failure to remove it is only logged since the event has already
+// been sent, it is sent again later and the bookings that have already been handled by
+// the route change are skipped.
+func Publish(ctx context.Context, eventbus string, change schema.BusRouteChange) error {
+	err := awswrapper.EventBridgePutEvents(ctx, utility.EncodeJSON(change), change.EventSource(), eventbus)
+	if err != nil {
+		change.Error(err, "EventBridgeError", "failed to send the route change event", utility.KVP{Key: "source", Value: change.EventSource()})
+		return err
+	}
+
+	err = query.ClearBusRouteChange(ctx, change)
+	if err != nil {
+		change.Error(err, "DynamoDBError", "failed to remove the pending change of the bus route that has been sent")
+	}
+
+	return nil
+}
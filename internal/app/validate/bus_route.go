@@ -14,7 +14,7 @@ import (
 // are empty or not to set its previous value.
 //
 // Fields that are validated:
-//  currency_code, rate, active, departure_time, arrival_time, from_route, to_route
+//  currency_code, rate, fares, active, departure_time, arrival_time, from_route, to_route
 func UpdateBusRouteFields(route, old schema.BusRoute) schema.BusRoute {
 	if route.Currency == "" {
 		route.Currency = old.Currency
@@ -24,6 +24,10 @@ func UpdateBusRouteFields(route, old schema.BusRoute) schema.BusRoute {
 		route.Rate = old.Rate
 	}
 
+	if route.Fares == nil {
+		route.Fares = old.Fares
+	}
+
 	if route.Active == nil {
 		route.Active = old.Active
 	}
@@ -60,10 +64,21 @@ func IsBusRouteExisting(ctx context.Context, routefilter schema.BusRouteFilter)
 		return false, err
 	}
 
-	result, err := query.FilterBusRoute(ctx, routefilter)
-	if err != nil {
-		return false, err
-	}
+	// A page can be empty even if there are more bus routes to be read
+	var page = query.Page{Limit: 1}
+	for {
+		result, nextToken, err := query.FilterBusRoute(ctx, routefilter, page)
+		if err != nil {
+			return false, err
+		}
+
+		if len(result) > 0 || nextToken == "" {
+			return (len(result) > 0), nil
+		}
 
-	return (len(result) > 0), nil
+		page, err = query.NewPage("1", nextToken)
+		if err != nil {
+			return false, err
+		}
+	}
 }
@@ -15,7 +15,7 @@ import (
 // are empty or not to set its previous value.
 //
 // Fields that are valdiated:
-//  active, min_capacity, max_capacity
+//  active, min_capacity, max_capacity, layout_id, amenities
 func UpdateBusUnitFields(unit, old schema.BusUnit) schema.BusUnit {
 	if unit.Active == nil {
 		unit.Active = old.Active
@@ -29,6 +29,14 @@ func UpdateBusUnitFields(unit, old schema.BusUnit) schema.BusUnit {
 		unit.MaxCapacity = old.MaxCapacity
 	}
 
+	if unit.LayoutID == "" {
+		unit.LayoutID = old.LayoutID
+	}
+
+	if unit.Amenities == nil {
+		unit.Amenities = old.Amenities
+	}
+
 	return unit
 }
 
@@ -0,0 +1,47 @@
+package validate
+
+import "github.com/rmarasigan/bus-ticketing/api/schema"
+
+// UpdateCrewFields validates if the field that are going to be updated
+// are empty or not to set its previous value.
+//
+// Fields that are validated:
+//  role, first_name, last_name, mobile_number, licence_number, licence_type, licence_expiry, active
+func UpdateCrewFields(crew, old schema.Crew) schema.Crew {
+	crew.BusID = old.BusID
+	crew.ID = old.ID
+
+	if crew.Role == "" {
+		crew.Role = old.Role
+	}
+
+	if crew.FirstName == "" {
+		crew.FirstName = old.FirstName
+	}
+
+	if crew.LastName == "" {
+		crew.LastName = old.LastName
+	}
+
+	if crew.MobileNumber == "" {
+		crew.MobileNumber = old.MobileNumber
+	}
+
+	if crew.LicenceNumber == "" {
+		crew.LicenceNumber = old.LicenceNumber
+	}
+
+	if crew.LicenceType == "" {
+		crew.LicenceType = old.LicenceType
+	}
+
+	if crew.LicenceExpiry == "" {
+		crew.LicenceExpiry = old.LicenceExpiry
+	}
+
+	if crew.Active == nil {
+		crew.Active = old.Active
+	}
+
+	return crew
+}
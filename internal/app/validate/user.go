@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
 	"github.com/rmarasigan/bus-ticketing/internal/app/env"
 	"github.com/rmarasigan/bus-ticketing/internal/app/query"
 	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
@@ -69,6 +70,10 @@ func IsUsernameExisting(ctx context.Context, username string) (bool, error) {
 
 // UserAccountExists checks if the DynamoDB Table is configured on the environment, and
 // returns a boolean and error value to check whether the user account credentials are correct or not.
+//
+// The password is verified against the stored password hash, or the plaintext password of
+// a legacy account. The returned user account has the stored password so that it can be
+// checked with auth.NeedsRehash, it must not be returned to the client.
 func UserAccountExists(ctx context.Context, username, password string) (bool, schema.User, error) {
 	var (
 		user      schema.User
@@ -90,23 +95,26 @@ func UserAccountExists(ctx context.Context, username, password string) (bool, sc
 	// to be returned.
 	var namesList = []expression.NameBuilder{
 		expression.Name("user_type"),
+		expression.Name("bus_id"),
 		expression.Name("first_name"),
 		expression.Name("last_name"),
 		expression.Name("username"),
+		expression.Name("password"),
 		expression.Name("address"),
 		expression.Name("email"),
+		expression.Name("email_verified"),
 		expression.Name("mobile_number"),
+		expression.Name("version"),
+		expression.Name("session_version"),
+		expression.Name("mfa_enabled"),
+		expression.Name("deactivated"),
 	}
 
-	// SELECT id, user_type, first_name, last_name, username, address, email, mobile_number
+	// SELECT id, user_type, bus_id, first_name, last_name, username, password, address, email, email_verified, mobile_number, version, session_version, mfa_enabled, deactivated
 	projection := expression.NamesList(expression.Name("id"), namesList...)
 
-	// Construct the filter builder with a name and value.
-	// WHERE password = password_value
-	filter := expression.Name("password").Equal(expression.Value(password))
-
 	// Build an expression to retrieve the item from the DynamoDB
-	expr, err := expression.NewBuilder().WithKeyCondition(key).WithFilter(filter).WithProjection(projection).Build()
+	expr, err := expression.NewBuilder().WithKeyCondition(key).WithProjection(projection).Build()
 	if err != nil {
 		return false, user, err
 	}
@@ -116,7 +124,6 @@ func UserAccountExists(ctx context.Context, username, password string) (bool, sc
 		TableName:                 aws.String(tablename),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
-		FilterExpression:          expr.Filter(),
 		ProjectionExpression:      expr.Projection(),
 		KeyConditionExpression:    expr.KeyCondition(),
 	}
@@ -134,5 +141,11 @@ func UserAccountExists(ctx context.Context, username, password string) (bool, sc
 		}
 	}
 
-	return (result.Count > 0), user, nil
+	// The password is verified even if the username does not exist, so that
+	// both take the same time.
+	if !auth.VerifyPassword(user.Password, password) {
+		return false, schema.User{}, nil
+	}
+
+	return true, user, nil
 }
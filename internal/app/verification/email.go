@@ -0,0 +1,79 @@
+package verification
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rmarasigan/bus-ticketing/api/schema"
+	"github.com/rmarasigan/bus-ticketing/internal/app/auth"
+	"github.com/rmarasigan/bus-ticketing/internal/app/config"
+	"github.com/rmarasigan/bus-ticketing/internal/app/email/template"
+	"github.com/rmarasigan/bus-ticketing/internal/app/notification"
+	"github.com/rmarasigan/bus-ticketing/internal/app/query"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// VERIFY_PATH is the resource path of the endpoint that verifies the e-mail address.
+const VERIFY_PATH = "/user/email/verify"
+
+// VerifyURL returns the URL that the verification token is sent to. It is the
+// EMAIL_VERIFICATION_URL environment variable if it is set (e.g. a page of the
+// front-end), otherwise the verify endpoint of the API of the request.
+//
+// Example:
+//  https://{api_id}.execute-api.{region}.amazonaws.com/prod/user/email/verify
+func VerifyURL(request events.APIGatewayProxyRequest) string {
+	if verifyURL := os.Getenv("EMAIL_VERIFICATION_URL"); verifyURL != "" {
+		return verifyURL
+	}
+
+	return fmt.Sprintf("https://%s/%s%s", request.RequestContext.DomainName, request.RequestContext.Stage, VERIFY_PATH)
+}
+
+// SendEmail issues a new e-mail verification token to the user account, stores its hash,
+// and sends the verification link of the token to the e-mail address of the user account.
+func SendEmail(ctx context.Context, user schema.User, verifyURL string) error {
+	ttl, err := config.GetVerifyTokenTTL()
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := auth.NewOneTimeToken()
+	if err != nil {
+		return err
+	}
+
+	verification := schema.NewEmailVerification(user, hash, ttl)
+
+	err = query.CreateEmailVerification(ctx, verification)
+	if err != nil {
+		verification.Error(err, "DynamoDBError", "failed to save the e-mail verification")
+		return err
+	}
+
+	// Fetch email configuration
+	email, err := config.GetEmailConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	link := verifyURL + "?token=" + url.QueryEscape(token)
+
+	// Set the email content
+	email.Content.To = append(email.Content.To, user.Email)
+	email.Content.Subject = "VERIFY YOUR E-MAIL ADDRESS"
+	email.Content.Message = template.EmailVerification(user, link, ttl, email.CustomerSupport)
+
+	// Send email to the client
+	err = notification.Send(ctx, email, schema.NewNotification(user, schema.NOTIFY_EMAIL_VERIFICATION, ""))
+	if err != nil {
+		email.Error(err, "EmailError", "failed to send email to client")
+		return err
+	}
+	utility.Info("EmailVerification", "Successfully sent the e-mail verification link", utility.KVP{Key: "id", Value: user.ID})
+
+	return nil
+}
@@ -1,5 +1,101 @@
 package awswrapper
 
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
 const (
-	AWS_REGION = "us-east-1"
+	AWS_REGION           = "us-east-1"      // The region if the AWS_REGION environment variable is not set
+	DEFAULT_MAX_ATTEMPTS = 3                // The number of attempts of a request if AWS_MAX_ATTEMPTS is not set
+	DEFAULT_HTTP_TIMEOUT = 10 * time.Second // The timeout of a request if AWS_HTTP_TIMEOUT is not set
 )
+
+// loadConfig returns the AWS configuration that is shared by the service clients. It uses
+// the SDK's default configuration, loading additional config and credentials values from
+// the environment variables, shared credentials, and shared configuration files.
+//
+// Environment variables:
+//  AWS_REGION: the region of the services, defaults to AWS_REGION
+//  AWS_MAX_ATTEMPTS: the maximum number of attempts of a request, including the first one
+//  AWS_HTTP_TIMEOUT: the timeout of a single attempt as a duration (e.g. 10s)
+func loadConfig(ctx context.Context) (aws.Config, error) {
+	var region = os.Getenv("AWS_REGION")
+	if region == "" {
+		region = AWS_REGION
+	}
+
+	maxAttempts, err := envInt("AWS_MAX_ATTEMPTS", DEFAULT_MAX_ATTEMPTS)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	timeout, err := envDuration("AWS_HTTP_TIMEOUT", DEFAULT_HTTP_TIMEOUT)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	return config.LoadDefaultConfig(ctx,
+		config.WithRegion(region),
+		config.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(timeout)),
+		config.WithRetryer(func() aws.Retryer {
+			return retry.AddWithMaxAttempts(retry.NewStandard(), maxAttempts)
+		}),
+	)
+}
+
+// endpoint returns the endpoint URL override of the service. The service specific
+// AWS_ENDPOINT_URL_<SERVICE> takes precedence over the AWS_ENDPOINT_URL that
+// applies to every service. It returns an empty string if neither is set.
+//
+// Example:
+//  AWS_ENDPOINT_URL_DYNAMODB=http://localhost:8000
+func endpoint(service string) string {
+	url := os.Getenv("AWS_ENDPOINT_URL_" + strings.ToUpper(service))
+	if url != "" {
+		return url
+	}
+
+	return os.Getenv("AWS_ENDPOINT_URL")
+}
+
+// envInt returns the positive integer value of the environment variable, or the
+// fallback if it is not set.
+func envInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("invalid %s environment variable value: %s", key, value)
+	}
+
+	return number, nil
+}
+
+// envDuration returns the positive duration value of the environment variable, or
+// the fallback if it is not set.
+func envDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid %s environment variable value: %s", key, value)
+	}
+
+	return duration, nil
+}
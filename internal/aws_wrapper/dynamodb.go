@@ -2,45 +2,69 @@ package awswrapper
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
+const (
+	DYNAMODB_BATCH_WRITE_LIMIT   = 25                     // The maximum number of items of a single BatchWriteItem request
+	DYNAMODB_BATCH_WRITE_RETRIES = 5                      // The maximum number of retries of the unprocessed items
+	DYNAMODB_BATCH_WRITE_BACKOFF = 100 * time.Millisecond // The delay before the first retry, it doubles on every retry
+)
+
+// ErrDynamoDBUnprocessedItem is returned for an item that has still not been processed
+// after all of the retries of the batch write.
+var ErrDynamoDBUnprocessedItem = errors.New("the item was not processed, try again later")
+
 var (
-	dynamoClient *dynamodb.Client
+	dynamoClient      *dynamodb.Client
+	dynamoClientMutex sync.Mutex
 )
 
-// initDynamoClient initializes the DynamoDB client from the
-// prpovided configuration.
-func initDynamoClient(ctx context.Context) {
+// initDynamoClient returns the DynamoDB Client, it is created from the shared configuration
+// on the first call.
+func initDynamoClient(ctx context.Context) (*dynamodb.Client, error) {
+	dynamoClientMutex.Lock()
+	defer dynamoClientMutex.Unlock()
+
 	if dynamoClient != nil {
-		return
+		return dynamoClient, nil
 	}
 
-	// Using the SDK's default configuration, loading additional config
-	// and credentials values from the environment variables, shared
-	// credentials, and shared configuration files
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(AWS_REGION))
+	cfg, err := loadConfig(ctx)
 	if err != nil {
 		utility.Error(err, "DynamoClientError", "failed to load the default config")
-		return
+		return nil, err
 	}
 
-	// Using the cfg value to create the DynamoDB client
-	dynamoClient = dynamodb.NewFromConfig(cfg)
+	// Using the cfg value to create the DynamoDB client, and the endpoint
+	// override of the service if there is any
+	dynamoClient = dynamodb.NewFromConfig(cfg, func(options *dynamodb.Options) {
+		if url := endpoint("DYNAMODB"); url != "" {
+			options.BaseEndpoint = aws.String(url)
+		}
+	})
+
+	return dynamoClient, nil
 }
 
 // DynamoDBScan initializes the DynamoDB Client and reads every item in a table or a secondary index.
 // It returns one or more items and item attributes.
 func DynamoDBScan(ctx context.Context, params *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
 	// Initialize the DynamoClient
-	initDynamoClient(ctx)
+	client, err := initDynamoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	output, err := dynamoClient.Scan(ctx, params)
+	output, err := client.Scan(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -52,9 +76,12 @@ func DynamoDBScan(ctx context.Context, params *dynamodb.ScanInput) (*dynamodb.Sc
 // returns all items with that partition key value.
 func DynamoDBQuery(ctx context.Context, params *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
 	// Initialize the DynamoClient
-	initDynamoClient(ctx)
+	client, err := initDynamoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	output, err := dynamoClient.Query(ctx, params)
+	output, err := client.Query(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -65,9 +92,12 @@ func DynamoDBQuery(ctx context.Context, params *dynamodb.QueryInput) (*dynamodb.
 // DynamoDBPutItem initializes the DynamoDB Client and creates a new item, or replaces an old item with a new item.
 func DynamoDBPutItem(ctx context.Context, params *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
 	// Initialize the DynamoClient
-	initDynamoClient(ctx)
+	client, err := initDynamoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	output, err := dynamoClient.PutItem(ctx, params)
+	output, err := client.PutItem(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -79,9 +109,134 @@ func DynamoDBPutItem(ctx context.Context, params *dynamodb.PutItemInput) (*dynam
 // the table if it does not already exist.
 func DynamoDBUpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
 	// Initialize the DynamoClient
-	initDynamoClient(ctx)
+	client, err := initDynamoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.UpdateItem(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// DynamoDBDeleteItem initializes the DynamoDB Client and deletes a single item in a table by primary key.
+func DynamoDBDeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	// Initialize the DynamoClient
+	client, err := initDynamoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.DeleteItem(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// DynamoDBBatchWriteItem initializes the DynamoDB Client and puts or deletes multiple items in one or more tables.
+// The items that were not processed are returned in the UnprocessedItems of the output.
+func DynamoDBBatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	// Initialize the DynamoClient
+	client, err := initDynamoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.BatchWriteItem(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// DynamoDBBatchWrite writes the put or delete requests of the table with the DynamoDB
+// BatchWriteItem operation in chunks of DYNAMODB_BATCH_WRITE_LIMIT requests. The requests
+// that were not processed are retried with an exponential backoff.
+//
+// It returns the requests that have still not been written after all of the retries or
+// because their chunk failed, and the error of the last failed chunk.
+func DynamoDBBatchWrite(ctx context.Context, tablename string, requests []types.WriteRequest) ([]types.WriteRequest, error) {
+	var (
+		unprocessed []types.WriteRequest
+		failure     error
+	)
+
+	for start := 0; start < len(requests); start += DYNAMODB_BATCH_WRITE_LIMIT {
+		end := start + DYNAMODB_BATCH_WRITE_LIMIT
+		if end > len(requests) {
+			end = len(requests)
+		}
+
+		rest, err := dynamoDBBatchWriteChunk(ctx, tablename, requests[start:end])
+		if err != nil {
+			unprocessed = append(unprocessed, rest...)
+			failure = err
+		}
+	}
+
+	return unprocessed, failure
+}
+
+// dynamoDBBatchWriteChunk writes a chunk of requests with the DynamoDB BatchWriteItem
+// operation until every request has been processed, and returns the requests that
+// were not processed if it fails.
+func dynamoDBBatchWriteChunk(ctx context.Context, tablename string, requests []types.WriteRequest) ([]types.WriteRequest, error) {
+	for retry := 0; len(requests) > 0; retry++ {
+		if retry > 0 {
+			err := DynamoDBBackoff(ctx, retry)
+			if err != nil {
+				return requests, err
+			}
+		}
+
+		params := &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{tablename: requests},
+		}
+
+		output, err := DynamoDBBatchWriteItem(ctx, params)
+		if err != nil {
+			return requests, err
+		}
+
+		requests = output.UnprocessedItems[tablename]
+	}
+
+	return nil, nil
+}
+
+// DynamoDBBackoff waits before the retry of the unprocessed items. It returns
+// ErrDynamoDBUnprocessedItem if all of the DYNAMODB_BATCH_WRITE_RETRIES have been
+// used, or the error of the context if it is done.
+func DynamoDBBackoff(ctx context.Context, retry int) error {
+	if retry > DYNAMODB_BATCH_WRITE_RETRIES {
+		return ErrDynamoDBUnprocessedItem
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-time.After(DYNAMODB_BATCH_WRITE_BACKOFF << (retry - 1)):
+		return nil
+	}
+}
+
+// DynamoDBTransactWriteItems initializes the DynamoDB Client and performs the write operations
+// as a single all-or-nothing operation. Either all of them succeed or none of them are applied.
+func DynamoDBTransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	// Initialize the DynamoClient
+	client, err := initDynamoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	output, err := dynamoClient.UpdateItem(ctx, params)
+	output, err := client.TransactWriteItems(ctx, params)
 	if err != nil {
 		return nil, err
 	}
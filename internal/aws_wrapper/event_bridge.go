@@ -2,43 +2,55 @@ package awswrapper
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
 var (
-	evbClient *eventbridge.Client
+	evbClient      *eventbridge.Client
+	evbClientMutex sync.Mutex
 )
 
-// initEventBridgeClient initializes the EventBridge Service Client
-// from the provided configuration.
-func initEventBridgeClient(ctx context.Context) {
+// initEventBridgeClient returns the EventBridge Client, it is created from the shared configuration
+// on the first call.
+func initEventBridgeClient(ctx context.Context) (*eventbridge.Client, error) {
+	evbClientMutex.Lock()
+	defer evbClientMutex.Unlock()
+
 	if evbClient != nil {
-		return
+		return evbClient, nil
 	}
 
-	// Using the SDK's default configuration, loading additional config
-	// and credentials values from the environment variables, shared
-	// credentials, and shared configuration files
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(AWS_REGION))
+	cfg, err := loadConfig(ctx)
 	if err != nil {
 		utility.Error(err, "EVBError", "failed to load the default config")
-		return
+		return nil, err
 	}
 
-	// Using the cfg value to create the EventBridge client
-	evbClient = eventbridge.NewFromConfig(cfg)
+	// Using the cfg value to create the EventBridge client, and the endpoint
+	// override of the service if there is any
+	evbClient = eventbridge.NewFromConfig(cfg, func(options *eventbridge.Options) {
+		if url := endpoint("EVENTBRIDGE"); url != "" {
+			options.BaseEndpoint = aws.String(url)
+		}
+	})
+
+	return evbClient, nil
 }
 
 // EventBridgePutEvents send custom events to the specified Amazon EventBridge Event
-// Bus Name.
+// Bus Name. It returns an error if the event has been rejected.
 func EventBridgePutEvents(ctx context.Context, detail, source, eventBusName string) error {
 	// Initialize the EventBridge Client
-	initEventBridgeClient(ctx)
+	client, err := initEventBridgeClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	var input = &eventbridge.PutEventsInput{
 		Entries: []types.PutEventsRequestEntry{
@@ -51,10 +63,16 @@ func EventBridgePutEvents(ctx context.Context, detail, source, eventBusName stri
 		},
 	}
 
-	_, err := evbClient.PutEvents(ctx, input)
+	result, err := client.PutEvents(ctx, input)
 	if err != nil {
 		return err
 	}
 
+	// The event is rejected without an error if it failed to be sent
+	if result.FailedEntryCount > 0 && len(result.Entries) > 0 {
+		entry := result.Entries[0]
+		return fmt.Errorf("failed to send the event: %s %s", aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+
 	return nil
 }
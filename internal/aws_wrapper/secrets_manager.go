@@ -2,48 +2,59 @@ package awswrapper
 
 import (
 	"context"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
 var (
-	smClient *secretsmanager.Client
+	smClient      *secretsmanager.Client
+	smClientMutex sync.Mutex
 )
 
-// initSMClient initializes the Secrets Manager Client from the
-// provided configuration.
-func initSMClient(ctx context.Context) {
+// initSMClient returns the Secrets Manager Client, it is created from the shared configuration
+// on the first call.
+func initSMClient(ctx context.Context) (*secretsmanager.Client, error) {
+	smClientMutex.Lock()
+	defer smClientMutex.Unlock()
+
 	if smClient != nil {
-		return
+		return smClient, nil
 	}
 
-	// Using the SDK's default configuration, loading additional config
-	// and credentials values from the environment variables, shared
-	// credentials, and shared configuration files
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(AWS_REGION))
+	cfg, err := loadConfig(ctx)
 	if err != nil {
 		utility.Error(err, "SMClientError", "failed to load the default config")
-		return
+		return nil, err
 	}
 
-	// Using the cfg value to create the Secrets Manager client
-	smClient = secretsmanager.NewFromConfig(cfg)
+	// Using the cfg value to create the Secrets Manager client, and the endpoint
+	// override of the service if there is any
+	smClient = secretsmanager.NewFromConfig(cfg, func(options *secretsmanager.Options) {
+		if url := endpoint("SECRETS_MANAGER"); url != "" {
+			options.BaseEndpoint = aws.String(url)
+		}
+	})
+
+	return smClient, nil
 }
 
 // SecretGetValue initializes the Secrets Manager client and retrieves
 // the contents of the encrypted fields.
 func SecretGetValue(ctx context.Context, secretId string) (*secretsmanager.GetSecretValueOutput, error) {
 	// Initialize the SecretManager Client
-	initSMClient(ctx)
+	client, err := initSMClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var input = &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(secretId),
 	}
 
-	output, err := smClient.GetSecretValue(ctx, input)
+	output, err := client.GetSecretValue(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -5,36 +5,44 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/rmarasigan/bus-ticketing/internal/utility"
 )
 
 var (
 	sqsClient            *sqs.Client
+	sqsClientMutex       sync.Mutex
 	BOOKING_MSG_GROUP_ID = "process.booking"
 )
 
-// initSQSClient initializes the SQS Client from the provided
-// configuration.
-func initSQSClient(ctx context.Context) {
+// initSQSClient returns the SQS Client, it is created from the shared configuration
+// on the first call.
+func initSQSClient(ctx context.Context) (*sqs.Client, error) {
+	sqsClientMutex.Lock()
+	defer sqsClientMutex.Unlock()
+
 	if sqsClient != nil {
-		return
+		return sqsClient, nil
 	}
 
-	// Using the SDK's default configuration, loading additional config
-	// and credentials values from the environment variables, shared
-	// credentials, and shared configuration files
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(AWS_REGION))
+	cfg, err := loadConfig(ctx)
 	if err != nil {
 		utility.Error(err, "SQSClientError", "failed to load the default config")
-		return
+		return nil, err
 	}
 
-	// Using the cfg value to create the SQS client
-	sqsClient = sqs.NewFromConfig(cfg)
+	// Using the cfg value to create the SQS client, and the endpoint
+	// override of the service if there is any
+	sqsClient = sqs.NewFromConfig(cfg, func(options *sqs.Options) {
+		if url := endpoint("SQS"); url != "" {
+			options.BaseEndpoint = aws.String(url)
+		}
+	})
+
+	return sqsClient, nil
 }
 
 // generateMessageDeduplicationID returns a token to be used for the SQS message
@@ -47,7 +55,10 @@ func generateMessageDeduplicationID(message string) string {
 // SQSSendMessage initializes the SQS client and delivers message to the specified queue.
 func SQSSendMessage(ctx context.Context, queue, message, groupdId string) error {
 	// Initlaize the SQS client.
-	initSQSClient(ctx)
+	client, err := initSQSClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	var input = &sqs.SendMessageInput{
 		QueueUrl:    aws.String(queue),
@@ -59,7 +70,7 @@ func SQSSendMessage(ctx context.Context, queue, message, groupdId string) error
 		input.MessageDeduplicationId = aws.String(generateMessageDeduplicationID(message))
 	}
 
-	_, err := sqsClient.SendMessage(ctx, input)
+	_, err = client.SendMessage(ctx, input)
 	if err != nil {
 		return err
 	}
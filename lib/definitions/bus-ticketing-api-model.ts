@@ -24,6 +24,10 @@ export function UserApiModel(api: apigw.RestApi) {
       type: apigw.JsonSchemaType.OBJECT,
       properties: {
         user_type: {
+          pattern: '^[1-3]$',
+          type: apigw.JsonSchemaType.STRING
+        },
+        bus_id: {
           pattern: '^.+',
           type: apigw.JsonSchemaType.STRING
         },
@@ -41,6 +45,7 @@ export function UserApiModel(api: apigw.RestApi) {
         },
         password: {
           minLength: 8,
+          maxLength: 72,
           type: apigw.JsonSchemaType.STRING
         },
         address: {
@@ -143,7 +148,7 @@ export function BusLineApiModel(api: apigw.RestApi) {
  * Represents the data structure of the creation of *Bus Unit* payload and
  * accepts an array of objects with the following fields and are validated:
  * 
- * `bus_id`, `code`, `active`, `min_capacity`, `max_capacity`
+ * `bus_id`, `code`, `active`, `min_capacity`, `max_capacity`, `amenities`
  *
  * @param api REST API that this model is part of.
 **/
@@ -176,6 +181,12 @@ export function BusUnitApiModel(api: apigw.RestApi) {
             minimum: 25,
             type: apigw.JsonSchemaType.NUMBER,
           },
+          amenities: {
+            type: apigw.JsonSchemaType.ARRAY,
+            items: {
+              type: apigw.JsonSchemaType.STRING
+            }
+          },
         }
       },
       required: [ 'bus_id', 'code', 'active', 'min_capacity', 'max_capacity' ]
@@ -187,7 +198,7 @@ export function BusUnitApiModel(api: apigw.RestApi) {
  * Represents the data structure of the creation of *Bus Route* payload and
  * accepts an object with the following fields and are validated:
  * 
- * `bus_id`, `bus_unit_id`, `currency_code`, `rate`, `available`, `departure_time`
+ * `bus_id`, `bus_unit_id`, `currency_code`, `rate`, `fares`, `available`, `departure_time`
  * `arrival_time`, `from_route`, `to_route`
  *
  * @param api REST API that this model is part of.
@@ -214,6 +225,13 @@ export function BusRouteApiModel(api: apigw.RestApi) {
           minimum: 1,
           type: apigw.JsonSchemaType.NUMBER
         },
+        fares: {
+          type: apigw.JsonSchemaType.OBJECT,
+          additionalProperties: {
+            minimum: 0,
+            type: apigw.JsonSchemaType.NUMBER
+          }
+        },
         active: {
           type: apigw.JsonSchemaType.BOOLEAN
         },
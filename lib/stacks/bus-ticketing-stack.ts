@@ -25,7 +25,32 @@ export class BusTicketingStack extends cdk.Stack
       'YOUR_AWS_SECRETS_MANAGER_ARN'
     );
 
+    // The HMAC key that signs the access and refresh tokens.
+    const TokenSecret = new secretsmanager.Secret(this, 'BusTicketing_TokenSecret', {
+      secretName: 'BusTicketing_TokenSecret',
+      removalPolicy: REMOVAL_POLICY,
+      generateSecretString: {
+        passwordLength: 64,
+        excludePunctuation: true,
+        secretStringTemplate: '{}',
+        generateStringKey: 'signing_key'
+      }
+    });
+
     // ******************** DynamoDB ******************** //
+    // CloudFormation can only create or delete one Global Secondary Index of a table per
+    // stack update. A new stack creates every index with its table, but an existing stack
+    // has to be updated in stages: the "indexStage" context only adds the indexes up to the
+    // stage, and "make deploy_indexes" deploys every stage in order (see the README).
+    const MAX_INDEX_STAGE = 4;
+    const INDEX_STAGE = Number(this.node.tryGetContext('indexStage') ?? MAX_INDEX_STAGE);
+
+    const addIndex = (table: dynamodb.Table, stage: number, index: dynamodb.GlobalSecondaryIndexProps) => {
+      if (stage <= INDEX_STAGE) {
+        table.addGlobalSecondaryIndex(index);
+      }
+    };
+
     // 1. Create a DynamoDB Table that will contain the basic user record
     // that has a partition and sort key.
     const UsersTable = new dynamodb.Table(this, 'BusTicketing_UsersTable', {
@@ -42,6 +67,28 @@ export class BusTicketingStack extends cdk.Stack
       removalPolicy: REMOVAL_POLICY
     });
 
+    // Query the user account by its ID alone.
+    addIndex(UsersTable, 1, {
+      indexName: 'id-index',
+      partitionKey: {
+        name: 'id',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
+    // Search the user accounts of a user type by the date they were created.
+    addIndex(UsersTable, 2, {
+      indexName: 'user_type-index',
+      partitionKey: {
+        name: 'user_type',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'date_created',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
     // 2. Create a DynamoDB Table that will contain the bus line information that has
     // a partition and sort key.
     const BusTable = new dynamodb.Table(this, 'BusTicketing_BusTable', {
@@ -58,6 +105,15 @@ export class BusTicketingStack extends cdk.Stack
       removalPolicy: REMOVAL_POLICY
     });
 
+    // Query the bus line by its ID alone.
+    addIndex(BusTable, 1, {
+      indexName: 'id-index',
+      partitionKey: {
+        name: 'id',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
     // 3. Create a DynamoDB Table that will contain the Bus Line Units information that
     // has a partition and sorty key.
     const BusUnitTable = new dynamodb.Table(this, 'BusTicketing_BusUnitTable', {
@@ -74,6 +130,19 @@ export class BusTicketingStack extends cdk.Stack
       removalPolicy: REMOVAL_POLICY
     });
 
+    // Query the bus units of a bus line.
+    addIndex(BusUnitTable, 1, {
+      indexName: 'bus_id-index',
+      partitionKey: {
+        name: 'bus_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'code',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
     // 4. Create a DynamoDB Table that will contain the Bus Route information that has a
     // partition and sort key.
     const BusRouteTable = new dynamodb.Table(this, 'BusTicketing_BusRouteTable', {
@@ -90,6 +159,32 @@ export class BusTicketingStack extends cdk.Stack
       removalPolicy: REMOVAL_POLICY
     });
 
+    // Query the bus routes by its starting point and destination.
+    addIndex(BusRouteTable, 1, {
+      indexName: 'from_route-to_route-index',
+      partitionKey: {
+        name: 'from_route',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'to_route',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
+    // Query the bus routes of a bus line and its bus unit.
+    addIndex(BusRouteTable, 2, {
+      indexName: 'bus_id-index',
+      partitionKey: {
+        name: 'bus_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'bus_unit_id',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
     // 5. Create a DynamoDB Table that will contain the Booking information that has a
     // partition and sort key.
     const BookingTable = new dynamodb.Table(this, 'BusTicketing_BookingTable', {
@@ -105,6 +200,56 @@ export class BusTicketingStack extends cdk.Stack
       removalPolicy: REMOVAL_POLICY
     });
 
+    // Query the bookings by status, bus line and bus route.
+    addIndex(BookingTable, 1, {
+      indexName: 'status-index',
+      partitionKey: {
+        name: 'status',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'date_created',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
+    addIndex(BookingTable, 2, {
+      indexName: 'bus_id-status-index',
+      partitionKey: {
+        name: 'bus_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'status',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
+    addIndex(BookingTable, 3, {
+      indexName: 'bus_route_id-status-index',
+      partitionKey: {
+        name: 'bus_route_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'status',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
+    // Query the bookings of a user account.
+    addIndex(BookingTable, 4, {
+      indexName: 'user_id-status-index',
+      partitionKey: {
+        name: 'user_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'status',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
     // 6. Create a DynamoDB Table that will contain the Cancelled Booking information
     // that has a partition/primary key.
     const CancelledBookingTable = new dynamodb.Table(this, 'BusTicketing_CancelledBookingTable', {
@@ -117,6 +262,167 @@ export class BusTicketingStack extends cdk.Stack
       removalPolicy: REMOVAL_POLICY
     });
 
+    // 7. Create a DynamoDB Table that will contain the values that must be unique
+    // across the records but are not part of their primary key (e.g. username and the
+    // IDs of the user accounts, bus lines and bus routes).
+    const UniqueKeyTable = new dynamodb.Table(this, 'BusTicketing_UniqueKeyTable', {
+      tableName: 'BusTicketing_UniqueKeyTable',
+      partitionKey: {
+        name: 'key',
+        type: dynamodb.AttributeType.STRING
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // 8. Create a DynamoDB Table that will contain the pending password resets that
+    // has a partition/primary key. Only the hash of the reset token is stored, and
+    // the expired password resets are deleted by the TTL.
+    const PasswordResetTable = new dynamodb.Table(this, 'BusTicketing_PasswordResetTable', {
+      tableName: 'BusTicketing_PasswordResetTable',
+      partitionKey: {
+        name: 'token_hash',
+        type: dynamodb.AttributeType.STRING
+      },
+      timeToLiveAttribute: 'expires_at',
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // 9. Create a DynamoDB Table that will contain the pending e-mail verifications
+    // that has a partition/primary key. Only the hash of the verification token is
+    // stored, and the expired e-mail verifications are deleted by the TTL.
+    const EmailVerificationTable = new dynamodb.Table(this, 'BusTicketing_EmailVerificationTable', {
+      tableName: 'BusTicketing_EmailVerificationTable',
+      partitionKey: {
+        name: 'token_hash',
+        type: dynamodb.AttributeType.STRING
+      },
+      timeToLiveAttribute: 'expires_at',
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // 10. Create a DynamoDB Table that will contain the failed login attempts and the
+    // lockout of every username and source IP that has a partition/primary key. They
+    // are deleted by the TTL once they are forgotten.
+    const LoginAttemptTable = new dynamodb.Table(this, 'BusTicketing_LoginAttemptTable', {
+      tableName: 'BusTicketing_LoginAttemptTable',
+      partitionKey: {
+        name: 'key',
+        type: dynamodb.AttributeType.STRING
+      },
+      timeToLiveAttribute: 'expires_at',
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // 11. Create a DynamoDB Table that will contain the e-mails that have been sent to
+    // the user accounts that has a partition/primary key and a sort key. They are part
+    // of the personal data export of a user account.
+    const NotificationTable = new dynamodb.Table(this, 'BusTicketing_NotificationTable', {
+      tableName: 'BusTicketing_NotificationTable',
+      partitionKey: {
+        name: 'user_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'id',
+        type: dynamodb.AttributeType.STRING
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // 12. Create a DynamoDB Table that will contain the changes that were made to the
+    // user accounts by an ADMIN that has a partition/primary key and a sort key.
+    const UserAuditTable = new dynamodb.Table(this, 'BusTicketing_UserAuditTable', {
+      tableName: 'BusTicketing_UserAuditTable',
+      partitionKey: {
+        name: 'user_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'id',
+        type: dynamodb.AttributeType.STRING
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // 13. Create a DynamoDB Table that will contain the maintenance of the bus units
+    // that has a partition/primary key and a sort key.
+    const MaintenanceTable = new dynamodb.Table(this, 'BusTicketing_BusUnitMaintenanceTable', {
+      tableName: 'BusTicketing_BusUnitMaintenanceTable',
+      partitionKey: {
+        name: 'bus_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'id',
+        type: dynamodb.AttributeType.STRING
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // 14. Create a DynamoDB Table that will contain the drivers and conductors of the
+    // bus lines that has a partition/primary key and a sort key.
+    const CrewTable = new dynamodb.Table(this, 'BusTicketing_CrewTable', {
+      tableName: 'BusTicketing_CrewTable',
+      partitionKey: {
+        name: 'bus_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'id',
+        type: dynamodb.AttributeType.STRING
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // 15. Create a DynamoDB Table that will contain the assignments of the crew to the
+    // trips of the bus routes that has a partition/primary key and a sort key.
+    const CrewAssignmentTable = new dynamodb.Table(this, 'BusTicketing_CrewAssignmentTable', {
+      tableName: 'BusTicketing_CrewAssignmentTable',
+      partitionKey: {
+        name: 'crew_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'id',
+        type: dynamodb.AttributeType.STRING
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
+    // Fetch the crew that is assigned to the trips of a bus route.
+    addIndex(CrewAssignmentTable, 1, {
+      indexName: 'bus_route_id-index',
+      partitionKey: {
+        name: 'bus_route_id',
+        type: dynamodb.AttributeType.STRING
+      }
+    });
+
+    // 16. Create a DynamoDB Table that will contain the seat layouts of the bus lines
+    // and the shared templates that has a partition/primary key and a sort key.
+    const SeatLayoutTable = new dynamodb.Table(this, 'BusTicketing_SeatLayoutTable', {
+      tableName: 'BusTicketing_SeatLayoutTable',
+      partitionKey: {
+        name: 'bus_id',
+        type: dynamodb.AttributeType.STRING
+      },
+      sortKey: {
+        name: 'id',
+        type: dynamodb.AttributeType.STRING
+      },
+      billingMode: dynamodb.BillingMode.PAY_PER_REQUEST,
+      removalPolicy: REMOVAL_POLICY
+    });
+
     // ******************** Lambda Functions ******************** //
     // ***** User Lambda Functions Specification ***** //
     const createUser = new lambda.Function(this, 'createUser', {
@@ -128,10 +434,22 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/user/createUser'),
       description: 'A Lambda Function that will process API requests and create a new user account',
       environment: {
-        "USERS_TABLE": UsersTable.tableName
+        "BUS_TABLE": BusTable.tableName,
+        "USERS_TABLE": UsersTable.tableName,
+        "UNIQUE_KEY_TABLE": UniqueKeyTable.tableName,
+        "EMAIL_VERIFICATION_TABLE": EmailVerificationTable.tableName,
+        "TOKEN_SECRET": TokenSecret.secretArn,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
+        "EMAIL_SECRET": EmailSecret.secretArn
       }
     });
+    TokenSecret.grantRead(createUser);
+    EmailSecret.grantRead(createUser);
+    BusTable.grantReadData(createUser);
     UsersTable.grantReadWriteData(createUser);
+    UniqueKeyTable.grantReadWriteData(createUser);
+    EmailVerificationTable.grantWriteData(createUser);
+    NotificationTable.grantWriteData(createUser);
     createUser.applyRemovalPolicy(REMOVAL_POLICY);
 
     const login = new lambda.Function(this, 'login', {
@@ -143,12 +461,231 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/user/login'),
       description: 'A Lambda Function that will process API requests and login the user account',
       environment: {
-        "USERS_TABLE": UsersTable.tableName
+        "USERS_TABLE": UsersTable.tableName,
+        "LOGIN_ATTEMPT_TABLE": LoginAttemptTable.tableName,
+        "TOKEN_SECRET": TokenSecret.secretArn,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
+        "EMAIL_SECRET": EmailSecret.secretArn,
+        "MFA_REQUIRED_OPERATOR": 'false'
       }
     });
+    TokenSecret.grantRead(login);
+    EmailSecret.grantRead(login);
     UsersTable.grantReadWriteData(login);
+    LoginAttemptTable.grantReadWriteData(login);
+    NotificationTable.grantWriteData(login);
     login.applyRemovalPolicy(REMOVAL_POLICY);
 
+    const refreshToken = new lambda.Function(this, 'refreshToken', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'refreshToken',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/refreshToken'),
+      description: 'A Lambda Function that will process API requests and issue new tokens from a refresh token',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "TOKEN_SECRET": TokenSecret.secretArn,
+        "MFA_REQUIRED_OPERATOR": 'false'
+      }
+    });
+    TokenSecret.grantRead(refreshToken);
+    UsersTable.grantReadData(refreshToken);
+    refreshToken.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const loginMFA = new lambda.Function(this, 'loginMFA', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'loginMFA',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/loginMFA'),
+      description: 'A Lambda Function that will process API requests and complete the login with a TOTP code or a recovery code',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "LOGIN_ATTEMPT_TABLE": LoginAttemptTable.tableName,
+        "TOKEN_SECRET": TokenSecret.secretArn,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
+        "EMAIL_SECRET": EmailSecret.secretArn
+      }
+    });
+    TokenSecret.grantRead(loginMFA);
+    EmailSecret.grantRead(loginMFA);
+    UsersTable.grantReadWriteData(loginMFA);
+    LoginAttemptTable.grantReadWriteData(loginMFA);
+    NotificationTable.grantWriteData(loginMFA);
+    loginMFA.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const enrollMFA = new lambda.Function(this, 'enrollMFA', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'enrollMFA',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/enrollMFA'),
+      description: 'A Lambda Function that will process API requests and generate a new TOTP secret of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName
+      }
+    });
+    UsersTable.grantReadWriteData(enrollMFA);
+    enrollMFA.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const confirmMFA = new lambda.Function(this, 'confirmMFA', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'confirmMFA',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/confirmMFA'),
+      description: 'A Lambda Function that will process API requests and enable the MFA of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName
+      }
+    });
+    UsersTable.grantReadWriteData(confirmMFA);
+    confirmMFA.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const disableMFA = new lambda.Function(this, 'disableMFA', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'disableMFA',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/disableMFA'),
+      description: 'A Lambda Function that will process API requests and disable the MFA of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "MFA_REQUIRED_OPERATOR": 'false'
+      }
+    });
+    UsersTable.grantReadWriteData(disableMFA);
+    disableMFA.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const regenerateRecoveryCodes = new lambda.Function(this, 'regenerateRecoveryCodes', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'regenerateRecoveryCodes',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/regenerateRecoveryCodes'),
+      description: 'A Lambda Function that will process API requests and replace the MFA recovery codes of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName
+      }
+    });
+    UsersTable.grantReadWriteData(regenerateRecoveryCodes);
+    regenerateRecoveryCodes.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const forgotPassword = new lambda.Function(this, 'forgotPassword', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'forgotPassword',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/forgotPassword'),
+      description: 'A Lambda Function that will process API requests and send a password reset token to the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "PASSWORD_RESET_TABLE": PasswordResetTable.tableName,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
+        "EMAIL_SECRET": EmailSecret.secretArn
+      }
+    });
+    EmailSecret.grantRead(forgotPassword);
+    UsersTable.grantReadData(forgotPassword);
+    PasswordResetTable.grantWriteData(forgotPassword);
+    NotificationTable.grantWriteData(forgotPassword);
+    forgotPassword.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const resetPassword = new lambda.Function(this, 'resetPassword', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'resetPassword',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/resetPassword'),
+      description: 'A Lambda Function that will process API requests and reset the password of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "PASSWORD_RESET_TABLE": PasswordResetTable.tableName
+      }
+    });
+    UsersTable.grantReadWriteData(resetPassword);
+    PasswordResetTable.grantReadWriteData(resetPassword);
+    resetPassword.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const verifyEmail = new lambda.Function(this, 'verifyEmail', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'verifyEmail',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/verifyEmail'),
+      description: 'A Lambda Function that will process API requests and verify the e-mail address of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "EMAIL_VERIFICATION_TABLE": EmailVerificationTable.tableName
+      }
+    });
+    UsersTable.grantReadWriteData(verifyEmail);
+    EmailVerificationTable.grantReadWriteData(verifyEmail);
+    verifyEmail.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const resendVerification = new lambda.Function(this, 'resendVerification', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'resendVerification',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/resendVerification'),
+      description: 'A Lambda Function that will process API requests and send a new e-mail verification link to the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "EMAIL_VERIFICATION_TABLE": EmailVerificationTable.tableName,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
+        "EMAIL_SECRET": EmailSecret.secretArn
+      }
+    });
+    EmailSecret.grantRead(resendVerification);
+    UsersTable.grantReadData(resendVerification);
+    EmailVerificationTable.grantWriteData(resendVerification);
+    NotificationTable.grantWriteData(resendVerification);
+    resendVerification.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const unlockUser = new lambda.Function(this, 'unlockUser', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'unlockUser',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/unlockUser'),
+      description: 'A Lambda Function that will process API requests and unlock a username or source IP after too many failed logins',
+      environment: {
+        "LOGIN_ATTEMPT_TABLE": LoginAttemptTable.tableName
+      }
+    });
+    LoginAttemptTable.grantReadWriteData(unlockUser);
+    unlockUser.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const authorizer = new lambda.Function(this, 'authorizer', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'authorizer',
+      timeout: cdk.Duration.seconds(30),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/authorizer'),
+      description: 'A Lambda Function that will validate the access tokens of the API requests',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "TOKEN_SECRET": TokenSecret.secretArn
+      }
+    });
+    TokenSecret.grantRead(authorizer);
+    UsersTable.grantReadData(authorizer);
+    authorizer.applyRemovalPolicy(REMOVAL_POLICY);
+
     const getUser = new lambda.Function(this, 'getUser', {
       memorySize: 1024,
       handler: 'bootstrap',
@@ -158,26 +695,138 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/user/getUser'),
       description: 'A Lambda Function that will process API requests and get the user account record',
       environment: {
-        "USERS_TABLE": UsersTable.tableName
+        "USERS_TABLE": UsersTable.tableName
+      }
+    });
+    UsersTable.grantReadData(getUser);
+    getUser.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const updateUser = new lambda.Function(this, 'updateUser', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'updateUser',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/updateUser'),
+      description: 'A Lambda Function that will process API requests and update the user account record',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName
+      }
+    });
+    UsersTable.grantReadWriteData(updateUser);
+    updateUser.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const exportUser = new lambda.Function(this, 'exportUser', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'exportUser',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/exportUser'),
+      description: 'A Lambda Function that will process API requests and export the personal data of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "BOOKING_TABLE": BookingTable.tableName,
+        "BOOKING_CANCELLED_TABLE": CancelledBookingTable.tableName,
+        "NOTIFICATION_TABLE": NotificationTable.tableName
+      }
+    });
+    UsersTable.grantReadData(exportUser);
+    BookingTable.grantReadData(exportUser);
+    CancelledBookingTable.grantReadData(exportUser);
+    NotificationTable.grantReadData(exportUser);
+    exportUser.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const eraseUser = new lambda.Function(this, 'eraseUser', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'eraseUser',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/eraseUser'),
+      description: 'A Lambda Function that will process API requests and erase the personal data of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "UNIQUE_KEY_TABLE": UniqueKeyTable.tableName,
+        "BOOKING_TABLE": BookingTable.tableName,
+        "BOOKING_CANCELLED_TABLE": CancelledBookingTable.tableName,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
+        "LOGIN_ATTEMPT_TABLE": LoginAttemptTable.tableName
+      }
+    });
+    UsersTable.grantReadWriteData(eraseUser);
+    UniqueKeyTable.grantReadWriteData(eraseUser);
+    BookingTable.grantReadData(eraseUser);
+    CancelledBookingTable.grantReadWriteData(eraseUser);
+    NotificationTable.grantReadWriteData(eraseUser);
+    LoginAttemptTable.grantReadWriteData(eraseUser);
+    eraseUser.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const filterUser = new lambda.Function(this, 'filterUser', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'filterUser',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/filterUser'),
+      description: 'A Lambda Function that will process API requests and search the user account records',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName
+      }
+    });
+    UsersTable.grantReadData(filterUser);
+    filterUser.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const updateUserStatus = new lambda.Function(this, 'updateUserStatus', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'updateUserStatus',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/updateUserStatus'),
+      description: 'A Lambda Function that will process API requests and deactivate or reactivate the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "USER_AUDIT_TABLE": UserAuditTable.tableName
+      }
+    });
+    UsersTable.grantReadWriteData(updateUserStatus);
+    UserAuditTable.grantWriteData(updateUserStatus);
+    updateUserStatus.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const changeUserType = new lambda.Function(this, 'changeUserType', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'changeUserType',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/user/changeUserType'),
+      description: 'A Lambda Function that will process API requests and change the user type of the user account',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "USER_AUDIT_TABLE": UserAuditTable.tableName,
+        "BUS_TABLE": BusTable.tableName
       }
     });
-    UsersTable.grantReadData(getUser);
-    getUser.applyRemovalPolicy(REMOVAL_POLICY);
+    UsersTable.grantReadWriteData(changeUserType);
+    UserAuditTable.grantWriteData(changeUserType);
+    BusTable.grantReadData(changeUserType);
+    changeUserType.applyRemovalPolicy(REMOVAL_POLICY);
 
-    const updateUser = new lambda.Function(this, 'updateUser', {
+    const getUserAudit = new lambda.Function(this, 'getUserAudit', {
       memorySize: 1024,
       handler: 'bootstrap',
-      functionName: 'updateUser',
+      functionName: 'getUserAudit',
       timeout: cdk.Duration.seconds(60),
       runtime: lambda.Runtime.PROVIDED_AL2,
-      code: lambda.Code.fromAsset('cmd/user/updateUser'),
-      description: 'A Lambda Function that will process API requests and update the user account record',
+      code: lambda.Code.fromAsset('cmd/user/getUserAudit'),
+      description: 'A Lambda Function that will process API requests and get the audit trail of the user account',
       environment: {
-        "USERS_TABLE": UsersTable.tableName
+        "USER_AUDIT_TABLE": UserAuditTable.tableName
       }
     });
-    UsersTable.grantReadWriteData(updateUser);
-    updateUser.applyRemovalPolicy(REMOVAL_POLICY);
+    UserAuditTable.grantReadData(getUserAudit);
+    getUserAudit.applyRemovalPolicy(REMOVAL_POLICY);
 
     // ***** Bus Lambda Functions Specification ***** //
     const createBus = new lambda.Function(this, 'createBus', {
@@ -189,10 +838,12 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bus/createBus'),
       description: 'A Lambda Function that will process API requests and create a new bus line record',
       environment: {
-        "BUS_TABLE": BusTable.tableName
+        "BUS_TABLE": BusTable.tableName,
+        "UNIQUE_KEY_TABLE": UniqueKeyTable.tableName
       }
     });
     BusTable.grantReadWriteData(createBus);
+    UniqueKeyTable.grantReadWriteData(createBus);
     createBus.applyRemovalPolicy(REMOVAL_POLICY);
 
     const getBus = new lambda.Function(this, 'getBus', {
@@ -250,10 +901,12 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bus_unit/createBusUnit'),
       description: 'A Lambda Function that will process API requests and create a new bus line unit record',
       environment: {
-        "BUS_UNIT_TABLE": BusUnitTable.tableName
+        "BUS_UNIT_TABLE": BusUnitTable.tableName,
+        "SEAT_LAYOUT_TABLE": SeatLayoutTable.tableName
       }
     });
     BusUnitTable.grantReadWriteData(createBusUnit);
+    SeatLayoutTable.grantReadData(createBusUnit);
     createBusUnit.applyRemovalPolicy(REMOVAL_POLICY);
 
     const getBusUnit = new lambda.Function(this, 'getBusUnit', {
@@ -280,10 +933,14 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bus_unit/updateBusUnit'),
       description: 'A Lambda Function that will process API requests and update the bus line unit record',
       environment: {
-        "BUS_UNIT_TABLE": BusUnitTable.tableName
+        "BUS_UNIT_TABLE": BusUnitTable.tableName,
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "SEAT_LAYOUT_TABLE": SeatLayoutTable.tableName
       }
     });
     BusUnitTable.grantReadWriteData(updateBusUnit);
+    BusRouteTable.grantReadWriteData(updateBusUnit);
+    SeatLayoutTable.grantReadData(updateBusUnit);
     updateBusUnit.applyRemovalPolicy(REMOVAL_POLICY);
 
     const filterBusUnit = new lambda.Function(this, 'filterBusUnit', {
@@ -301,6 +958,108 @@ export class BusTicketingStack extends cdk.Stack
     BusUnitTable.grantReadData(filterBusUnit);
     filterBusUnit.applyRemovalPolicy(REMOVAL_POLICY);
 
+    const createBusUnitMaintenance = new lambda.Function(this, 'createBusUnitMaintenance', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'createBusUnitMaintenance',
+      timeout: cdk.Duration.seconds(90),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_unit/createBusUnitMaintenance'),
+      description: 'A Lambda Function that will process API requests, schedule the maintenance of a bus unit and flag its bookings',
+      environment: {
+        "MAINTENANCE_TABLE": MaintenanceTable.tableName,
+        "BUS_UNIT_TABLE": BusUnitTable.tableName,
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "BOOKING_TABLE": BookingTable.tableName
+      }
+    });
+    MaintenanceTable.grantReadWriteData(createBusUnitMaintenance);
+    BusUnitTable.grantReadData(createBusUnitMaintenance);
+    BusRouteTable.grantReadData(createBusUnitMaintenance);
+    BookingTable.grantReadWriteData(createBusUnitMaintenance);
+    createBusUnitMaintenance.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const getBusUnitMaintenance = new lambda.Function(this, 'getBusUnitMaintenance', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'getBusUnitMaintenance',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_unit/getBusUnitMaintenance'),
+      description: 'A Lambda Function that will process API requests and fetch the maintenance of the bus units',
+      environment: {
+        "MAINTENANCE_TABLE": MaintenanceTable.tableName
+      }
+    });
+    MaintenanceTable.grantReadData(getBusUnitMaintenance);
+    getBusUnitMaintenance.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const deleteBusUnitMaintenance = new lambda.Function(this, 'deleteBusUnitMaintenance', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'deleteBusUnitMaintenance',
+      timeout: cdk.Duration.seconds(90),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_unit/deleteBusUnitMaintenance'),
+      description: 'A Lambda Function that will process API requests, remove the flag of the bookings of a bus unit maintenance and delete it',
+      environment: {
+        "MAINTENANCE_TABLE": MaintenanceTable.tableName,
+        "BOOKING_TABLE": BookingTable.tableName
+      }
+    });
+    MaintenanceTable.grantReadWriteData(deleteBusUnitMaintenance);
+    BookingTable.grantReadWriteData(deleteBusUnitMaintenance);
+    deleteBusUnitMaintenance.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const getMaintenanceConflicts = new lambda.Function(this, 'getMaintenanceConflicts', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'getMaintenanceConflicts',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_unit/getMaintenanceConflicts'),
+      description: 'A Lambda Function that will process API requests and fetch the bookings that have to be reassigned from a bus unit maintenance',
+      environment: {
+        "MAINTENANCE_TABLE": MaintenanceTable.tableName,
+        "BOOKING_TABLE": BookingTable.tableName
+      }
+    });
+    MaintenanceTable.grantReadData(getMaintenanceConflicts);
+    BookingTable.grantReadData(getMaintenanceConflicts);
+    getMaintenanceConflicts.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const createSeatLayout = new lambda.Function(this, 'createSeatLayout', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'createSeatLayout',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_unit/createSeatLayout'),
+      description: 'A Lambda Function that will process API requests and create a new seat layout of a bus line or a template',
+      environment: {
+        "SEAT_LAYOUT_TABLE": SeatLayoutTable.tableName,
+        "BUS_TABLE": BusTable.tableName
+      }
+    });
+    SeatLayoutTable.grantReadWriteData(createSeatLayout);
+    BusTable.grantReadData(createSeatLayout);
+    createSeatLayout.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const getSeatLayout = new lambda.Function(this, 'getSeatLayout', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'getSeatLayout',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_unit/getSeatLayout'),
+      description: 'A Lambda Function that will process API requests and fetch the seat layouts of a bus line or the templates',
+      environment: {
+        "SEAT_LAYOUT_TABLE": SeatLayoutTable.tableName
+      }
+    });
+    SeatLayoutTable.grantReadData(getSeatLayout);
+    getSeatLayout.applyRemovalPolicy(REMOVAL_POLICY);
+
     // ***** Bus Route Lambda Functions Specification ***** //
     const createBusRoute = new lambda.Function(this, 'createBusRoute', {
       memorySize: 1024,
@@ -311,10 +1070,14 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bus_route/createBusRoute'),
       description: 'A Lambda Function that will process API requests and create a new bus route record',
       environment: {
-        "BUS_ROUTE_TABLE": BusRouteTable.tableName
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "BUS_UNIT_TABLE": BusUnitTable.tableName,
+        "UNIQUE_KEY_TABLE": UniqueKeyTable.tableName
       }
     });
     BusRouteTable.grantReadWriteData(createBusRoute);
+    BusUnitTable.grantReadData(createBusRoute);
+    UniqueKeyTable.grantReadWriteData(createBusRoute);
     createBusRoute.applyRemovalPolicy(REMOVAL_POLICY);
 
     const getBusRoute = new lambda.Function(this, 'getBusRoute', {
@@ -362,6 +1125,146 @@ export class BusTicketingStack extends cdk.Stack
     BusRouteTable.grantReadWriteData(updateBusRoute);
     updateBusRoute.applyRemovalPolicy(REMOVAL_POLICY);
 
+    const getTripManifest = new lambda.Function(this, 'getTripManifest', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'getTripManifest',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_route/getTripManifest'),
+      description: 'A Lambda Function that will process API requests and fetch the crew and the bookings of a trip of the bus route',
+      environment: {
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "BOOKING_TABLE": BookingTable.tableName,
+        "CREW_TABLE": CrewTable.tableName,
+        "CREW_ASSIGNMENT_TABLE": CrewAssignmentTable.tableName
+      }
+    });
+    BusRouteTable.grantReadData(getTripManifest);
+    BookingTable.grantReadData(getTripManifest);
+    CrewTable.grantReadData(getTripManifest);
+    CrewAssignmentTable.grantReadData(getTripManifest);
+    getTripManifest.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const getSeatAvailability = new lambda.Function(this, 'getSeatAvailability', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'getSeatAvailability',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_route/getSeatAvailability'),
+      description: 'A Lambda Function that will process API requests and count the available seats of every seat class of a trip of the bus route',
+      environment: {
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "BUS_UNIT_TABLE": BusUnitTable.tableName,
+        "SEAT_LAYOUT_TABLE": SeatLayoutTable.tableName,
+        "BOOKING_TABLE": BookingTable.tableName
+      }
+    });
+    BusRouteTable.grantReadData(getSeatAvailability);
+    BusUnitTable.grantReadData(getSeatAvailability);
+    SeatLayoutTable.grantReadData(getSeatAvailability);
+    BookingTable.grantReadData(getSeatAvailability);
+    getSeatAvailability.applyRemovalPolicy(REMOVAL_POLICY);
+
+    // ***** Crew Lambda Functions Specification ***** //
+    const createCrew = new lambda.Function(this, 'createCrew', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'createCrew',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/crew/createCrew'),
+      description: 'A Lambda Function that will process API requests and create a new crew record',
+      environment: {
+        "CREW_TABLE": CrewTable.tableName,
+        "BUS_TABLE": BusTable.tableName
+      }
+    });
+    CrewTable.grantReadWriteData(createCrew);
+    BusTable.grantReadData(createCrew);
+    createCrew.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const getCrew = new lambda.Function(this, 'getCrew', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'getCrew',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/crew/getCrew'),
+      description: 'A Lambda Function that will process API requests and fetch the crew records of the bus line',
+      environment: {
+        "CREW_TABLE": CrewTable.tableName
+      }
+    });
+    CrewTable.grantReadData(getCrew);
+    getCrew.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const updateCrew = new lambda.Function(this, 'updateCrew', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'updateCrew',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/crew/updateCrew'),
+      description: 'A Lambda Function that will process API requests and update the crew record',
+      environment: {
+        "CREW_TABLE": CrewTable.tableName
+      }
+    });
+    CrewTable.grantReadWriteData(updateCrew);
+    updateCrew.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const assignCrew = new lambda.Function(this, 'assignCrew', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'assignCrew',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/crew/assignCrew'),
+      description: 'A Lambda Function that will process API requests, validate the duty of the crew and assign it to the trips of a bus route',
+      environment: {
+        "CREW_TABLE": CrewTable.tableName,
+        "CREW_ASSIGNMENT_TABLE": CrewAssignmentTable.tableName,
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "CREW_MAX_DUTY": '10h'
+      }
+    });
+    CrewTable.grantReadData(assignCrew);
+    CrewAssignmentTable.grantReadWriteData(assignCrew);
+    BusRouteTable.grantReadData(assignCrew);
+    assignCrew.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const unassignCrew = new lambda.Function(this, 'unassignCrew', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'unassignCrew',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/crew/unassignCrew'),
+      description: 'A Lambda Function that will process API requests and remove the assignment of the crew',
+      environment: {
+        "CREW_ASSIGNMENT_TABLE": CrewAssignmentTable.tableName
+      }
+    });
+    CrewAssignmentTable.grantReadWriteData(unassignCrew);
+    unassignCrew.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const getCrewAssignment = new lambda.Function(this, 'getCrewAssignment', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'getCrewAssignment',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/crew/getCrewAssignment'),
+      description: 'A Lambda Function that will process API requests and fetch the assignments of the crew or of the bus route',
+      environment: {
+        "CREW_ASSIGNMENT_TABLE": CrewAssignmentTable.tableName
+      }
+    });
+    CrewAssignmentTable.grantReadData(getCrewAssignment);
+    getCrewAssignment.applyRemovalPolicy(REMOVAL_POLICY);
+
     // ***** Booking Lambda Functions and SQS Specification ***** //
     // SQS QUEUE
     // 1. Create a deadletter queue that will contain the unsuccessfully
@@ -396,10 +1299,21 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bookings/createBooking'),
       description: 'A Lambda Function that will process API requests and sends new booking record to the SQS Queue',
       environment: {
-        "BOOKING_QUEUE": bookingQueue.queueUrl
+        "BOOKING_QUEUE": bookingQueue.queueUrl,
+        "USERS_TABLE": UsersTable.tableName,
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "MAINTENANCE_TABLE": MaintenanceTable.tableName,
+        "BUS_UNIT_TABLE": BusUnitTable.tableName,
+        "SEAT_LAYOUT_TABLE": SeatLayoutTable.tableName,
+        "EMAIL_VERIFICATION_GRACE": '0s'
       }
     });
     bookingQueue.grantSendMessages(createBooking);
+    UsersTable.grantReadData(createBooking);
+    BusRouteTable.grantReadData(createBooking);
+    MaintenanceTable.grantReadData(createBooking);
+    BusUnitTable.grantReadData(createBooking);
+    SeatLayoutTable.grantReadData(createBooking);
     createBooking.applyRemovalPolicy(REMOVAL_POLICY);
 
     const processBooking = new lambda.Function(this, 'processBooking', {
@@ -472,13 +1386,23 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bookings/updateBookingStatus'),
       environment: {
         "EVENT_BUS": eventbus.eventBusName,
-        "BOOKING_TABLE": BookingTable.tableName
+        "BOOKING_TABLE": BookingTable.tableName,
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "BUS_UNIT_TABLE": BusUnitTable.tableName,
+        "SEAT_LAYOUT_TABLE": SeatLayoutTable.tableName
       }
     });
     eventbus.grantPutEventsTo(updateBookingStatus);
     BookingTable.grantReadData(updateBookingStatus);
+    BusRouteTable.grantReadData(updateBookingStatus);
+    BusUnitTable.grantReadData(updateBookingStatus);
+    SeatLayoutTable.grantReadData(updateBookingStatus);
     updateBookingStatus.applyRemovalPolicy(REMOVAL_POLICY);
 
+    // The bus route updates send the route change events to the event bus
+    updateBusRoute.addEnvironment("EVENT_BUS", eventbus.eventBusName);
+    eventbus.grantPutEventsTo(updateBusRoute);
+
     const confirmedBooking = new lambda.Function(this, 'confirmedBooking', {
       memorySize: 1024,
       handler: 'bootstrap',
@@ -488,6 +1412,7 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bookings/confirmedBooking'),
       environment: {
         "USERS_TABLE": UsersTable.tableName,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
         "EMAIL_SECRET": EmailSecret.secretArn,
         "BOOKING_TABLE": BookingTable.tableName,
         "BUS_ROUTE_TABLE": BusRouteTable.tableName
@@ -497,6 +1422,7 @@ export class BusTicketingStack extends cdk.Stack
     UsersTable.grantReadData(confirmedBooking);
     BusRouteTable.grantReadData(confirmedBooking);
     BookingTable.grantReadWriteData(confirmedBooking);
+    NotificationTable.grantWriteData(confirmedBooking);
     confirmedBooking.applyRemovalPolicy(REMOVAL_POLICY);
 
     // A rule in where to send the confirmed booking events,
@@ -526,6 +1452,7 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bookings/cancelledBooking'),
       environment: {
         "USERS_TABLE": UsersTable.tableName,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
         "EMAIL_SECRET": EmailSecret.secretArn,
         "BOOKING_TABLE": BookingTable.tableName,
         "BUS_ROUTE_TABLE": BusRouteTable.tableName,
@@ -536,6 +1463,7 @@ export class BusTicketingStack extends cdk.Stack
     UsersTable.grantReadData(cancelledBooking);
     BusRouteTable.grantReadData(cancelledBooking);
     BookingTable.grantReadWriteData(cancelledBooking);
+    NotificationTable.grantReadWriteData(cancelledBooking);
     cancelledBooking.applyRemovalPolicy(REMOVAL_POLICY);
     CancelledBookingTable.grantReadWriteData(cancelledBooking);
 
@@ -557,6 +1485,78 @@ export class BusTicketingStack extends cdk.Stack
       ]
     });
 
+    const changedBusRoute = new lambda.Function(this, 'changedBusRoute', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'changedBusRoute',
+      timeout: cdk.Duration.seconds(300),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_route/changedBusRoute'),
+      description: 'A Lambda Function that will cancel, flag and notify the bookings of a deactivated or rescheduled bus route',
+      environment: {
+        "USERS_TABLE": UsersTable.tableName,
+        "NOTIFICATION_TABLE": NotificationTable.tableName,
+        "EMAIL_SECRET": EmailSecret.secretArn,
+        "BOOKING_TABLE": BookingTable.tableName,
+        "BOOKING_CANCELLED_TABLE": CancelledBookingTable.tableName,
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "CREW_ASSIGNMENT_TABLE": CrewAssignmentTable.tableName,
+        "CREW_MAX_DUTY": '10h'
+      }
+    });
+    EmailSecret.grantRead(changedBusRoute);
+    UsersTable.grantReadData(changedBusRoute);
+    BookingTable.grantReadWriteData(changedBusRoute);
+    NotificationTable.grantReadWriteData(changedBusRoute);
+    CancelledBookingTable.grantReadWriteData(changedBusRoute);
+    BusRouteTable.grantReadData(changedBusRoute);
+    CrewAssignmentTable.grantReadWriteData(changedBusRoute);
+    changedBusRoute.applyRemovalPolicy(REMOVAL_POLICY);
+
+    // A rule in where to send the route change events of the
+    // deactivated or rescheduled bus routes.
+    new eventbridge.Rule(this, 'bus-ticketing-route-changed-rule', {
+      enabled: true,
+      eventBus: eventbus,
+      ruleName: 'bus-ticketing-route-changed-rule',
+      eventPattern: {
+        source: [ 'route:changed' ]
+      },
+      targets: [
+        new eventtarget.LambdaFunction(changedBusRoute, {
+          retryAttempts: 5
+        })
+      ]
+    });
+
+    const publishBusRouteChange = new lambda.Function(this, 'publishBusRouteChange', {
+      memorySize: 1024,
+      handler: 'bootstrap',
+      functionName: 'publishBusRouteChange',
+      timeout: cdk.Duration.seconds(60),
+      runtime: lambda.Runtime.PROVIDED_AL2,
+      code: lambda.Code.fromAsset('cmd/bus_route/publishBusRouteChange'),
+      description: 'A Lambda Function that will send the route changes that failed to be sent when the bus route was updated',
+      environment: {
+        "BUS_ROUTE_TABLE": BusRouteTable.tableName,
+        "EVENT_BUS": eventbus.eventBusName
+      }
+    });
+    BusRouteTable.grantReadWriteData(publishBusRouteChange);
+    eventbus.grantPutEventsTo(publishBusRouteChange);
+    publishBusRouteChange.applyRemovalPolicy(REMOVAL_POLICY);
+
+    // A schedule that sends the pending route changes of the bus routes,
+    // e.g. when the event bus was unavailable during the update.
+    new eventbridge.Rule(this, 'bus-ticketing-route-change-schedule-rule', {
+      enabled: true,
+      ruleName: 'bus-ticketing-route-change-schedule-rule',
+      schedule: eventbridge.Schedule.rate(cdk.Duration.minutes(5)),
+      targets: [
+        new eventtarget.LambdaFunction(publishBusRouteChange)
+      ]
+    });
+
     const getCancelledBooking = new lambda.Function(this, 'getCancelledBooking', {
       memorySize: 1024,
       handler: 'getCancelledBooking',
@@ -566,10 +1566,12 @@ export class BusTicketingStack extends cdk.Stack
       code: lambda.Code.fromAsset('cmd/bookings/getCancelledBooking'),
       description: 'A Lambda Function that will process API reqeusts and fetch the cancelled booking record(s)',
       environment: {
+        "BOOKING_TABLE": BookingTable.tableName,
         "BOOKING_CANCELLED_TABLE": CancelledBookingTable.tableName
       }
     });
     getCancelledBooking.applyRemovalPolicy(REMOVAL_POLICY);
+    BookingTable.grantReadData(getCancelledBooking);
     CancelledBookingTable.grantReadData(getCancelledBooking);
 
     // ******************** API Gateway ******************** //
@@ -593,6 +1595,21 @@ export class BusTicketingStack extends cdk.Stack
       }
     });
 
+    // Validates the "Authorization: Bearer" access token. The policy is not cached,
+    // so that a revoked session, a deactivated account or a changed user type is
+    // denied on its next request instead of when the cache expires.
+    const TokenAuthorizer = new apigw.TokenAuthorizer(this, 'BusTicketing_TokenAuthorizer', {
+      handler: authorizer,
+      authorizerName: 'BusTicketing_TokenAuthorizer',
+      resultsCacheTtl: cdk.Duration.seconds(0)
+    });
+
+    // The method options of the resources that require an access token.
+    const AuthorizedMethod: apigw.MethodOptions = {
+      authorizer: TokenAuthorizer,
+      authorizationType: apigw.AuthorizationType.CUSTOM
+    };
+
     const ApiParameterValidator = new apigw.RequestValidator(this, 'BusTicketing_ApiParameterValidator', {
       restApi: api,
       validateRequestParameters: true,
@@ -632,13 +1649,96 @@ export class BusTicketingStack extends cdk.Stack
       requestValidator: ApiRequestBodyValidator
     });
 
+    const loginMFAApiIntegration = new apigw.LambdaIntegration(loginMFA);
+    const loginMFAApi = loginUserApi.addResource('mfa');
+    loginMFAApi.addMethod('POST', loginMFAApiIntegration);
+
+    const refreshTokenApiIntegration = new apigw.LambdaIntegration(refreshToken);
+    const refreshTokenApi = UserApiRoot.addResource('token').addResource('refresh');
+    refreshTokenApi.addMethod('POST', refreshTokenApiIntegration);
+
+    const UserPasswordApiRoot = UserApiRoot.addResource('password');
+    UserPasswordApiRoot.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const forgotPasswordApiIntegration = new apigw.LambdaIntegration(forgotPassword);
+    const forgotPasswordApi = UserPasswordApiRoot.addResource('forgot');
+    forgotPasswordApi.addMethod('POST', forgotPasswordApiIntegration);
+
+    const resetPasswordApiIntegration = new apigw.LambdaIntegration(resetPassword);
+    const resetPasswordApi = UserPasswordApiRoot.addResource('reset');
+    resetPasswordApi.addMethod('POST', resetPasswordApiIntegration);
+
+    const UserEmailApiRoot = UserApiRoot.addResource('email');
+    UserEmailApiRoot.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const verifyEmailApiIntegration = new apigw.LambdaIntegration(verifyEmail);
+    const verifyEmailApi = UserEmailApiRoot.addResource('verify');
+    verifyEmailApi.addMethod('GET', verifyEmailApiIntegration, {
+      requestParameters: {
+        'method.request.querystring.token': true
+      },
+      requestValidator: ApiParameterValidator
+    });
+
+    const resendVerificationApiIntegration = new apigw.LambdaIntegration(resendVerification);
+    const resendVerificationApi = verifyEmailApi.addResource('resend');
+    resendVerificationApi.addMethod('POST', resendVerificationApiIntegration, AuthorizedMethod);
+
+    const UserMFAApiRoot = UserApiRoot.addResource('mfa');
+    UserMFAApiRoot.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const enrollMFAApiIntegration = new apigw.LambdaIntegration(enrollMFA);
+    const enrollMFAApi = UserMFAApiRoot.addResource('enroll');
+    enrollMFAApi.addMethod('POST', enrollMFAApiIntegration, AuthorizedMethod);
+
+    const confirmMFAApiIntegration = new apigw.LambdaIntegration(confirmMFA);
+    const confirmMFAApi = UserMFAApiRoot.addResource('confirm');
+    confirmMFAApi.addMethod('POST', confirmMFAApiIntegration, AuthorizedMethod);
+
+    const disableMFAApiIntegration = new apigw.LambdaIntegration(disableMFA);
+    const disableMFAApi = UserMFAApiRoot.addResource('disable');
+    disableMFAApi.addMethod('POST', disableMFAApiIntegration, AuthorizedMethod);
+
+    const regenerateRecoveryCodesApiIntegration = new apigw.LambdaIntegration(regenerateRecoveryCodes);
+    const regenerateRecoveryCodesApi = UserMFAApiRoot.addResource('recovery');
+    regenerateRecoveryCodesApi.addMethod('POST', regenerateRecoveryCodesApiIntegration, AuthorizedMethod);
+
     const getUserApiIntegration = new apigw.LambdaIntegration(getUser);
     const getUserApi = UserAccountApiRoot.addResource('get');
-    getUserApi.addMethod('GET', getUserApiIntegration);
+    getUserApi.addMethod('GET', getUserApiIntegration, AuthorizedMethod);
+
+    const unlockUserApiIntegration = new apigw.LambdaIntegration(unlockUser);
+    const unlockUserApi = UserAccountApiRoot.addResource('unlock');
+    unlockUserApi.addMethod('POST', unlockUserApiIntegration, AuthorizedMethod);
+
+    const exportUserApiIntegration = new apigw.LambdaIntegration(exportUser);
+    const exportUserApi = UserAccountApiRoot.addResource('export');
+    exportUserApi.addMethod('GET', exportUserApiIntegration, AuthorizedMethod);
+
+    const eraseUserApiIntegration = new apigw.LambdaIntegration(eraseUser);
+    const eraseUserApi = UserAccountApiRoot.addResource('erase');
+    eraseUserApi.addMethod('POST', eraseUserApiIntegration, AuthorizedMethod);
+
+    const filterUserApiIntegration = new apigw.LambdaIntegration(filterUser);
+    const filterUserApi = UserAccountApiRoot.addResource('search');
+    filterUserApi.addMethod('GET', filterUserApiIntegration, AuthorizedMethod);
+
+    const updateUserStatusApiIntegration = new apigw.LambdaIntegration(updateUserStatus);
+    const updateUserStatusApi = UserAccountApiRoot.addResource('status');
+    updateUserStatusApi.addMethod('POST', updateUserStatusApiIntegration, AuthorizedMethod);
+
+    const changeUserTypeApiIntegration = new apigw.LambdaIntegration(changeUserType);
+    const changeUserTypeApi = UserAccountApiRoot.addResource('type');
+    changeUserTypeApi.addMethod('POST', changeUserTypeApiIntegration, AuthorizedMethod);
+
+    const getUserAuditApiIntegration = new apigw.LambdaIntegration(getUserAudit);
+    const getUserAuditApi = UserAccountApiRoot.addResource('audit');
+    getUserAuditApi.addMethod('GET', getUserAuditApiIntegration, AuthorizedMethod);
 
     const updateUserApiIntegration = new apigw.LambdaIntegration(updateUser);
     const updateUserApi = UserAccountApiRoot.addResource('update');
     updateUserApi.addMethod('POST', updateUserApiIntegration, {
+      ...AuthorizedMethod,
       requestParameters: {
         'method.request.querystring.id': true,
         'method.request.querystring.username': true
@@ -654,6 +1754,7 @@ export class BusTicketingStack extends cdk.Stack
     const createBusApiIntegration = new apigw.LambdaIntegration(createBus);
     const createBusApi = BusApiRoot.addResource('create');
     createBusApi.addMethod('POST', createBusApiIntegration, {
+      ...AuthorizedMethod,
       requestModels: {
         'application/json': BusLineModel
       },
@@ -662,11 +1763,12 @@ export class BusTicketingStack extends cdk.Stack
 
     const getBusApiIntegration = new apigw.LambdaIntegration(getBus);
     const getBusApi = BusApiRoot.addResource('get');
-    getBusApi.addMethod('GET', getBusApiIntegration);
+    getBusApi.addMethod('GET', getBusApiIntegration, AuthorizedMethod);
 
     const updateBusApiIntegration = new apigw.LambdaIntegration(updateBus);
     const updateBusApi = BusApiRoot.addResource('update');
     updateBusApi.addMethod('POST', updateBusApiIntegration, {
+      ...AuthorizedMethod,
       requestParameters: {
         'method.request.querystring.id': true,
         'method.request.querystring.name': true
@@ -677,6 +1779,7 @@ export class BusTicketingStack extends cdk.Stack
     const filterBusApiIntegration = new apigw.LambdaIntegration(filterBus);
     const filterBusApi = BusApiRoot.addResource('search');
     filterBusApi.addMethod('GET', filterBusApiIntegration, {
+      ...AuthorizedMethod,
       requestParameters: {
         'method.request.querystring.name': true,
         'method.request.querystring.company': true
@@ -691,6 +1794,7 @@ export class BusTicketingStack extends cdk.Stack
     const createBusUnitApiIntegration = new apigw.LambdaIntegration(createBusUnit);
     const createBusUnitApi = BusUnitApiRoot.addResource('create');
     createBusUnitApi.addMethod('POST', createBusUnitApiIntegration, {
+      ...AuthorizedMethod,
       requestModels: {
         'application/json': BusUnitModel
       },
@@ -699,11 +1803,12 @@ export class BusTicketingStack extends cdk.Stack
 
     const getBusUnitApiIntegration = new apigw.LambdaIntegration(getBusUnit);
     const getBusUnitApi = BusUnitApiRoot.addResource('get');
-    getBusUnitApi.addMethod('GET', getBusUnitApiIntegration);
+    getBusUnitApi.addMethod('GET', getBusUnitApiIntegration, AuthorizedMethod);
 
     const updateBusUnitApiIntegration = new apigw.LambdaIntegration(updateBusUnit);
     const updateBusUnitApi = BusUnitApiRoot.addResource('update');
     updateBusUnitApi.addMethod('POST', updateBusUnitApiIntegration, {
+      ...AuthorizedMethod,
       requestParameters: {
         'method.request.querystring.code': true,
         'method.request.querystring.bus_id': true
@@ -714,6 +1819,53 @@ export class BusTicketingStack extends cdk.Stack
     const filterBusUnitApiIntegration = new apigw.LambdaIntegration(filterBusUnit);
     const filterBusUnitApi = BusUnitApiRoot.addResource('search');
     filterBusUnitApi.addMethod('GET', filterBusUnitApiIntegration, {
+      ...AuthorizedMethod,
+      requestParameters: {
+        'method.request.querystring.bus_id': true,
+        'method.request.querystring.amenities': false
+      },
+      requestValidator: ApiParameterValidator
+    });
+
+    const BusUnitMaintenanceApiRoot = BusUnitApiRoot.addResource('maintenance');
+    const createBusUnitMaintenanceApiIntegration = new apigw.LambdaIntegration(createBusUnitMaintenance);
+    const createBusUnitMaintenanceApi = BusUnitMaintenanceApiRoot.addResource('create');
+    createBusUnitMaintenanceApi.addMethod('POST', createBusUnitMaintenanceApiIntegration, AuthorizedMethod);
+
+    const getBusUnitMaintenanceApiIntegration = new apigw.LambdaIntegration(getBusUnitMaintenance);
+    const getBusUnitMaintenanceApi = BusUnitMaintenanceApiRoot.addResource('get');
+    getBusUnitMaintenanceApi.addMethod('GET', getBusUnitMaintenanceApiIntegration, {
+      ...AuthorizedMethod,
+      requestParameters: {
+        'method.request.querystring.bus_id': true
+      },
+      requestValidator: ApiParameterValidator
+    });
+
+    const deleteBusUnitMaintenanceApiIntegration = new apigw.LambdaIntegration(deleteBusUnitMaintenance);
+    const deleteBusUnitMaintenanceApi = BusUnitMaintenanceApiRoot.addResource('delete');
+    deleteBusUnitMaintenanceApi.addMethod('POST', deleteBusUnitMaintenanceApiIntegration, {
+      ...AuthorizedMethod,
+      requestParameters: {
+        'method.request.querystring.bus_id': true,
+        'method.request.querystring.id': true
+      },
+      requestValidator: ApiParameterValidator
+    });
+
+    const getMaintenanceConflictsApiIntegration = new apigw.LambdaIntegration(getMaintenanceConflicts);
+    const getMaintenanceConflictsApi = BusUnitMaintenanceApiRoot.addResource('conflicts');
+    getMaintenanceConflictsApi.addMethod('GET', getMaintenanceConflictsApiIntegration, AuthorizedMethod);
+
+    const SeatLayoutApiRoot = BusUnitApiRoot.addResource('layout');
+    const createSeatLayoutApiIntegration = new apigw.LambdaIntegration(createSeatLayout);
+    const createSeatLayoutApi = SeatLayoutApiRoot.addResource('create');
+    createSeatLayoutApi.addMethod('POST', createSeatLayoutApiIntegration, AuthorizedMethod);
+
+    const getSeatLayoutApiIntegration = new apigw.LambdaIntegration(getSeatLayout);
+    const getSeatLayoutApi = SeatLayoutApiRoot.addResource('get');
+    getSeatLayoutApi.addMethod('GET', getSeatLayoutApiIntegration, {
+      ...AuthorizedMethod,
       requestParameters: {
         'method.request.querystring.bus_id': true
       },
@@ -728,6 +1880,7 @@ export class BusTicketingStack extends cdk.Stack
     const createBusRouteApiIntegration = new apigw.LambdaIntegration(createBusRoute);
     const createBusRouteApi = BusRouteApiRoot.addResource('create');
     createBusRouteApi.addMethod('POST', createBusRouteApiIntegration, {
+      ...AuthorizedMethod,
       requestModels: {
         'application/json': BusRouteModel
       },
@@ -742,7 +1895,10 @@ export class BusTicketingStack extends cdk.Stack
     const filterBusRouteApi = BusRouteApiRoot.addResource('search');
     filterBusRouteApi.addMethod('GET', filterBusRouteApiIntegration, {
       requestParameters: {
-        'method.request.querystring.bus_id': true
+        'method.request.querystring.bus_id': false,
+        'method.request.querystring.from_route': false,
+        'method.request.querystring.to_route': false,
+        'method.request.querystring.amenities': false
       },
       requestValidator: ApiParameterValidator
     });
@@ -750,13 +1906,81 @@ export class BusTicketingStack extends cdk.Stack
     const updateBusRouteApiIntegration = new apigw.LambdaIntegration(updateBusRoute);
     const updateBusRouteApi = BusRouteApiRoot.addResource('update');
     updateBusRouteApi.addMethod('POST', updateBusRouteApiIntegration, {
+      ...AuthorizedMethod,
       requestParameters: {
         'method.request.querystring.id': true,
-        'method.request.querystring.bus_id': true
+        'method.request.querystring.bus_id': true,
+        'method.request.querystring.booking_action': false,
+        'method.request.querystring.reason': false
+      },
+      requestValidator: ApiParameterValidator
+    });
+
+    const getTripManifestApiIntegration = new apigw.LambdaIntegration(getTripManifest);
+    const getTripManifestApi = BusRouteApiRoot.addResource('manifest');
+    getTripManifestApi.addMethod('GET', getTripManifestApiIntegration, {
+      ...AuthorizedMethod,
+      requestParameters: {
+        'method.request.querystring.id': true,
+        'method.request.querystring.bus_id': true,
+        'method.request.querystring.travel_date': true
+      },
+      requestValidator: ApiParameterValidator
+    });
+
+    const getSeatAvailabilityApiIntegration = new apigw.LambdaIntegration(getSeatAvailability);
+    const getSeatAvailabilityApi = BusRouteApiRoot.addResource('availability');
+    getSeatAvailabilityApi.addMethod('GET', getSeatAvailabilityApiIntegration, {
+      requestParameters: {
+        'method.request.querystring.id': true,
+        'method.request.querystring.bus_id': true,
+        'method.request.querystring.travel_date': true
+      },
+      requestValidator: ApiParameterValidator
+    });
+
+    // ***** Crew API Specification ***** //
+    const CrewApiRoot = api.root.addResource('crew');
+    CrewApiRoot.applyRemovalPolicy(REMOVAL_POLICY);
+
+    const createCrewApiIntegration = new apigw.LambdaIntegration(createCrew);
+    const createCrewApi = CrewApiRoot.addResource('create');
+    createCrewApi.addMethod('POST', createCrewApiIntegration, AuthorizedMethod);
+
+    const getCrewApiIntegration = new apigw.LambdaIntegration(getCrew);
+    const getCrewApi = CrewApiRoot.addResource('get');
+    getCrewApi.addMethod('GET', getCrewApiIntegration, AuthorizedMethod);
+
+    const updateCrewApiIntegration = new apigw.LambdaIntegration(updateCrew);
+    const updateCrewApi = CrewApiRoot.addResource('update');
+    updateCrewApi.addMethod('POST', updateCrewApiIntegration, {
+      ...AuthorizedMethod,
+      requestParameters: {
+        'method.request.querystring.bus_id': true,
+        'method.request.querystring.id': true
+      },
+      requestValidator: ApiParameterValidator
+    });
+
+    const assignCrewApiIntegration = new apigw.LambdaIntegration(assignCrew);
+    const assignCrewApi = CrewApiRoot.addResource('assign');
+    assignCrewApi.addMethod('POST', assignCrewApiIntegration, AuthorizedMethod);
+
+    const unassignCrewApiIntegration = new apigw.LambdaIntegration(unassignCrew);
+    const unassignCrewApi = CrewApiRoot.addResource('unassign');
+    unassignCrewApi.addMethod('POST', unassignCrewApiIntegration, {
+      ...AuthorizedMethod,
+      requestParameters: {
+        'method.request.querystring.crew_id': true,
+        'method.request.querystring.id': true
       },
       requestValidator: ApiParameterValidator
     });
 
+    const getCrewAssignmentApiIntegration = new apigw.LambdaIntegration(getCrewAssignment);
+    const getCrewAssignmentApi = CrewApiRoot.addResource('assignment');
+    getCrewAssignmentApi.addMethod('GET', getCrewAssignmentApiIntegration, AuthorizedMethod);
+
     // ***** Booking API Specification ***** //
     const BookingApiRoot = api.root.addResource('bookings');
     BookingApiRoot.applyRemovalPolicy(REMOVAL_POLICY);
@@ -765,6 +1989,7 @@ export class BusTicketingStack extends cdk.Stack
     const createBookingApiIntegration = new apigw.LambdaIntegration(createBooking);
     const createBookingApi = BookingApiRoot.addResource('create');
     createBookingApi.addMethod('POST', createBookingApiIntegration, {
+      ...AuthorizedMethod,
       requestModels: {
         'application/json': BookingModel
       },
@@ -773,11 +1998,12 @@ export class BusTicketingStack extends cdk.Stack
 
     const getBookingApiIntegration = new apigw.LambdaIntegration(getBooking);
     const getBookingApi = BookingApiRoot.addResource('get');
-    getBookingApi.addMethod('GET', getBookingApiIntegration);
+    getBookingApi.addMethod('GET', getBookingApiIntegration, AuthorizedMethod);
     
     const filterBookingApiIntegration = new apigw.LambdaIntegration(filterBooking);
     const filterBookingApi = BookingApiRoot.addResource('search');
     filterBookingApi.addMethod('GET', filterBookingApiIntegration, {
+      ...AuthorizedMethod,
       requestParameters: {
         'method.request.querystring.status': true
       },
@@ -786,12 +2012,13 @@ export class BusTicketingStack extends cdk.Stack
 
     const getCancelledBookingApiIntegration = new apigw.LambdaIntegration(getCancelledBooking);
     const getCancelledBookingApi = BookingApiRoot.addResource('cancelled').addResource('get');
-    getCancelledBookingApi.addMethod('GET', getCancelledBookingApiIntegration);
+    getCancelledBookingApi.addMethod('GET', getCancelledBookingApiIntegration, AuthorizedMethod);
 
     const updateBookingApi = BookingApiRoot.addResource('update');
     const updateBookingStatusApiIntegration = new apigw.LambdaIntegration(updateBookingStatus);
     const updateBookingStatusApi = updateBookingApi.addResource('status');
     updateBookingStatusApi.addMethod('POST', updateBookingStatusApiIntegration, {
+      ...AuthorizedMethod,
       requestParameters: {
         'method.request.querystring.id': true,
         'method.request.querystring.bus_route_id': true
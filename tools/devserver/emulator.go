@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	awswrapper "github.com/rmarasigan/bus-ticketing/internal/aws_wrapper"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+)
+
+const (
+	ACCOUNT_ID   = "000000000000" // The account ID of the local resources
+	QUEUE_BUFFER = 100            // The number of messages that a queue holds before SendMessage blocks
+)
+
+// emulator receives the SQS SendMessage and EventBridge PutEvents requests of the
+// Lambda Functions, and invokes the queue consumers and rule targets locally, as well
+// as the schedule targets. It also serves the Secrets Manager GetSecretValue requests
+// of the local secrets.
+//
+// The Lambda Functions are pointed to the emulator with the AWS_ENDPOINT_URL_SQS,
+// AWS_ENDPOINT_URL_EVENTBRIDGE and AWS_ENDPOINT_URL_SECRETS_MANAGER environment
+// variables.
+type emulator struct {
+	functions map[string]*function
+	queues    map[string]chan events.SQSMessage
+	secrets   map[string]string // The secret strings by the secret ID
+}
+
+// newEmulator creates the queue of every queue consumer and starts to consume
+// the messages. The messages of a queue are processed one at a time, in the
+// order that they were sent.
+func newEmulator(functions map[string]*function, secrets map[string]string) *emulator {
+	em := &emulator{functions: functions, queues: make(map[string]chan events.SQSMessage), secrets: secrets}
+
+	for queue, name := range queueConsumers {
+		messages := make(chan events.SQSMessage, QUEUE_BUFFER)
+		em.queues[queue] = messages
+
+		go em.consume(functions[name], messages)
+	}
+
+	return em
+}
+
+func (em *emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Header.Get("X-Amz-Target") {
+	case "AWSEvents.PutEvents":
+		em.putEvents(w, r)
+		return
+
+	case "secretsmanager.GetSecretValue":
+		em.getSecretValue(w, r)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil || r.PostForm.Get("Action") != "SendMessage" {
+		queryError(w, http.StatusBadRequest, "InvalidAction", "the action is not supported by the local emulator")
+		return
+	}
+
+	em.sendMessage(w, r)
+}
+
+// ********************************************************************* //
+// ******************************** SQS ******************************** //
+// ********************************************************************* //
+
+type sendMessageResponse struct {
+	XMLName          xml.Name `xml:"SendMessageResponse"`
+	MessageId        string   `xml:"SendMessageResult>MessageId"`
+	MD5OfMessageBody string   `xml:"SendMessageResult>MD5OfMessageBody"`
+	RequestId        string   `xml:"ResponseMetadata>RequestId"`
+}
+
+type errorResponse struct {
+	XMLName   xml.Name `xml:"ErrorResponse"`
+	Type      string   `xml:"Error>Type"`
+	Code      string   `xml:"Error>Code"`
+	Message   string   `xml:"Error>Message"`
+	RequestId string   `xml:"RequestId"`
+}
+
+// sendMessage adds the message to the queue of the queue URL.
+func (em *emulator) sendMessage(w http.ResponseWriter, r *http.Request) {
+	var (
+		form  = r.PostForm
+		body  = form.Get("MessageBody")
+		queue = path.Base(form.Get("QueueUrl"))
+	)
+
+	messages, ok := em.queues[queue]
+	if !ok {
+		queryError(w, http.StatusBadRequest, "AWS.SimpleQueueService.NonExistentQueue", "the specified queue does not exist")
+		return
+	}
+
+	hash := md5.Sum([]byte(body))
+	message := events.SQSMessage{
+		MessageId:      uuid.NewString(),
+		ReceiptHandle:  uuid.NewString(),
+		Body:           body,
+		Md5OfBody:      hex.EncodeToString(hash[:]),
+		EventSource:    "aws:sqs",
+		EventSourceARN: "arn:aws:sqs:" + region() + ":" + ACCOUNT_ID + ":" + queue,
+		AWSRegion:      region(),
+		Attributes: map[string]string{
+			"MessageGroupId":         form.Get("MessageGroupId"),
+			"MessageDeduplicationId": form.Get("MessageDeduplicationId"),
+			"SentTimestamp":          strconv.FormatInt(time.Now().UnixMilli(), 10),
+		},
+	}
+
+	messages <- message
+
+	writeXML(w, http.StatusOK, sendMessageResponse{
+		MessageId:        message.MessageId,
+		MD5OfMessageBody: message.Md5OfBody,
+		RequestId:        uuid.NewString(),
+	})
+}
+
+// consume invokes the queue consumer with every message of the queue. A message
+// that failed is received again until MAX_RECEIVE, then it is dropped as it would
+// be moved to the dead-letter queue.
+func (em *emulator) consume(fn *function, messages chan events.SQSMessage) {
+	for message := range messages {
+		for receive := 1; receive <= MAX_RECEIVE; receive++ {
+			message.Attributes["ApproximateReceiveCount"] = strconv.Itoa(receive)
+
+			_, err := fn.invoke(events.SQSEvent{Records: []events.SQSMessage{message}})
+			if err == nil {
+				break
+			}
+
+			trail.Error("message %s failed on receive %d of %d: %v", message.MessageId, receive, MAX_RECEIVE, err)
+			if receive == MAX_RECEIVE {
+				trail.Error("message %s is moved to the dead-letter queue: %s", message.MessageId, message.Body)
+			}
+		}
+	}
+}
+
+// queryError writes the error response of the SQS query protocol.
+func queryError(w http.ResponseWriter, status int, code, message string) {
+	writeXML(w, status, errorResponse{Type: "Sender", Code: code, Message: message, RequestId: uuid.NewString()})
+}
+
+// writeXML writes the XML-encoded response with the status code.
+func writeXML(w http.ResponseWriter, status int, response interface{}) {
+	data, err := xml.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// ********************************************************************* //
+// **************************** EventBridge **************************** //
+// ********************************************************************* //
+
+type putEventsRequest struct {
+	Entries []struct {
+		Source       string `json:"Source"`
+		DetailType   string `json:"DetailType"`
+		Detail       string `json:"Detail"`
+		EventBusName string `json:"EventBusName"`
+	} `json:"Entries"`
+}
+
+type putEventsResultEntry struct {
+	EventId      string `json:"EventId,omitempty"`
+	ErrorCode    string `json:"ErrorCode,omitempty"`
+	ErrorMessage string `json:"ErrorMessage,omitempty"`
+}
+
+type putEventsResponse struct {
+	Entries          []putEventsResultEntry `json:"Entries"`
+	FailedEntryCount int                    `json:"FailedEntryCount"`
+}
+
+// putEvents sends every event to the target of the rule that matches the event
+// source. The events without a matching rule are accepted and discarded.
+func (em *emulator) putEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		request  putEventsRequest
+		response putEventsResponse
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"__type":"ValidationException","message":%q}`, err.Error())
+
+		return
+	}
+
+	for _, entry := range request.Entries {
+		if !json.Valid([]byte(entry.Detail)) {
+			response.FailedEntryCount++
+			response.Entries = append(response.Entries, putEventsResultEntry{
+				ErrorCode:    "MalformedDetail",
+				ErrorMessage: "Detail is malformed.",
+			})
+
+			continue
+		}
+
+		event := events.CloudWatchEvent{
+			Version:    "0",
+			ID:         uuid.NewString(),
+			DetailType: entry.DetailType,
+			Source:     entry.Source,
+			AccountID:  ACCOUNT_ID,
+			Time:       time.Now().UTC(),
+			Region:     region(),
+			Resources:  []string{},
+			Detail:     json.RawMessage(entry.Detail),
+		}
+		response.Entries = append(response.Entries, putEventsResultEntry{EventId: event.ID})
+
+		if name, ok := ruleTargets[entry.Source]; ok {
+			go em.target(em.functions[name], event)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// target invokes the rule target with the event, a failed event is retried
+// until MAX_RULE_RETRIES.
+func (em *emulator) target(fn *function, event events.CloudWatchEvent) {
+	for attempt := 0; attempt <= MAX_RULE_RETRIES; attempt++ {
+		_, err := fn.invoke(event)
+		if err == nil {
+			return
+		}
+
+		trail.Error("event %s of %s failed on attempt %d: %v", event.ID, event.Source, attempt+1, err)
+	}
+
+	trail.Error("event %s of %s is dropped after %d retries", event.ID, event.Source, MAX_RULE_RETRIES)
+}
+
+// schedule invokes every schedule target at the rate of its rule with a scheduled
+// event, until the context is done. The first event is sent after the first rate.
+func (em *emulator) schedule(ctx context.Context) {
+	for name, rate := range scheduleTargets {
+		go func(fn *function, rate time.Duration) {
+			ticker := time.NewTicker(rate)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+
+				case now := <-ticker.C:
+					em.target(fn, events.CloudWatchEvent{
+						Version:    "0",
+						ID:         uuid.NewString(),
+						DetailType: "Scheduled Event",
+						Source:     "aws.events",
+						AccountID:  ACCOUNT_ID,
+						Time:       now.UTC(),
+						Region:     region(),
+						Resources:  []string{},
+						Detail:     json.RawMessage("{}"),
+					})
+				}
+			}
+		}(em.functions[name], rate)
+	}
+}
+
+// ********************************************************************* //
+// ************************** Secrets Manager ************************** //
+// ********************************************************************* //
+
+// getSecretValue returns the secret string of the local secret.
+func (em *emulator) getSecretValue(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		SecretId string `json:"SecretId"`
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	secret, ok := em.secrets[request.SecretId]
+	if err != nil || !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`)
+
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"ARN":          "arn:aws:secretsmanager:" + region() + ":" + ACCOUNT_ID + ":secret:" + request.SecretId,
+		"Name":         request.SecretId,
+		"SecretString": secret,
+		"VersionId":    ACCOUNT_ID,
+	})
+}
+
+// region returns the region of the AWS_REGION environment variable, or the
+// default region of the AWS clients.
+func region() string {
+	if region := strings.TrimSpace(os.Getenv("AWS_REGION")); region != "" {
+		return region
+	}
+
+	return awswrapper.AWS_REGION
+}
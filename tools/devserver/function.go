@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda/messages"
+	"github.com/google/uuid"
+)
+
+const (
+	RPC_DIAL_RETRIES = 50                     // The number of attempts to connect to the function process
+	RPC_DIAL_DELAY   = 100 * time.Millisecond // The delay between the attempts to connect
+)
+
+// function is a Lambda Function that runs as a separate local process, its handler
+// does not run in the process of the devserver. The aws-lambda-go runtime serves the
+// handler over net/rpc when the _LAMBDA_SERVER_PORT environment variable is set, so
+// the cmd packages are used as they are and a debugger is attached to the process of
+// the Lambda Function.
+type function struct {
+	name    string
+	timeout time.Duration
+	cmd     *exec.Cmd
+	client  *rpc.Client
+}
+
+// buildFunctions compiles every main package of the cmd directory into the
+// directory, the binaries are named after their package directory.
+func buildFunctions(ctx context.Context, dir string) error {
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", dir+string(filepath.Separator), "./cmd/...")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// startFunction starts the binary of the Lambda Function with the environment
+// variables, and connects to its RPC server.
+func startFunction(dir, name string, env []string) (*function, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+
+	fn := &function{name: name, timeout: DEFAULT_TIMEOUT}
+	if timeout, ok := timeouts[name]; ok {
+		fn.timeout = timeout
+	}
+
+	fn.cmd = exec.Command(filepath.Join(dir, name))
+	fn.cmd.Env = append(env, "_LAMBDA_SERVER_PORT="+port, "AWS_LAMBDA_FUNCTION_NAME="+name)
+	fn.cmd.Stdout = os.Stdout
+	fn.cmd.Stderr = os.Stderr
+
+	err = fn.cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+
+	for retry := 0; retry < RPC_DIAL_RETRIES; retry++ {
+		fn.client, err = rpc.Dial("tcp", "localhost:"+port)
+		if err == nil {
+			return fn, nil
+		}
+
+		time.Sleep(RPC_DIAL_DELAY)
+	}
+
+	fn.stop()
+	return nil, fmt.Errorf("failed to connect to %s: %w", name, err)
+}
+
+// invoke sends the event to the handler of the Lambda Function and returns the
+// JSON-encoded result of the handler.
+func (fn *function) invoke(event interface{}) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		deadline = time.Now().Add(fn.timeout)
+		response messages.InvokeResponse
+		request  = &messages.InvokeRequest{
+			Payload:            payload,
+			RequestId:          uuid.NewString(),
+			InvokedFunctionArn: "arn:aws:lambda:" + region() + ":" + ACCOUNT_ID + ":function:" + fn.name,
+			Deadline: messages.InvokeRequest_Timestamp{
+				Seconds: deadline.Unix(),
+				Nanos:   int64(deadline.Nanosecond()),
+			},
+		}
+	)
+
+	err = fn.client.Call("Function.Invoke", request, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke %s: %w", fn.name, err)
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("%s returned %s: %s", fn.name, response.Error.Type, response.Error.Message)
+	}
+
+	return response.Payload, nil
+}
+
+// stop closes the RPC connection and kills the process of the Lambda Function.
+func (fn *function) stop() {
+	if fn.client != nil {
+		fn.client.Close()
+	}
+
+	if fn.cmd.Process != nil {
+		_ = fn.cmd.Process.Kill()
+		_ = fn.cmd.Wait()
+	}
+}
+
+// freePort returns a TCP port on localhost that is not in use.
+func freePort() (string, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port), nil
+}
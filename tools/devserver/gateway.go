@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"github.com/rmarasigan/bus-ticketing/api"
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+// gateway adapts the HTTP requests to the API Gateway proxy integration requests
+// of the Lambda Functions, and writes back their responses.
+type gateway struct {
+	functions map[string]*function
+}
+
+func (gw *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var start = time.Now()
+
+	// The path may or may not have the stage, like the invoke URL of the stage
+	resource := r.URL.Path
+	if strings.HasPrefix(resource, "/"+STAGE+"/") {
+		resource = strings.TrimPrefix(resource, "/"+STAGE)
+	}
+
+	route, ok := findRoute(r.Method, resource)
+	if !ok {
+		writeResponse(w, api.Response(http.StatusForbidden, api.Message{Custom: "Missing Authentication Token"}))
+		return
+	}
+
+	var (
+		query   = r.URL.Query()
+		missing []string
+	)
+
+	for _, parameter := range route.required {
+		if query.Get(parameter) == "" {
+			missing = append(missing, parameter)
+		}
+	}
+
+	if len(missing) > 0 {
+		writeResponse(w, api.Response(http.StatusBadRequest, api.Message{
+			Custom: "Missing required request parameters: [" + strings.Join(missing, ", ") + "]",
+		}))
+
+		return
+	}
+
+	request, err := proxyRequest(r, route)
+	if err != nil {
+		writeResponse(w, api.Response(http.StatusBadRequest, api.Message{Custom: "failed to read the request body"}))
+		return
+	}
+
+	if route.authorized {
+		status, message := gw.authorize(r, &request)
+		if status != http.StatusOK {
+			writeResponse(w, api.Response(status, api.Message{Custom: message}))
+			trail.Info("%s %s %d %s", r.Method, r.URL.RequestURI(), status, time.Since(start).Round(time.Millisecond))
+
+			return
+		}
+	}
+
+	var response events.APIGatewayProxyResponse
+
+	payload, err := gw.functions[route.function].invoke(request)
+	if err == nil {
+		err = utility.ParseJSON(payload, &response)
+	}
+
+	if err == nil && response.StatusCode == 0 {
+		err = errors.New("the response has no status code")
+	}
+
+	if err != nil {
+		trail.Error("%s %s: %v", r.Method, r.URL.Path, err)
+		writeResponse(w, api.Response(http.StatusBadGateway, api.Message{Custom: "Internal server error"}))
+
+		return
+	}
+
+	writeResponse(w, &response)
+	trail.Info("%s %s %d %s", r.Method, r.URL.RequestURI(), response.StatusCode, time.Since(start).Round(time.Millisecond))
+}
+
+// authorize invokes the token authorizer with the "Authorization" header, and sets the
+// context of the authorizer response on the request context. It returns the status
+// code and message that the API Gateway responds with if the request is not allowed.
+func (gw *gateway) authorize(r *http.Request, request *events.APIGatewayProxyRequest) (int, string) {
+	var token = r.Header.Get("Authorization")
+	if token == "" {
+		return http.StatusUnauthorized, "Unauthorized"
+	}
+
+	payload, err := gw.functions[AUTHORIZER].invoke(events.APIGatewayCustomAuthorizerRequest{
+		Type:               "TOKEN",
+		AuthorizationToken: token,
+		MethodArn:          "arn:aws:execute-api:" + region() + ":" + ACCOUNT_ID + ":local/" + STAGE + "/" + r.Method + request.Resource,
+	})
+
+	// The API Gateway responds with a 401 Unauthorized if the authorizer
+	// fails with the "Unauthorized" error message.
+	if err != nil {
+		if strings.HasSuffix(err.Error(), ": Unauthorized") {
+			return http.StatusUnauthorized, "Unauthorized"
+		}
+
+		trail.Error("%s: %v", AUTHORIZER, err)
+		return http.StatusInternalServerError, "Internal server error"
+	}
+
+	var response events.APIGatewayCustomAuthorizerResponse
+
+	err = utility.ParseJSON(payload, &response)
+	if err != nil {
+		return http.StatusInternalServerError, "Internal server error"
+	}
+
+	for _, statement := range response.PolicyDocument.Statement {
+		if statement.Effect != "Allow" {
+			return http.StatusForbidden, "User is not authorized to access this resource"
+		}
+	}
+
+	if len(response.PolicyDocument.Statement) == 0 {
+		return http.StatusForbidden, "User is not authorized to access this resource"
+	}
+
+	request.RequestContext.Authorizer = map[string]interface{}{"principalId": response.PrincipalID}
+	for key, value := range response.Context {
+		request.RequestContext.Authorizer[key] = value
+	}
+
+	return http.StatusOK, ""
+}
+
+// findRoute returns the route of the method and resource path.
+func findRoute(method, resource string) (route, bool) {
+	resource = strings.TrimSuffix(resource, "/")
+
+	for _, route := range routes {
+		if route.method == method && route.path == resource {
+			return route, true
+		}
+	}
+
+	return route{}, false
+}
+
+// proxyRequest converts the HTTP request into the API Gateway proxy request of the route.
+// Like the API Gateway, the single value headers and query string parameters have the
+// last value, and a body that is not valid UTF-8 is base64-encoded.
+func proxyRequest(r *http.Request, route route) (events.APIGatewayProxyRequest, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	var (
+		body            = string(data)
+		isBase64Encoded bool
+		headers         = make(map[string]string)
+		parameters      = make(map[string]string)
+		query           = r.URL.Query()
+	)
+
+	if !utf8.Valid(data) {
+		body = base64.StdEncoding.EncodeToString(data)
+		isBase64Encoded = true
+	}
+
+	for key, values := range r.Header {
+		headers[key] = values[len(values)-1]
+	}
+
+	for key, values := range query {
+		parameters[key] = values[len(values)-1]
+	}
+
+	sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		sourceIP = r.RemoteAddr
+	}
+
+	return events.APIGatewayProxyRequest{
+		Resource:                        route.path,
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         headers,
+		MultiValueHeaders:               r.Header,
+		QueryStringParameters:           parameters,
+		MultiValueQueryStringParameters: query,
+		Body:                            body,
+		IsBase64Encoded:                 isBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:    ACCOUNT_ID,
+			DomainName:   r.Host,
+			Stage:        STAGE,
+			RequestID:    uuid.NewString(),
+			Protocol:     r.Proto,
+			ResourcePath: route.path,
+			Path:         r.URL.Path,
+			HTTPMethod:   r.Method,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+			RequestTimeEpoch: time.Now().UnixMilli(),
+		},
+	}, nil
+}
+
+// writeResponse writes the API Gateway proxy response of the Lambda Function.
+func writeResponse(w http.ResponseWriter, response *events.APIGatewayProxyResponse) {
+	for key, value := range response.Headers {
+		w.Header().Set(key, value)
+	}
+
+	for key, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	var body = []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err == nil {
+			body = decoded
+		}
+	}
+
+	w.WriteHeader(response.StatusCode)
+	_, _ = w.Write(body)
+}
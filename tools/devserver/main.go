@@ -0,0 +1,178 @@
+// The devserver runs the API locally. It serves the API Gateway resource methods of
+// lib/stacks/bus-ticketing-stack.ts over HTTP, runs the SQS and EventBridge consumers
+// of the stack when the Lambda Functions send a message or an event, and runs the
+// targets of the EventBridge schedules at the rate of their rule.
+//
+// Every Lambda Function of the cmd directory is compiled and started as a separate
+// process, not in the process of the devserver, and is invoked over the net/rpc server
+// of the aws-lambda-go runtime the same way as the Lambda service. The environment of
+// the devserver is passed to them. DynamoDB is not
+// emulated, use the AWS_ENDPOINT_URL_DYNAMODB environment variable to point to a
+// local DynamoDB. Secrets Manager is only emulated for the token secret if neither
+// the TOKEN_SECRET nor the AWS_ENDPOINT_URL_SECRETS_MANAGER variable is set.
+//
+// Usage (from the root of the project):
+//  go run ./tools/devserver -addr localhost:3000 -aws-addr localhost:4566
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rmarasigan/bus-ticketing/internal/trail"
+	"github.com/rmarasigan/bus-ticketing/internal/utility"
+)
+
+func main() {
+	var (
+		addr    = flag.String("addr", "localhost:3000", "the address of the API")
+		awsAddr = flag.String("aws-addr", "localhost:4566", "the address of the SQS and EventBridge emulator")
+	)
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, *addr, *awsAddr)
+	if err != nil {
+		trail.Error(err)
+		os.Exit(1)
+	}
+}
+
+// run compiles and starts the Lambda Functions, and serves the API and the emulator
+// until the context is done.
+func run(ctx context.Context, addr, awsAddr string) error {
+	dir, err := os.MkdirTemp("", "bus-ticketing-devserver")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	trail.Info("compiling the Lambda Functions")
+	err = buildFunctions(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("failed to compile the Lambda Functions: %w", err)
+	}
+
+	secrets, err := localSecrets()
+	if err != nil {
+		return err
+	}
+
+	var (
+		env       = environment("http://"+addr, "http://"+awsAddr, secrets)
+		functions = make(map[string]*function)
+	)
+
+	defer func() {
+		for _, fn := range functions {
+			fn.stop()
+		}
+	}()
+
+	for _, name := range functionNames() {
+		fn, err := startFunction(dir, name, env)
+		if err != nil {
+			return err
+		}
+
+		functions[name] = fn
+	}
+
+	var (
+		em        = newEmulator(functions, secrets)
+		apiServer = &http.Server{Addr: addr, Handler: &gateway{functions: functions}}
+		awsServer = &http.Server{Addr: awsAddr, Handler: em}
+		failed    = make(chan error, 2)
+	)
+
+	em.schedule(ctx)
+
+	for _, server := range []*http.Server{apiServer, awsServer} {
+		go func(server *http.Server) {
+			err := server.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) {
+				failed <- err
+			}
+		}(server)
+	}
+
+	trail.Ok("serving the API on http://%s and the emulator on http://%s", addr, awsAddr)
+
+	select {
+	case <-ctx.Done():
+	case err = <-failed:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_ = apiServer.Shutdown(shutdownCtx)
+	_ = awsServer.Shutdown(shutdownCtx)
+
+	return err
+}
+
+// localSecrets returns the secrets that are served by the emulator. The token secret
+// has a random signing key, so the tokens are no longer valid after a restart.
+func localSecrets() (map[string]string, error) {
+	var secrets = make(map[string]string)
+
+	if os.Getenv("TOKEN_SECRET") != "" || os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER") != "" {
+		return secrets, nil
+	}
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets[TOKEN_SECRET] = utility.EncodeJSON(map[string]string{"signing_key": hex.EncodeToString(key)})
+	return secrets, nil
+}
+
+// environment returns the environment variables of the Lambda Functions. The tables
+// default to the table names of the stack, and the SQS and EventBridge clients are
+// pointed to the emulator, as well as the Secrets Manager client if there are local
+// secrets. The e-mail verification links point to the local API.
+func environment(apiURL, emulatorURL string, secrets map[string]string) []string {
+	var env = os.Environ()
+
+	for key, table := range tables {
+		if os.Getenv(key) == "" {
+			env = append(env, key+"="+table)
+		}
+	}
+
+	if os.Getenv("BOOKING_QUEUE") == "" {
+		env = append(env, "BOOKING_QUEUE="+emulatorURL+"/"+ACCOUNT_ID+"/"+BOOKING_QUEUE)
+	}
+
+	if os.Getenv("EVENT_BUS") == "" {
+		env = append(env, "EVENT_BUS="+EVENT_BUS)
+	}
+
+	if os.Getenv("EMAIL_VERIFICATION_URL") == "" {
+		env = append(env, "EMAIL_VERIFICATION_URL="+apiURL+"/user/email/verify")
+	}
+
+	if _, ok := secrets[TOKEN_SECRET]; ok {
+		env = append(env, "TOKEN_SECRET="+TOKEN_SECRET, "AWS_ENDPOINT_URL_SECRETS_MANAGER="+emulatorURL)
+	}
+
+	return append(env,
+		"AWS_ENDPOINT_URL_SQS="+emulatorURL,
+		"AWS_ENDPOINT_URL_EVENTBRIDGE="+emulatorURL,
+	)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	STAGE            = "prod"                           // The API Gateway deployment stage, it is optional on the request path
+	AUTHORIZER       = "authorizer"                     // The Lambda Function of the token authorizer
+	BOOKING_QUEUE    = "bus-ticketing-booking.fifo"     // The name of the booking SQS Queue
+	EVENT_BUS        = "bus-ticketing-booking-eventbus" // The name of the booking EventBridge Event Bus
+	TOKEN_SECRET     = "BusTicketing_TokenSecret"       // The name of the token secret
+	DEFAULT_TIMEOUT  = 60 * time.Second                 // The timeout of a Lambda Function that has no timeout on the timeouts
+	MAX_RECEIVE      = 5                                // The number of times an SQS message is received before it is dropped
+	MAX_RULE_RETRIES = 5                                // The number of times an event is retried on the rule target
+)
+
+// route is an API Gateway resource method and the Lambda Function of its integration.
+type route struct {
+	method     string
+	path       string
+	function   string
+	authorized bool     // The access token is validated by the token authorizer
+	required   []string // The required query string parameters of the request validator
+}
+
+// routes are the API Gateway resource methods of lib/stacks/bus-ticketing-stack.ts.
+var routes = []route{
+	// User API
+	{method: http.MethodPost, path: "/user/create", function: "createUser"},
+	{method: http.MethodPost, path: "/user/login", function: "login"},
+	{method: http.MethodPost, path: "/user/login/mfa", function: "loginMFA"},
+	{method: http.MethodPost, path: "/user/token/refresh", function: "refreshToken"},
+	{method: http.MethodPost, path: "/user/password/forgot", function: "forgotPassword"},
+	{method: http.MethodPost, path: "/user/password/reset", function: "resetPassword"},
+	{method: http.MethodGet, path: "/user/email/verify", function: "verifyEmail", required: []string{"token"}},
+	{method: http.MethodPost, path: "/user/email/verify/resend", function: "resendVerification", authorized: true},
+	{method: http.MethodPost, path: "/user/mfa/enroll", function: "enrollMFA", authorized: true},
+	{method: http.MethodPost, path: "/user/mfa/confirm", function: "confirmMFA", authorized: true},
+	{method: http.MethodPost, path: "/user/mfa/disable", function: "disableMFA", authorized: true},
+	{method: http.MethodPost, path: "/user/mfa/recovery", function: "regenerateRecoveryCodes", authorized: true},
+	{method: http.MethodGet, path: "/user/account/get", function: "getUser", authorized: true},
+	{method: http.MethodPost, path: "/user/account/update", function: "updateUser", authorized: true, required: []string{"id", "username"}},
+	{method: http.MethodPost, path: "/user/account/unlock", function: "unlockUser", authorized: true},
+	{method: http.MethodGet, path: "/user/account/export", function: "exportUser", authorized: true},
+	{method: http.MethodPost, path: "/user/account/erase", function: "eraseUser", authorized: true},
+	{method: http.MethodGet, path: "/user/account/search", function: "filterUser", authorized: true},
+	{method: http.MethodPost, path: "/user/account/status", function: "updateUserStatus", authorized: true},
+	{method: http.MethodPost, path: "/user/account/type", function: "changeUserType", authorized: true},
+	{method: http.MethodGet, path: "/user/account/audit", function: "getUserAudit", authorized: true},
+
+	// Bus API
+	{method: http.MethodPost, path: "/bus/create", function: "createBus", authorized: true},
+	{method: http.MethodGet, path: "/bus/get", function: "getBus", authorized: true},
+	{method: http.MethodPost, path: "/bus/update", function: "updateBus", authorized: true, required: []string{"id", "name"}},
+	{method: http.MethodGet, path: "/bus/search", function: "filterBus", authorized: true},
+
+	// Bus Unit API
+	{method: http.MethodPost, path: "/bus-unit/create", function: "createBusUnit", authorized: true},
+	{method: http.MethodGet, path: "/bus-unit/get", function: "getBusUnit", authorized: true},
+	{method: http.MethodPost, path: "/bus-unit/update", function: "updateBusUnit", authorized: true, required: []string{"code", "bus_id"}},
+	{method: http.MethodGet, path: "/bus-unit/search", function: "filterBusUnit", authorized: true, required: []string{"bus_id"}},
+	{method: http.MethodPost, path: "/bus-unit/maintenance/create", function: "createBusUnitMaintenance", authorized: true},
+	{method: http.MethodGet, path: "/bus-unit/maintenance/get", function: "getBusUnitMaintenance", authorized: true, required: []string{"bus_id"}},
+	{method: http.MethodPost, path: "/bus-unit/maintenance/delete", function: "deleteBusUnitMaintenance", authorized: true, required: []string{"bus_id", "id"}},
+	{method: http.MethodGet, path: "/bus-unit/maintenance/conflicts", function: "getMaintenanceConflicts", authorized: true},
+	{method: http.MethodPost, path: "/bus-unit/layout/create", function: "createSeatLayout", authorized: true},
+	{method: http.MethodGet, path: "/bus-unit/layout/get", function: "getSeatLayout", authorized: true, required: []string{"bus_id"}},
+
+	// Bus Route API
+	{method: http.MethodPost, path: "/bus-route/create", function: "createBusRoute", authorized: true},
+	{method: http.MethodGet, path: "/bus-route/get", function: "getBusRoute"},
+	{method: http.MethodGet, path: "/bus-route/search", function: "filterBusRoute"},
+	{method: http.MethodPost, path: "/bus-route/update", function: "updateBusRoute", authorized: true, required: []string{"id", "bus_id"}},
+	{method: http.MethodGet, path: "/bus-route/manifest", function: "getTripManifest", authorized: true, required: []string{"id", "bus_id", "travel_date"}},
+	{method: http.MethodGet, path: "/bus-route/availability", function: "getSeatAvailability", required: []string{"id", "bus_id", "travel_date"}},
+
+	// Crew API
+	{method: http.MethodPost, path: "/crew/create", function: "createCrew", authorized: true},
+	{method: http.MethodGet, path: "/crew/get", function: "getCrew", authorized: true},
+	{method: http.MethodPost, path: "/crew/update", function: "updateCrew", authorized: true, required: []string{"bus_id", "id"}},
+	{method: http.MethodPost, path: "/crew/assign", function: "assignCrew", authorized: true},
+	{method: http.MethodPost, path: "/crew/unassign", function: "unassignCrew", authorized: true, required: []string{"crew_id", "id"}},
+	{method: http.MethodGet, path: "/crew/assignment", function: "getCrewAssignment", authorized: true},
+
+	// Booking API
+	{method: http.MethodPost, path: "/bookings/create", function: "createBooking", authorized: true},
+	{method: http.MethodGet, path: "/bookings/get", function: "getBooking", authorized: true},
+	{method: http.MethodGet, path: "/bookings/search", function: "filterBooking", authorized: true, required: []string{"status"}},
+	{method: http.MethodGet, path: "/bookings/cancelled/get", function: "getCancelledBooking", authorized: true},
+	{method: http.MethodPost, path: "/bookings/update/status", function: "updateBookingStatus", authorized: true, required: []string{"id", "bus_route_id"}},
+}
+
+// queueConsumers are the Lambda Functions of the SQS event sources by the queue name.
+var queueConsumers = map[string]string{
+	BOOKING_QUEUE: "processBooking",
+}
+
+// ruleTargets are the Lambda Functions of the EventBridge rules by the event source.
+var ruleTargets = map[string]string{
+	"booking:confirmed": "confirmedBooking",
+	"booking:cancelled": "cancelledBooking",
+	"route:changed":     "changedBusRoute",
+}
+
+// scheduleTargets are the Lambda Functions of the EventBridge schedule rules and their rate.
+var scheduleTargets = map[string]time.Duration{
+	"publishBusRouteChange": 5 * time.Minute,
+}
+
+// timeouts are the Lambda Function timeouts that are not the DEFAULT_TIMEOUT.
+var timeouts = map[string]time.Duration{
+	"createBooking":   90 * time.Second,
+	"processBooking":  90 * time.Second,
+	"changedBusRoute": 300 * time.Second,
+}
+
+// tables are the default DynamoDB table names of the environment variables.
+var tables = map[string]string{
+	"USERS_TABLE":              "BusTicketing_UsersTable",
+	"BUS_TABLE":                "BusTicketing_BusTable",
+	"BUS_UNIT_TABLE":           "BusTicketing_BusUnitTable",
+	"BUS_ROUTE_TABLE":          "BusTicketing_BusRouteTable",
+	"BOOKING_TABLE":            "BusTicketing_BookingTable",
+	"BOOKING_CANCELLED_TABLE":  "BusTicketing_CancelledBookingTable",
+	"UNIQUE_KEY_TABLE":         "BusTicketing_UniqueKeyTable",
+	"PASSWORD_RESET_TABLE":     "BusTicketing_PasswordResetTable",
+	"EMAIL_VERIFICATION_TABLE": "BusTicketing_EmailVerificationTable",
+	"LOGIN_ATTEMPT_TABLE":      "BusTicketing_LoginAttemptTable",
+	"NOTIFICATION_TABLE":       "BusTicketing_NotificationTable",
+	"USER_AUDIT_TABLE":         "BusTicketing_UserAuditTable",
+	"MAINTENANCE_TABLE":        "BusTicketing_BusUnitMaintenanceTable",
+	"CREW_TABLE":               "BusTicketing_CrewTable",
+	"CREW_ASSIGNMENT_TABLE":    "BusTicketing_CrewAssignmentTable",
+	"SEAT_LAYOUT_TABLE":        "BusTicketing_SeatLayoutTable",
+}
+
+// functionNames returns the names of every Lambda Function of the routes, the
+// queue consumers, the rule targets and the schedule targets.
+func functionNames() []string {
+	var (
+		names []string
+		seen  = make(map[string]bool)
+	)
+
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, route := range routes {
+		add(route.function)
+
+		if route.authorized {
+			add(AUTHORIZER)
+		}
+	}
+
+	for _, name := range queueConsumers {
+		add(name)
+	}
+
+	for _, name := range ruleTargets {
+		add(name)
+	}
+
+	for name := range scheduleTargets {
+		add(name)
+	}
+
+	return names
+}